@@ -6,11 +6,10 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -24,62 +23,54 @@ import (
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
 	addonlisterv1alpha1 "open-cluster-management.io/api/client/addon/listers/addon/v1alpha1"
-	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
-	workv1client "open-cluster-management.io/api/client/work/clientset/versioned"
-	workv1informers "open-cluster-management.io/api/client/work/informers/externalversions"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
 	"open-cluster-management.io/ocm/pkg/addon/templateagent"
-	"open-cluster-management.io/ocm/pkg/common/queue"
 )
 
-// addonTemplateController monitors ManagedClusterAddOns on hub to get all the in-used addon templates,
-// and starts an addon manager for every addon template to handle agent requests deployed by this template
+// addonTemplateController monitors ClusterManagementAddOns and ManagedClusterAddOns on hub to get all the in-used
+// addon templates, and runs one addon manager to handle the agent requests of all the template type addons.
+//
+// The addon manager does not support adding or removing agents once it is started, so the manager is restarted
+// with the new agents when the template type addons change. The informers of a manager, including the RoleBinding
+// informer of the agents, are only used by the manager and stopped together with it, so their event handlers
+// are not leaked to the restarted manager, and only the objects of the template type addons are cached.
 type addonTemplateController struct {
-	// addonManagers holds all addon managers that will be deployed with template type addons.
-	// The key is the name of the template type addon.
-	addonManagers map[string]context.CancelFunc
+	// addonNames is the names of the template type addons registered to the running manager.
+	addonNames sets.Set[string]
+	// stopManager stops the running manager, it is nil if no manager is running.
+	stopManager context.CancelFunc
 
 	kubeConfig        *rest.Config
 	addonClient       addonv1alpha1client.Interface
-	workClient        workv1client.Interface
 	kubeClient        kubernetes.Interface
 	cmaLister         addonlisterv1alpha1.ClusterManagementAddOnLister
+	mcaLister         addonlisterv1alpha1.ManagedClusterAddOnLister
 	addonInformers    addoninformers.SharedInformerFactory
-	clusterInformers  clusterv1informers.SharedInformerFactory
-	dynamicInformers  dynamicinformer.DynamicSharedInformerFactory
-	workInformers     workv1informers.SharedInformerFactory
 	runControllerFunc runController
 	eventRecorder     events.Recorder
 }
 
-type runController func(ctx context.Context, addonName string) error
+type runController func(ctx context.Context, addonNames []string) error
 
 // NewAddonTemplateController returns an instance of addonTemplateController
 func NewAddonTemplateController(
 	hubKubeconfig *rest.Config,
 	hubKubeClient kubernetes.Interface,
 	addonClient addonv1alpha1client.Interface,
-	workClient workv1client.Interface,
 	addonInformers addoninformers.SharedInformerFactory,
-	clusterInformers clusterv1informers.SharedInformerFactory,
-	dynamicInformers dynamicinformer.DynamicSharedInformerFactory,
-	workInformers workv1informers.SharedInformerFactory,
 	recorder events.Recorder,
 	runController ...runController,
 ) factory.Controller {
 	c := &addonTemplateController{
-		kubeConfig:       hubKubeconfig,
-		kubeClient:       hubKubeClient,
-		addonClient:      addonClient,
-		workClient:       workClient,
-		cmaLister:        addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
-		addonManagers:    make(map[string]context.CancelFunc),
-		addonInformers:   addonInformers,
-		clusterInformers: clusterInformers,
-		dynamicInformers: dynamicInformers,
-		workInformers:    workInformers,
-		eventRecorder:    recorder,
+		addonNames:     sets.New[string](),
+		kubeConfig:     hubKubeconfig,
+		kubeClient:     hubKubeClient,
+		addonClient:    addonClient,
+		cmaLister:      addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
+		mcaLister:      addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+		addonInformers: addonInformers,
+		eventRecorder:  recorder,
 	}
 
 	if len(runController) > 0 {
@@ -88,9 +79,10 @@ func NewAddonTemplateController(
 		// easy to mock in unit tests
 		c.runControllerFunc = c.runController
 	}
-	return factory.New().WithInformersQueueKeysFunc(
-		queue.QueueKeyByMetaNamespaceName,
-		addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Informer()).
+	return factory.New().WithInformers(
+		addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Informer(),
+		// the agent of an addon is kept until all the managed cluster addon instances are deleted
+		addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer()).
 		WithBareInformers(
 			// do not need to queue, just make sure the controller reconciles after the addonTemplate cache is synced
 			// otherwise, there will be "xx-addon-template" not found" errors in the log as the controller uses the
@@ -100,80 +92,91 @@ func NewAddonTemplateController(
 		ToController("addon-template-controller", recorder)
 }
 
-func (c *addonTemplateController) stopUnusedManagers(
-	ctx context.Context, syncCtx factory.SyncContext, addOnName string) {
-	// TODO: check if all managed cluster addon instances are deleted
-	stopFunc, ok := c.addonManagers[addOnName]
-	if ok {
-		stopFunc()
-		delete(c.addonManagers, addOnName)
-		klog.FromContext(ctx).Info("Stopping the manager for addon", "addonName", addOnName)
+// templateAddonNames returns the names of the addons which should be handled by the manager. An addon is
+// handled if its ClusterManagementAddOn supports AddOnTemplate, or there are still managed cluster addon instances
+// deployed by an AddOnTemplate after the ClusterManagementAddOn is deleted or no longer supports AddOnTemplate, so
+// the agents are cleaned up.
+func (c *addonTemplateController) templateAddonNames() (sets.Set[string], error) {
+	addonNames := sets.New[string]()
+	cmas, err := c.cmaLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cma := range cmas {
+		if templateagent.SupportAddOnTemplate(cma) {
+			addonNames.Insert(cma.Name)
+		}
 	}
+
+	mcas, err := c.mcaLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, mca := range mcas {
+		if ok, _ := templateagent.AddonTemplateConfigRef(mca.Status.ConfigReferences); ok {
+			addonNames.Insert(mca.Name)
+		}
+	}
+	return addonNames, nil
 }
 
 func (c *addonTemplateController) sync(ctx context.Context, syncCtx factory.SyncContext) error {
 	logger := klog.FromContext(ctx)
-	addonName := syncCtx.QueueKey()
 
-	cma, err := c.cmaLister.Get(addonName)
+	addonNames, err := c.templateAddonNames()
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.stopUnusedManagers(ctx, syncCtx, addonName)
-			return nil
-		}
 		return err
 	}
-
-	if !templateagent.SupportAddOnTemplate(cma) {
-		c.stopUnusedManagers(ctx, syncCtx, cma.Name)
+	if addonNames.Equal(c.addonNames) {
 		return nil
 	}
 
-	_, exist := c.addonManagers[addonName]
-	if exist {
-		logger.Info("There already is a manager started for addon, skipping", "addonName", addonName)
+	if c.stopManager != nil {
+		logger.Info("Stopping the addon manager", "addonNames", sets.List(c.addonNames))
+		c.stopManager()
+		c.stopManager = nil
+	}
+	c.addonNames = addonNames
+	if addonNames.Len() == 0 {
 		return nil
 	}
 
-	logger.Info("Starting an addon manager for addon", "addonName", addonName)
-
-	stopFunc := c.startManager(ctx, addonName)
-	c.addonManagers[addonName] = stopFunc
+	logger.Info("Starting the addon manager", "addonNames", sets.List(addonNames))
+	c.stopManager = c.startManager(ctx, sets.List(addonNames))
 	return nil
 }
 
 func (c *addonTemplateController) startManager(
 	pctx context.Context,
-	addonName string) context.CancelFunc {
+	addonNames []string) context.CancelFunc {
 	ctx, stopFunc := context.WithCancel(pctx)
 	logger := klog.FromContext(ctx)
 	go func() {
-		err := c.runControllerFunc(ctx, addonName)
+		err := c.runControllerFunc(ctx, addonNames)
 		if err != nil {
-			logger.Error(err, "Error running controller for addon", "addonName", addonName)
+			logger.Error(err, "Error running the addon manager", "addonNames", addonNames)
 			utilruntime.HandleError(err)
 		}
 
-		// use the parent context to start all shared informers, otherwise once the context is cancelled,
-		// the informers will stop and all other shared go routines will be impacted.
-		c.workInformers.Start(pctx.Done())
+		// use the parent context to start the shared addon informers used by the agents, otherwise once the
+		// context is cancelled, the informers will stop and the restarted manager will be impacted.
 		c.addonInformers.Start(pctx.Done())
-		c.clusterInformers.Start(pctx.Done())
-		c.dynamicInformers.Start(pctx.Done())
 
 		<-ctx.Done()
-		logger.Info("Addon Manager stopped", "addonName", addonName)
+		logger.Info("Addon Manager stopped", "addonNames", addonNames)
 	}()
 	return stopFunc
 }
 
-func (c *addonTemplateController) runController(ctx context.Context, addonName string) error {
+func (c *addonTemplateController) runController(ctx context.Context, addonNames []string) error {
 	logger := klog.FromContext(ctx)
 	mgr, err := addonmanager.New(c.kubeConfig)
 	if err != nil {
 		return err
 	}
 
+	// the RoleBindings of the template type addons are watched by the informer of the manager, so it is stopped
+	// together with the manager.
 	kubeInformers := kubeinformers.NewSharedInformerFactoryWithOptions(c.kubeClient, 10*time.Minute,
 		kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
 			selector := &metav1.LabelSelector{
@@ -181,7 +184,7 @@ func (c *addonTemplateController) runController(ctx context.Context, addonName s
 					{
 						Key:      addonv1alpha1.AddonLabelKey,
 						Operator: metav1.LabelSelectorOpIn,
-						Values:   []string{addonName},
+						Values:   addonNames,
 					},
 				},
 			}
@@ -191,46 +194,50 @@ func (c *addonTemplateController) runController(ctx context.Context, addonName s
 	getValuesClosure := func(cluster *clusterv1.ManagedCluster, addon *addonapiv1alpha1.ManagedClusterAddOn) (addonfactory.Values, error) {
 		return templateagent.GetAddOnRegistriesPrivateValuesFromClusterAnnotation(klog.FromContext(ctx), cluster, addon)
 	}
-	agentAddon := templateagent.NewCRDTemplateAgentAddon(
-		ctx,
-		addonName,
-		c.kubeClient,
-		c.addonClient,
-		c.addonInformers, // use the shared informers, whose cache is synced already
-		kubeInformers.Rbac().V1().RoleBindings().Lister(),
-		c.eventRecorder,
-		// image overrides from cluster annotation has lower priority than from the addonDeploymentConfig
-		getValuesClosure,
-		addonfactory.GetAddOnDeploymentConfigValues(
-			utils.NewAddOnDeploymentConfigGetter(c.addonClient),
-			addonfactory.ToAddOnCustomizedVariableValues,
-			templateagent.ToAddOnNodePlacementPrivateValues,
-			templateagent.ToAddOnRegistriesPrivateValues,
-			templateagent.ToAddOnInstallNamespacePrivateValues,
-			templateagent.ToAddOnProxyPrivateValues,
-			templateagent.ToAddOnResourceRequirementsPrivateValues,
-		),
-	)
-	err = mgr.AddAgent(agentAddon)
-	if err != nil {
-		return err
+	for _, addonName := range addonNames {
+		agentAddon := templateagent.NewCRDTemplateAgentAddon(
+			ctx,
+			addonName,
+			c.kubeClient,
+			c.addonClient,
+			c.addonInformers, // use the shared informers, whose cache is synced already
+			kubeInformers.Rbac().V1().RoleBindings().Lister(),
+			c.eventRecorder,
+			// image overrides from cluster annotation has lower priority than from the addonDeploymentConfig
+			getValuesClosure,
+			addonfactory.GetAddOnDeploymentConfigValues(
+				utils.NewAddOnDeploymentConfigGetter(c.addonClient),
+				addonfactory.ToAddOnCustomizedVariableValues,
+				templateagent.ToAddOnNodePlacementPrivateValues,
+				templateagent.ToAddOnRegistriesPrivateValues,
+				templateagent.ToAddOnInstallNamespacePrivateValues,
+				templateagent.ToAddOnProxyPrivateValues,
+				templateagent.ToAddOnResourceRequirementsPrivateValues,
+			),
+		)
+		err = mgr.AddAgent(agentAddon)
+		if err != nil {
+			return err
+		}
 	}
 
-	err = mgr.StartWithInformers(ctx, c.workClient, c.workInformers.Work().V1().ManifestWorks(),
-		kubeInformers, c.addonInformers, c.clusterInformers, c.dynamicInformers)
+	// the manager starts its own informers filtered by the names of the addons, they are stopped when the
+	// context is cancelled.
+	err = mgr.Start(ctx)
 	if err != nil {
 		return err
 	}
 	kubeInformers.Start(ctx.Done())
 
 	// trigger the manager to reconcile for the existing managed cluster addons
-	mcas, err := c.addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Lister().List(labels.Everything())
+	names := sets.New(addonNames...)
+	mcas, err := c.mcaLister.List(labels.Everything())
 	if err != nil {
 		logger.Info("Failed to list ManagedClusterAddOns", "error", err)
 	} else {
 		for _, mca := range mcas {
-			if mca.Name == addonName {
-				mgr.Trigger(mca.Namespace, addonName)
+			if names.Has(mca.Name) {
+				mgr.Trigger(mca.Namespace, mca.Name)
 			}
 		}
 	}
@@ -2,15 +2,14 @@ package addontemplate
 
 import (
 	"context"
-	"sync"
 	"testing"
 	"time"
 
+	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/dynamic/dynamicinformer"
-	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/apimachinery/pkg/util/sets"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 
@@ -19,220 +18,222 @@ import (
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	fakeaddon "open-cluster-management.io/api/client/addon/clientset/versioned/fake"
 	addoninformers "open-cluster-management.io/api/client/addon/informers/externalversions"
-	fakecluster "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
-	clusterv1informers "open-cluster-management.io/api/client/cluster/informers/externalversions"
-	fakework "open-cluster-management.io/api/client/work/clientset/versioned/fake"
-	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 )
 
+func newTemplateClusterManagementAddon(name string) *addonv1alpha1.ClusterManagementAddOn {
+	return addontesting.NewClusterManagementAddon(name, "", "").WithSupportedConfigs(
+		addonv1alpha1.ConfigMeta{
+			ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
+				Group:    utils.AddOnTemplateGVR.Group,
+				Resource: utils.AddOnTemplateGVR.Resource,
+			},
+			DefaultConfig: &addonv1alpha1.ConfigReferent{Name: name},
+		}).Build()
+}
+
+func newTemplateAddon(name, namespace string) *addonv1alpha1.ManagedClusterAddOn {
+	addon := addontesting.NewAddon(name, namespace)
+	addon.Status.ConfigReferences = []addonv1alpha1.ConfigReference{
+		{
+			ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
+				Group:    utils.AddOnTemplateGVR.Group,
+				Resource: utils.AddOnTemplateGVR.Resource,
+			},
+			ConfigReferent: addonv1alpha1.ConfigReferent{Name: name},
+		},
+	}
+	return addon
+}
+
 func TestReconcile(t *testing.T) {
 	cases := []struct {
 		name                   string
-		syncKeys               []string
+		registeredAddons       []string
 		managedClusteraddon    []runtime.Object
 		clusterManagementAddon []runtime.Object
-		expectedCount          int
-		expectedTimeout        bool
+		expectedAddons         []string
+		expectedRestart        bool
 	}{
 		{
 			name:                   "no clustermanagementaddon",
-			syncKeys:               []string{"test"},
 			managedClusteraddon:    []runtime.Object{},
 			clusterManagementAddon: []runtime.Object{},
-			expectedCount:          0,
-			expectedTimeout:        true,
 		},
 		{
 			name:                "not template type clustermanagementaddon",
-			syncKeys:            []string{"test"},
 			managedClusteraddon: []runtime.Object{},
 			clusterManagementAddon: []runtime.Object{
 				addontesting.NewClusterManagementAddon("test", "", "").Build()},
-			expectedCount:   0,
-			expectedTimeout: true,
 		},
 		{
 			name:                "one template type clustermanagementaddon",
-			syncKeys:            []string{"test"},
 			managedClusteraddon: []runtime.Object{},
 			clusterManagementAddon: []runtime.Object{
-				addontesting.NewClusterManagementAddon("test", "", "").WithSupportedConfigs(
-					addonv1alpha1.ConfigMeta{
-						ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
-							Group:    utils.AddOnTemplateGVR.Group,
-							Resource: utils.AddOnTemplateGVR.Resource,
-						},
-						DefaultConfig: &addonv1alpha1.ConfigReferent{Name: "test"},
-					}).Build()},
-			expectedCount:   1,
-			expectedTimeout: false,
+				newTemplateClusterManagementAddon("test")},
+			expectedAddons:  []string{"test"},
+			expectedRestart: true,
 		},
 		{
-			name:                "two template type clustermanagementaddon",
-			syncKeys:            []string{"test", "test1"},
+			name:                "two template type and one not template type clustermanagementaddon",
 			managedClusteraddon: []runtime.Object{},
 			clusterManagementAddon: []runtime.Object{
-				addontesting.NewClusterManagementAddon("test", "", "").WithSupportedConfigs(
-					addonv1alpha1.ConfigMeta{
-						ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
-							Group:    utils.AddOnTemplateGVR.Group,
-							Resource: utils.AddOnTemplateGVR.Resource,
-						},
-						DefaultConfig: &addonv1alpha1.ConfigReferent{Name: "test"},
-					}).Build(),
-				addontesting.NewClusterManagementAddon("test1", "", "").WithSupportedConfigs(
-					addonv1alpha1.ConfigMeta{
-						ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
-							Group:    utils.AddOnTemplateGVR.Group,
-							Resource: utils.AddOnTemplateGVR.Resource,
-						},
-						DefaultConfig: &addonv1alpha1.ConfigReferent{Name: "test"},
-					}).Build(),
+				newTemplateClusterManagementAddon("test"),
+				newTemplateClusterManagementAddon("test1"),
+				addontesting.NewClusterManagementAddon("test2", "", "").Build(),
 			},
-			expectedCount:   2,
-			expectedTimeout: false,
+			expectedAddons:  []string{"test", "test1"},
+			expectedRestart: true,
 		},
 		{
-			name:                "two template type and one not template type clustermanagementaddon",
-			syncKeys:            []string{"test", "test1", "test2"},
+			name:                "template type addons are not changed",
+			registeredAddons:    []string{"test", "test1"},
+			managedClusteraddon: []runtime.Object{},
+			clusterManagementAddon: []runtime.Object{
+				newTemplateClusterManagementAddon("test"),
+				newTemplateClusterManagementAddon("test1"),
+			},
+			expectedAddons: []string{"test", "test1"},
+		},
+		{
+			name:                "new template type clustermanagementaddon",
+			registeredAddons:    []string{"test"},
 			managedClusteraddon: []runtime.Object{},
 			clusterManagementAddon: []runtime.Object{
-				addontesting.NewClusterManagementAddon("test", "", "").WithSupportedConfigs(
-					addonv1alpha1.ConfigMeta{
-						ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
-							Group:    utils.AddOnTemplateGVR.Group,
-							Resource: utils.AddOnTemplateGVR.Resource,
-						},
-						DefaultConfig: &addonv1alpha1.ConfigReferent{Name: "test"},
-					}).Build(),
-				addontesting.NewClusterManagementAddon("test1", "", "").WithSupportedConfigs(
-					addonv1alpha1.ConfigMeta{
-						ConfigGroupResource: addonv1alpha1.ConfigGroupResource{
-							Group:    utils.AddOnTemplateGVR.Group,
-							Resource: utils.AddOnTemplateGVR.Resource,
-						},
-						DefaultConfig: &addonv1alpha1.ConfigReferent{Name: "test"},
-					}).Build(),
+				newTemplateClusterManagementAddon("test"),
+				newTemplateClusterManagementAddon("test1"),
+			},
+			expectedAddons:  []string{"test", "test1"},
+			expectedRestart: true,
+		},
+		{
+			name:             "clustermanagementaddon is deleted with managed cluster addon instances",
+			registeredAddons: []string{"test"},
+			managedClusteraddon: []runtime.Object{
+				newTemplateAddon("test", "cluster1"),
+			},
+			clusterManagementAddon: []runtime.Object{},
+			expectedAddons:         []string{"test"},
+		},
+		{
+			name: "managed cluster addon instances of a deleted clustermanagementaddon after restart",
+			managedClusteraddon: []runtime.Object{
+				newTemplateAddon("test", "cluster1"),
+			},
+			clusterManagementAddon: []runtime.Object{},
+			expectedAddons:         []string{"test"},
+			expectedRestart:        true,
+		},
+		{
+			name:             "clustermanagementaddon is deleted without managed cluster addon instances",
+			registeredAddons: []string{"test", "test1"},
+			managedClusteraddon: []runtime.Object{
+				addontesting.NewAddon("test2", "cluster1"),
+			},
+			clusterManagementAddon: []runtime.Object{
+				newTemplateClusterManagementAddon("test1"),
 				addontesting.NewClusterManagementAddon("test2", "", "").Build(),
 			},
-			expectedCount:   2,
-			expectedTimeout: true,
+			expectedAddons:  []string{"test1"},
+			expectedRestart: true,
+		},
+		{
+			name:                   "all template type addons are deleted",
+			registeredAddons:       []string{"test"},
+			managedClusteraddon:    []runtime.Object{},
+			clusterManagementAddon: []runtime.Object{},
+			expectedRestart:        true,
 		},
 	}
 
 	for _, c := range cases {
-		count := 0
-		var wg sync.WaitGroup
-		lock := &sync.Mutex{}
-		rederCount := func() int {
-			lock.Lock()
-			defer lock.Unlock()
-			return count
-		}
-		increaseCount := func() {
-			lock.Lock()
-			defer lock.Unlock()
-			count++
-		}
-
-		for range c.syncKeys {
-			wg.Add(1)
-		}
-		runController := func(ctx context.Context, addonName string) error {
-			defer wg.Done()
-			increaseCount()
-			return nil
-		}
-		obj := append(c.clusterManagementAddon, c.managedClusteraddon...) //nolint:gocritic
-		fakeAddonClient := fakeaddon.NewSimpleClientset(obj...)
+		t.Run(c.name, func(t *testing.T) {
+			started := make(chan []string, 10)
+			runController := func(ctx context.Context, addonNames []string) error {
+				started <- addonNames
+				return nil
+			}
+			obj := append(c.clusterManagementAddon, c.managedClusteraddon...) //nolint:gocritic
+			fakeAddonClient := fakeaddon.NewSimpleClientset(obj...)
 
-		addonInformers := addoninformers.NewSharedInformerFactory(fakeAddonClient, 10*time.Minute)
+			addonInformers := addoninformers.NewSharedInformerFactory(fakeAddonClient, 10*time.Minute)
 
-		for _, obj := range c.managedClusteraddon {
-			if err := addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(obj); err != nil {
-				t.Fatal(err)
+			for _, obj := range c.managedClusteraddon {
+				if err := addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
 			}
-		}
-		for _, obj := range c.clusterManagementAddon {
-			if err := addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Informer().GetStore().Add(obj); err != nil {
-				t.Fatal(err)
+			for _, obj := range c.clusterManagementAddon {
+				if err := addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
 			}
-		}
-
-		fakeDynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
-		dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(fakeDynamicClient, 0)
-
-		fakeClusterClient := fakecluster.NewSimpleClientset()
-		clusterInformers := clusterv1informers.NewSharedInformerFactory(fakeClusterClient, 10*time.Minute)
 
-		fakeWorkClient := fakework.NewSimpleClientset()
-		workInformers := workinformers.NewSharedInformerFactory(fakeWorkClient, 10*time.Minute)
+			controller := &addonTemplateController{
+				addonNames:        sets.New(c.registeredAddons...),
+				kubeClient:        fakekube.NewSimpleClientset(),
+				addonClient:       fakeAddonClient,
+				cmaLister:         addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
+				mcaLister:         addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+				addonInformers:    addonInformers,
+				runControllerFunc: runController,
+				eventRecorder:     eventstesting.NewTestingEventRecorder(t),
+			}
 
-		hubKubeClient := fakekube.NewSimpleClientset()
+			// the manager of the registered addons is running
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			runningCtx, stopRunning := context.WithCancel(ctx)
+			defer stopRunning()
+			if len(c.registeredAddons) > 0 {
+				controller.stopManager = stopRunning
+			}
 
-		controller := NewAddonTemplateController(
-			nil,
-			hubKubeClient,
-			fakeAddonClient,
-			fakeWorkClient,
-			addonInformers,
-			clusterInformers,
-			dynamicInformerFactory,
-			workInformers,
-			eventstesting.NewTestingEventRecorder(t),
-			runController,
-		)
-		ctx := context.TODO()
-		for _, syncKey := range c.syncKeys {
-			syncContext := testingcommon.NewFakeSyncContext(t, syncKey)
-			err := controller.Sync(ctx, syncContext)
-			if err != nil {
+			syncContext := testingcommon.NewFakeSyncContext(t, factory.DefaultQueueKey)
+			if err := controller.sync(ctx, syncContext); err != nil {
 				t.Errorf("expected no error when sync: %v", err)
 			}
-		}
+			assert.ElementsMatch(t, c.expectedAddons, sets.List(controller.addonNames))
+			assert.Equal(t, len(c.expectedAddons) > 0, controller.stopManager != nil)
 
-		ch := make(chan struct{})
-		go func() {
-			defer close(ch)
-			wg.Wait()
-		}()
-
-		select {
-		case <-ch:
-			actualCount := rederCount()
-			if actualCount != c.expectedCount {
-				t.Errorf("name : %s, expected runControllerFunc to be called %d, but was called %d times",
-					c.name, c.expectedCount, actualCount)
+			// the running manager is stopped when the addons are changed
+			if len(c.registeredAddons) > 0 {
+				assert.Equal(t, c.expectedRestart, runningCtx.Err() != nil)
 			}
-		case <-time.After(1 * time.Second):
-			if !c.expectedTimeout {
-				t.Errorf("name : %s, expected not timeout, but timeout", c.name)
+
+			// a manager is started with all the addons when the addons are changed
+			if c.expectedRestart && len(c.expectedAddons) > 0 {
+				select {
+				case addonNames := <-started:
+					assert.ElementsMatch(t, c.expectedAddons, addonNames)
+				case <-time.After(time.Second):
+					t.Fatalf("expected the manager of %v started", c.expectedAddons)
+				}
 			}
-			actualCount := rederCount()
-			if actualCount != c.expectedCount {
-				t.Errorf("name : %s, expected runControllerFunc to be called %d, but was called %d times",
-					c.name, c.expectedCount, actualCount)
+			select {
+			case addonNames := <-started:
+				t.Errorf("unexpected manager of %v started", addonNames)
+			case <-time.After(100 * time.Millisecond):
 			}
-		}
+		})
 	}
 }
 
 func TestRunController(t *testing.T) {
 	cases := []struct {
 		name        string
-		addonName   string
+		addonNames  []string
 		expectedErr string
 	}{
 		{
 			name:        "addon name empty",
-			addonName:   "",
+			addonNames:  []string{""},
 			expectedErr: "addon name should be set",
 		},
 		{
 			name:        "fake kubeconfig",
-			addonName:   "test",
+			addonNames:  []string{"test", "test1"},
 			expectedErr: `connect: connection refused`,
 		},
 	}
@@ -240,31 +241,22 @@ func TestRunController(t *testing.T) {
 	for _, c := range cases {
 		fakeAddonClient := fakeaddon.NewSimpleClientset()
 		addonInformers := addoninformers.NewSharedInformerFactory(fakeAddonClient, 10*time.Minute)
-		fakeDynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
-		dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(fakeDynamicClient, 0)
-		fakeClusterClient := fakecluster.NewSimpleClientset()
-		clusterInformers := clusterv1informers.NewSharedInformerFactory(fakeClusterClient, 10*time.Minute)
-		fakeWorkClient := fakework.NewSimpleClientset()
-		workInformers := workinformers.NewSharedInformerFactory(fakeWorkClient, 10*time.Minute)
-		hubKubeClient := fakekube.NewSimpleClientset()
 		controller := &addonTemplateController{
-			kubeConfig:       &rest.Config{},
-			kubeClient:       hubKubeClient,
-			addonClient:      fakeAddonClient,
-			cmaLister:        addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
-			addonManagers:    make(map[string]context.CancelFunc),
-			addonInformers:   addonInformers,
-			clusterInformers: clusterInformers,
-			dynamicInformers: dynamicInformerFactory,
-			workInformers:    workInformers,
+			kubeConfig:     &rest.Config{},
+			kubeClient:     fakekube.NewSimpleClientset(),
+			addonClient:    fakeAddonClient,
+			cmaLister:      addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Lister(),
+			mcaLister:      addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Lister(),
+			addonInformers: addonInformers,
 		}
-		ctx := context.TODO()
+		ctx, cancel := context.WithCancel(context.TODO())
 
-		err := controller.runController(ctx, c.addonName)
+		err := controller.runController(ctx, c.addonNames)
 		if err == nil {
 			assert.Empty(t, c.expectedErr)
 		} else {
 			assert.Contains(t, err.Error(), c.expectedErr, "name : %s, expected error %v, but got %v", c.name, c.expectedErr, err)
 		}
+		cancel()
 	}
 }
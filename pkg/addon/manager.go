@@ -6,12 +6,9 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
-	"open-cluster-management.io/addon-framework/pkg/index"
 	"open-cluster-management.io/addon-framework/pkg/utils"
 	addonv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
 	addonv1alpha1client "open-cluster-management.io/api/client/addon/clientset/versioned"
@@ -52,11 +49,6 @@ func RunManager(ctx context.Context, controllerContext *controllercmd.Controller
 		return err
 	}
 
-	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
-	if err != nil {
-		return err
-	}
-
 	clusterInformerFactory := clusterinformers.NewSharedInformerFactory(hubClusterClient, 30*time.Minute)
 	addonInformerFactory := addoninformers.NewSharedInformerFactory(addonClient, 10*time.Minute)
 	workInformers := workv1informers.NewSharedInformerFactoryWithOptions(workClient, 10*time.Minute,
@@ -73,17 +65,13 @@ func RunManager(ctx context.Context, controllerContext *controllercmd.Controller
 		}),
 	)
 
-	dynamicInformers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 10*time.Minute)
-
 	return RunControllerManagerWithInformers(
 		ctx, controllerContext,
 		hubKubeClient,
 		addonClient,
-		workClient,
 		clusterInformerFactory,
 		addonInformerFactory,
 		workInformers,
-		dynamicInformers,
 	)
 }
 
@@ -92,40 +80,22 @@ func RunControllerManagerWithInformers(
 	controllerContext *controllercmd.ControllerContext,
 	hubKubeClient kubernetes.Interface,
 	hubAddOnClient addonv1alpha1client.Interface,
-	hubWorkClient workv1client.Interface,
 	clusterInformers clusterinformers.SharedInformerFactory,
 	addonInformers addoninformers.SharedInformerFactory,
 	workinformers workv1informers.SharedInformerFactory,
-	dynamicInformers dynamicinformer.DynamicSharedInformerFactory,
 ) error {
-	// addonDeployController
-	err := workinformers.Work().V1().ManifestWorks().Informer().AddIndexers(
-		cache.Indexers{
-			index.ManifestWorkByAddon:           index.IndexManifestWorkByAddon,
-			index.ManifestWorkByHostedAddon:     index.IndexManifestWorkByHostedAddon,
-			index.ManifestWorkHookByHostedAddon: index.IndexManifestWorkHookByHostedAddon,
-		},
-	)
-	if err != nil {
-		return err
-	}
-
-	err = addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().AddIndexers(
+	err := addonInformers.Addon().V1alpha1().ManagedClusterAddOns().Informer().AddIndexers(
 		cache.Indexers{
 			addonindex.ManagedClusterAddonByName: addonindex.IndexManagedClusterAddonByName, // addonConfigurationController, addonManagementController
-			index.ManagedClusterAddonByNamespace: index.IndexManagedClusterAddonByNamespace, // agentDeployController
-			index.AddonByConfig:                  index.IndexAddonByConfig,                  // addonConfigController
 		},
 	)
 	if err != nil {
 		return err
 	}
 
-	// managementAddonConfigController
 	err = addonInformers.Addon().V1alpha1().ClusterManagementAddOns().Informer().AddIndexers(
 		cache.Indexers{
 			addonindex.ClusterManagementAddonByPlacement: addonindex.IndexClusterManagementAddonByPlacement, // addonConfigurationController, addonManagementController
-			index.ClusterManagementAddonByConfig:         index.IndexClusterManagementAddonByConfig,         // cmaConfigController
 		})
 	if err != nil {
 		return err
@@ -180,13 +150,7 @@ func RunControllerManagerWithInformers(
 		controllerContext.KubeConfig,
 		hubKubeClient,
 		hubAddOnClient,
-		hubWorkClient,
 		addonInformers,
-		clusterInformers,
-		// can share the same dynamic informers for different template type addons since
-		// these addons only support addontemplate and addondeploymentconfig
-		dynamicInformers,
-		workinformers,
 		controllerContext.EventRecorder,
 	)
 
@@ -196,13 +160,12 @@ func RunControllerManagerWithInformers(
 	go addonProgressingController.Run(ctx, 2)
 	go mgmtAddonInstallProgressionController.Run(ctx, 2)
 	// There should be only one instance of addonTemplateController running, since the addonTemplateController will
-	// start a goroutine for each template-type addon it watches.
+	// start an addon manager for all the template-type addons it watches.
 	go addonTemplateController.Run(ctx, 1)
 
 	clusterInformers.Start(ctx.Done())
 	addonInformers.Start(ctx.Done())
 	workinformers.Start(ctx.Done())
-	dynamicInformers.Start(ctx.Done())
 
 	<-ctx.Done()
 	return nil
@@ -0,0 +1,433 @@
+package templateagent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/valyala/fasttemplate"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"open-cluster-management.io/addon-framework/pkg/addonfactory"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+)
+
+// The template engines are alpha, they are configured by the annotations below instead of the API fields, so they
+// could be changed before they are moved to the api repo. There is no webhook of the AddOnTemplate, the annotations
+// are validated by ValidateAddOnTemplateAnnotations before any manifest is rendered.
+const (
+	// AddOnTemplateEngineAnnotationKey is the annotation key on the AddOnTemplate to select the engine rendering
+	// the manifests, the value is Simple or GoTemplate. The Simple engine is used if it is not set.
+	AddOnTemplateEngineAnnotationKey = "addon.open-cluster-management.io/template-engine"
+
+	// AddOnTemplateManifestConditionAnnotationKey is the annotation key on a manifest of the AddOnTemplate rendered
+	// by the GoTemplate engine. The value is a template, and the manifest is only deployed when it is rendered to
+	// true. The annotation is removed from the rendered manifest.
+	AddOnTemplateManifestConditionAnnotationKey = "addon.open-cluster-management.io/template-condition"
+)
+
+// TemplateEngine is the engine rendering the manifests of the AddOnTemplate.
+type TemplateEngine string
+
+const (
+	// TemplateEngineSimple replaces the {{KEY}} placeholders in the manifests with the config values.
+	TemplateEngineSimple TemplateEngine = "Simple"
+
+	// TemplateEngineGoTemplate renders each string value in the manifests as a go text/template. The data of the
+	// template is templateData, and the functions are listed in templateFuncs.
+	TemplateEngineGoTemplate TemplateEngine = "GoTemplate"
+)
+
+// the placeholders returned by the inject and omit functions, they are replaced after a string value is rendered.
+const (
+	injectPlaceholder = "\x00inject:%d\x00"
+	omitPlaceholder   = "\x00omit\x00"
+)
+
+// manifestRenderer renders a manifest of the AddOnTemplate to an object.
+type manifestRenderer interface {
+	// render returns a nil object if the manifest is excluded.
+	render(raw []byte) (*unstructured.Unstructured, error)
+}
+
+// ValidateAddOnTemplateAnnotations validates the engine annotation of the AddOnTemplate and the condition
+// annotations of its manifests. The conditions are only parsed, since they could only be executed with the
+// values of a cluster.
+func ValidateAddOnTemplateAnnotations(template *addonapiv1alpha1.AddOnTemplate) error {
+	engine := TemplateEngine(template.Annotations[AddOnTemplateEngineAnnotationKey])
+	switch engine {
+	case "", TemplateEngineSimple, TemplateEngineGoTemplate:
+	default:
+		return fmt.Errorf("unsupported template engine %q of addon template %s", engine, template.Name)
+	}
+
+	for i, manifest := range template.Spec.AgentSpec.Workload.Manifests {
+		object := &unstructured.Unstructured{}
+		if err := object.UnmarshalJSON(manifest.Raw); err != nil {
+			// the simple engine renders the placeholders before the manifest is decoded.
+			continue
+		}
+		condition, ok := object.GetAnnotations()[AddOnTemplateManifestConditionAnnotationKey]
+		if !ok {
+			continue
+		}
+		if engine != TemplateEngineGoTemplate {
+			return fmt.Errorf("the annotation %s of manifest %d of addon template %s is only supported by the %s engine",
+				AddOnTemplateManifestConditionAnnotationKey, i, template.Name, TemplateEngineGoTemplate)
+		}
+		funcs := templateFuncs()
+		funcs["inject"] = func(v interface{}) string { return "" }
+		if _, err := parseTemplate(condition, "condition", funcs); err != nil {
+			return fmt.Errorf("invalid annotation %s of manifest %d of addon template %s: %v",
+				AddOnTemplateManifestConditionAnnotationKey, i, template.Name, err)
+		}
+	}
+	return nil
+}
+
+func newManifestRenderer(template *addonapiv1alpha1.AddOnTemplate,
+	configValues, privateValues addonfactory.Values) (manifestRenderer, error) {
+	if err := ValidateAddOnTemplateAnnotations(template); err != nil {
+		return nil, err
+	}
+	if TemplateEngine(template.Annotations[AddOnTemplateEngineAnnotationKey]) == TemplateEngineGoTemplate {
+		return newGoTemplateRenderer(configValues, privateValues)
+	}
+	return &simpleRenderer{values: configValues}, nil
+}
+
+type simpleRenderer struct {
+	values addonfactory.Values
+}
+
+func (r *simpleRenderer) render(raw []byte) (*unstructured.Unstructured, error) {
+	t := fasttemplate.New(string(raw), "{{", "}}")
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON([]byte(t.ExecuteString(r.values))); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// templateData is the data of the templates rendered by the GoTemplate engine.
+type templateData struct {
+	// Values are the config values, including the builtin, default and customized variables.
+	Values addonfactory.Values
+	// NodePlacement, Registries and ProxyConfig are from the AddOnDeploymentConfig. They are read only in the
+	// templates and still applied to the workloads by the decorators.
+	NodePlacement *addonapiv1alpha1.NodePlacement
+	Registries    []addonapiv1alpha1.ImageMirror
+	ProxyConfig   addonapiv1alpha1.ProxyConfig
+}
+
+type goTemplateRenderer struct {
+	data templateData
+}
+
+func newGoTemplateRenderer(configValues, privateValues addonfactory.Values) (*goTemplateRenderer, error) {
+	data := templateData{
+		Values:        configValues,
+		NodePlacement: &addonapiv1alpha1.NodePlacement{},
+	}
+	if value, ok := privateValues[NodePlacementPrivateValueKey]; ok {
+		np, ok := value.(*addonapiv1alpha1.NodePlacement)
+		if !ok {
+			return nil, fmt.Errorf("node placement value is invalid")
+		}
+		if np != nil {
+			data.NodePlacement = np
+		}
+	}
+	if value, ok := privateValues[RegistriesPrivateValueKey]; ok {
+		registries, ok := value.([]addonapiv1alpha1.ImageMirror)
+		if !ok {
+			return nil, fmt.Errorf("registries value is invalid")
+		}
+		data.Registries = registries
+	}
+	if value, ok := privateValues[ProxyPrivateValueKey]; ok {
+		proxyConfig, ok := value.(addonapiv1alpha1.ProxyConfig)
+		if !ok {
+			return nil, fmt.Errorf("proxy config value is invalid")
+		}
+		data.ProxyConfig = proxyConfig
+	}
+	return &goTemplateRenderer{data: data}, nil
+}
+
+func (r *goTemplateRenderer) render(raw []byte) (*unstructured.Unstructured, error) {
+	manifest := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, err
+	}
+
+	condition, found, err := unstructured.NestedString(manifest, "metadata", "annotations",
+		AddOnTemplateManifestConditionAnnotationKey)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		unstructured.RemoveNestedField(manifest, "metadata", "annotations", AddOnTemplateManifestConditionAnnotationKey)
+		if annotations, _, _ := unstructured.NestedMap(manifest, "metadata", "annotations"); len(annotations) == 0 {
+			unstructured.RemoveNestedField(manifest, "metadata", "annotations")
+		}
+
+		result, err := r.renderString(condition, "condition")
+		if err != nil {
+			return nil, err
+		}
+		include, err := strconv.ParseBool(strings.TrimSpace(fmt.Sprint(result)))
+		if err != nil {
+			return nil, fmt.Errorf("the condition should be rendered to a boolean: %v", err)
+		}
+		if !include {
+			return nil, nil
+		}
+	}
+
+	rendered, _, err := r.renderValue(manifest, "")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// renderValue renders the string values in the value recursively, it returns true if the value is omitted.
+func (r *goTemplateRenderer) renderValue(value interface{}, path string) (interface{}, bool, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result, omitted, err := r.renderValue(item, fmt.Sprintf("%s.%s", path, key))
+			if err != nil {
+				return nil, false, err
+			}
+			if !omitted {
+				rendered[key] = result
+			}
+		}
+		return rendered, false, nil
+	case []interface{}:
+		rendered := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			result, omitted, err := r.renderValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, false, err
+			}
+			if !omitted {
+				rendered = append(rendered, result)
+			}
+		}
+		return rendered, false, nil
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, false, nil
+		}
+		result, err := r.renderString(v, path)
+		if err != nil {
+			return nil, false, err
+		}
+		return result, result == omitPlaceholder, nil
+	}
+	return value, false, nil
+}
+
+// renderString renders a string value. The result is the injected value if the string is rendered to the
+// placeholder of the inject function.
+func (r *goTemplateRenderer) renderString(text, path string) (interface{}, error) {
+	var injected []interface{}
+	funcs := templateFuncs()
+	funcs["inject"] = func(v interface{}) string {
+		injected = append(injected, v)
+		return fmt.Sprintf(injectPlaceholder, len(injected)-1)
+	}
+
+	t, err := parseTemplate(text, path, funcs)
+	if err != nil {
+		return nil, err
+	}
+	buf := &strings.Builder{}
+	if err := t.Execute(buf, r.data); err != nil {
+		return nil, err
+	}
+
+	result := buf.String()
+	for i, v := range injected {
+		if result == fmt.Sprintf(injectPlaceholder, i) {
+			return v, nil
+		}
+	}
+	if result != omitPlaceholder && strings.Contains(result, "\x00") {
+		return nil, fmt.Errorf("%s: inject and omit should be the whole value", path)
+	}
+	return result, nil
+}
+
+// parseTemplate parses the text with the functions and the omit function, the inject function is provided by
+// the caller since it records the injected values.
+func parseTemplate(text, path string, funcs template.FuncMap) (*template.Template, error) {
+	funcs["omit"] = func() string {
+		return omitPlaceholder
+	}
+	return template.New(path).Option("missingkey=error").Funcs(funcs).Parse(text)
+}
+
+// templateFuncs returns the functions of the GoTemplate engine. It is a restricted sprig like function set, the
+// functions accessing the environment, files or network are not provided.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"quote": func(v interface{}) string {
+			return strconv.Quote(toString(v))
+		},
+		"squote": func(v interface{}) string {
+			return "'" + toString(v) + "'"
+		},
+		"b64enc": func(v interface{}) string {
+			return base64.StdEncoding.EncodeToString([]byte(toString(v)))
+		},
+		"b64dec": func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			return string(data), err
+		},
+		"toJson": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+		"fromJson": func(s string) (interface{}, error) {
+			var v interface{}
+			err := json.Unmarshal([]byte(s), &v)
+			return v, err
+		},
+		"toString": toString,
+		"default": func(d, v interface{}) interface{} {
+			if isEmpty(v) {
+				return d
+			}
+			return v
+		},
+		"required": func(message string, v interface{}) (interface{}, error) {
+			if isEmpty(v) {
+				return nil, fmt.Errorf("%s", message)
+			}
+			return v, nil
+		},
+		"empty": isEmpty,
+		"ternary": func(vt, vf interface{}, condition bool) interface{} {
+			if condition {
+				return vt
+			}
+			return vf
+		},
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"join": func(sep string, v interface{}) string {
+			return strings.Join(toStrings(v), sep)
+		},
+		"indent": func(spaces int, s string) string {
+			pad := strings.Repeat(" ", spaces)
+			return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+		"nindent": func(spaces int, s string) string {
+			pad := strings.Repeat(" ", spaces)
+			return "\n" + pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+		},
+		"list": func(items ...interface{}) []interface{} {
+			return items
+		},
+		"append": func(list interface{}, item interface{}) ([]interface{}, error) {
+			items, err := toList(list)
+			if err != nil {
+				return nil, err
+			}
+			return append(items, item), nil
+		},
+		"dict": func(pairs ...interface{}) (map[string]interface{}, error) {
+			if len(pairs)%2 != 0 {
+				return nil, fmt.Errorf("dict requires key value pairs")
+			}
+			dict := make(map[string]interface{}, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				dict[toString(pairs[i])] = pairs[i+1]
+			}
+			return dict, nil
+		},
+		"hasKey": func(dict map[string]interface{}, key string) bool {
+			_, ok := dict[key]
+			return ok
+		},
+	}
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
+
+func toList(v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%T is not a list", v)
+	}
+	items := make([]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		items = append(items, value.Index(i).Interface())
+	}
+	return items, nil
+}
+
+func toStrings(v interface{}) []string {
+	items, err := toList(v)
+	if err != nil {
+		return []string{toString(v)}
+	}
+	strs := make([]string, 0, len(items))
+	for _, item := range items {
+		strs = append(strs, toString(item))
+	}
+	return strs
+}
+
+// isEmpty returns true if the value is nil or the zero value of its type.
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
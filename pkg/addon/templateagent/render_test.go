@@ -0,0 +1,221 @@
+package templateagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"open-cluster-management.io/addon-framework/pkg/addonfactory"
+	addonapiv1alpha1 "open-cluster-management.io/api/addon/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+func TestRenderManifest(t *testing.T) {
+	configValues := addonfactory.Values{
+		"CLUSTER_NAME":      "cluster1",
+		"INSTALL_NAMESPACE": "open-cluster-management-agent-addon",
+		"LOG_LEVEL":         "4",
+		"DEBUG":             "false",
+	}
+	privateValues := addonfactory.Values{
+		NodePlacementPrivateValueKey: &addonapiv1alpha1.NodePlacement{
+			Tolerations: []corev1.Toleration{
+				{Key: "foo", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+			},
+		},
+		RegistriesPrivateValueKey: []addonapiv1alpha1.ImageMirror{
+			{Source: "quay.io/ocm", Mirror: "quay.io/ocm-mirror"},
+		},
+	}
+
+	cases := []struct {
+		name           string
+		engine         TemplateEngine
+		manifest       string
+		expectedObject map[string]interface{}
+		expectedErr    bool
+	}{
+		{
+			name:     "simple engine",
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"{{CLUSTER_NAME}}"}}`,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "cluster1"},
+			},
+		},
+		{
+			name:   "go template functions",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"{{ .Values.CLUSTER_NAME | upper }}"},
+				"data":{"level":"{{ .Values.LOG_LEVEL | quote }}","encoded":"{{ b64enc .Values.CLUSTER_NAME }}",
+				"default":"{{ default \"info\" (index .Values \"MISSING\") }}",
+				"mirrors":"{{ range .Registries }}{{ .Source }}={{ .Mirror }}{{ end }}"}}`,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "CLUSTER1"},
+				"data": map[string]interface{}{
+					"level":   `"4"`,
+					"encoded": "Y2x1c3RlcjE=",
+					"default": "info",
+					"mirrors": "quay.io/ocm=quay.io/ocm-mirror",
+				},
+			},
+		},
+		{
+			name:   "go template inject and omit",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test"},"spec":{
+				"tolerations":"{{ inject .NodePlacement.Tolerations }}",
+				"nodeSelector":"{{ if not .NodePlacement.NodeSelector }}{{ omit }}{{ end }}",
+				"containers":[{"name":"agent","args":"{{ inject (list \"--v\" .Values.LOG_LEVEL) }}"},
+					"{{ if eq .Values.DEBUG \"true\" }}{{ inject (dict \"name\" \"debug\") }}{{ else }}{{ omit }}{{ end }}"]}}`,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "test"},
+				"spec": map[string]interface{}{
+					"tolerations": []interface{}{
+						map[string]interface{}{"key": "foo", "operator": "Exists", "effect": "NoExecute"},
+					},
+					"containers": []interface{}{
+						map[string]interface{}{"name": "agent", "args": []interface{}{"--v", "4"}},
+					},
+				},
+			},
+		},
+		{
+			name:   "go template condition is true",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"{{ eq .Values.CLUSTER_NAME \"cluster1\" }}"}}}`,
+			expectedObject: map[string]interface{}{
+				"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "test"},
+			},
+		},
+		{
+			name:   "go template condition is false",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"{{ .Values.DEBUG }}","keep":"true"}}}`,
+		},
+		{
+			name:   "go template condition is not a boolean",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"{{ .Values.LOG_LEVEL }}"}}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "go template missing value",
+			engine:      TemplateEngineGoTemplate,
+			manifest:    `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"{{ .Values.MISSING }}"}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "go template syntax error",
+			engine:      TemplateEngineGoTemplate,
+			manifest:    `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"{{ if }}"}}`,
+			expectedErr: true,
+		},
+		{
+			name:   "go template inject is not the whole value",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"},
+				"data":{"key":"prefix-{{ inject .Values.LOG_LEVEL }}"}}`,
+			expectedErr: true,
+		},
+		{
+			name:        "unsupported engine",
+			engine:      "Helm",
+			manifest:    `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			template := &addonapiv1alpha1.AddOnTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			}
+			if len(c.engine) > 0 {
+				template.Annotations = map[string]string{AddOnTemplateEngineAnnotationKey: string(c.engine)}
+			}
+
+			renderer, err := newManifestRenderer(template, configValues, privateValues)
+			var object *unstructured.Unstructured
+			if err == nil {
+				object, err = renderer.render([]byte(c.manifest))
+			}
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if c.expectedObject == nil {
+				assert.Nil(t, object)
+				return
+			}
+			assert.Equal(t, c.expectedObject, object.Object)
+		})
+	}
+}
+
+func TestValidateAddOnTemplateAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		engine      TemplateEngine
+		manifest    string
+		expectedErr bool
+	}{
+		{
+			name:     "simple engine",
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"{{CLUSTER_NAME}}"}}`,
+		},
+		{
+			name:   "go template condition",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"{{ eq .Values.LOG_LEVEL \"4\" }}"}}}`,
+		},
+		{
+			name:        "unsupported engine",
+			engine:      "Helm",
+			manifest:    `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test"}}`,
+			expectedErr: true,
+		},
+		{
+			name:   "go template condition syntax error",
+			engine: TemplateEngineGoTemplate,
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"{{ if }}"}}}`,
+			expectedErr: true,
+		},
+		{
+			name: "condition with the simple engine",
+			manifest: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","annotations":{
+				"addon.open-cluster-management.io/template-condition":"true"}}}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			template := &addonapiv1alpha1.AddOnTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: addonapiv1alpha1.AddOnTemplateSpec{
+					AgentSpec: workapiv1.ManifestWorkSpec{
+						Workload: workapiv1.ManifestsTemplate{
+							Manifests: []workapiv1.Manifest{{RawExtension: runtime.RawExtension{Raw: []byte(c.manifest)}}},
+						},
+					},
+				},
+			}
+			if len(c.engine) > 0 {
+				template.Annotations = map[string]string{AddOnTemplateEngineAnnotationKey: string(c.engine)}
+			}
+
+			err := ValidateAddOnTemplateAnnotations(template)
+			assert.Equal(t, c.expectedErr, err != nil, "unexpected error %v", err)
+		})
+	}
+}
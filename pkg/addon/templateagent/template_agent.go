@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"github.com/openshift/library-go/pkg/operator/events"
-	"github.com/valyala/fasttemplate"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -179,17 +178,26 @@ func (a *CRDTemplateAgentAddon) renderObjects(
 		"configValues", configValues,
 		"privateValues", privateValues)
 
-	for _, manifest := range template.Spec.AgentSpec.Workload.Manifests {
-		t := fasttemplate.New(string(manifest.Raw), "{{", "}}")
-		manifestStr := t.ExecuteString(configValues)
+	renderer, err := newManifestRenderer(template, configValues, privateValues)
+	if err != nil {
+		return objects, err
+	}
+	for i, manifest := range template.Spec.AgentSpec.Workload.Manifests {
+		object, err := renderer.render(manifest.Raw)
+		if err != nil {
+			return objects, fmt.Errorf("failed to render manifest %d of addon template %s: %w", i, template.Name, err)
+		}
+		if object == nil {
+			a.logger.V(4).Info("Addon manifest is excluded by its condition",
+				"addonNamespace", addon.Namespace,
+				"addonName", addon.Name,
+				"manifestIndex", i)
+			continue
+		}
 		a.logger.V(4).Info("Addon render result",
 			"addonNamespace", addon.Namespace,
 			"addonName", addon.Name,
-			"renderResult", manifestStr)
-		object := &unstructured.Unstructured{}
-		if err := object.UnmarshalJSON([]byte(manifestStr)); err != nil {
-			return objects, err
-		}
+			"renderResult", object)
 
 		object, err = a.decorateObject(template, object, presetValues, privateValues)
 		if err != nil {
@@ -13,13 +13,15 @@ import (
 
 func NewPlacementController() *cobra.Command {
 	opts := commonoptions.NewOptions()
+	manager := controllers.NewPlacementManagerOptions()
 	cmdConfig := opts.
-		NewControllerCommandConfig("placement", version.Get(), controllers.RunControllerManager, clock.RealClock{})
+		NewControllerCommandConfig("placement", version.Get(), manager.RunControllerManager, clock.RealClock{})
 	cmd := cmdConfig.NewCommandWithContext(context.TODO())
 	cmd.Use = "controller"
 	cmd.Short = "Start the Placement Scheduling Controller"
 
 	flags := cmd.Flags()
+	manager.AddFlags(flags)
 	opts.AddFlags(flags)
 
 	return cmd
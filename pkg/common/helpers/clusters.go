@@ -5,6 +5,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clustersdkv1beta1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta1"
 )
@@ -12,8 +13,18 @@ import (
 const (
 	// GcFinalizer is added to the managedCluster for resource cleanup, which maintained by gc controller.
 	GcFinalizer = "cluster.open-cluster-management.io/resource-cleanup"
+
+	// FreeResourcePrefix is the prefix of the resources in the capacity of a managedCluster, which are
+	// the free capacity of the cluster reported by the agent. The free capacity of a resource is the
+	// allocatable minus the requests of the pods running on the schedulable nodes.
+	FreeResourcePrefix = "free.open-cluster-management.io/"
 )
 
+// FreeResourceName returns the name of the free capacity of the resource in the capacity of a managedCluster.
+func FreeResourceName(resourceName clusterv1.ResourceName) clusterv1.ResourceName {
+	return clusterv1.ResourceName(FreeResourcePrefix + string(resourceName))
+}
+
 type PlacementDecisionGetter struct {
 	Client clusterlister.PlacementDecisionLister
 }
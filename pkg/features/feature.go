@@ -3,7 +3,17 @@
 package features
 
 import (
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/component-base/featuregate"
+
+	ocmfeature "open-cluster-management.io/api/feature"
+)
+
+const (
+	// FreeResources enables the registration agent to report the free resources of the managed cluster, which are
+	// the allocatable minus the pod requests. The agent watches all the pods on the managed cluster once it is
+	// enabled, so the permission is only granted to the agent when the feature is enabled.
+	FreeResources featuregate.Feature = "FreeResources"
 )
 
 var (
@@ -13,3 +23,26 @@ var (
 	// SpokeMutableFeatureGate of multiple mutable feature-gates for agent
 	SpokeMutableFeatureGate = featuregate.NewFeatureGate()
 )
+
+// spokeRegistrationFeatureGates are the feature gates of the registration agent which are not defined in the
+// api repo.
+var spokeRegistrationFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	FreeResources: {Default: false, PreRelease: featuregate.Alpha},
+}
+
+func init() {
+	utilruntime.Must(SpokeMutableFeatureGate.Add(spokeRegistrationFeatureGates))
+}
+
+// DefaultSpokeRegistrationFeatureGates returns all the known feature gates of the registration agent, including
+// the ones defined in the api repo.
+func DefaultSpokeRegistrationFeatureGates() map[featuregate.Feature]featuregate.FeatureSpec {
+	gates := map[featuregate.Feature]featuregate.FeatureSpec{}
+	for feature, spec := range ocmfeature.DefaultSpokeRegistrationFeatureGates {
+		gates[feature] = spec
+	}
+	for feature, spec := range spokeRegistrationFeatureGates {
+		gates[feature] = spec
+	}
+	return gates
+}
@@ -32,6 +32,7 @@ import (
 
 	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/common/queue"
+	"open-cluster-management.io/ocm/pkg/features"
 	"open-cluster-management.io/ocm/pkg/operator/helpers"
 )
 
@@ -212,6 +213,10 @@ type klusterletConfig struct {
 
 	// flag to enable about about-api
 	AboutAPIEnabled bool
+
+	// FreeResourcesEnabled grants the registration agent the permission to watch the pods to report the free
+	// resources of the managed cluster.
+	FreeResourcesEnabled bool
 }
 
 // If multiplehubs feature gate is enabled, using the bootstrapkubeconfigs from klusterlet CR.
@@ -391,10 +396,13 @@ func (n *klusterletController) sync(ctx context.Context, controllerContext facto
 		config.ClusterAnnotationsString = strings.Join(annotationsArray, ",")
 	}
 
+	defaultRegistrationFeatureGates := features.DefaultSpokeRegistrationFeatureGates()
 	config.AboutAPIEnabled = helpers.FeatureGateEnabled(
-		registrationFeatureGates, ocmfeature.DefaultSpokeRegistrationFeatureGates, ocmfeature.ClusterProperty)
+		registrationFeatureGates, defaultRegistrationFeatureGates, ocmfeature.ClusterProperty)
+	config.FreeResourcesEnabled = helpers.FeatureGateEnabled(
+		registrationFeatureGates, defaultRegistrationFeatureGates, features.FreeResources)
 	config.RegistrationFeatureGates, registrationFeatureMsgs = helpers.ConvertToFeatureGateFlags("Registration",
-		registrationFeatureGates, ocmfeature.DefaultSpokeRegistrationFeatureGates)
+		registrationFeatureGates, defaultRegistrationFeatureGates)
 
 	var workFeatureGates []operatorapiv1.FeatureGate
 	if klusterlet.Spec.WorkConfiguration != nil {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
+	"github.com/spf13/pflag"
 	"k8s.io/apiserver/pkg/server/mux"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/utils/clock"
@@ -18,10 +19,39 @@ import (
 	"open-cluster-management.io/ocm/pkg/placement/controllers/metrics"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/scheduling"
 	"open-cluster-management.io/ocm/pkg/placement/debugger"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
 )
 
-// RunControllerManager starts the controllers on hub to make placement decisions.
+// PlacementManagerOptions holds configuration for the placement controller
+type PlacementManagerOptions struct {
+	// SchedulerProfileFile is the path of the scheduler profile which picks and orders the
+	// filters and prioritizers. The default profile is used if it is empty.
+	SchedulerProfileFile string
+
+	// OutOfTreeRegistry contains the filters and prioritizers that are not part of this repo. It
+	// is merged with the in-tree registry so the plugins can be referenced by the scheduler profile
+	// and by the BuiltIn name of a placement's ScoreCoordinate.
+	OutOfTreeRegistry *plugins.Registry
+}
+
+// NewPlacementManagerOptions returns a PlacementManagerOptions
+func NewPlacementManagerOptions() *PlacementManagerOptions {
+	return &PlacementManagerOptions{}
+}
+
+// AddFlags registers flags for the placement controller
+func (o *PlacementManagerOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.SchedulerProfileFile, "scheduler-profile", o.SchedulerProfileFile,
+		"The path of the scheduler profile file which picks and orders the filters and prioritizers.")
+}
+
+// RunControllerManager starts the controllers on hub to make placement decisions with the default options.
 func RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
+	return NewPlacementManagerOptions().RunControllerManager(ctx, controllerContext)
+}
+
+// RunControllerManager starts the controllers on hub to make placement decisions.
+func (o *PlacementManagerOptions) RunControllerManager(ctx context.Context, controllerContext *controllercmd.ControllerContext) error {
 	clusterClient, err := clusterclient.NewForConfig(controllerContext.KubeConfig)
 	if err != nil {
 		return err
@@ -34,10 +64,10 @@ func RunControllerManager(ctx context.Context, controllerContext *controllercmd.
 
 	clusterInformers := clusterinformers.NewSharedInformerFactory(clusterClient, 10*time.Minute)
 
-	return RunControllerManagerWithInformers(ctx, controllerContext, kubeClient, clusterClient, clusterInformers)
+	return o.RunControllerManagerWithInformers(ctx, controllerContext, kubeClient, clusterClient, clusterInformers)
 }
 
-func RunControllerManagerWithInformers(
+func (o *PlacementManagerOptions) RunControllerManagerWithInformers(
 	ctx context.Context,
 	controllerContext *controllercmd.ControllerContext,
 	kubeClient kubernetes.Interface,
@@ -51,20 +81,40 @@ func RunControllerManagerWithInformers(
 
 	metrics := metrics.NewScheduleMetrics(clock.RealClock{})
 
-	scheduler := scheduling.NewPluginScheduler(
+	registry := scheduling.NewInTreeRegistry()
+	if err := registry.Merge(o.OutOfTreeRegistry); err != nil {
+		return err
+	}
+
+	profile := scheduling.DefaultSchedulerProfile()
+	if len(o.SchedulerProfileFile) > 0 {
+		profile, err = scheduling.LoadSchedulerProfile(o.SchedulerProfileFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	scheduler, err := scheduling.NewPluginSchedulerWithProfile(
 		scheduling.NewSchedulerHandler(
 			clusterClient,
+			clusterInformers.Cluster().V1beta1().Placements().Lister(),
 			clusterInformers.Cluster().V1beta1().PlacementDecisions().Lister(),
 			clusterInformers.Cluster().V1alpha1().AddOnPlacementScores().Lister(),
 			clusterInformers.Cluster().V1().ManagedClusters().Lister(),
 			recorder, metrics),
+		registry, profile,
 	)
+	if err != nil {
+		return err
+	}
 
 	if controllerContext.Server != nil {
 		debug := debugger.NewDebugger(
 			scheduler,
 			clusterInformers.Cluster().V1beta1().Placements(),
 			clusterInformers.Cluster().V1().ManagedClusters(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSets(),
+			clusterInformers.Cluster().V1beta2().ManagedClusterSetBindings(),
 		)
 
 		installDebugger(controllerContext.Server.Handler.NonGoRestfulMux, debug)
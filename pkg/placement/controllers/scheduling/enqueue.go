@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
@@ -19,6 +20,8 @@ import (
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
+
+	"open-cluster-management.io/ocm/pkg/placement/plugins/affinity"
 )
 
 const (
@@ -28,6 +31,7 @@ const (
 	placementsByClusterSetBinding  = "placementsByClusterSet"
 	clustersetBindingsByClusterSet = "clustersetBindingsByClusterSet"
 	placementsByScore              = "placementsByScore"
+	placementsByAffinity           = "placementsByAffinity"
 )
 
 type enqueuer struct {
@@ -51,6 +55,7 @@ func newEnqueuer(
 	err := placementInformer.Informer().AddIndexers(cache.Indexers{
 		placementsByScore:             indexPlacementsByScore,
 		placementsByClusterSetBinding: indexPlacementByClusterSetBinding,
+		placementsByAffinity:          indexPlacementsByAffinity,
 	})
 	if err != nil {
 		runtime.HandleError(err)
@@ -213,6 +218,61 @@ func (e *enqueuer) enqueuePlacementScore(obj interface{}) {
 	}
 }
 
+// enqueuePlacementDecision enqueues the placements whose affinity references the placement
+// owning the decision, so they are rescheduled when the decisions they depend on change.
+func (e *enqueuer) enqueuePlacementDecision(obj interface{}) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			accessor, err = meta.Accessor(tombstone.Obj)
+		}
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+	}
+
+	placementName, ok := accessor.GetLabels()[clusterapiv1beta1.PlacementLabel]
+	if !ok {
+		return
+	}
+
+	objs, err := e.placementIndexer.ByIndex(placementsByAffinity, accessor.GetNamespace())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	if len(objs) == 0 {
+		return
+	}
+
+	// the referenced placement might have been deleted, enqueue all the dependent placements
+	// in this case since the selector can not be evaluated.
+	var referenced *clusterapiv1beta1.Placement
+	referencedObj, exists, err := e.placementIndexer.GetByKey(fmt.Sprintf("%s/%s", accessor.GetNamespace(), placementName))
+	if err == nil && exists {
+		referenced = referencedObj.(*clusterapiv1beta1.Placement)
+	}
+
+	for _, o := range objs {
+		placement := o.(*clusterapiv1beta1.Placement)
+		if placement.Namespace == accessor.GetNamespace() && placement.Name == placementName {
+			continue
+		}
+
+		if referenced != nil {
+			placementAffinity, err := affinity.GetPlacementAffinity(placement)
+			if err != nil || placementAffinity == nil || !placementAffinity.References(placement, referenced) {
+				continue
+			}
+		}
+
+		e.logger.V(4).Info("Enqueue placement because of placement decision", "placementNamespace", placement.Namespace,
+			"placementName", placement.Name, "decision", klog.KRef(accessor.GetNamespace(), accessor.GetName()))
+		e.enqueuePlacementFunc(placement, e.queue)
+	}
+}
+
 func indexPlacementByClusterSetBinding(obj interface{}) ([]string, error) {
 	placement, ok := obj.(*clusterapiv1beta1.Placement)
 	if !ok {
@@ -255,6 +315,22 @@ func indexPlacementsByScore(obj interface{}) ([]string, error) {
 	return keys, nil
 }
 
+func indexPlacementsByAffinity(obj interface{}) ([]string, error) {
+	placement, ok := obj.(*clusterapiv1beta1.Placement)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a Placement", obj)
+	}
+
+	placementAffinity, err := affinity.GetPlacementAffinity(placement)
+	if err != nil || placementAffinity == nil {
+		// the invalid affinity is reported as misconfigured when the placement is scheduled.
+		return []string{}, nil
+	}
+
+	// the affinity only references the placements in the same namespace.
+	return []string{placement.Namespace}, nil
+}
+
 func indexClusterSetBindingByClusterSet(obj interface{}) ([]string, error) {
 	binding, ok := obj.(*clusterapiv1beta2.ManagedClusterSetBinding)
 	if !ok {
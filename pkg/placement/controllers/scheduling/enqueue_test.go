@@ -22,6 +22,7 @@ import (
 
 	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
 	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/affinity"
 )
 
 func newClusterInformerFactory(t *testing.T, clusterClient clusterclient.Interface, objects ...runtime.Object) clusterinformers.SharedInformerFactory {
@@ -30,6 +31,7 @@ func newClusterInformerFactory(t *testing.T, clusterClient clusterclient.Interfa
 	err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().AddIndexers(cache.Indexers{
 		placementsByScore:             indexPlacementsByScore,
 		placementsByClusterSetBinding: indexPlacementByClusterSetBinding,
+		placementsByAffinity:          indexPlacementsByAffinity,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -412,3 +414,93 @@ func TestEnqueuePlacementsByScore(t *testing.T) {
 		})
 	}
 }
+
+func newAffinityPlacement(namespace, name string, placementLabels map[string]string, value string) *clusterapiv1beta1.Placement {
+	placement := testinghelpers.NewPlacementWithAnnotations(namespace, name, map[string]string{
+		affinity.PlacementAffinityAnnotation: value,
+	}).Build()
+	placement.Labels = placementLabels
+	return placement
+}
+
+func TestEnqueuePlacementsByDecision(t *testing.T) {
+	cases := []struct {
+		name       string
+		decision   interface{}
+		initObjs   []runtime.Object
+		queuedKeys []string
+	}{
+		{
+			name: "enqueue placements referencing the decision owner",
+			decision: testinghelpers.NewPlacementDecision("ns1", "primary-decision-1").
+				WithLabel(clusterapiv1beta1.PlacementLabel, "primary").Build(),
+			initObjs: []runtime.Object{
+				newAffinityPlacement("ns1", "primary", map[string]string{"app": "ha", "role": "primary"}, ""),
+				newAffinityPlacement("ns1", "standby", map[string]string{"app": "ha", "role": "standby"},
+					`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}}]}`),
+				newAffinityPlacement("ns1", "other", nil,
+					`{"preferredAffinity":[{"placementSelector":{"matchLabels":{"role":"other"}}}]}`),
+				newAffinityPlacement("ns2", "remote", nil,
+					`{"requiredAffinity":[{"placementSelector":{"matchLabels":{"app":"ha"}}}]}`),
+			},
+			queuedKeys: []string{
+				"ns1/standby",
+			},
+		},
+		{
+			name: "the placement does not enqueue itself",
+			decision: testinghelpers.NewPlacementDecision("ns1", "standby-decision-1").
+				WithLabel(clusterapiv1beta1.PlacementLabel, "standby").Build(),
+			initObjs: []runtime.Object{
+				newAffinityPlacement("ns1", "standby", map[string]string{"app": "ha", "role": "standby"},
+					`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"app":"ha"}}}]}`),
+			},
+			queuedKeys: []string{},
+		},
+		{
+			name: "tombstone of a deleted placement",
+			decision: cache.DeletedFinalStateUnknown{
+				Key: "ns1/primary-decision-1",
+				Obj: testinghelpers.NewPlacementDecision("ns1", "primary-decision-1").
+					WithLabel(clusterapiv1beta1.PlacementLabel, "primary").Build(),
+			},
+			initObjs: []runtime.Object{
+				newAffinityPlacement("ns1", "standby", nil,
+					`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}}]}`),
+			},
+			queuedKeys: []string{
+				"ns1/standby",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, ctx := ktesting.NewTestContext(t)
+			clusterClient := clusterfake.NewSimpleClientset(c.initObjs...)
+			clusterInformerFactory := newClusterInformerFactory(t, clusterClient, c.initObjs...)
+
+			syncCtx := testingcommon.NewFakeSyncContext(t, "fake")
+			q := newEnqueuer(
+				ctx,
+				syncCtx.Queue(),
+				clusterInformerFactory.Cluster().V1().ManagedClusters(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
+				clusterInformerFactory.Cluster().V1beta1().Placements(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings(),
+			)
+			queuedKeys := sets.NewString()
+			fakeEnqueuePlacement := func(obj interface{}, queue workqueue.RateLimitingInterface) {
+				key, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+				queuedKeys.Insert(key)
+			}
+			q.enqueuePlacementFunc = fakeEnqueuePlacement
+			q.enqueuePlacementDecision(c.decision)
+
+			expectedQueuedKeys := sets.NewString(c.queuedKeys...)
+			if !queuedKeys.Equal(expectedQueuedKeys) {
+				t.Errorf("expected queued placements %q, but got %s", strings.Join(expectedQueuedKeys.List(), ","), strings.Join(queuedKeys.List(), ","))
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package scheduling
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/affinity"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/balance"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/celscore"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/extender"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/predicate"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/resource"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/steady"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/tainttoleration"
+)
+
+const (
+	FilterPredicate       string = "Predicate"
+	FilterTaintToleration string = "TaintToleration"
+
+	// FilterAffinity and PrioritizerAffinity are enabled by default, they keep all the clusters and
+	// score them 0 if the placement has no affinity annotation.
+	FilterAffinity      string = "Affinity"
+	PrioritizerAffinity string = "Affinity"
+
+	// FilterResourceMinAvailable is enabled by default, it keeps all the clusters if the placement
+	// has no resource min available annotation.
+	FilterResourceMinAvailable string = "ResourceMinAvailable"
+)
+
+// SchedulerProfile picks and orders the plugins used by the scheduler.
+type SchedulerProfile struct {
+	// Filters is the ordered list of filter names to run. The filters are run in the
+	// given order, and the default filters are used if it is empty.
+	Filters []string `json:"filters,omitempty"`
+
+	// Prioritizers is the list of prioritizers enabled by default with their weight. A placement
+	// can still override the weight with its PrioritizerPolicy. The default prioritizers are used
+	// if it is empty.
+	Prioritizers []PrioritizerProfile `json:"prioritizers,omitempty"`
+
+	// Extenders is the list of out-of-process webhooks. An extender with a filterVerb runs after the
+	// filters above, and an extender with a prioritizeVerb is enabled by default with its weight.
+	Extenders []extender.Config `json:"extenders,omitempty"`
+}
+
+// PrioritizerProfile defines the default weight of a prioritizer.
+type PrioritizerProfile struct {
+	Name   string `json:"name"`
+	Weight int32  `json:"weight"`
+}
+
+// DefaultSchedulerProfile returns the profile with the in-tree filters, and Balance, Steady and
+// Affinity prioritizers with weight 1.
+func DefaultSchedulerProfile() *SchedulerProfile {
+	return &SchedulerProfile{
+		Filters: []string{FilterPredicate, FilterTaintToleration, FilterAffinity, FilterResourceMinAvailable},
+		Prioritizers: []PrioritizerProfile{
+			{Name: PrioritizerBalance, Weight: 1},
+			{Name: PrioritizerSteady, Weight: 1},
+			{Name: PrioritizerAffinity, Weight: 1},
+		},
+	}
+}
+
+// LoadSchedulerProfile reads a yaml or json scheduler profile from the file. The sections not set
+// in the file are defaulted with DefaultSchedulerProfile.
+func LoadSchedulerProfile(path string) (*SchedulerProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &SchedulerProfile{}
+	if err := yaml.UnmarshalStrict(data, profile); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduler profile %q: %w", path, err)
+	}
+
+	defaultProfile := DefaultSchedulerProfile()
+	if len(profile.Filters) == 0 {
+		profile.Filters = defaultProfile.Filters
+	}
+	if len(profile.Prioritizers) == 0 {
+		profile.Prioritizers = defaultProfile.Prioritizers
+	}
+	return profile, nil
+}
+
+// Validate checks that all the plugins referenced in the profile are registered.
+func (p *SchedulerProfile) Validate(registry *plugins.Registry) error {
+	filters := map[string]bool{}
+	for _, name := range p.Filters {
+		if _, ok := registry.Filter(name); !ok {
+			return fmt.Errorf("filter %q is not registered", name)
+		}
+		if filters[name] {
+			return fmt.Errorf("filter %q is duplicated", name)
+		}
+		filters[name] = true
+	}
+
+	prioritizers := map[string]bool{}
+	for _, p := range p.Prioritizers {
+		if _, ok := registry.Prioritizer(p.Name); !ok {
+			return fmt.Errorf("prioritizer %q is not registered", p.Name)
+		}
+		if prioritizers[p.Name] {
+			return fmt.Errorf("prioritizer %q is duplicated", p.Name)
+		}
+		if p.Weight < -10 || p.Weight > 10 {
+			return fmt.Errorf("weight of prioritizer %q should be in range [-10, 10]", p.Name)
+		}
+		prioritizers[p.Name] = true
+	}
+
+	extenders := map[string]bool{}
+	for i := range p.Extenders {
+		config := &p.Extenders[i]
+		if err := config.Validate(); err != nil {
+			return err
+		}
+		if extenders[config.Name] {
+			return fmt.Errorf("extender %q is duplicated", config.Name)
+		}
+		_, isFilter := registry.Filter(config.Name)
+		_, isPrioritizer := registry.Prioritizer(config.Name)
+		if isFilter || isPrioritizer {
+			return fmt.Errorf("extender %q conflicts with a registered plugin", config.Name)
+		}
+		extenders[config.Name] = true
+	}
+	return nil
+}
+
+// prioritizerWeights converts the prioritizers of the profile to the default weights of the scheduler.
+func (p *SchedulerProfile) prioritizerWeights() map[clusterapiv1beta1.ScoreCoordinate]int32 {
+	weights := map[clusterapiv1beta1.ScoreCoordinate]int32{}
+	for _, p := range p.Prioritizers {
+		weights[clusterapiv1beta1.ScoreCoordinate{
+			Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
+			BuiltIn: p.Name,
+		}] = p.Weight
+	}
+	for _, e := range p.Extenders {
+		if len(e.PrioritizeVerb) == 0 {
+			continue
+		}
+		weights[clusterapiv1beta1.ScoreCoordinate{
+			Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
+			BuiltIn: e.Name,
+		}] = e.DefaultWeight()
+	}
+	return weights
+}
+
+// NewInTreeRegistry returns a registry with all the filters and prioritizers in this repo.
+func NewInTreeRegistry() *plugins.Registry {
+	registry := plugins.NewRegistry()
+	filters := map[string]plugins.FilterFactory{
+		FilterPredicate: func(handle plugins.Handle) plugins.Filter {
+			return predicate.New(handle)
+		},
+		FilterTaintToleration: func(handle plugins.Handle) plugins.Filter {
+			return tainttoleration.New(handle)
+		},
+		FilterAffinity: func(handle plugins.Handle) plugins.Filter {
+			return affinity.New(handle)
+		},
+		FilterResourceMinAvailable: func(handle plugins.Handle) plugins.Filter {
+			return resource.NewMinAvailable(handle)
+		},
+	}
+	prioritizers := map[string]plugins.PrioritizerFactory{
+		PrioritizerBalance: func(handle plugins.Handle) plugins.Prioritizer {
+			return balance.New(handle)
+		},
+		PrioritizerSteady: func(handle plugins.Handle) plugins.Prioritizer {
+			return steady.New(handle)
+		},
+		PrioritizerAffinity: func(handle plugins.Handle) plugins.Prioritizer {
+			return affinity.New(handle)
+		},
+	}
+
+	// the in-tree names never conflict, so the errors are ignored.
+	for name, factory := range filters {
+		_ = registry.RegisterFilter(name, factory)
+	}
+	for name, factory := range prioritizers {
+		_ = registry.RegisterPrioritizer(name, factory)
+	}
+
+	// the resource prioritizers, such as ResourceAllocatableCPU and ResourceFreeMemory, and the
+	// prioritizers of the extended resources, such as ResourceFree/nvidia.com/gpu.
+	for _, name := range resource.PrioritizerNames() {
+		factory, _ := resource.ResolvePrioritizer(name)
+		_ = registry.RegisterPrioritizer(name, factory)
+	}
+	_ = registry.RegisterPrioritizerResolver(resource.ResolvePrioritizer)
+
+	// the CEL prioritizers, such as CEL/newest-minor, with the expressions defined in the placement annotation.
+	_ = registry.RegisterPrioritizerResolver(celscore.ResolvePrioritizer)
+	return registry
+}
@@ -0,0 +1,351 @@
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/extender"
+)
+
+// excludeFilter filters out the clusters with the given label.
+type excludeFilter struct {
+	label string
+}
+
+func (f *excludeFilter) Name() string        { return "Exclude" }
+func (f *excludeFilter) Description() string { return "exclude clusters with label" }
+func (f *excludeFilter) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(f.Name(), framework.Success, "")
+}
+func (f *excludeFilter) Filter(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginFilterResult, *framework.Status) {
+	var filtered []*clusterapiv1.ManagedCluster
+	for _, cluster := range clusters {
+		if _, ok := cluster.Labels[f.label]; !ok {
+			filtered = append(filtered, cluster)
+		}
+	}
+	return plugins.PluginFilterResult{Filtered: filtered}, framework.NewStatus(f.Name(), framework.Success, "")
+}
+
+// constPrioritizer gives a preset score to each cluster.
+type constPrioritizer struct {
+	scores map[string]int64
+}
+
+func (p *constPrioritizer) Name() string        { return "Const" }
+func (p *constPrioritizer) Description() string { return "constant scores" }
+func (p *constPrioritizer) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(p.Name(), framework.Success, "")
+}
+func (p *constPrioritizer) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = p.scores[cluster.Name]
+	}
+	return plugins.PluginScoreResult{Scores: scores}, framework.NewStatus(p.Name(), framework.Success, "")
+}
+
+func newTestRegistry(t *testing.T) *plugins.Registry {
+	outOfTree := plugins.NewRegistry()
+	if err := outOfTree.RegisterFilter("Exclude", func(handle plugins.Handle) plugins.Filter {
+		return &excludeFilter{label: "freeze"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := outOfTree.RegisterPrioritizer("Const", func(handle plugins.Handle) plugins.Prioritizer {
+		return &constPrioritizer{scores: map[string]int64{"cluster1": 10, "cluster2": 100, "cluster3": 50}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewInTreeRegistry()
+	if err := registry.Merge(outOfTree); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+func TestRegistryMergeConflict(t *testing.T) {
+	outOfTree := plugins.NewRegistry()
+	_ = outOfTree.RegisterFilter(FilterPredicate, func(handle plugins.Handle) plugins.Filter {
+		return &excludeFilter{}
+	})
+	if err := NewInTreeRegistry().Merge(outOfTree); err == nil {
+		t.Errorf("expected conflict error, but got nil")
+	}
+}
+
+func TestLoadSchedulerProfile(t *testing.T) {
+	cases := []struct {
+		name            string
+		content         string
+		expectedProfile *SchedulerProfile
+		expectedErr     bool
+	}{
+		{
+			name:            "empty profile",
+			content:         "",
+			expectedProfile: DefaultSchedulerProfile(),
+		},
+		{
+			name:    "filters only",
+			content: "filters: [Exclude, Predicate]",
+			expectedProfile: &SchedulerProfile{
+				Filters:      []string{"Exclude", FilterPredicate},
+				Prioritizers: DefaultSchedulerProfile().Prioritizers,
+			},
+		},
+		{
+			name: "full profile",
+			content: `
+filters:
+- Predicate
+- TaintToleration
+- Exclude
+prioritizers:
+- name: Const
+  weight: 2
+`,
+			expectedProfile: &SchedulerProfile{
+				Filters:      []string{FilterPredicate, FilterTaintToleration, "Exclude"},
+				Prioritizers: []PrioritizerProfile{{Name: "Const", Weight: 2}},
+			},
+		},
+		{
+			name:        "unknown field",
+			content:     "plugins: [Exclude]",
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profile.yaml")
+			if err := os.WriteFile(path, []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := LoadSchedulerProfile(path)
+			if c.expectedErr != (err != nil) {
+				t.Fatalf("expected error %v, but got %v", c.expectedErr, err)
+			}
+			if err == nil && !reflect.DeepEqual(profile, c.expectedProfile) {
+				t.Errorf("expected profile %v, but got %v", c.expectedProfile, profile)
+			}
+		})
+	}
+}
+
+func TestSchedulerProfileValidate(t *testing.T) {
+	registry := newTestRegistry(t)
+	cases := []struct {
+		name        string
+		profile     *SchedulerProfile
+		expectedErr bool
+	}{
+		{
+			name:    "default profile",
+			profile: DefaultSchedulerProfile(),
+		},
+		{
+			name: "out of tree plugins",
+			profile: &SchedulerProfile{
+				Filters:      []string{"Exclude"},
+				Prioritizers: []PrioritizerProfile{{Name: "Const", Weight: 1}},
+			},
+		},
+		{
+			name: "resource plugins",
+			profile: &SchedulerProfile{
+				Filters: []string{FilterResourceMinAvailable},
+				Prioritizers: []PrioritizerProfile{
+					{Name: "ResourceFreeCPU", Weight: 1},
+					{Name: "ResourceFree/nvidia.com/gpu", Weight: 1},
+				},
+			},
+		},
+		{
+			name:        "unknown resource prioritizer",
+			profile:     &SchedulerProfile{Prioritizers: []PrioritizerProfile{{Name: "ResourceRatioCPU", Weight: 1}}},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown filter",
+			profile:     &SchedulerProfile{Filters: []string{"Unknown"}},
+			expectedErr: true,
+		},
+		{
+			name:        "duplicated filter",
+			profile:     &SchedulerProfile{Filters: []string{FilterPredicate, FilterPredicate}},
+			expectedErr: true,
+		},
+		{
+			name:        "unknown prioritizer",
+			profile:     &SchedulerProfile{Prioritizers: []PrioritizerProfile{{Name: "Unknown", Weight: 1}}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid weight",
+			profile:     &SchedulerProfile{Prioritizers: []PrioritizerProfile{{Name: "Const", Weight: 11}}},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.profile.Validate(registry)
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestScheduleWithProfile(t *testing.T) {
+	cases := []struct {
+		name                 string
+		profile              *SchedulerProfile
+		placement            *clusterapiv1beta1.Placement
+		expectedFilterResult []FilterResult
+		expectedDecisions    []string
+		expectedStatusCode   framework.Code
+	}{
+		{
+			name: "out of tree filter and default prioritizer",
+			profile: &SchedulerProfile{
+				Filters:      []string{FilterPredicate, "Exclude"},
+				Prioritizers: []PrioritizerProfile{{Name: "Const", Weight: 1}},
+			},
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).Build(),
+			expectedFilterResult: []FilterResult{
+				{Name: "Predicate", FilteredClusters: []string{"cluster1", "cluster2", "cluster3"}},
+				{Name: "Predicate,Exclude", FilteredClusters: []string{"cluster3", "cluster1"}},
+			},
+			expectedDecisions:  []string{"cluster3"},
+			expectedStatusCode: framework.Success,
+		},
+		{
+			name:    "out of tree prioritizer referenced by placement",
+			profile: &SchedulerProfile{Filters: []string{FilterPredicate}},
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).
+				WithPrioritizerPolicy(clusterapiv1beta1.PrioritizerPolicyModeExact).
+				WithPrioritizerConfig("Const", 1).Build(),
+			expectedFilterResult: []FilterResult{
+				{Name: "Predicate", FilteredClusters: []string{"cluster2", "cluster3", "cluster1"}},
+			},
+			expectedDecisions:  []string{"cluster2"},
+			expectedStatusCode: framework.Success,
+		},
+		{
+			name:    "unknown prioritizer referenced by placement",
+			profile: &SchedulerProfile{Filters: []string{FilterPredicate}},
+			placement: testinghelpers.NewPlacement(placementNamespace, placementName).
+				WithPrioritizerConfig("Unknown", 1).Build(),
+			expectedFilterResult: []FilterResult{
+				{Name: "Predicate", FilteredClusters: []string{"cluster1", "cluster2", "cluster3"}},
+			},
+			expectedStatusCode: framework.Misconfigured,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(c.placement)
+			s, err := NewPluginSchedulerWithProfile(
+				testinghelpers.NewFakePluginHandle(t, clusterClient, c.placement), newTestRegistry(t), c.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			clusters := []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithLabel("freeze", "true").Build(),
+				testinghelpers.NewManagedCluster("cluster3").Build(),
+			}
+			result, status := s.Schedule(context.TODO(), c.placement, clusters)
+			if status.Code() != c.expectedStatusCode {
+				t.Errorf("expected status code %v, but got %v", c.expectedStatusCode, status.Code())
+			}
+			if !reflect.DeepEqual(result.FilterResults(), c.expectedFilterResult) {
+				t.Errorf("expected filter results %v, but got %v", c.expectedFilterResult, result.FilterResults())
+			}
+
+			var decisions []string
+			for _, d := range result.Decisions() {
+				decisions = append(decisions, d.Name)
+			}
+			if !reflect.DeepEqual(decisions, c.expectedDecisions) {
+				t.Errorf("expected decisions %v, but got %v", c.expectedDecisions, decisions)
+			}
+		})
+	}
+}
+
+func TestScheduleWithExtender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args := &extender.Args{}
+		_ = json.NewDecoder(r.Body).Decode(args)
+		switch r.URL.Path {
+		case "/filter":
+			_ = json.NewEncoder(w).Encode(&extender.FilterResult{ClusterNames: []string{"cluster1", "cluster2"}})
+		case "/prioritize":
+			_ = json.NewEncoder(w).Encode(&extender.PrioritizeResult{Scores: map[string]int64{"cluster1": 10, "cluster2": 90}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	profile := &SchedulerProfile{
+		Filters:      []string{FilterPredicate},
+		Prioritizers: []PrioritizerProfile{{Name: PrioritizerSteady, Weight: 1}},
+		Extenders: []extender.Config{
+			{Name: "Webhook", URL: server.URL, FilterVerb: "filter", PrioritizeVerb: "prioritize"},
+		},
+	}
+	placement := testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).Build()
+	clusterClient := clusterfake.NewSimpleClientset(placement)
+	s, err := NewPluginSchedulerWithProfile(
+		testinghelpers.NewFakePluginHandle(t, clusterClient, placement), newTestRegistry(t), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").Build(),
+		testinghelpers.NewManagedCluster("cluster3").Build(),
+	}
+	result, status := s.Schedule(context.TODO(), placement, clusters)
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status %v", status.AsError())
+	}
+
+	expectedFilterResult := []FilterResult{
+		{Name: "Predicate", FilteredClusters: []string{"cluster1", "cluster2", "cluster3"}},
+		{Name: "Predicate,Webhook", FilteredClusters: []string{"cluster2", "cluster1"}},
+	}
+	if !reflect.DeepEqual(result.FilterResults(), expectedFilterResult) {
+		t.Errorf("expected filter results %v, but got %v", expectedFilterResult, result.FilterResults())
+	}
+	if len(result.Decisions()) != 1 || result.Decisions()[0].Name != "cluster2" {
+		t.Errorf("expected decision cluster2, but got %v", result.Decisions())
+	}
+	if result.PrioritizerScores()["cluster2"] != 90 {
+		t.Errorf("expected score 90 of cluster2, but got %v", result.PrioritizerScores())
+	}
+}
@@ -0,0 +1,186 @@
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+)
+
+const (
+	// RejectedClustersAnnotation is the annotation on the first PlacementDecision of a placement. It
+	// explains why the candidate clusters are not selected by the placement. The value is a json encoded
+	// RejectedClusters, which is bounded and only contains a part of the rejected clusters if there
+	// are too many, the clusters rejected by the filter plugins are kept first. The full list, including
+	// the clusters not in the bound clustersets, is returned by the debugger.
+	RejectedClustersAnnotation = "cluster.open-cluster-management.io/rejected-clusters"
+
+	// RejectionStageClusterSet means the cluster is not in any clusterset bound to the namespace
+	// and selected by the placement. It is only returned by the debugger, so the clusters outside the
+	// bound clustersets are not exposed to the users of the namespace.
+	RejectionStageClusterSet = "ClusterSet"
+
+	// RejectionStageNumberOfClusters means the cluster passes all the filters but is not selected
+	// since the placement already selects the NumberOfClusters clusters with higher scores.
+	RejectionStageNumberOfClusters = "NumberOfClusters"
+
+	// RejectionStageSpreadConstraints means the cluster passes all the filters but selecting it
+	// violates a DoNotSchedule spread constraint of the placement.
+	RejectionStageSpreadConstraints = "SpreadConstraints"
+
+	// maxNumOfRejectedClustersInAnnotation is the max number of rejected clusters in the annotation.
+	maxNumOfRejectedClustersInAnnotation = 20
+
+	// maxRejectionReasonLength is the max length of a rejection reason in the annotation.
+	maxRejectionReasonLength = 256
+)
+
+// ClusterRejection explains why a cluster is not selected by the placement.
+type ClusterRejection struct {
+	ClusterName string `json:"clusterName"`
+	// Stage is the name of the filter plugin rejecting the cluster, or the scheduling stage after
+	// the filters, such as ClusterSet, NumberOfClusters and SpreadConstraints.
+	Stage  string `json:"stage"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RejectedClusters is the value of the RejectedClustersAnnotation.
+type RejectedClusters struct {
+	// Total is the number of all the rejected clusters, which might be larger than the length of Clusters.
+	Total    int                `json:"total"`
+	Clusters []ClusterRejection `json:"clusters"`
+}
+
+// recordFilterRejections records the clusters filtered out by a filter plugin.
+func (r *scheduleResult) recordFilterRejections(
+	plugin string, before, after []*clusterapiv1.ManagedCluster, reasons map[string]string) {
+	passed := map[string]bool{}
+	for _, cluster := range after {
+		passed[cluster.Name] = true
+	}
+	for _, cluster := range before {
+		if passed[cluster.Name] {
+			continue
+		}
+		reason := reasons[cluster.Name]
+		if len(reason) == 0 {
+			reason = "filtered out by the plugin"
+		}
+		r.rejectedRecords = append(r.rejectedRecords, ClusterRejection{
+			ClusterName: cluster.Name,
+			Stage:       plugin,
+			Reason:      reason,
+		})
+	}
+}
+
+// recordSelectionRejections records the feasible clusters which are not selected.
+func (r *scheduleResult) recordSelectionRejections(placement *clusterapiv1beta1.Placement) {
+	selected := map[string]bool{}
+	for _, cluster := range r.scheduledDecisions {
+		selected[cluster.Name] = true
+	}
+
+	numOfClustersReached := placement.Spec.NumberOfClusters != nil &&
+		len(r.scheduledDecisions) >= int(*placement.Spec.NumberOfClusters)
+	for _, cluster := range r.feasibleClusters {
+		if selected[cluster.Name] {
+			continue
+		}
+		rejection := ClusterRejection{ClusterName: cluster.Name}
+		if numOfClustersReached {
+			rejection.Stage = RejectionStageNumberOfClusters
+			// the live score is not in the reason, otherwise the placement decision is rewritten whenever
+			// a score changes. The scores are returned by the debugger.
+			rejection.Reason = fmt.Sprintf("%d clusters with higher priority are selected",
+				*placement.Spec.NumberOfClusters)
+		} else {
+			rejection.Stage = RejectionStageSpreadConstraints
+			rejection.Reason = "selecting the cluster violates a DoNotSchedule spread constraint"
+		}
+		r.rejectedRecords = append(r.rejectedRecords, rejection)
+	}
+}
+
+func (r *scheduleResult) NumOfSpreadRejections() int {
+	count := 0
+	for _, rejection := range r.rejectedRecords {
+		if rejection.Stage == RejectionStageSpreadConstraints {
+			count++
+		}
+	}
+	return count
+}
+
+// ClusterSetRejections returns the rejections of the clusters which are not candidates of the placement
+// since they are not in any clusterset bound to the namespace and selected by the placement.
+func ClusterSetRejections(namespace string, clusters, candidates []*clusterapiv1.ManagedCluster) []ClusterRejection {
+	candidateNames := map[string]bool{}
+	for _, cluster := range candidates {
+		candidateNames[cluster.Name] = true
+	}
+
+	var rejections []ClusterRejection
+	for _, cluster := range clusters {
+		if candidateNames[cluster.Name] {
+			continue
+		}
+		rejection := ClusterRejection{
+			ClusterName: cluster.Name,
+			Stage:       RejectionStageClusterSet,
+			Reason: fmt.Sprintf(
+				"not in any ManagedClusterSet bound to namespace %s and selected by the placement", namespace),
+		}
+		if !cluster.DeletionTimestamp.IsZero() {
+			rejection.Reason = "the cluster is being deleted"
+		}
+		rejections = append(rejections, rejection)
+	}
+	return rejections
+}
+
+// SortClusterRejections sorts the rejections by cluster name.
+func SortClusterRejections(rejections []ClusterRejection) {
+	sort.SliceStable(rejections, func(i, j int) bool {
+		return rejections[i].ClusterName < rejections[j].ClusterName
+	})
+}
+
+// isSelectionStage returns true if the cluster is rejected after the filters.
+func isSelectionStage(stage string) bool {
+	return stage == RejectionStageNumberOfClusters || stage == RejectionStageSpreadConstraints ||
+		stage == RejectionStageClusterSet
+}
+
+// rejectedClustersAnnotationValue returns the bounded value of the RejectedClustersAnnotation, or an
+// empty string if there is no rejected cluster. The rejections are expected to be sorted by cluster
+// name, the rejections of the filter plugins are kept first when the value is truncated.
+func rejectedClustersAnnotationValue(rejections []ClusterRejection) (string, error) {
+	if len(rejections) == 0 {
+		return "", nil
+	}
+
+	rejections = append([]ClusterRejection{}, rejections...)
+	sort.SliceStable(rejections, func(i, j int) bool {
+		return !isSelectionStage(rejections[i].Stage) && isSelectionStage(rejections[j].Stage)
+	})
+
+	value := RejectedClusters{Total: len(rejections)}
+	for _, rejection := range rejections {
+		if len(value.Clusters) >= maxNumOfRejectedClustersInAnnotation {
+			break
+		}
+		if len(rejection.Reason) > maxRejectionReasonLength {
+			rejection.Reason = rejection.Reason[:maxRejectionReasonLength] + "..."
+		}
+		value.Clusters = append(value.Clusters, rejection)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
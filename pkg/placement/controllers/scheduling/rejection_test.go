@@ -0,0 +1,156 @@
+package scheduling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func TestScheduleRejectedClusters(t *testing.T) {
+	placement := testinghelpers.NewPlacement(placementNamespace, placementName).WithNOC(1).AddPredicate(
+		&metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}, nil, nil).Build()
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithLabel("env", "prod").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel("env", "prod").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithLabel("env", "dev").Build(),
+		testinghelpers.NewManagedCluster("cluster4").WithLabel("env", "prod").WithTaint(&clusterapiv1.Taint{
+			Key:    "gpu",
+			Effect: clusterapiv1.TaintEffectNoSelect,
+		}).Build(),
+	}
+
+	clusterClient := clusterfake.NewSimpleClientset()
+	s := NewPluginScheduler(testinghelpers.NewFakePluginHandle(t, clusterClient))
+	result, status := s.Schedule(context.TODO(), placement, clusters)
+	if status.IsError() {
+		t.Fatalf("unexpected status %v", status.AsError())
+	}
+
+	stages := map[string]string{}
+	for _, rejection := range result.RejectedClusters() {
+		stages[rejection.ClusterName] = rejection.Stage
+	}
+	expectedStages := map[string]string{
+		"cluster2": RejectionStageNumberOfClusters,
+		"cluster3": "Predicate",
+		"cluster4": "TaintToleration",
+	}
+	if !reflect.DeepEqual(stages, expectedStages) {
+		t.Errorf("expected rejection stages %v, but got %v", expectedStages, stages)
+	}
+
+	// the reasons do not include the live scores or resources, so they are stable across the syncs.
+	expectedReasons := map[string]string{
+		"cluster2": "1 clusters with higher priority are selected",
+		"cluster3": `predicate[0]: label selector "env=prod" does not match`,
+		"cluster4": "taint gpu=:NoSelect is not tolerated",
+	}
+	for _, rejection := range result.RejectedClusters() {
+		expected, ok := expectedReasons[rejection.ClusterName]
+		if ok && rejection.Reason != expected {
+			t.Errorf("expected reason %q of %s, but got %q", expected, rejection.ClusterName, rejection.Reason)
+		}
+	}
+}
+
+func TestClusterSetRejections(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").Build(),
+		testinghelpers.NewManagedCluster("cluster2").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithDeletionTimestamp().Build(),
+	}
+
+	rejections := ClusterSetRejections(placementNamespace, clusters, clusters[:1])
+	expected := []ClusterRejection{
+		{
+			ClusterName: "cluster2",
+			Stage:       RejectionStageClusterSet,
+			Reason:      fmt.Sprintf("not in any ManagedClusterSet bound to namespace %s and selected by the placement", placementNamespace),
+		},
+		{
+			ClusterName: "cluster3",
+			Stage:       RejectionStageClusterSet,
+			Reason:      "the cluster is being deleted",
+		},
+	}
+	if !reflect.DeepEqual(rejections, expected) {
+		t.Errorf("expected rejections %v, but got %v", expected, rejections)
+	}
+}
+
+func TestRejectedClustersAnnotationValue(t *testing.T) {
+	var rejections []ClusterRejection
+	for i := 0; i < maxNumOfRejectedClustersInAnnotation+5; i++ {
+		rejections = append(rejections, ClusterRejection{
+			ClusterName: fmt.Sprintf("cluster%02d", i),
+			Stage:       "Predicate",
+			Reason:      string(make([]byte, maxRejectionReasonLength+10)),
+		})
+	}
+
+	value, err := rejectedClustersAnnotationValue(rejections)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rejected := &RejectedClusters{}
+	if err := json.Unmarshal([]byte(value), rejected); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if rejected.Total != len(rejections) {
+		t.Errorf("expected total %d, but got %d", len(rejections), rejected.Total)
+	}
+	if len(rejected.Clusters) != maxNumOfRejectedClustersInAnnotation {
+		t.Errorf("expected %d clusters, but got %d", maxNumOfRejectedClustersInAnnotation, len(rejected.Clusters))
+	}
+	if len(rejected.Clusters[0].Reason) != maxRejectionReasonLength+len("...") {
+		t.Errorf("expected reason to be truncated, but got length %d", len(rejected.Clusters[0].Reason))
+	}
+
+	value, err = rejectedClustersAnnotationValue(nil)
+	if err != nil || len(value) != 0 {
+		t.Errorf("expected empty value without rejections, but got %q, %v", value, err)
+	}
+}
+
+func TestRejectedClustersAnnotationValueOrder(t *testing.T) {
+	var rejections []ClusterRejection
+	for i := 0; i < maxNumOfRejectedClustersInAnnotation; i++ {
+		rejections = append(rejections, ClusterRejection{
+			ClusterName: fmt.Sprintf("a-cluster%02d", i),
+			Stage:       RejectionStageNumberOfClusters,
+		})
+	}
+	rejections = append(rejections,
+		ClusterRejection{ClusterName: "b-cluster1", Stage: "TaintToleration"},
+		ClusterRejection{ClusterName: "b-cluster2", Stage: "Predicate"},
+	)
+
+	value, err := rejectedClustersAnnotationValue(rejections)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	rejected := &RejectedClusters{}
+	if err := json.Unmarshal([]byte(value), rejected); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if rejected.Total != len(rejections) {
+		t.Errorf("expected total %d, but got %d", len(rejections), rejected.Total)
+	}
+	expected := []string{"b-cluster1", "b-cluster2", "a-cluster00"}
+	for i, name := range expected {
+		if rejected.Clusters[i].ClusterName != name {
+			t.Errorf("expected cluster %s at %d, but got %s", name, i, rejected.Clusters[i].ClusterName)
+		}
+	}
+}
@@ -22,11 +22,7 @@ import (
 	"open-cluster-management.io/ocm/pkg/placement/controllers/metrics"
 	"open-cluster-management.io/ocm/pkg/placement/plugins"
 	"open-cluster-management.io/ocm/pkg/placement/plugins/addon"
-	"open-cluster-management.io/ocm/pkg/placement/plugins/balance"
-	"open-cluster-management.io/ocm/pkg/placement/plugins/predicate"
-	"open-cluster-management.io/ocm/pkg/placement/plugins/resource"
-	"open-cluster-management.io/ocm/pkg/placement/plugins/steady"
-	"open-cluster-management.io/ocm/pkg/placement/plugins/tainttoleration"
+	"open-cluster-management.io/ocm/pkg/placement/plugins/extender"
 )
 
 const (
@@ -66,6 +62,16 @@ type ScheduleResult interface {
 
 	// RequeueAfter returns the requeue time interval of the placement
 	RequeueAfter() *time.Duration
+
+	// SpreadResults returns the result for each spread constraint of the placement
+	SpreadResults() []SpreadResult
+
+	// RejectedClusters returns the reason why each given cluster is not selected, sorted by cluster name
+	RejectedClusters() []ClusterRejection
+
+	// NumOfSpreadRejections returns the number of feasible clusters which are not selected to honor the
+	// DoNotSchedule spread constraints
+	NumOfSpreadRejections() int
 }
 
 type FilterResult struct {
@@ -90,12 +96,15 @@ type scheduleResult struct {
 	filteredRecords map[string][]*clusterapiv1.ManagedCluster
 	scoreRecords    []PrioritizerResult
 	scoreSum        PrioritizerScore
+	spreadRecords   []SpreadResult
+	rejectedRecords []ClusterRejection
 	requeueAfter    *time.Duration
 }
 
 type schedulerHandler struct {
 	eventsRecorder          kevents.EventRecorder
 	metricsRecorder         *metrics.ScheduleMetrics
+	placementLister         clusterlisterv1beta1.PlacementLister
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister
 	scoreLister             clusterlisterv1alpha1.AddOnPlacementScoreLister
 	clusterLister           clusterlisterv1.ManagedClusterLister
@@ -104,6 +113,7 @@ type schedulerHandler struct {
 
 func NewSchedulerHandler(
 	clusterClient clusterclient.Interface,
+	placementLister clusterlisterv1beta1.PlacementLister,
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister,
 	scoreLister clusterlisterv1alpha1.AddOnPlacementScoreLister,
 	clusterLister clusterlisterv1.ManagedClusterLister,
@@ -114,6 +124,7 @@ func NewSchedulerHandler(
 	return &schedulerHandler{
 		eventsRecorder:          eventsRecorder,
 		metricsRecorder:         metricsRecorder,
+		placementLister:         placementLister,
 		placementDecisionLister: placementDecisionLister,
 		scoreLister:             scoreLister,
 		clusterLister:           clusterLister,
@@ -125,6 +136,10 @@ func (s *schedulerHandler) EventRecorder() kevents.EventRecorder {
 	return s.eventsRecorder
 }
 
+func (s *schedulerHandler) PlacementLister() clusterlisterv1beta1.PlacementLister {
+	return s.placementLister
+}
+
 func (s *schedulerHandler) DecisionLister() clusterlisterv1beta1.PlacementDecisionLister {
 	return s.placementDecisionLister
 }
@@ -145,35 +160,63 @@ func (s *schedulerHandler) MetricsRecorder() *metrics.ScheduleMetrics {
 	return s.metricsRecorder
 }
 
-// Initialize the default prioritizer weight.
-// Balane and Steady weight 1, others weight 0.
-// The default weight can be replaced by each placement's PrioritizerConfigs.
-var defaultPrioritizerConfig = map[clusterapiv1beta1.ScoreCoordinate]int32{
-	{
-		Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
-		BuiltIn: PrioritizerBalance,
-	}: 1,
-	{
-		Type:    clusterapiv1beta1.ScoreCoordinateTypeBuiltIn,
-		BuiltIn: PrioritizerSteady,
-	}: 1,
-}
-
 type pluginScheduler struct {
 	handle             plugins.Handle
+	registry           *plugins.Registry
 	filters            []plugins.Filter
 	prioritizerWeights map[clusterapiv1beta1.ScoreCoordinate]int32
 }
 
+// NewPluginScheduler returns a scheduler with the in-tree plugins and the default scheduler profile.
 func NewPluginScheduler(handle plugins.Handle) *pluginScheduler {
-	return &pluginScheduler{
-		handle: handle,
-		filters: []plugins.Filter{
-			predicate.New(handle),
-			tainttoleration.New(handle),
-		},
-		prioritizerWeights: defaultPrioritizerConfig,
+	// the default profile only references in-tree plugins, so it never fails.
+	s, _ := NewPluginSchedulerWithProfile(handle, NewInTreeRegistry(), DefaultSchedulerProfile())
+	return s
+}
+
+// NewPluginSchedulerWithProfile returns a scheduler whose filters and default prioritizer weights are
+// picked from the registry by the profile. Prioritizers referenced by a placement are also resolved
+// through the registry, and the extenders in the profile are resolved by their names.
+func NewPluginSchedulerWithProfile(
+	handle plugins.Handle, registry *plugins.Registry, profile *SchedulerProfile) (*pluginScheduler, error) {
+	if err := profile.Validate(registry); err != nil {
+		return nil, err
+	}
+
+	var filters []plugins.Filter
+	for _, name := range profile.Filters {
+		factory, _ := registry.Filter(name)
+		filters = append(filters, factory(handle))
+	}
+
+	// copy the registry so the extenders are only visible to this scheduler.
+	schedulerRegistry := plugins.NewRegistry()
+	if err := schedulerRegistry.Merge(registry); err != nil {
+		return nil, err
+	}
+	for _, config := range profile.Extenders {
+		e, err := extender.New(config)
+		if err != nil {
+			return nil, err
+		}
+		if e.IsFilter() {
+			filters = append(filters, e)
+		}
+		if e.IsPrioritizer() {
+			if err := schedulerRegistry.RegisterPrioritizer(e.Name(), func(plugins.Handle) plugins.Prioritizer {
+				return e
+			}); err != nil {
+				return nil, err
+			}
+		}
 	}
+
+	return &pluginScheduler{
+		handle:             handle,
+		registry:           schedulerRegistry,
+		filters:            filters,
+		prioritizerWeights: profile.prioritizerWeights(),
+	}, nil
 }
 
 func (s *pluginScheduler) Schedule(
@@ -203,8 +246,6 @@ func (s *pluginScheduler) Schedule(
 			"plugin_name": f.Name(),
 		}).Observe(s.handle.MetricsRecorder().SinceInSeconds(startTime))
 
-		filtered = filterResult.Filtered
-
 		switch {
 		case status.IsError():
 			return results, status
@@ -213,6 +254,9 @@ func (s *pluginScheduler) Schedule(
 			finalStatus = status
 		}
 
+		results.recordFilterRejections(f.Name(), filtered, filterResult.Filtered, filterResult.Rejected)
+		filtered = filterResult.Filtered
+
 		filterPipline = append(filterPipline, f.Name())
 
 		results.filteredRecords[strings.Join(filterPipline, ",")] = filtered
@@ -231,7 +275,7 @@ func (s *pluginScheduler) Schedule(
 	}
 
 	// 2. Generate prioritizers for each placement whose weight != 0.
-	prioritizers, status := getPrioritizers(weights, s.handle)
+	prioritizers, status := getPrioritizers(weights, s.registry, s.handle)
 	switch {
 	case status.IsError():
 		return results, status
@@ -292,12 +336,19 @@ func (s *pluginScheduler) Schedule(
 	results.scoreSum = scoreSum
 
 	// select clusters and generate cluster decisions
-	decisions := selectClusters(placement, filtered)
+	decisions, spreadResults := selectClustersWithSpread(placement, filtered)
 	scheduled, unscheduled := len(decisions), 0
 	if placement.Spec.NumberOfClusters != nil {
 		unscheduled = int(*placement.Spec.NumberOfClusters) - scheduled
 	}
 	results.scheduledDecisions = decisions
+	results.spreadRecords = spreadResults
+	results.recordSelectionRejections(placement)
+	// without NumberOfClusters all the feasible clusters are expected to be selected, so the clusters
+	// dropped by the DoNotSchedule spread constraints are unscheduled.
+	if placement.Spec.NumberOfClusters == nil {
+		unscheduled = results.NumOfSpreadRejections()
+	}
 	results.unscheduledDecisions = unscheduled
 
 	// set placement requeue time
@@ -385,7 +436,7 @@ func mergeWeights(defaultWeight map[clusterapiv1beta1.ScoreCoordinate]int32,
 }
 
 // Generate prioritizers for the placement.
-func getPrioritizers(weights map[clusterapiv1beta1.ScoreCoordinate]int32, handle plugins.Handle,
+func getPrioritizers(weights map[clusterapiv1beta1.ScoreCoordinate]int32, registry *plugins.Registry, handle plugins.Handle,
 ) (map[clusterapiv1beta1.ScoreCoordinate]plugins.Prioritizer, *framework.Status) {
 	result := make(map[clusterapiv1beta1.ScoreCoordinate]plugins.Prioritizer)
 	status := framework.NewStatus("", framework.Success, "")
@@ -394,17 +445,12 @@ func getPrioritizers(weights map[clusterapiv1beta1.ScoreCoordinate]int32, handle
 			continue
 		}
 		if k.Type == clusterapiv1beta1.ScoreCoordinateTypeBuiltIn {
-			switch {
-			case k.BuiltIn == PrioritizerBalance:
-				result[k] = balance.New(handle)
-			case k.BuiltIn == PrioritizerSteady:
-				result[k] = steady.New(handle)
-			case k.BuiltIn == PrioritizerResourceAllocatableCPU || k.BuiltIn == PrioritizerResourceAllocatableMemory:
-				result[k] = resource.NewResourcePrioritizerBuilder(handle).WithPrioritizerName(k.BuiltIn).Build()
-			default:
+			factory, ok := registry.Prioritizer(k.BuiltIn)
+			if !ok {
 				msg := fmt.Sprintf("incorrect builtin prioritizer: %s", k.BuiltIn)
 				return nil, framework.NewStatus("", framework.Misconfigured, msg)
 			}
+			result[k] = factory(handle)
 		} else {
 			if k.AddOn == nil {
 				return nil, framework.NewStatus("", framework.Misconfigured, "addOn should not be empty")
@@ -439,6 +485,12 @@ func (r *scheduleResult) FilterResults() []FilterResult {
 	return results
 }
 
+func (r *scheduleResult) RejectedClusters() []ClusterRejection {
+	rejections := append([]ClusterRejection{}, r.rejectedRecords...)
+	SortClusterRejections(rejections)
+	return rejections
+}
+
 func (r *scheduleResult) PrioritizerResults() []PrioritizerResult {
 	return r.scoreRecords
 }
@@ -458,3 +510,7 @@ func (r *scheduleResult) NumOfUnscheduled() int {
 func (r *scheduleResult) RequeueAfter() *time.Duration {
 	return r.requeueAfter
 }
+
+func (r *scheduleResult) SpreadResults() []SpreadResult {
+	return r.spreadRecords
+}
@@ -58,6 +58,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -70,6 +78,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0},
+				},
 			},
 			expectedUnScheduled: 0,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -96,6 +109,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -108,6 +129,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0},
+				},
 			},
 			expectedUnScheduled: 2,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -169,6 +195,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -181,6 +215,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 100, "cluster2": 100, "cluster3": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+				},
 			},
 			expectedUnScheduled: 0,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -204,6 +243,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -216,6 +263,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0},
+				},
 			},
 			clusters: []*clusterapiv1.ManagedCluster{
 				testinghelpers.NewManagedCluster("cluster1").WithLabel(clusterapiv1beta2.ClusterSetLabel, clusterSetName).Build(),
@@ -274,6 +326,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1", "cluster3"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1", "cluster3"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1", "cluster3"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -286,6 +346,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0, "cluster3": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster3": 0},
+				},
 			},
 			expectedUnScheduled: 1,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -333,6 +398,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -345,6 +418,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+				},
 				{
 					Name:   "ResourceAllocatableMemory",
 					Weight: 1,
@@ -397,6 +475,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1", "cluster2", "cluster3"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -444,6 +530,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster1", "cluster2"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster1", "cluster2"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -456,6 +550,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 100, "cluster2": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0},
+				},
 			},
 			expectedUnScheduled: 2,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -490,6 +589,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -502,6 +609,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+				},
 			},
 			expectedUnScheduled: 0,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
@@ -541,6 +653,14 @@ func TestSchedule(t *testing.T) {
 					Name:             "Predicate,TaintToleration",
 					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
 				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity",
+					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
+				},
+				{
+					Name:             "Predicate,TaintToleration,Affinity,ResourceMinAvailable",
+					FilteredClusters: []string{"cluster3", "cluster1", "cluster2"},
+				},
 			},
 			expectedScoreResult: []PrioritizerResult{
 				{
@@ -553,6 +673,11 @@ func TestSchedule(t *testing.T) {
 					Weight: 1,
 					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 100},
 				},
+				{
+					Name:   "Affinity",
+					Weight: 1,
+					Scores: PrioritizerScore{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+				},
 			},
 			expectedUnScheduled: 0,
 			expectedStatus:      *framework.NewStatus("", framework.Success, ""),
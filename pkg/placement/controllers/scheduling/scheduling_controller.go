@@ -163,6 +163,21 @@ func NewSchedulingController(
 		utilruntime.HandleError(err)
 	}
 
+	// setup event handler for placementdecision informer
+	// Once a placementdecision changes, the placements whose affinity references the owner
+	// of the decision are enqueued. The owner itself is enqueued by the filtered events
+	// informer below.
+	_, err = placementDecisionInformer.Informer().AddEventHandler(&cache.ResourceEventHandlerFuncs{
+		AddFunc: enQueuer.enqueuePlacementDecision,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			enQueuer.enqueuePlacementDecision(newObj)
+		},
+		DeleteFunc: enQueuer.enqueuePlacementDecision,
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
 	return factory.New().
 		WithSyncContext(syncCtx).
 		WithInformersQueueKeysFunc(
@@ -256,9 +271,15 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 		len(clusters),
 		len(scheduleResult.Decisions()),
 		scheduleResult.NumOfUnscheduled(),
+		scheduleResult.NumOfSpreadRejections(),
 		status,
 	)
 
+	// explain why the other clusters are not selected on the first placement decision
+	if err := c.setRejectedClustersAnnotation(decisions, scheduleResult.RejectedClusters()); err != nil {
+		return err
+	}
+
 	// requeue placement if requeueAfter is defined in scheduleResult
 	if syncCtx != nil && scheduleResult.RequeueAfter() != nil {
 		key, _ := cache.MetaNamespaceKeyFunc(placement)
@@ -286,8 +307,18 @@ func (c *schedulingController) syncPlacement(ctx context.Context, syncCtx factor
 
 // getManagedClusterSetBindings returns all bindings found in the placement namespace.
 func (c *schedulingController) getValidManagedClusterSetBindings(placementNamespace string) ([]*clusterapiv1beta2.ManagedClusterSetBinding, error) {
+	return GetValidManagedClusterSetBindings(placementNamespace, c.clusterSetBindingLister, c.clusterSetLister)
+}
+
+// GetValidManagedClusterSetBindings returns the clustersetbindings in the placement namespace which
+// refer to an existing clusterset.
+func GetValidManagedClusterSetBindings(
+	placementNamespace string,
+	clusterSetBindingLister clusterlisterv1beta2.ManagedClusterSetBindingLister,
+	clusterSetLister clusterlisterv1beta2.ManagedClusterSetLister,
+) ([]*clusterapiv1beta2.ManagedClusterSetBinding, error) {
 	// get all clusterset bindings under the placement namespace
-	bindings, err := c.clusterSetBindingLister.ManagedClusterSetBindings(placementNamespace).List(labels.Everything())
+	bindings, err := clusterSetBindingLister.ManagedClusterSetBindings(placementNamespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
@@ -298,7 +329,7 @@ func (c *schedulingController) getValidManagedClusterSetBindings(placementNamesp
 	var validBindings []*clusterapiv1beta2.ManagedClusterSetBinding
 	for _, binding := range bindings {
 		// ignore clustersetbinding refers to a non-existent clusterset
-		_, err := c.clusterSetLister.Get(binding.Name)
+		_, err := clusterSetLister.Get(binding.Name)
 		if errors.IsNotFound(err) {
 			continue
 		}
@@ -313,6 +344,11 @@ func (c *schedulingController) getValidManagedClusterSetBindings(placementNamesp
 
 // getEligibleClusterSets returns the names of clusterset that eligible for the placement
 func (c *schedulingController) getEligibleClusterSets(placement *clusterapiv1beta1.Placement, bindings []*clusterapiv1beta2.ManagedClusterSetBinding) []string {
+	return GetEligibleClusterSets(placement, bindings)
+}
+
+// GetEligibleClusterSets returns the names of clusterset that eligible for the placement
+func GetEligibleClusterSets(placement *clusterapiv1beta1.Placement, bindings []*clusterapiv1beta2.ManagedClusterSetBinding) []string {
 	// filter out invaid clustersetbindings
 	clusterSetNames := sets.NewString()
 	for _, binding := range bindings {
@@ -371,6 +407,29 @@ func (c *schedulingController) getAvailableClusters(clusterSetNames []string) ([
 	return result, nil
 }
 
+// setRejectedClustersAnnotation sets the RejectedClustersAnnotation on the first placement decision, with the
+// candidate clusters rejected by the scheduler. The clusters not in the eligible clustersets are not in the
+// annotation, since they should not be exposed to the namespace.
+func (c *schedulingController) setRejectedClustersAnnotation(
+	decisions []*clusterapiv1beta1.PlacementDecision,
+	rejections []ClusterRejection,
+) error {
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	value, err := rejectedClustersAnnotationValue(rejections)
+	if err != nil {
+		return err
+	}
+	if len(value) > 0 {
+		decisions[0].Annotations = map[string]string{
+			RejectedClustersAnnotation: value,
+		}
+	}
+	return nil
+}
+
 // updateStatus updates the status of the placement according to intermediate scheduling data.
 func (c *schedulingController) updateStatus(
 	ctx context.Context,
@@ -409,7 +468,8 @@ func newSatisfiedCondition(
 	numOfBindings,
 	numOfAvailableClusters,
 	numOfFeasibleClusters,
-	numOfUnscheduledDecisions int,
+	numOfUnscheduledDecisions,
+	numOfSpreadRejections int,
 	status *framework.Status,
 ) metav1.Condition {
 	condition := metav1.Condition{
@@ -444,6 +504,10 @@ func newSatisfiedCondition(
 		condition.Status = metav1.ConditionFalse
 		condition.Reason = "NotAllDecisionsScheduled"
 		condition.Message = fmt.Sprintf("%d cluster decisions unscheduled", numOfUnscheduledDecisions)
+		if numOfSpreadRejections > 0 {
+			condition.Message += fmt.Sprintf(
+				", %d clusters are not selected to honor the DoNotSchedule spread constraints", numOfSpreadRejections)
+		}
 	}
 	return condition
 }
@@ -476,7 +540,7 @@ func (c *schedulingController) generatePlacementDecisionsAndStatus(
 	var decisionGroupStatus []*clusterapiv1beta1.DecisionGroupStatus
 
 	// generate decision group
-	decisionGroups, status := c.generateDecisionGroups(placement, clusters)
+	decisionGroups, status := generateDecisionGroups(placement, clusters)
 
 	// generate placement decision for each decision group
 	for decisionGroupIndex, decisionGroup := range decisionGroups {
@@ -494,7 +558,7 @@ func (c *schedulingController) generatePlacementDecisionsAndStatus(
 }
 
 // generateDecisionGroups group clusters based on the placement decision strategy.
-func (c *schedulingController) generateDecisionGroups(
+func generateDecisionGroups(
 	placement *clusterapiv1beta1.Placement,
 	clusters []*clusterapiv1.ManagedCluster,
 ) (clusterDecisionGroups, *framework.Status) {
@@ -546,6 +610,40 @@ func (c *schedulingController) generateDecisionGroups(
 	return groups, framework.NewStatus("", framework.Success, "")
 }
 
+// DecisionGroupResult is the cluster names of a decision group generated for the placement.
+type DecisionGroupResult struct {
+	GroupName    string   `json:"groupName"`
+	GroupIndex   int      `json:"groupIndex"`
+	ClusterNames []string `json:"clusterNames"`
+}
+
+// GenerateDecisionGroupResults groups the selected clusters by the placement decision strategy in the
+// same way as the scheduling controller does when it creates the placement decisions.
+func GenerateDecisionGroupResults(
+	placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster,
+) ([]DecisionGroupResult, *framework.Status) {
+	groups, status := generateDecisionGroups(placement, clusters)
+	if status.IsError() {
+		return nil, status
+	}
+
+	results := make([]DecisionGroupResult, 0, len(groups))
+	for index, group := range groups {
+		result := DecisionGroupResult{
+			GroupName:    group.decisionGroupName,
+			GroupIndex:   index,
+			ClusterNames: []string{},
+		}
+		for _, d := range group.clusterDecisions {
+			result.ClusterNames = append(result.ClusterNames, d.ClusterName)
+		}
+		sort.Strings(result.ClusterNames)
+		results = append(results, result)
+	}
+	return results, status
+}
+
 func (c *schedulingController) generateDecision(
 	placement *clusterapiv1beta1.Placement,
 	clusterDecisionGroup clusterDecisionGroup,
@@ -703,6 +801,14 @@ func (c *schedulingController) createOrUpdatePlacementDecision(
 
 	newPlacementDecision := existPlacementDecision.DeepCopy()
 	newPlacementDecision.Labels = placementDecision.Labels
+	if value, ok := placementDecision.Annotations[RejectedClustersAnnotation]; ok {
+		if newPlacementDecision.Annotations == nil {
+			newPlacementDecision.Annotations = map[string]string{}
+		}
+		newPlacementDecision.Annotations[RejectedClustersAnnotation] = value
+	} else {
+		delete(newPlacementDecision.Annotations, RejectedClustersAnnotation)
+	}
 	newPlacementDecision.Status.Decisions = clusterDecisions
 	updated, err := placementDecisionPatcher.PatchStatus(ctx, newPlacementDecision, newPlacementDecision.Status, existPlacementDecision.Status)
 	// If status has been updated, just return, this is to avoid conflict when updating the label later.
@@ -861,8 +861,10 @@ func TestNewSatisfiedCondition(t *testing.T) {
 		numOfAvailableClusters    int
 		numOfFeasibleClusters     int
 		numOfUnscheduledDecisions int
+		numOfSpreadRejections     int
 		expectedStatus            metav1.ConditionStatus
 		expectedReason            string
+		expectedMessage           string
 	}{
 		{
 			name:                      "NoManagedClusterSetBindings",
@@ -921,6 +923,19 @@ func TestNewSatisfiedCondition(t *testing.T) {
 			expectedStatus:            metav1.ConditionFalse,
 			expectedReason:            "NotAllDecisionsScheduled",
 		},
+		{
+			name:                      "NotAllDecisionsScheduled with spread rejections",
+			eligibleClusterSets:       []string{"clusterset1"},
+			numOfBindings:             1,
+			numOfAvailableClusters:    3,
+			numOfFeasibleClusters:     3,
+			numOfUnscheduledDecisions: 2,
+			numOfSpreadRejections:     2,
+			expectedStatus:            metav1.ConditionFalse,
+			expectedReason:            "NotAllDecisionsScheduled",
+			expectedMessage: "2 cluster decisions unscheduled, " +
+				"2 clusters are not selected to honor the DoNotSchedule spread constraints",
+		},
 	}
 
 	for _, c := range cases {
@@ -932,6 +947,7 @@ func TestNewSatisfiedCondition(t *testing.T) {
 				c.numOfAvailableClusters,
 				c.numOfFeasibleClusters,
 				c.numOfUnscheduledDecisions,
+				c.numOfSpreadRejections,
 				nil,
 			)
 
@@ -941,6 +957,9 @@ func TestNewSatisfiedCondition(t *testing.T) {
 			if condition.Reason != c.expectedReason {
 				t.Errorf("expected reason %q but got %q", c.expectedReason, condition.Reason)
 			}
+			if len(c.expectedMessage) > 0 && condition.Message != c.expectedMessage {
+				t.Errorf("expected message %q but got %q", c.expectedMessage, condition.Message)
+			}
 		})
 	}
 }
@@ -0,0 +1,205 @@
+package scheduling
+
+import (
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/helpers"
+)
+
+// SpreadResult is the result of one spread constraint of the placement, including the number of
+// selected clusters in each topology and the final skew.
+type SpreadResult struct {
+	TopologyKey       string                                       `json:"topologyKey"`
+	TopologyKeyType   clusterapiv1beta1.TopologyKeyType            `json:"topologyKeyType"`
+	MaxSkew           int32                                        `json:"maxSkew"`
+	WhenUnsatisfiable clusterapiv1beta1.UnsatisfiableMaxSkewAction `json:"whenUnsatisfiable"`
+	// TopologyCounts is the number of selected clusters in each topology. A topology with
+	// no selected cluster has a zero count.
+	TopologyCounts map[string]int `json:"topologyCounts"`
+	// Skew is the difference between the maximum and the minimum count of the topologies.
+	Skew int `json:"skew"`
+}
+
+// spreadConstraint tracks the number of selected clusters in each topology of a spread constraint term.
+type spreadConstraint struct {
+	term clusterapiv1beta1.SpreadConstraintsTerm
+	// topologies maps the cluster name to its topology value. The cluster without the topology key is
+	// not in the map.
+	topologies map[string]string
+	counts     map[string]int
+}
+
+func newSpreadConstraint(term clusterapiv1beta1.SpreadConstraintsTerm, clusters []*clusterapiv1.ManagedCluster) *spreadConstraint {
+	if term.MaxSkew < 1 {
+		term.MaxSkew = 1
+	}
+	if len(term.WhenUnsatisfiable) == 0 {
+		term.WhenUnsatisfiable = clusterapiv1beta1.ScheduleAnyway
+	}
+
+	c := &spreadConstraint{
+		term:       term,
+		topologies: map[string]string{},
+		counts:     map[string]int{},
+	}
+	for _, cluster := range clusters {
+		value, ok := topologyValue(term, cluster)
+		if !ok {
+			continue
+		}
+		c.topologies[cluster.Name] = value
+		c.counts[value] = 0
+	}
+	return c
+}
+
+func topologyValue(term clusterapiv1beta1.SpreadConstraintsTerm, cluster *clusterapiv1.ManagedCluster) (string, bool) {
+	switch term.TopologyKeyType {
+	case clusterapiv1beta1.TopologyKeyTypeLabel:
+		value, ok := cluster.Labels[term.TopologyKey]
+		return value, ok
+	case clusterapiv1beta1.TopologyKeyTypeClaim:
+		value, ok := helpers.GetClusterClaims(cluster)[term.TopologyKey]
+		return value, ok
+	}
+	return "", false
+}
+
+// violation returns how much the skew exceeds MaxSkew if the cluster is selected, and whether the
+// cluster is eligible for this constraint at all. A cluster without the topology key is not eligible
+// for a DoNotSchedule constraint and is ignored by a ScheduleAnyway constraint.
+func (c *spreadConstraint) violation(clusterName string) (int, bool) {
+	value, ok := c.topologies[clusterName]
+	if !ok {
+		return 0, c.term.WhenUnsatisfiable != clusterapiv1beta1.DoNotSchedule
+	}
+
+	newCount := c.counts[value] + 1
+	minCount := newCount
+	for v, count := range c.counts {
+		if v != value && count < minCount {
+			minCount = count
+		}
+	}
+
+	skew := newCount - minCount
+	if skew <= int(c.term.MaxSkew) {
+		return 0, true
+	}
+	return skew - int(c.term.MaxSkew), c.term.WhenUnsatisfiable != clusterapiv1beta1.DoNotSchedule
+}
+
+func (c *spreadConstraint) add(clusterName string) {
+	if value, ok := c.topologies[clusterName]; ok {
+		c.counts[value]++
+	}
+}
+
+func (c *spreadConstraint) result() SpreadResult {
+	result := SpreadResult{
+		TopologyKey:       c.term.TopologyKey,
+		TopologyKeyType:   c.term.TopologyKeyType,
+		MaxSkew:           c.term.MaxSkew,
+		WhenUnsatisfiable: c.term.WhenUnsatisfiable,
+		TopologyCounts:    map[string]int{},
+	}
+
+	first := true
+	var minCount, maxCount int
+	for value, count := range c.counts {
+		result.TopologyCounts[value] = count
+		if first || count < minCount {
+			minCount = count
+		}
+		if first || count > maxCount {
+			maxCount = count
+		}
+		first = false
+	}
+	result.Skew = maxCount - minCount
+	return result
+}
+
+// selectClustersWithSpread selects clusters from the sorted cluster slice while honoring the spread
+// constraints of the placement. In each round, the clusters violating a DoNotSchedule constraint are
+// skipped, and the cluster with the least violation of the constraints is selected. The constraints
+// with a smaller index are considered first, and the clusters with the same violation are selected by
+// their order in the slice, which is sorted by score.
+func selectClustersWithSpread(
+	placement *clusterapiv1beta1.Placement, clusters []*clusterapiv1.ManagedCluster) ([]*clusterapiv1.ManagedCluster, []SpreadResult) {
+	terms := placement.Spec.SpreadPolicy.SpreadConstraints
+	if len(terms) == 0 {
+		return selectClusters(placement, clusters), nil
+	}
+
+	numOfDecisions := len(clusters)
+	if placement.Spec.NumberOfClusters != nil && int(*placement.Spec.NumberOfClusters) < numOfDecisions {
+		numOfDecisions = int(*placement.Spec.NumberOfClusters)
+	}
+
+	constraints := make([]*spreadConstraint, 0, len(terms))
+	for _, term := range terms {
+		constraints = append(constraints, newSpreadConstraint(term, clusters))
+	}
+
+	selected := make([]bool, len(clusters))
+	for numOfSelected := 0; numOfSelected < numOfDecisions; numOfSelected++ {
+		best := -1
+		var bestViolations []int
+		for i, cluster := range clusters {
+			if selected[i] {
+				continue
+			}
+
+			violations, eligible := make([]int, len(constraints)), true
+			for j, c := range constraints {
+				violations[j], eligible = c.violation(cluster.Name)
+				if !eligible {
+					break
+				}
+			}
+			if !eligible {
+				continue
+			}
+
+			if best == -1 || lessViolations(violations, bestViolations) {
+				best, bestViolations = i, violations
+			}
+		}
+
+		// no cluster can be selected without violating a DoNotSchedule constraint.
+		if best == -1 {
+			break
+		}
+
+		selected[best] = true
+		for _, c := range constraints {
+			c.add(clusters[best].Name)
+		}
+	}
+
+	// keep the decisions in the order of the sorted clusters.
+	decisions := []*clusterapiv1.ManagedCluster{}
+	for i, cluster := range clusters {
+		if selected[i] {
+			decisions = append(decisions, cluster)
+		}
+	}
+
+	results := make([]SpreadResult, 0, len(constraints))
+	for _, c := range constraints {
+		results = append(results, c.result())
+	}
+	return decisions, results
+}
+
+// lessViolations compares the violations in the order of the constraint index.
+func lessViolations(a, b []int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package scheduling
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newSpreadPlacement(noc *int32, terms ...clusterapiv1beta1.SpreadConstraintsTerm) *clusterapiv1beta1.Placement {
+	placement := testinghelpers.NewPlacement(placementNamespace, placementName).Build()
+	placement.Spec.NumberOfClusters = noc
+	placement.Spec.SpreadPolicy.SpreadConstraints = terms
+	return placement
+}
+
+func TestSelectClustersWithSpread(t *testing.T) {
+	noc := func(n int32) *int32 { return &n }
+
+	// the clusters are sorted by score, region-a clusters have the highest scores.
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("a1").WithLabel("region", "a").WithClaim("zone", "z1").Build(),
+		testinghelpers.NewManagedCluster("a2").WithLabel("region", "a").WithClaim("zone", "z2").Build(),
+		testinghelpers.NewManagedCluster("a3").WithLabel("region", "a").WithClaim("zone", "z1").Build(),
+		testinghelpers.NewManagedCluster("a4").WithLabel("region", "a").WithClaim("zone", "z2").Build(),
+		testinghelpers.NewManagedCluster("b1").WithLabel("region", "b").WithClaim("zone", "z1").Build(),
+		testinghelpers.NewManagedCluster("c1").WithLabel("region", "c").WithClaim("zone", "z2").Build(),
+		testinghelpers.NewManagedCluster("none").Build(),
+	}
+
+	cases := []struct {
+		name              string
+		placement         *clusterapiv1beta1.Placement
+		expectedDecisions []string
+		expectedResults   []SpreadResult
+	}{
+		{
+			name:              "no spread constraints",
+			placement:         newSpreadPlacement(noc(3)),
+			expectedDecisions: []string{"a1", "a2", "a3"},
+		},
+		{
+			name: "spread by label with schedule anyway",
+			placement: newSpreadPlacement(noc(3), clusterapiv1beta1.SpreadConstraintsTerm{
+				TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+			}),
+			expectedDecisions: []string{"a1", "b1", "c1"},
+			expectedResults: []SpreadResult{
+				{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+					WhenUnsatisfiable: clusterapiv1beta1.ScheduleAnyway,
+					TopologyCounts:    map[string]int{"a": 1, "b": 1, "c": 1},
+				},
+			},
+		},
+		{
+			name: "schedule anyway exceeds max skew when needed",
+			placement: newSpreadPlacement(noc(6), clusterapiv1beta1.SpreadConstraintsTerm{
+				TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+				WhenUnsatisfiable: clusterapiv1beta1.ScheduleAnyway,
+			}),
+			expectedDecisions: []string{"a1", "a2", "a3", "b1", "c1", "none"},
+			expectedResults: []SpreadResult{
+				{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+					WhenUnsatisfiable: clusterapiv1beta1.ScheduleAnyway,
+					TopologyCounts:    map[string]int{"a": 3, "b": 1, "c": 1},
+					Skew:              2,
+				},
+			},
+		},
+		{
+			name: "do not schedule leaves decisions unscheduled",
+			placement: newSpreadPlacement(noc(6), clusterapiv1beta1.SpreadConstraintsTerm{
+				TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+				WhenUnsatisfiable: clusterapiv1beta1.DoNotSchedule,
+			}),
+			expectedDecisions: []string{"a1", "a2", "b1", "c1"},
+			expectedResults: []SpreadResult{
+				{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+					WhenUnsatisfiable: clusterapiv1beta1.DoNotSchedule,
+					TopologyCounts:    map[string]int{"a": 2, "b": 1, "c": 1},
+					Skew:              1,
+				},
+			},
+		},
+		{
+			name: "do not schedule with larger max skew",
+			placement: newSpreadPlacement(nil, clusterapiv1beta1.SpreadConstraintsTerm{
+				TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 2,
+				WhenUnsatisfiable: clusterapiv1beta1.DoNotSchedule,
+			}),
+			expectedDecisions: []string{"a1", "a2", "a3", "b1", "c1"},
+			expectedResults: []SpreadResult{
+				{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 2,
+					WhenUnsatisfiable: clusterapiv1beta1.DoNotSchedule,
+					TopologyCounts:    map[string]int{"a": 3, "b": 1, "c": 1},
+					Skew:              2,
+				},
+			},
+		},
+		{
+			name: "constraints are considered by index",
+			placement: newSpreadPlacement(noc(2),
+				clusterapiv1beta1.SpreadConstraintsTerm{
+					TopologyKey: "zone", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeClaim, MaxSkew: 1,
+				},
+				clusterapiv1beta1.SpreadConstraintsTerm{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+				},
+			),
+			expectedDecisions: []string{"a1", "c1"},
+			expectedResults: []SpreadResult{
+				{
+					TopologyKey: "zone", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeClaim, MaxSkew: 1,
+					WhenUnsatisfiable: clusterapiv1beta1.ScheduleAnyway,
+					TopologyCounts:    map[string]int{"z1": 1, "z2": 1},
+				},
+				{
+					TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+					WhenUnsatisfiable: clusterapiv1beta1.ScheduleAnyway,
+					TopologyCounts:    map[string]int{"a": 1, "b": 0, "c": 1},
+					Skew:              1,
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			decisions, results := selectClustersWithSpread(c.placement, clusters)
+
+			var actual []string
+			for _, d := range decisions {
+				actual = append(actual, d.Name)
+			}
+			if !reflect.DeepEqual(actual, c.expectedDecisions) {
+				t.Errorf("expected decisions %v, but got %v", c.expectedDecisions, actual)
+			}
+			if !reflect.DeepEqual(results, c.expectedResults) {
+				t.Errorf("expected spread results %v, but got %v", c.expectedResults, results)
+			}
+		})
+	}
+}
+
+func TestScheduleSpreadWithoutNumberOfClusters(t *testing.T) {
+	placement := newSpreadPlacement(nil, clusterapiv1beta1.SpreadConstraintsTerm{
+		TopologyKey: "region", TopologyKeyType: clusterapiv1beta1.TopologyKeyTypeLabel, MaxSkew: 1,
+		WhenUnsatisfiable: clusterapiv1beta1.DoNotSchedule,
+	})
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("a1").WithLabel("region", "a").Build(),
+		testinghelpers.NewManagedCluster("a2").WithLabel("region", "a").Build(),
+		testinghelpers.NewManagedCluster("a3").WithLabel("region", "a").Build(),
+		testinghelpers.NewManagedCluster("b1").WithLabel("region", "b").Build(),
+	}
+
+	s := NewPluginScheduler(testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset()))
+	result, status := s.Schedule(context.TODO(), placement, clusters)
+	if status.IsError() {
+		t.Fatalf("unexpected status %v", status.AsError())
+	}
+
+	if len(result.Decisions()) != 3 {
+		t.Errorf("expected 3 decisions, but got %d", len(result.Decisions()))
+	}
+	// the dropped cluster is unscheduled and reported as rejected by the spread constraints
+	if result.NumOfUnscheduled() != 1 {
+		t.Errorf("expected 1 unscheduled decision, but got %d", result.NumOfUnscheduled())
+	}
+	if result.NumOfSpreadRejections() != 1 {
+		t.Errorf("expected 1 spread rejection, but got %d", result.NumOfSpreadRejections())
+	}
+	expected := []ClusterRejection{{
+		ClusterName: "a3",
+		Stage:       RejectionStageSpreadConstraints,
+		Reason:      "selecting the cluster violates a DoNotSchedule spread constraint",
+	}}
+	if !reflect.DeepEqual(result.RejectedClusters(), expected) {
+		t.Errorf("expected rejections %v, but got %v", expected, result.RejectedClusters())
+	}
+}
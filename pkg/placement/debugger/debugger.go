@@ -1,49 +1,130 @@
 package debugger
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 
 	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
+	clusterinformerv1beta2 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta2"
 	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
 	clusterlisterv1beta1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	clusterlisterv1beta2 "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta2"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clustersdkv1beta2 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta2"
 
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/scheduling"
 )
 
 const DebugPath = "/debug/placements/"
 
+// maxDryRunRequestBytes is the maximum size of a dry-run request body.
+const maxDryRunRequestBytes = 10 * 1024 * 1024
+
 // Debugger provides a debug http endpoint for scheduler
 type Debugger struct {
-	scheduler       scheduling.Scheduler
-	clusterLister   clusterlisterv1.ManagedClusterLister
-	placementLister clusterlisterv1beta1.PlacementLister
+	scheduler               scheduling.Scheduler
+	clusterLister           clusterlisterv1.ManagedClusterLister
+	clusterSetLister        clusterlisterv1beta2.ManagedClusterSetLister
+	clusterSetBindingLister clusterlisterv1beta2.ManagedClusterSetBindingLister
+	placementLister         clusterlisterv1beta1.PlacementLister
 }
 
 // DebugResult is the result returned by debugger
 type DebugResult struct {
 	FilterResults     []scheduling.FilterResult      `json:"filteredPiplieResults,omitempty"`
 	PrioritizeResults []scheduling.PrioritizerResult `json:"prioritizeResults,omitempty"`
+	SpreadResults     []scheduling.SpreadResult      `json:"spreadResults,omitempty"`
+	RejectedClusters  []scheduling.ClusterRejection  `json:"rejectedClusters,omitempty"`
 	Error             string                         `json:"error,omitempty"`
 }
 
+// DryRunRequest is the body of a dry-run request. The placement is scheduled against the current
+// clusters with the overrides applied and the hypothetical clusters added, and nothing is persisted.
+type DryRunRequest struct {
+	// Labels and Annotations of the placement. They are copied from the existing placement with the
+	// same namespace and name if not set.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Spec is the placement spec to schedule. The spec of the existing placement with the same
+	// namespace and name is used if it is not set.
+	Spec *clusterapiv1beta1.PlacementSpec `json:"spec,omitempty"`
+
+	// ClusterOverrides modifies the existing clusters before scheduling.
+	ClusterOverrides []ClusterOverride `json:"clusterOverrides,omitempty"`
+
+	// HypotheticalClusters are added to the existing clusters before scheduling. A hypothetical
+	// cluster is a candidate of the placement if it belongs to an eligible clusterset by its labels.
+	HypotheticalClusters []clusterapiv1.ManagedCluster `json:"hypotheticalClusters,omitempty"`
+}
+
+// ClusterOverride modifies the labels, taints and claims of an existing cluster.
+type ClusterOverride struct {
+	// Name is the name of the existing cluster.
+	Name string `json:"name"`
+
+	// Labels are merged into the labels of the cluster. A label with an empty value is removed.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Taints replaces the taints of the cluster if it is not nil.
+	Taints []clusterapiv1.Taint `json:"taints,omitempty"`
+
+	// Claims are merged into the cluster claims of the cluster. A claim with an empty value is removed.
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+// DryRunResult is the result returned by a dry-run request
+type DryRunResult struct {
+	FilterResults     []scheduling.FilterResult        `json:"filteredPiplieResults,omitempty"`
+	PrioritizeResults []scheduling.PrioritizerResult   `json:"prioritizeResults,omitempty"`
+	SpreadResults     []scheduling.SpreadResult        `json:"spreadResults,omitempty"`
+	RejectedClusters  []scheduling.ClusterRejection    `json:"rejectedClusters,omitempty"`
+	AggregatedScores  scheduling.PrioritizerScore      `json:"aggregatedScores,omitempty"`
+	Decisions         []string                         `json:"decisions"`
+	DecisionGroups    []scheduling.DecisionGroupResult `json:"decisionGroups,omitempty"`
+	NumOfUnscheduled  int                              `json:"numOfUnscheduled"`
+	RequeueAfter      string                           `json:"requeueAfter,omitempty"`
+	Warning           string                           `json:"warning,omitempty"`
+	Error             string                           `json:"error,omitempty"`
+}
+
 func NewDebugger(
 	scheduler scheduling.Scheduler,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
-	clusterInformer clusterinformerv1.ManagedClusterInformer) *Debugger {
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	clusterSetInformer clusterinformerv1beta2.ManagedClusterSetInformer,
+	clusterSetBindingInformer clusterinformerv1beta2.ManagedClusterSetBindingInformer) *Debugger {
 	return &Debugger{
-		scheduler:       scheduler,
-		clusterLister:   clusterInformer.Lister(),
-		placementLister: placementInformer.Lister(),
+		scheduler:               scheduler,
+		clusterLister:           clusterInformer.Lister(),
+		clusterSetLister:        clusterSetInformer.Lister(),
+		clusterSetBindingLister: clusterSetBindingInformer.Lister(),
+		placementLister:         placementInformer.Lister(),
 	}
 }
 
+// Handler replays the scheduling of an existing placement on GET, and schedules a placement
+// in dry-run mode on POST.
 func (d *Debugger) Handler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		d.dryRunHandler(w, r)
+		return
+	}
+
 	namespace, name, err := d.parsePath(r.URL.Path)
 	if err != nil {
 		d.reportErr(w, err)
@@ -64,13 +145,218 @@ func (d *Debugger) Handler(w http.ResponseWriter, r *http.Request) {
 
 	scheduleResults, _ := d.scheduler.Schedule(r.Context(), placement, clusters)
 
-	result := DebugResult{FilterResults: scheduleResults.FilterResults(), PrioritizeResults: scheduleResults.PrioritizerResults()}
+	result := DebugResult{
+		FilterResults:     scheduleResults.FilterResults(),
+		PrioritizeResults: scheduleResults.PrioritizerResults(),
+		SpreadResults:     scheduleResults.SpreadResults(),
+		RejectedClusters:  scheduleResults.RejectedClusters(),
+	}
+
+	resultByte, _ := json.Marshal(result)
+
+	_, _ = w.Write(resultByte)
+}
+
+func (d *Debugger) dryRunHandler(w http.ResponseWriter, r *http.Request) {
+	namespace, name, err := d.parsePath(r.URL.Path)
+	if err != nil {
+		d.reportErr(w, err)
+		return
+	}
+	if len(namespace) == 0 || len(name) == 0 {
+		d.reportErr(w, fmt.Errorf("the path should be %s<namespace>/<name>", DebugPath))
+		return
+	}
+
+	request := &DryRunRequest{}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxDryRunRequestBytes)).Decode(request); err != nil {
+		d.reportErr(w, fmt.Errorf("failed to decode dry-run request: %w", err))
+		return
+	}
+
+	result, err := d.dryRun(r.Context(), namespace, name, request)
+	if err != nil {
+		d.reportErr(w, err)
+		return
+	}
 
 	resultByte, _ := json.Marshal(result)
 
 	_, _ = w.Write(resultByte)
 }
 
+func (d *Debugger) dryRun(ctx context.Context, namespace, name string, request *DryRunRequest) (*DryRunResult, error) {
+	placement, err := d.placementLister.Placements(namespace).Get(name)
+	switch {
+	case errors.IsNotFound(err):
+		if request.Spec == nil {
+			return nil, fmt.Errorf("spec is required since placement %s/%s does not exist", namespace, name)
+		}
+		placement = &clusterapiv1beta1.Placement{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		}
+	case err != nil:
+		return nil, err
+	default:
+		placement = placement.DeepCopy()
+	}
+	if request.Spec != nil {
+		placement.Spec = *request.Spec
+	}
+	if request.Labels != nil {
+		placement.Labels = request.Labels
+	}
+	if request.Annotations != nil {
+		placement.Annotations = request.Annotations
+	}
+
+	clusters, candidates, err := d.candidateClusters(placement, request)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduleResult, status := d.scheduler.Schedule(ctx, placement, candidates)
+	rejections := append(scheduleResult.RejectedClusters(), scheduling.ClusterSetRejections(placement.Namespace, clusters, candidates)...)
+	scheduling.SortClusterRejections(rejections)
+	result := &DryRunResult{
+		FilterResults:     scheduleResult.FilterResults(),
+		PrioritizeResults: scheduleResult.PrioritizerResults(),
+		SpreadResults:     scheduleResult.SpreadResults(),
+		RejectedClusters:  rejections,
+		AggregatedScores:  scheduleResult.PrioritizerScores(),
+		Decisions:         []string{},
+		NumOfUnscheduled:  scheduleResult.NumOfUnscheduled(),
+	}
+	if status.IsError() {
+		result.Error = status.Message()
+		return result, nil
+	}
+	if status.Code() == framework.Warning {
+		result.Warning = status.Message()
+	}
+
+	for _, cluster := range scheduleResult.Decisions() {
+		result.Decisions = append(result.Decisions, cluster.Name)
+	}
+	if requeueAfter := scheduleResult.RequeueAfter(); requeueAfter != nil {
+		result.RequeueAfter = requeueAfter.String()
+	}
+
+	groups, status := scheduling.GenerateDecisionGroupResults(placement, scheduleResult.Decisions())
+	if status.IsError() {
+		result.Error = status.Message()
+		return result, nil
+	}
+	result.DecisionGroups = groups
+
+	return result, nil
+}
+
+// candidateClusters returns all the clusters and the clusters in the clustersets eligible for the placement,
+// after the overrides are applied and the hypothetical clusters are added. The clusterset membership is evaluated
+// with the modified labels, so an override or a hypothetical cluster can move a cluster into or out of a clusterset.
+func (d *Debugger) candidateClusters(placement *clusterapiv1beta1.Placement, request *DryRunRequest) (
+	[]*clusterapiv1.ManagedCluster, []*clusterapiv1.ManagedCluster, error) {
+	bindings, err := scheduling.GetValidManagedClusterSetBindings(placement.Namespace, d.clusterSetBindingLister, d.clusterSetLister)
+	if err != nil {
+		return nil, nil, err
+	}
+	eligibleClusterSets := sets.New[string](scheduling.GetEligibleClusterSets(placement, bindings)...)
+
+	existing, err := d.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	clusters := map[string]*clusterapiv1.ManagedCluster{}
+	for _, cluster := range existing {
+		clusters[cluster.Name] = cluster.DeepCopy()
+	}
+
+	for _, override := range request.ClusterOverrides {
+		cluster, ok := clusters[override.Name]
+		if !ok {
+			return nil, nil, fmt.Errorf("cluster %q in clusterOverrides does not exist", override.Name)
+		}
+		applyClusterOverride(cluster, override)
+	}
+
+	for i := range request.HypotheticalClusters {
+		cluster := request.HypotheticalClusters[i].DeepCopy()
+		if len(cluster.Name) == 0 {
+			return nil, nil, fmt.Errorf("name of hypothetical cluster is required")
+		}
+		if _, ok := clusters[cluster.Name]; ok {
+			return nil, nil, fmt.Errorf("hypothetical cluster %q already exists", cluster.Name)
+		}
+		clusters[cluster.Name] = cluster
+	}
+
+	var all, candidates []*clusterapiv1.ManagedCluster
+	for _, cluster := range clusters {
+		all = append(all, cluster)
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		clusterSets, err := clustersdkv1beta2.GetClusterSetsOfCluster(cluster, d.clusterSetLister)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, clusterSet := range clusterSets {
+			if eligibleClusterSets.Has(clusterSet.Name) {
+				candidates = append(candidates, cluster)
+				break
+			}
+		}
+	}
+
+	// keep the order stable since the map is not ordered.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+	return all, candidates, nil
+}
+
+func applyClusterOverride(cluster *clusterapiv1.ManagedCluster, override ClusterOverride) {
+	if len(override.Labels) > 0 && cluster.Labels == nil {
+		cluster.Labels = map[string]string{}
+	}
+	for key, value := range override.Labels {
+		if len(value) == 0 {
+			delete(cluster.Labels, key)
+			continue
+		}
+		cluster.Labels[key] = value
+	}
+
+	if override.Taints != nil {
+		cluster.Spec.Taints = override.Taints
+	}
+
+	if len(override.Claims) > 0 {
+		claims := map[string]string{}
+		for _, claim := range cluster.Status.ClusterClaims {
+			claims[claim.Name] = claim.Value
+		}
+		for key, value := range override.Claims {
+			if len(value) == 0 {
+				delete(claims, key)
+				continue
+			}
+			claims[key] = value
+		}
+
+		names := sets.List(sets.KeySet(claims))
+		cluster.Status.ClusterClaims = make([]clusterapiv1.ManagedClusterClaim, 0, len(names))
+		for _, name := range names {
+			cluster.Status.ClusterClaims = append(cluster.Status.ClusterClaims, clusterapiv1.ManagedClusterClaim{
+				Name:  name,
+				Value: claims[name],
+			})
+		}
+	}
+}
+
 func (d *Debugger) parsePath(path string) (string, string, error) {
 	metaNamespaceKey := strings.TrimPrefix(path, DebugPath)
 	return cache.SplitMetaNamespaceKey(metaNamespaceKey)
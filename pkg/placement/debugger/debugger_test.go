@@ -8,14 +8,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
 	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
 
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/scheduling"
@@ -30,6 +33,8 @@ type testResult struct {
 	filterResults     []scheduling.FilterResult
 	prioritizeResults []scheduling.PrioritizerResult
 	scoreSum          scheduling.PrioritizerScore
+	spreadResults     []scheduling.SpreadResult
+	decisions         []*clusterapiv1.ManagedCluster
 }
 
 func (r *testResult) FilterResults() []scheduling.FilterResult {
@@ -45,7 +50,7 @@ func (r *testResult) PrioritizerScores() scheduling.PrioritizerScore {
 }
 
 func (r *testResult) Decisions() []*clusterapiv1.ManagedCluster {
-	return []*clusterapiv1.ManagedCluster{}
+	return r.decisions
 }
 
 func (r *testResult) NumOfUnscheduled() int {
@@ -56,6 +61,8 @@ func (s *testScheduler) Schedule(ctx context.Context,
 	placement *clusterapiv1beta1.Placement,
 	clusters []*clusterapiv1.ManagedCluster,
 ) (scheduling.ScheduleResult, *framework.Status) {
+	// select all the given clusters
+	s.result.decisions = clusters
 	return s.result, nil
 }
 
@@ -63,6 +70,18 @@ func (r *testResult) RequeueAfter() *time.Duration {
 	return nil
 }
 
+func (r *testResult) SpreadResults() []scheduling.SpreadResult {
+	return r.spreadResults
+}
+
+func (r *testResult) RejectedClusters() []scheduling.ClusterRejection {
+	return nil
+}
+
+func (r *testResult) NumOfSpreadRejections() int {
+	return 0
+}
+
 func TestDebugger(t *testing.T) {
 	placementNamespace := "test"
 
@@ -94,7 +113,9 @@ func TestDebugger(t *testing.T) {
 			clusterInformerFactory := testinghelpers.NewClusterInformerFactory(clusterClient, c.initObjs...)
 			s := &testScheduler{result: &testResult{filterResults: c.filterResults, prioritizeResults: c.prioritizeResults}}
 			debugger := NewDebugger(
-				s, clusterInformerFactory.Cluster().V1beta1().Placements(), clusterInformerFactory.Cluster().V1().ManagedClusters())
+				s, clusterInformerFactory.Cluster().V1beta1().Placements(), clusterInformerFactory.Cluster().V1().ManagedClusters(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings())
 			server := httptest.NewServer(http.HandlerFunc(debugger.Handler))
 			res, err := http.Get(fmt.Sprintf("%s%s%s", server.URL, DebugPath, c.key))
 
@@ -125,3 +146,121 @@ func TestDebugger(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRun(t *testing.T) {
+	placementNamespace := "test"
+
+	clusterSetSelector := func(env string) clusterapiv1beta2.ManagedClusterSelector {
+		return clusterapiv1beta2.ManagedClusterSelector{
+			SelectorType: clusterapiv1beta2.LabelSelector,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": env},
+			},
+		}
+	}
+
+	initObjs := []runtime.Object{
+		testinghelpers.NewClusterSet("prod").WithClusterSelector(clusterSetSelector("prod")).Build(),
+		testinghelpers.NewClusterSet("dev").WithClusterSelector(clusterSetSelector("dev")).Build(),
+		testinghelpers.NewClusterSetBinding(placementNamespace, "prod"),
+		testinghelpers.NewPlacement(placementNamespace, "existing").WithClusterSets("prod").Build(),
+		testinghelpers.NewManagedCluster("cluster1").WithLabel("env", "prod").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel("env", "prod").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithLabel("env", "dev").Build(),
+	}
+
+	cases := []struct {
+		name              string
+		placementName     string
+		request           string
+		expectedClusters  []string
+		expectedGroups    []scheduling.DecisionGroupResult
+		expectedErrSubstr string
+	}{
+		{
+			name:             "existing placement",
+			placementName:    "existing",
+			request:          `{}`,
+			expectedClusters: []string{"cluster1", "cluster2"},
+			expectedGroups: []scheduling.DecisionGroupResult{
+				{GroupName: "", GroupIndex: 0, ClusterNames: []string{"cluster1", "cluster2"}},
+			},
+		},
+		{
+			name:          "inline spec with overrides and hypothetical clusters",
+			placementName: "new",
+			request: `{"spec":{"decisionStrategy":{"groupStrategy":{"clustersPerDecisionGroup":2}}},` +
+				`"clusterOverrides":[{"name":"cluster2","labels":{"env":""}},{"name":"cluster3","labels":{"env":"prod"}}],` +
+				`"hypotheticalClusters":[{"metadata":{"name":"cluster4","labels":{"env":"prod"}}},` +
+				`{"metadata":{"name":"cluster5","labels":{"env":"dev"}}}]}`,
+			expectedClusters: []string{"cluster1", "cluster3", "cluster4"},
+			expectedGroups: []scheduling.DecisionGroupResult{
+				{GroupName: "", GroupIndex: 0, ClusterNames: []string{"cluster1", "cluster3"}},
+				{GroupName: "", GroupIndex: 1, ClusterNames: []string{"cluster4"}},
+			},
+		},
+		{
+			name:              "spec is required for a new placement",
+			placementName:     "new",
+			request:           `{}`,
+			expectedErrSubstr: "spec is required",
+		},
+		{
+			name:              "override of a nonexistent cluster",
+			placementName:     "existing",
+			request:           `{"clusterOverrides":[{"name":"cluster9"}]}`,
+			expectedErrSubstr: "does not exist",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusterClient := clusterfake.NewSimpleClientset(initObjs...)
+			clusterInformerFactory := testinghelpers.NewClusterInformerFactory(clusterClient, initObjs...)
+			s := &testScheduler{result: &testResult{}}
+			debugger := NewDebugger(
+				s, clusterInformerFactory.Cluster().V1beta1().Placements(), clusterInformerFactory.Cluster().V1().ManagedClusters(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSets(),
+				clusterInformerFactory.Cluster().V1beta2().ManagedClusterSetBindings())
+			server := httptest.NewServer(http.HandlerFunc(debugger.Handler))
+			defer server.Close()
+
+			res, err := http.Post(fmt.Sprintf("%s%s%s/%s", server.URL, DebugPath, placementNamespace, c.placementName),
+				"application/json", strings.NewReader(c.request))
+			if err != nil {
+				t.Fatalf("Expect no error but get %v", err)
+			}
+			defer res.Body.Close()
+
+			responseBody, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error reading response body: %v", err)
+			}
+
+			if len(c.expectedErrSubstr) > 0 {
+				errResult := &DebugResult{}
+				if err := json.Unmarshal(responseBody, errResult); err != nil {
+					t.Fatalf("Unexpected error unmarshaling result: %v", err)
+				}
+				if !strings.Contains(errResult.Error, c.expectedErrSubstr) {
+					t.Errorf("Expect error containing %q, but got %q", c.expectedErrSubstr, errResult.Error)
+				}
+				return
+			}
+
+			result := &DryRunResult{}
+			if err := json.Unmarshal(responseBody, result); err != nil {
+				t.Fatalf("Unexpected error unmarshaling result: %v", err)
+			}
+			if len(result.Error) > 0 {
+				t.Fatalf("Unexpected error in result: %s", result.Error)
+			}
+			if !reflect.DeepEqual(result.Decisions, c.expectedClusters) {
+				t.Errorf("Expect decisions to be: %v. but got: %v", c.expectedClusters, result.Decisions)
+			}
+			if !reflect.DeepEqual(result.DecisionGroups, c.expectedGroups) {
+				t.Errorf("Expect decision groups to be: %v. but got: %v", c.expectedGroups, result.DecisionGroups)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package helpers
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/cel-go/cel"
@@ -72,34 +74,35 @@ func (c *CELSelector) Compile() []CompilationResult {
 		return c.compilationResult
 	}
 
-	estimator := newEstimator()
 	for i, expr := range c.celExpressions {
-		ast, issues := c.env.Compile(expr)
-		if issues != nil {
-			c.compilationResult[i].Error = &apiservercel.Error{
-				Type:   apiservercel.ErrorTypeInvalid,
-				Detail: "compilation failed: " + issues.String(),
-			}
-			continue
-		}
-
-		prg, err := c.env.Program(ast,
-			cel.CostLimit(celconfig.PerCallLimit),
-			cel.CostTracking(estimator),
-			cel.InterruptCheckFrequency(celconfig.CheckFrequency),
-		)
+		c.compilationResult[i] = compile(c.env, expr)
+	}
+	return c.compilationResult
+}
 
-		if err != nil {
-			c.compilationResult[i].Error = &apiservercel.Error{
-				Type:   apiservercel.ErrorTypeInvalid,
-				Detail: "instantiation failed: " + err.Error(),
-			}
-			continue
-		}
+// compile compiles a single CEL expression with the cost limit of the placement.
+func compile(env *cel.Env, expr string) CompilationResult {
+	ast, issues := env.Compile(expr)
+	if issues != nil {
+		return CompilationResult{Error: &apiservercel.Error{
+			Type:   apiservercel.ErrorTypeInvalid,
+			Detail: "compilation failed: " + issues.String(),
+		}}
+	}
 
-		c.compilationResult[i].Program = prg
+	prg, err := env.Program(ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.CostTracking(newEstimator()),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+	if err != nil {
+		return CompilationResult{Error: &apiservercel.Error{
+			Type:   apiservercel.ErrorTypeInvalid,
+			Detail: "instantiation failed: " + err.Error(),
+		}}
 	}
-	return c.compilationResult
+
+	return CompilationResult{Program: prg}
 }
 
 // Validate evaluates all compiled CEL expressions against a managed cluster.
@@ -168,3 +171,92 @@ func (c *CELSelector) isResultValid(evalResult ref.Val) bool {
 	value, ok := evalResult.Value().(bool)
 	return value && ok
 }
+
+// CELScorer handles CEL-based cluster scoring by evaluating a single CEL expression
+// which returns a number for each cluster.
+type CELScorer struct {
+	env               *cel.Env                 // CEL environment with registered libraries
+	metricsRecorder   *metrics.ScheduleMetrics // Metrics recorder
+	celExpression     string                   // Raw CEL expression to evaluate
+	compilationResult CompilationResult        // Cached compilation result
+}
+
+// NewCELScorer creates a new CEL scorer with the given environment and expression.
+func NewCELScorer(env *cel.Env, expression string, metricsRecorder *metrics.ScheduleMetrics) *CELScorer {
+	return &CELScorer{
+		env:             env,
+		metricsRecorder: metricsRecorder,
+		celExpression:   expression,
+	}
+}
+
+// Compile compiles the CEL expression and caches the result. It must be called before Score().
+func (c *CELScorer) Compile() CompilationResult {
+	if c.env == nil {
+		c.compilationResult = CompilationResult{Error: &apiservercel.Error{
+			Type:   apiservercel.ErrorTypeInvalid,
+			Detail: "CEL environment is not initialized",
+		}}
+		return c.compilationResult
+	}
+	c.compilationResult = compile(c.env, c.celExpression)
+	return c.compilationResult
+}
+
+// Score evaluates the compiled CEL expression against a managed cluster and returns the result
+// as an integer. A double result is truncated. It returns an error if the expression is not
+// compiled, fails to evaluate or does not return a number.
+func (c *CELScorer) Score(ctx context.Context, cluster *clusterapiv1.ManagedCluster) (int64, error) {
+	if !c.isProgramValid(c.compilationResult) {
+		return 0, fmt.Errorf("invalid compiled program of expression %q", c.celExpression)
+	}
+
+	convertedCluster, err := ocmcelcommon.ConvertObjectToUnstructured(cluster)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert cluster %s to unstructured format: %w", cluster.Name, err)
+	}
+
+	startTime := time.Now()
+	ctx = context.WithValue(ctx, "cluster", cluster.Name)
+	evalResult, _ := commonhelpers.EvaluateSingleExpression(
+		ctx,
+		c.compilationResult.Program,
+		globalCostBudget,
+		c.celExpression,
+		map[string]any{"managedCluster": convertedCluster.Object},
+	)
+	if c.metricsRecorder != nil {
+		metrics.CelDuration.WithLabelValues(metrics.SchedulingName).Observe(c.metricsRecorder.SinceInSeconds(startTime))
+	}
+	if evalResult == nil {
+		return 0, fmt.Errorf("failed to evaluate expression %q", c.celExpression)
+	}
+
+	switch value := evalResult.Value().(type) {
+	case int64:
+		return value, nil
+	case uint64:
+		if value > math.MaxInt64 {
+			return math.MaxInt64, nil
+		}
+		return int64(value), nil
+	case float64:
+		if math.IsNaN(value) {
+			return 0, fmt.Errorf("expression %q returns NaN", c.celExpression)
+		}
+		if value >= math.MaxInt64 {
+			return math.MaxInt64, nil
+		}
+		if value <= math.MinInt64 {
+			return math.MinInt64, nil
+		}
+		return int64(value), nil
+	default:
+		return 0, fmt.Errorf("expression %q returns %s instead of a number", c.celExpression, evalResult.Type().TypeName())
+	}
+}
+
+// isProgramValid checks if a compilation result contains a valid program
+func (c *CELScorer) isProgramValid(compiled CompilationResult) bool {
+	return compiled.Program != nil && compiled.Error == nil
+}
@@ -154,3 +154,73 @@ func TestCELSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestCELScorer(t *testing.T) {
+	env, err := NewEnv(nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		expression         string
+		cluster            *clusterapiv1.ManagedCluster
+		expectedScore      int64
+		expectCompileError bool
+		expectScoreError   bool
+	}{
+		{
+			name:       "int result",
+			expression: `"spot" in managedCluster.metadata.labels ? -100 : 0`,
+			cluster: &clusterapiv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"spot": "true"}},
+			},
+			expectedScore: -100,
+		},
+		{
+			name:       "double result is truncated",
+			expression: `double(managedCluster.metadata.labels["minor"]) / 2.0`,
+			cluster: &clusterapiv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"minor": "31"}},
+			},
+			expectedScore: 15,
+		},
+		{
+			name:               "invalid expression",
+			expression:         `invalid.expression`,
+			cluster:            &clusterapiv1.ManagedCluster{},
+			expectCompileError: true,
+			expectScoreError:   true,
+		},
+		{
+			name:             "non number result",
+			expression:       `managedCluster.metadata.name`,
+			cluster:          &clusterapiv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1"}},
+			expectScoreError: true,
+		},
+		{
+			name:             "evaluation error",
+			expression:       `int(managedCluster.metadata.labels["minor"])`,
+			cluster:          &clusterapiv1.ManagedCluster{},
+			expectScoreError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer := NewCELScorer(env, tt.expression, nil)
+			result := scorer.Compile()
+			if tt.expectCompileError {
+				assert.NotNil(t, result.Error)
+			} else {
+				assert.Nil(t, result.Error)
+			}
+
+			score, err := scorer.Score(context.TODO(), tt.cluster)
+			if tt.expectScoreError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedScore, score)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/cel-go/cel"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -53,24 +54,30 @@ func (c *ClusterSelector) Compile() []CompilationResult {
 // Note: If CEL expressions are used, Compile() must be called before this method.
 // If Compile() has not been called, CEL expressions will not be evaluated.
 func (c *ClusterSelector) Matches(ctx context.Context, cluster *clusterapiv1.ManagedCluster) bool {
+	return len(c.MismatchReason(ctx, cluster)) == 0
+}
+
+// MismatchReason returns the reason why a cluster does not match the selectors, or an empty
+// string if the cluster matches all of them.
+func (c *ClusterSelector) MismatchReason(ctx context.Context, cluster *clusterapiv1.ManagedCluster) string {
 	// match with label selector
 	if ok := c.labelSelector.Matches(labels.Set(cluster.Labels)); !ok {
-		return false
+		return fmt.Sprintf("label selector %q does not match", c.labelSelector.String())
 	}
 
 	// match with claim selector
 	if ok := c.claimSelector.Matches(labels.Set(GetClusterClaims(cluster))); !ok {
-		return false
+		return fmt.Sprintf("claim selector %q does not match", c.claimSelector.String())
 	}
 
 	// match with cel selector if exists
 	if c.celSelector != nil {
 		if ok, _ := c.celSelector.Validate(ctx, cluster); !ok {
-			return false
+			return "cel selector does not match"
 		}
 	}
 
-	return true
+	return ""
 }
 
 // convertLabelSelector converts metav1.LabelSelector to labels.Selector
@@ -13,6 +13,8 @@ import (
 	clusterapiv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	clusterapiv1beta2 "open-cluster-management.io/api/cluster/v1beta2"
+
+	commonhelpers "open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 type PlacementBuilder struct {
@@ -304,6 +306,15 @@ func (b *ManagedClusterBuilder) WithResource(resourceName clusterapiv1.ResourceN
 	return b
 }
 
+func (b *ManagedClusterBuilder) WithFreeResource(resourceName clusterapiv1.ResourceName, free string) *ManagedClusterBuilder {
+	if b.cluster.Status.Capacity == nil {
+		b.cluster.Status.Capacity = make(map[clusterapiv1.ResourceName]resource.Quantity)
+	}
+
+	b.cluster.Status.Capacity[commonhelpers.FreeResourceName(resourceName)], _ = resource.ParseQuantity(free)
+	return b
+}
+
 func (b *ManagedClusterBuilder) WithTaint(taint *clusterapiv1.Taint) *ManagedClusterBuilder {
 	if b.cluster.Spec.Taints == nil {
 		b.cluster.Spec.Taints = []clusterapiv1.Taint{}
@@ -18,6 +18,7 @@ import (
 
 type FakePluginHandle struct {
 	recorder                kevents.EventRecorder
+	placementLister         clusterlisterv1beta1.PlacementLister
 	placementDecisionLister clusterlisterv1beta1.PlacementDecisionLister
 	scoreLister             clusterlisterv1alpha1.AddOnPlacementScoreLister
 	clusterLister           clusterlisterv1.ManagedClusterLister
@@ -26,6 +27,9 @@ type FakePluginHandle struct {
 }
 
 func (f *FakePluginHandle) EventRecorder() kevents.EventRecorder { return f.recorder }
+func (f *FakePluginHandle) PlacementLister() clusterlisterv1beta1.PlacementLister {
+	return f.placementLister
+}
 func (f *FakePluginHandle) DecisionLister() clusterlisterv1beta1.PlacementDecisionLister {
 	return f.placementDecisionLister
 }
@@ -48,6 +52,7 @@ func NewFakePluginHandle(
 	return &FakePluginHandle{
 		recorder:                kevents.NewFakeRecorder(100),
 		client:                  client,
+		placementLister:         informers.Cluster().V1beta1().Placements().Lister(),
 		placementDecisionLister: informers.Cluster().V1beta1().PlacementDecisions().Lister(),
 		scoreLister:             informers.Cluster().V1alpha1().AddOnPlacementScores().Lister(),
 		clusterLister:           informers.Cluster().V1().ManagedClusters().Lister(),
@@ -0,0 +1,287 @@
+package affinity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+var _ plugins.Filter = &Affinity{}
+var _ plugins.Prioritizer = &Affinity{}
+
+const (
+	// PlacementAffinityAnnotation is the annotation on a placement to define its affinity and anti-affinity
+	// to other placements in the same namespace. The value is a json encoded PlacementAffinity.
+	PlacementAffinityAnnotation = "cluster.open-cluster-management.io/placement-affinity"
+
+	description = `
+	Affinity filters and scores the clusters based on the decisions of other placements. A placement
+	can require or prefer to be placed on the clusters selected by other placements (affinity), or
+	not on the clusters selected by other placements (anti-affinity). Only the placements in the same
+	namespace are considered.
+	`
+)
+
+// PlacementAffinity defines the affinity and anti-affinity of a placement to other placements.
+type PlacementAffinity struct {
+	// RequiredAffinity requires the cluster to be selected by the placements matching each term.
+	RequiredAffinity []PlacementAffinityTerm `json:"requiredAffinity,omitempty"`
+
+	// RequiredAntiAffinity requires the cluster not to be selected by the placements matching any term.
+	RequiredAntiAffinity []PlacementAffinityTerm `json:"requiredAntiAffinity,omitempty"`
+
+	// PreferredAffinity prefers the cluster selected by the placements matching each term.
+	PreferredAffinity []PlacementAffinityTerm `json:"preferredAffinity,omitempty"`
+
+	// PreferredAntiAffinity prefers the cluster not selected by the placements matching each term.
+	PreferredAntiAffinity []PlacementAffinityTerm `json:"preferredAntiAffinity,omitempty"`
+}
+
+// PlacementAffinityTerm selects a set of placements in the namespace of the placement defining the term.
+// The placements in other namespaces are never selected, so the decisions of a namespace are not exposed
+// to the users who can create placements in another namespace.
+type PlacementAffinityTerm struct {
+	// PlacementSelector selects the placements by label. An empty selector selects all the placements
+	// in the namespace.
+	PlacementSelector metav1.LabelSelector `json:"placementSelector"`
+}
+
+// GetPlacementAffinity returns the affinity defined on the placement, or nil if it is not defined.
+func GetPlacementAffinity(placement *clusterapiv1beta1.Placement) (*PlacementAffinity, error) {
+	value, ok := placement.Annotations[PlacementAffinityAnnotation]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+
+	// unknown fields are rejected, so the terms are not silently widened or narrowed by a typo.
+	affinity := &PlacementAffinity{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(affinity); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %w", PlacementAffinityAnnotation, err)
+	}
+	for _, term := range affinity.terms() {
+		if _, err := metav1.LabelSelectorAsSelector(&term.PlacementSelector); err != nil {
+			return nil, fmt.Errorf("invalid placementSelector in annotation %s: %w", PlacementAffinityAnnotation, err)
+		}
+	}
+	return affinity, nil
+}
+
+func (a *PlacementAffinity) terms() []PlacementAffinityTerm {
+	var terms []PlacementAffinityTerm
+	terms = append(terms, a.RequiredAffinity...)
+	terms = append(terms, a.RequiredAntiAffinity...)
+	terms = append(terms, a.PreferredAffinity...)
+	terms = append(terms, a.PreferredAntiAffinity...)
+	return terms
+}
+
+// References returns true if the other placement is matched by any term of the affinity of the placement.
+func (a *PlacementAffinity) References(placement, other *clusterapiv1beta1.Placement) bool {
+	for _, term := range a.terms() {
+		if term.matches(placement, other) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if the other placement is selected by the term defined on the placement. A placement
+// never matches itself or a placement in another namespace.
+func (t PlacementAffinityTerm) matches(placement, other *clusterapiv1beta1.Placement) bool {
+	if placement.Namespace != other.Namespace || placement.Name == other.Name {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&t.PlacementSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(other.Labels))
+}
+
+type Affinity struct {
+	handle plugins.Handle
+}
+
+func New(handle plugins.Handle) *Affinity {
+	return &Affinity{
+		handle: handle,
+	}
+}
+
+func (a *Affinity) Name() string {
+	return reflect.TypeOf(*a).Name()
+}
+
+func (a *Affinity) Description() string {
+	return description
+}
+
+// Filter keeps the clusters satisfying the required affinity and anti-affinity of the placement.
+func (a *Affinity) Filter(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginFilterResult, *framework.Status) {
+	affinity, err := GetPlacementAffinity(placement)
+	if err != nil {
+		return plugins.PluginFilterResult{}, framework.NewStatus(a.Name(), framework.Misconfigured, err.Error())
+	}
+	if affinity == nil || len(clusters) == 0 ||
+		(len(affinity.RequiredAffinity) == 0 && len(affinity.RequiredAntiAffinity) == 0) {
+		return plugins.PluginFilterResult{
+			Filtered: clusters,
+		}, framework.NewStatus(a.Name(), framework.Success, "")
+	}
+
+	var affinitySets, antiAffinitySets []sets.Set[string]
+	for _, term := range affinity.RequiredAffinity {
+		selected, err := a.selectedClusters(placement, term)
+		if err != nil {
+			return plugins.PluginFilterResult{}, framework.NewStatus(a.Name(), framework.Error, err.Error())
+		}
+		affinitySets = append(affinitySets, selected)
+	}
+	for _, term := range affinity.RequiredAntiAffinity {
+		selected, err := a.selectedClusters(placement, term)
+		if err != nil {
+			return plugins.PluginFilterResult{}, framework.NewStatus(a.Name(), framework.Error, err.Error())
+		}
+		antiAffinitySets = append(antiAffinitySets, selected)
+	}
+
+	filtered := []*clusterapiv1.ManagedCluster{}
+	rejected := map[string]string{}
+	for _, cluster := range clusters {
+		if reason := unsatisfiedReason(cluster.Name, affinitySets, antiAffinitySets); len(reason) > 0 {
+			rejected[cluster.Name] = reason
+			continue
+		}
+		filtered = append(filtered, cluster)
+	}
+
+	return plugins.PluginFilterResult{
+		Filtered: filtered,
+		Rejected: rejected,
+	}, framework.NewStatus(a.Name(), framework.Success, "")
+}
+
+// Score gives each cluster a score based on the preferred affinity and anti-affinity. Each term has the same
+// weight. A cluster matching all the preferred affinity terms and none of the preferred anti-affinity terms
+// gets MaxClusterScore, while the opposite gets MinClusterScore.
+func (a *Affinity) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = 0
+	}
+
+	affinity, err := GetPlacementAffinity(placement)
+	if err != nil {
+		return plugins.PluginScoreResult{}, framework.NewStatus(a.Name(), framework.Misconfigured, err.Error())
+	}
+	if affinity == nil {
+		return plugins.PluginScoreResult{
+			Scores: scores,
+		}, framework.NewStatus(a.Name(), framework.Success, "")
+	}
+
+	numOfTerms := int64(len(affinity.PreferredAffinity) + len(affinity.PreferredAntiAffinity))
+	if numOfTerms == 0 {
+		return plugins.PluginScoreResult{
+			Scores: scores,
+		}, framework.NewStatus(a.Name(), framework.Success, "")
+	}
+
+	hits := map[string]int64{}
+	for _, term := range affinity.PreferredAffinity {
+		selected, err := a.selectedClusters(placement, term)
+		if err != nil {
+			return plugins.PluginScoreResult{}, framework.NewStatus(a.Name(), framework.Error, err.Error())
+		}
+		for name := range scores {
+			if selected.Has(name) {
+				hits[name]++
+			} else {
+				hits[name]--
+			}
+		}
+	}
+	for _, term := range affinity.PreferredAntiAffinity {
+		selected, err := a.selectedClusters(placement, term)
+		if err != nil {
+			return plugins.PluginScoreResult{}, framework.NewStatus(a.Name(), framework.Error, err.Error())
+		}
+		for name := range scores {
+			if selected.Has(name) {
+				hits[name]--
+			} else {
+				hits[name]++
+			}
+		}
+	}
+
+	for name := range scores {
+		scores[name] = hits[name] * plugins.MaxClusterScore / numOfTerms
+	}
+
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(a.Name(), framework.Success, "")
+}
+
+func (a *Affinity) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(a.Name(), framework.Success, "")
+}
+
+// selectedClusters returns the names of the clusters selected by the placements matching the term.
+func (a *Affinity) selectedClusters(placement *clusterapiv1beta1.Placement, term PlacementAffinityTerm) (sets.Set[string], error) {
+	selected := sets.New[string]()
+	placements, err := a.handle.PlacementLister().Placements(placement.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range placements {
+		if !term.matches(placement, other) {
+			continue
+		}
+
+		decisionSelector := labels.SelectorFromSet(labels.Set{clusterapiv1beta1.PlacementLabel: other.Name})
+		decisions, err := a.handle.DecisionLister().PlacementDecisions(placement.Namespace).List(decisionSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, decision := range decisions {
+			for _, d := range decision.Status.Decisions {
+				selected.Insert(d.ClusterName)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// unsatisfiedReason returns the reason why the cluster does not satisfy the required affinity and
+// anti-affinity, or an empty string if it satisfies all of them.
+func unsatisfiedReason(clusterName string, affinitySets, antiAffinitySets []sets.Set[string]) string {
+	for i, selected := range affinitySets {
+		if !selected.Has(clusterName) {
+			return fmt.Sprintf("not selected by the placements of requiredAffinity[%d]", i)
+		}
+	}
+	for i, selected := range antiAffinitySets {
+		if selected.Has(clusterName) {
+			return fmt.Sprintf("selected by the placements of requiredAntiAffinity[%d]", i)
+		}
+	}
+	return ""
+}
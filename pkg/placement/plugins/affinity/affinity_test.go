@@ -0,0 +1,168 @@
+package affinity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newPlacement(namespace, name string, placementLabels map[string]string, value string) *clusterapiv1beta1.Placement {
+	placement := testinghelpers.NewPlacementWithAnnotations(namespace, name, map[string]string{
+		PlacementAffinityAnnotation: value,
+	}).Build()
+	placement.Labels = placementLabels
+	return placement
+}
+
+func newDecision(namespace, placementName string, clusterNames ...string) *clusterapiv1beta1.PlacementDecision {
+	return testinghelpers.NewPlacementDecision(namespace, testinghelpers.PlacementDecisionName(placementName, 1)).
+		WithLabel(clusterapiv1beta1.PlacementLabel, placementName).
+		WithDecisions(clusterNames...).Build()
+}
+
+func newClusters(names ...string) []*clusterapiv1.ManagedCluster {
+	var clusters []*clusterapiv1.ManagedCluster
+	for _, name := range names {
+		clusters = append(clusters, testinghelpers.NewManagedCluster(name).Build())
+	}
+	return clusters
+}
+
+var existingObjs = []runtime.Object{
+	newPlacement("ns1", "primary", map[string]string{"app": "ha", "role": "primary"}, ""),
+	newDecision("ns1", "primary", "cluster1", "cluster2"),
+	newPlacement("ns1", "cache", map[string]string{"app": "cache"}, ""),
+	newDecision("ns1", "cache", "cluster2", "cluster3"),
+	newPlacement("ns2", "primary", map[string]string{"app": "ha", "role": "primary"}, ""),
+	newDecision("ns2", "primary", "cluster4"),
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		name             string
+		placement        *clusterapiv1beta1.Placement
+		expectedClusters []string
+		expectedCode     framework.Code
+	}{
+		{
+			name:             "no affinity",
+			placement:        testinghelpers.NewPlacement("ns1", "test").Build(),
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name: "required anti affinity",
+			placement: newPlacement("ns1", "standby", map[string]string{"app": "ha", "role": "standby"},
+				`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}}]}`),
+			expectedClusters: []string{"cluster3", "cluster4", "cluster5"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name: "required anti affinity does not match itself",
+			placement: newPlacement("ns1", "primary", map[string]string{"app": "ha", "role": "primary"},
+				`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"app":"ha"}}}]}`),
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name: "placements in other namespaces are not selected",
+			placement: newPlacement("ns2", "standby", nil,
+				`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"app":"cache"}}}]}`),
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3", "cluster4", "cluster5"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name: "namespaces of the term are not allowed",
+			placement: newPlacement("ns1", "standby", nil,
+				`{"requiredAntiAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}},"namespaces":["ns1","ns2"]}]}`),
+			expectedCode: framework.Misconfigured,
+		},
+		{
+			name: "required affinity",
+			placement: newPlacement("ns1", "sidecar", nil,
+				`{"requiredAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}},`+
+					`{"placementSelector":{"matchLabels":{"app":"cache"}}}]}`),
+			expectedClusters: []string{"cluster2"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name:         "invalid annotation",
+			placement:    newPlacement("ns1", "invalid", nil, `{"requiredAffinity":`),
+			expectedCode: framework.Misconfigured,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs := append([]runtime.Object{c.placement}, existingObjs...)
+			clusterClient := clusterfake.NewSimpleClientset()
+			p := New(testinghelpers.NewFakePluginHandle(t, clusterClient, objs...))
+
+			result, status := p.Filter(context.TODO(), c.placement,
+				newClusters("cluster1", "cluster2", "cluster3", "cluster4", "cluster5"))
+			if status.Code() != c.expectedCode {
+				t.Errorf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+
+			var actual []string
+			for _, cluster := range result.Filtered {
+				actual = append(actual, cluster.Name)
+			}
+			if !reflect.DeepEqual(actual, c.expectedClusters) {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusters, actual)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		name           string
+		placement      *clusterapiv1beta1.Placement
+		expectedScores map[string]int64
+	}{
+		{
+			name:           "no affinity",
+			placement:      testinghelpers.NewPlacement("ns1", "test").Build(),
+			expectedScores: map[string]int64{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+		},
+		{
+			name: "preferred anti affinity",
+			placement: newPlacement("ns1", "standby", nil,
+				`{"preferredAntiAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}}]}`),
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": -100, "cluster3": 100},
+		},
+		{
+			name: "preferred affinity and anti affinity",
+			placement: newPlacement("ns1", "sidecar", nil,
+				`{"preferredAffinity":[{"placementSelector":{"matchLabels":{"role":"primary"}}}],`+
+					`"preferredAntiAffinity":[{"placementSelector":{"matchLabels":{"app":"cache"}}}]}`),
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 0, "cluster3": -100},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			objs := append([]runtime.Object{c.placement}, existingObjs...)
+			clusterClient := clusterfake.NewSimpleClientset()
+			p := New(testinghelpers.NewFakePluginHandle(t, clusterClient, objs...))
+
+			result, status := p.Score(context.TODO(), c.placement, newClusters("cluster1", "cluster2", "cluster3"))
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status %v", status.AsError())
+			}
+			if !reflect.DeepEqual(result.Scores, c.expectedScores) {
+				t.Errorf("expected scores %v, but got %v", c.expectedScores, result.Scores)
+			}
+		})
+	}
+}
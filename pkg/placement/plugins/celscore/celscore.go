@@ -0,0 +1,222 @@
+package celscore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/utils/lru"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/helpers"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+const (
+	// PrioritizerNamePrefix is the prefix of the CEL prioritizer names. A CEL prioritizer is referenced
+	// by a BuiltIn ScoreCoordinate with the name CEL/<expression name>, for example CEL/newest-minor.
+	PrioritizerNamePrefix = "CEL/"
+
+	// ScoreExpressionsAnnotation is the annotation on a placement to define the CEL score expressions.
+	// The value is a json encoded map with the expression name as the key and the CEL expression as
+	// the value, for example {"no-spot":"'spot' in managedCluster.metadata.labels ? -100 : 0"}.
+	ScoreExpressionsAnnotation = "cluster.open-cluster-management.io/cel-score-expressions"
+
+	description = `
+	CEL prioritizer scores the clusters with a CEL expression defined in the placement annotation.
+	The expression returns a number for each cluster, and the numbers are normalized to [-100, 100]
+	among the clusters. If all the clusters have the same number, the number clamped to [-100, 100]
+	is used. The cluster failing to evaluate the expression is given score 0.
+	`
+
+	// scorerCacheSize is the max number of the compiled expressions cached.
+	scorerCacheSize = 1000
+)
+
+// compiledScorers caches the compiled expressions, so that an expression is compiled only once rather than
+// on every scheduling. The CEL environment depends on the score lister of the handle, so the handle is a
+// part of the key.
+var compiledScorers = lru.New(scorerCacheSize)
+
+type scorerKey struct {
+	handle     plugins.Handle
+	expression string
+}
+
+type compiledScorer struct {
+	scorer *helpers.CELScorer
+	err    error
+}
+
+var _ plugins.Prioritizer = &CELScore{}
+
+type CELScore struct {
+	handle          plugins.Handle
+	prioritizerName string
+	expressionName  string
+}
+
+func New(handle plugins.Handle, prioritizerName string) *CELScore {
+	return &CELScore{
+		handle:          handle,
+		prioritizerName: prioritizerName,
+		expressionName:  strings.TrimPrefix(prioritizerName, PrioritizerNamePrefix),
+	}
+}
+
+// ResolvePrioritizer returns the factory of the prioritizer if the name is a valid CEL prioritizer name.
+func ResolvePrioritizer(name string) (plugins.PrioritizerFactory, bool) {
+	if !strings.HasPrefix(name, PrioritizerNamePrefix) || len(name) == len(PrioritizerNamePrefix) {
+		return nil, false
+	}
+	return func(handle plugins.Handle) plugins.Prioritizer {
+		return New(handle, name)
+	}, true
+}
+
+func (c *CELScore) Name() string {
+	return c.prioritizerName
+}
+
+func (c *CELScore) Description() string {
+	return description
+}
+
+// GetScoreExpressions returns the CEL score expressions defined on the placement.
+func GetScoreExpressions(placement *clusterapiv1beta1.Placement) (map[string]string, error) {
+	value, ok := placement.Annotations[ScoreExpressionsAnnotation]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+
+	expressions := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &expressions); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %w", ScoreExpressionsAnnotation, err)
+	}
+	return expressions, nil
+}
+
+func (c *CELScore) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	expressions, err := GetScoreExpressions(placement)
+	if err != nil {
+		return plugins.PluginScoreResult{}, framework.NewStatus(c.Name(), framework.Misconfigured, err.Error())
+	}
+	expression, ok := expressions[c.expressionName]
+	if !ok {
+		return plugins.PluginScoreResult{}, framework.NewStatus(c.Name(), framework.Misconfigured,
+			fmt.Sprintf("expression %q is not defined in annotation %s", c.expressionName, ScoreExpressionsAnnotation))
+	}
+
+	scorer, status := c.getScorer(expression)
+	if status != nil {
+		return plugins.PluginScoreResult{}, status
+	}
+
+	values := map[string]int64{}
+	var failed []string
+	for _, cluster := range clusters {
+		value, err := scorer.Score(ctx, cluster)
+		if err != nil {
+			failed = append(failed, cluster.Name)
+			continue
+		}
+		values[cluster.Name] = value
+	}
+
+	scores := normalizeScores(values)
+	for _, name := range failed {
+		scores[name] = 0
+	}
+
+	status = framework.NewStatus(c.Name(), framework.Success, "")
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		status = framework.NewStatus(c.Name(), framework.Warning,
+			fmt.Sprintf("failed to evaluate expression %q on clusters %s", c.expressionName, strings.Join(failed, ",")))
+	}
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, status
+}
+
+// getScorer returns the compiled scorer of the expression from the cache, and compiles the expression if it
+// is not cached yet. The expression failing to compile is cached as well.
+func (c *CELScore) getScorer(expression string) (*helpers.CELScorer, *framework.Status) {
+	key := scorerKey{handle: c.handle, expression: expression}
+	if cached, ok := compiledScorers.Get(key); ok {
+		compiled := cached.(compiledScorer)
+		if compiled.err != nil {
+			return nil, framework.NewStatus(c.Name(), framework.Misconfigured, compiled.err.Error())
+		}
+		return compiled.scorer, nil
+	}
+
+	env, err := helpers.NewEnv(c.handle.ScoreLister())
+	if err != nil {
+		return nil, framework.NewStatus(c.Name(), framework.Error, err.Error())
+	}
+
+	compiled := compiledScorer{scorer: helpers.NewCELScorer(env, expression, c.handle.MetricsRecorder())}
+	if result := compiled.scorer.Compile(); result.Error != nil {
+		compiled = compiledScorer{err: result.Error}
+	}
+	compiledScorers.Add(key, compiled)
+
+	if compiled.err != nil {
+		return nil, framework.NewStatus(c.Name(), framework.Misconfigured, compiled.err.Error())
+	}
+	return compiled.scorer, nil
+}
+
+func (c *CELScore) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(c.Name(), framework.Success, "")
+}
+
+// normalizeScores maps the values to [MinClusterScore, MaxClusterScore] linearly, so that the cluster with
+// the min value is given MinClusterScore and the cluster with the max value is given MaxClusterScore. If all
+// the values are the same, the value clamped to [MinClusterScore, MaxClusterScore] is used.
+func normalizeScores(values map[string]int64) map[string]int64 {
+	scores := map[string]int64{}
+	if len(values) == 0 {
+		return scores
+	}
+
+	first := true
+	var minValue, maxValue int64
+	for _, value := range values {
+		if first || value < minValue {
+			minValue = value
+		}
+		if first || value > maxValue {
+			maxValue = value
+		}
+		first = false
+	}
+
+	for name, value := range values {
+		if minValue == maxValue {
+			scores[name] = clamp(value)
+			continue
+		}
+		// use float to avoid overflow of the value range
+		ratio := (float64(value) - float64(minValue)) / (float64(maxValue) - float64(minValue))
+		scores[name] = clamp(int64(ratio*float64(plugins.MaxClusterScore-plugins.MinClusterScore)) + plugins.MinClusterScore)
+	}
+	return scores
+}
+
+func clamp(value int64) int64 {
+	if value > plugins.MaxClusterScore {
+		return plugins.MaxClusterScore
+	}
+	if value < plugins.MinClusterScore {
+		return plugins.MinClusterScore
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+package celscore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterfake "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func TestScore(t *testing.T) {
+	clusters := []*clusterapiv1.ManagedCluster{
+		testinghelpers.NewManagedCluster("cluster1").WithLabel("minor", "29").Build(),
+		testinghelpers.NewManagedCluster("cluster2").WithLabel("minor", "30").WithLabel("spot", "true").Build(),
+		testinghelpers.NewManagedCluster("cluster3").WithLabel("minor", "31").Build(),
+	}
+
+	cases := []struct {
+		name           string
+		prioritizer    string
+		expressions    string
+		expectedScores map[string]int64
+		expectedCode   framework.Code
+	}{
+		{
+			name:           "normalize scores",
+			prioritizer:    "CEL/newest-minor",
+			expressions:    `{"newest-minor":"int(managedCluster.metadata.labels['minor'])"}`,
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": 0, "cluster3": 100},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:           "penalize spot clusters",
+			prioritizer:    "CEL/no-spot",
+			expressions:    `{"no-spot":"'spot' in managedCluster.metadata.labels ? -100 : 0"}`,
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": -100, "cluster3": 100},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:           "clamp the same scores",
+			prioritizer:    "CEL/constant",
+			expressions:    `{"constant":"1000"}`,
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": 100, "cluster3": 100},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:           "evaluation failure",
+			prioritizer:    "CEL/spot",
+			expressions:    `{"spot":"int(managedCluster.metadata.labels['spot'] == 'true' ? '1' : 'x')"}`,
+			expectedScores: map[string]int64{"cluster1": 0, "cluster2": 1, "cluster3": 0},
+			expectedCode:   framework.Warning,
+		},
+		{
+			name:         "expression not defined",
+			prioritizer:  "CEL/missing",
+			expressions:  `{"newest-minor":"1"}`,
+			expectedCode: framework.Misconfigured,
+		},
+		{
+			name:         "invalid expression",
+			prioritizer:  "CEL/invalid",
+			expressions:  `{"invalid":"invalid.expression"}`,
+			expectedCode: framework.Misconfigured,
+		},
+		{
+			name:         "invalid annotation",
+			prioritizer:  "CEL/invalid",
+			expressions:  `{"invalid":`,
+			expectedCode: framework.Misconfigured,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placement := testinghelpers.NewPlacementWithAnnotations("test", "test", map[string]string{
+				ScoreExpressionsAnnotation: c.expressions,
+			}).Build()
+			factory, ok := ResolvePrioritizer(c.prioritizer)
+			if !ok {
+				t.Fatalf("failed to resolve prioritizer %s", c.prioritizer)
+			}
+			p := factory(testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset()))
+
+			result, status := p.Score(context.TODO(), placement, clusters)
+			if status.Code() != c.expectedCode {
+				t.Errorf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+			if c.expectedScores != nil && !reflect.DeepEqual(result.Scores, c.expectedScores) {
+				t.Errorf("expected scores %v, but got %v", c.expectedScores, result.Scores)
+			}
+		})
+	}
+}
+
+func TestResolvePrioritizer(t *testing.T) {
+	cases := map[string]bool{
+		"CEL/newest-minor": true,
+		"CEL/":             false,
+		"Balance":          false,
+	}
+	for name, expected := range cases {
+		if _, ok := ResolvePrioritizer(name); ok != expected {
+			t.Errorf("expected %v to resolve %q, but got %v", expected, name, ok)
+		}
+	}
+}
+
+func TestScorerIsCompiledOnce(t *testing.T) {
+	handle := testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset())
+	expression := "int(managedCluster.metadata.labels['minor'])"
+
+	first, status := New(handle, "CEL/newest-minor").getScorer(expression)
+	if status != nil {
+		t.Fatalf("unexpected status: %s", status.Message())
+	}
+	// a new prioritizer is created on every scheduling, and the compiled expression is reused.
+	second, status := New(handle, "CEL/newest-minor").getScorer(expression)
+	if status != nil {
+		t.Fatalf("unexpected status: %s", status.Message())
+	}
+	if first != second {
+		t.Errorf("expected the compiled scorer to be reused")
+	}
+
+	// the scorer is not shared with another handle since the score lister is different.
+	third, status := New(testinghelpers.NewFakePluginHandle(t, clusterfake.NewSimpleClientset()), "CEL/newest-minor").getScorer(expression)
+	if status != nil {
+		t.Fatalf("unexpected status: %s", status.Message())
+	}
+	if first == third {
+		t.Errorf("expected the compiled scorer not to be shared across handles")
+	}
+}
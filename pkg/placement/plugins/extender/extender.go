@@ -0,0 +1,376 @@
+package extender
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+var _ plugins.Filter = &Extender{}
+var _ plugins.Prioritizer = &Extender{}
+
+const (
+	description = `
+	Extender calls an out-of-process webhook to filter and score the clusters. The placement and the
+	names of the candidate clusters are posted to the webhook, which returns the filtered cluster names
+	and a score for each cluster.
+	`
+
+	defaultTimeout = 5 * time.Second
+
+	// maxResponseBytes is the maximum size of the response body read from the webhook.
+	maxResponseBytes = 10 * 1024 * 1024
+)
+
+// FailurePolicy defines how the scheduler handles an error when calling the extender.
+type FailurePolicy string
+
+const (
+	// FailurePolicyIgnore keeps all the candidate clusters and gives them a zero score when the
+	// extender fails, and the placement is still scheduled.
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+
+	// FailurePolicyFail fails the scheduling of the placement when the extender fails.
+	FailurePolicyFail FailurePolicy = "Fail"
+)
+
+// Config is the configuration of an extender.
+type Config struct {
+	// Name is the name of the extender. It is the plugin name in the filter results, prioritizer
+	// results and metrics, and a placement can reference the prioritizer of the extender by setting
+	// it as the BuiltIn name of a ScoreCoordinate.
+	Name string `json:"name"`
+
+	// URL is the base url of the extender webhook.
+	URL string `json:"url"`
+
+	// FilterVerb is appended to the URL when calling the filter of the extender. The extender is not
+	// used as a filter if it is empty.
+	FilterVerb string `json:"filterVerb,omitempty"`
+
+	// PrioritizeVerb is appended to the URL when calling the prioritizer of the extender. The extender
+	// is not used as a prioritizer if it is empty.
+	PrioritizeVerb string `json:"prioritizeVerb,omitempty"`
+
+	// Weight is the default weight of the prioritizer of the extender. It defaults to 1.
+	Weight *int32 `json:"weight,omitempty"`
+
+	// Timeout is the timeout of each call to the extender, for example "5s". It defaults to 5s.
+	Timeout string `json:"timeout,omitempty"`
+
+	// FailurePolicy is either Ignore or Fail. It defaults to Ignore.
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+
+	// TLSConfig is the tls configuration to connect to the extender.
+	TLSConfig *TLSConfig `json:"tlsConfig,omitempty"`
+}
+
+// TLSConfig contains the files used to build the tls configuration of the extender client.
+type TLSConfig struct {
+	// CAFile is the file of the CA bundle used to verify the extender serving certificate.
+	CAFile string `json:"caFile,omitempty"`
+	// CertFile and KeyFile is the client certificate presented to the extender.
+	CertFile string `json:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty"`
+	// InsecureSkipVerify skips the verification of the extender serving certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+}
+
+// Args is the request body posted to the extender.
+type Args struct {
+	Placement    *clusterapiv1beta1.Placement `json:"placement"`
+	ClusterNames []string                     `json:"clusterNames"`
+}
+
+// FilterResult is the response body of the filter of the extender.
+type FilterResult struct {
+	// ClusterNames is the names of the clusters that pass the filter.
+	ClusterNames []string `json:"clusterNames"`
+	// FailedClusters contains the reason why a cluster does not pass the filter, with the key as
+	// the cluster name. It is optional.
+	FailedClusters map[string]string `json:"failedClusters,omitempty"`
+	// Error is set when the extender fails to filter the clusters.
+	Error string `json:"error,omitempty"`
+}
+
+// PrioritizeResult is the response body of the prioritizer of the extender.
+type PrioritizeResult struct {
+	// Scores contains the score of each cluster. The score is expected to be in the range of
+	// [-100, 100] and the out of range score is clamped.
+	Scores map[string]int64 `json:"scores"`
+	// Error is set when the extender fails to score the clusters.
+	Error string `json:"error,omitempty"`
+}
+
+// Validate checks the config and sets the default values.
+func (c *Config) Validate() error {
+	if len(c.Name) == 0 {
+		return fmt.Errorf("extender name should not be empty")
+	}
+	if len(c.URL) == 0 {
+		return fmt.Errorf("url of extender %q should not be empty", c.Name)
+	}
+	if len(c.FilterVerb) == 0 && len(c.PrioritizeVerb) == 0 {
+		return fmt.Errorf("extender %q should have at least one of filterVerb or prioritizeVerb", c.Name)
+	}
+	if c.Weight != nil && (*c.Weight < -10 || *c.Weight > 10) {
+		return fmt.Errorf("weight of extender %q should be in range [-10, 10]", c.Name)
+	}
+	if len(c.Timeout) > 0 {
+		if _, err := time.ParseDuration(c.Timeout); err != nil {
+			return fmt.Errorf("invalid timeout of extender %q: %w", c.Name, err)
+		}
+	}
+	switch c.FailurePolicy {
+	case "", FailurePolicyIgnore, FailurePolicyFail:
+	default:
+		return fmt.Errorf("invalid failurePolicy %q of extender %q", c.FailurePolicy, c.Name)
+	}
+	return nil
+}
+
+// DefaultWeight returns the default weight of the prioritizer of the extender.
+func (c *Config) DefaultWeight() int32 {
+	if c.Weight == nil {
+		return 1
+	}
+	return *c.Weight
+}
+
+type Extender struct {
+	config        Config
+	client        *http.Client
+	failurePolicy FailurePolicy
+}
+
+// New builds an extender with the config. The config is expected to be validated.
+func New(config Config) (*Extender, error) {
+	timeout := defaultTimeout
+	if len(config.Timeout) > 0 {
+		d, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		timeout = d
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.TLSConfig != nil {
+		tlsConfig, err := buildTLSConfig(config.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tls config of extender %q: %w", config.Name, err)
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	failurePolicy := config.FailurePolicy
+	if len(failurePolicy) == 0 {
+		failurePolicy = FailurePolicyIgnore
+	}
+
+	return &Extender{
+		config:        config,
+		client:        &http.Client{Transport: transport, Timeout: timeout},
+		failurePolicy: failurePolicy,
+	}, nil
+}
+
+func (e *Extender) Name() string {
+	return e.config.Name
+}
+
+func (e *Extender) Description() string {
+	return description
+}
+
+// IsFilter returns true if the extender is used as a filter.
+func (e *Extender) IsFilter() bool {
+	return len(e.config.FilterVerb) > 0
+}
+
+// IsPrioritizer returns true if the extender is used as a prioritizer.
+func (e *Extender) IsPrioritizer() bool {
+	return len(e.config.PrioritizeVerb) > 0
+}
+
+func (e *Extender) Filter(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginFilterResult, *framework.Status) {
+	if len(clusters) == 0 || !e.IsFilter() {
+		return plugins.PluginFilterResult{
+			Filtered: clusters,
+		}, framework.NewStatus(e.Name(), framework.Success, "")
+	}
+
+	result := &FilterResult{}
+	if err := e.send(ctx, e.config.FilterVerb, placement, clusters, result); err != nil {
+		return e.onFilterError(ctx, clusters, err)
+	}
+	if len(result.Error) > 0 {
+		return e.onFilterError(ctx, clusters, fmt.Errorf("%s", result.Error))
+	}
+
+	names := sets.New[string](result.ClusterNames...)
+	filtered := []*clusterapiv1.ManagedCluster{}
+	rejected := map[string]string{}
+	for _, cluster := range clusters {
+		if names.Has(cluster.Name) {
+			filtered = append(filtered, cluster)
+			continue
+		}
+		if reason, ok := result.FailedClusters[cluster.Name]; ok {
+			rejected[cluster.Name] = reason
+		}
+	}
+
+	return plugins.PluginFilterResult{
+		Filtered: filtered,
+		Rejected: rejected,
+	}, framework.NewStatus(e.Name(), framework.Success, "")
+}
+
+func (e *Extender) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
+	scores := map[string]int64{}
+	for _, cluster := range clusters {
+		scores[cluster.Name] = 0
+	}
+	if len(clusters) == 0 || !e.IsPrioritizer() {
+		return plugins.PluginScoreResult{
+			Scores: scores,
+		}, framework.NewStatus(e.Name(), framework.Success, "")
+	}
+
+	result := &PrioritizeResult{}
+	if err := e.send(ctx, e.config.PrioritizeVerb, placement, clusters, result); err != nil {
+		return e.onScoreError(ctx, scores, err)
+	}
+	if len(result.Error) > 0 {
+		return e.onScoreError(ctx, scores, fmt.Errorf("%s", result.Error))
+	}
+
+	for name, score := range result.Scores {
+		if _, ok := scores[name]; !ok {
+			continue
+		}
+		switch {
+		case score > plugins.MaxClusterScore:
+			score = plugins.MaxClusterScore
+		case score < plugins.MinClusterScore:
+			score = plugins.MinClusterScore
+		}
+		scores[name] = score
+	}
+
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(e.Name(), framework.Success, "")
+}
+
+func (e *Extender) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(e.Name(), framework.Success, "")
+}
+
+func (e *Extender) onFilterError(ctx context.Context, clusters []*clusterapiv1.ManagedCluster,
+	err error) (plugins.PluginFilterResult, *framework.Status) {
+	msg := fmt.Sprintf("failed to call filter of extender %s: %v", e.Name(), err)
+	if e.failurePolicy == FailurePolicyFail {
+		return plugins.PluginFilterResult{}, framework.NewStatus(e.Name(), framework.Error, msg)
+	}
+
+	klog.FromContext(ctx).Info("Ignore the extender failure", "extender", e.Name(), "error", err)
+	return plugins.PluginFilterResult{
+		Filtered: clusters,
+	}, framework.NewStatus(e.Name(), framework.Warning, msg)
+}
+
+func (e *Extender) onScoreError(ctx context.Context, scores map[string]int64,
+	err error) (plugins.PluginScoreResult, *framework.Status) {
+	msg := fmt.Sprintf("failed to call prioritizer of extender %s: %v", e.Name(), err)
+	if e.failurePolicy == FailurePolicyFail {
+		return plugins.PluginScoreResult{}, framework.NewStatus(e.Name(), framework.Error, msg)
+	}
+
+	klog.FromContext(ctx).Info("Ignore the extender failure", "extender", e.Name(), "error", err)
+	return plugins.PluginScoreResult{
+		Scores: scores,
+	}, framework.NewStatus(e.Name(), framework.Warning, msg)
+}
+
+// send posts the placement and cluster names to the extender and decodes the response into result.
+func (e *Extender) send(ctx context.Context, verb string, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster, result interface{}) error {
+	args := &Args{Placement: placement, ClusterNames: make([]string, 0, len(clusters))}
+	for _, cluster := range clusters {
+		args.ClusterNames = append(args.ClusterNames, cluster.Name)
+	}
+
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimRight(e.config.URL, "/") + "/" + strings.TrimLeft(verb, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(result)
+}
+
+func buildTLSConfig(config *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify, // #nosec G402
+	}
+
+	if len(config.CAFile) > 0 {
+		caData, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no valid certificate in %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.CertFile) > 0 || len(config.KeyFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,241 @@
+package extender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func newClusters(names ...string) []*clusterapiv1.ManagedCluster {
+	var clusters []*clusterapiv1.ManagedCluster
+	for _, name := range names {
+		clusters = append(clusters, testinghelpers.NewManagedCluster(name).Build())
+	}
+	return clusters
+}
+
+func newServer(t *testing.T, delay time.Duration, statusCode int, response func(args *Args) interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		args := &Args{}
+		if err := json.NewDecoder(r.Body).Decode(args); err != nil {
+			t.Errorf("failed to decode args: %v", err)
+		}
+		if args.Placement == nil || args.Placement.Name != "test" {
+			t.Errorf("expected placement test, but got %v", args.Placement)
+		}
+		time.Sleep(delay)
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(response(args))
+	}))
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		name             string
+		delay            time.Duration
+		statusCode       int
+		failurePolicy    FailurePolicy
+		response         func(args *Args) interface{}
+		expectedClusters []string
+		expectedCode     framework.Code
+	}{
+		{
+			name:       "filter clusters",
+			statusCode: http.StatusOK,
+			response: func(args *Args) interface{} {
+				return &FilterResult{ClusterNames: []string{"cluster1", "cluster3", "unknown"}}
+			},
+			expectedClusters: []string{"cluster1", "cluster3"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name:          "error response with ignore policy",
+			statusCode:    http.StatusOK,
+			failurePolicy: FailurePolicyIgnore,
+			response: func(args *Args) interface{} {
+				return &FilterResult{Error: "internal error"}
+			},
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3"},
+			expectedCode:     framework.Warning,
+		},
+		{
+			name:          "bad status code with fail policy",
+			statusCode:    http.StatusInternalServerError,
+			failurePolicy: FailurePolicyFail,
+			response: func(args *Args) interface{} {
+				return &FilterResult{}
+			},
+			expectedCode: framework.Error,
+		},
+		{
+			name:          "timeout with fail policy",
+			delay:         500 * time.Millisecond,
+			statusCode:    http.StatusOK,
+			failurePolicy: FailurePolicyFail,
+			response: func(args *Args) interface{} {
+				return &FilterResult{ClusterNames: args.ClusterNames}
+			},
+			expectedCode: framework.Error,
+		},
+		{
+			name:       "timeout with default policy",
+			delay:      500 * time.Millisecond,
+			statusCode: http.StatusOK,
+			response: func(args *Args) interface{} {
+				return &FilterResult{}
+			},
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3"},
+			expectedCode:     framework.Warning,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := newServer(t, c.delay, c.statusCode, c.response)
+			defer server.Close()
+
+			e, err := New(Config{
+				Name:          "test",
+				URL:           server.URL,
+				FilterVerb:    "filter",
+				Timeout:       "100ms",
+				FailurePolicy: c.failurePolicy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, status := e.Filter(context.TODO(),
+				testinghelpers.NewPlacement("default", "test").Build(), newClusters("cluster1", "cluster2", "cluster3"))
+			if status.Code() != c.expectedCode {
+				t.Errorf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+
+			var actual []string
+			for _, cluster := range result.Filtered {
+				actual = append(actual, cluster.Name)
+			}
+			if !reflect.DeepEqual(actual, c.expectedClusters) {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusters, actual)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		name           string
+		statusCode     int
+		failurePolicy  FailurePolicy
+		response       func(args *Args) interface{}
+		expectedScores map[string]int64
+		expectedCode   framework.Code
+	}{
+		{
+			name:       "score clusters",
+			statusCode: http.StatusOK,
+			response: func(args *Args) interface{} {
+				return &PrioritizeResult{Scores: map[string]int64{"cluster1": 200, "cluster2": -300, "cluster3": 50, "unknown": 10}}
+			},
+			expectedScores: map[string]int64{"cluster1": 100, "cluster2": -100, "cluster3": 50},
+			expectedCode:   framework.Success,
+		},
+		{
+			name:          "error response with ignore policy",
+			statusCode:    http.StatusOK,
+			failurePolicy: FailurePolicyIgnore,
+			response: func(args *Args) interface{} {
+				return &PrioritizeResult{Error: "internal error"}
+			},
+			expectedScores: map[string]int64{"cluster1": 0, "cluster2": 0, "cluster3": 0},
+			expectedCode:   framework.Warning,
+		},
+		{
+			name:          "bad status code with fail policy",
+			statusCode:    http.StatusNotFound,
+			failurePolicy: FailurePolicyFail,
+			response: func(args *Args) interface{} {
+				return &PrioritizeResult{}
+			},
+			expectedCode: framework.Error,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := newServer(t, 0, c.statusCode, c.response)
+			defer server.Close()
+
+			e, err := New(Config{
+				Name:           "test",
+				URL:            server.URL,
+				PrioritizeVerb: "prioritize",
+				FailurePolicy:  c.failurePolicy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, status := e.Score(context.TODO(),
+				testinghelpers.NewPlacement("default", "test").Build(), newClusters("cluster1", "cluster2", "cluster3"))
+			if status.Code() != c.expectedCode {
+				t.Errorf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+			if !reflect.DeepEqual(result.Scores, c.expectedScores) {
+				t.Errorf("expected scores %v, but got %v", c.expectedScores, result.Scores)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	weight := int32(20)
+	cases := []struct {
+		name        string
+		config      Config
+		expectedErr bool
+	}{
+		{
+			name:   "valid",
+			config: Config{Name: "test", URL: "https://localhost", FilterVerb: "filter", Timeout: "1s"},
+		},
+		{
+			name:        "no verb",
+			config:      Config{Name: "test", URL: "https://localhost"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid timeout",
+			config:      Config{Name: "test", URL: "https://localhost", FilterVerb: "filter", Timeout: "1"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid weight",
+			config:      Config{Name: "test", URL: "https://localhost", PrioritizeVerb: "prioritize", Weight: &weight},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid failure policy",
+			config:      Config{Name: "test", URL: "https://localhost", FilterVerb: "filter", FailurePolicy: "Retry"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.config.Validate()
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
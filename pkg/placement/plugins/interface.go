@@ -59,6 +59,9 @@ type Prioritizer interface {
 // Handle provides data and some tools that plugins can use. It is
 // passed to the plugin factories at the time of plugin initialization.
 type Handle interface {
+	// PlacementLister lists all placements
+	PlacementLister() clusterlisterv1beta1.PlacementLister
+
 	// DecisionLister lists all decisions
 	DecisionLister() clusterlisterv1beta1.PlacementDecisionLister
 
@@ -82,6 +85,11 @@ type Handle interface {
 type PluginFilterResult struct {
 	// Filtered contains the filtered ManagedCluster.
 	Filtered []*clusterapiv1.ManagedCluster
+
+	// Rejected contains the reason why a ManagedCluster is filtered out, with the key as the
+	// cluster name. It is optional, a cluster filtered out without a reason is still reported
+	// as rejected by the plugin.
+	Rejected map[string]string
 }
 
 // PluginScoreResult contains the details of a score plugin result.
@@ -2,7 +2,9 @@ package predicate
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"k8s.io/klog/v2"
 
@@ -86,18 +88,26 @@ func (p *Predicate) Filter(
 
 	// match cluster with selectors one by one
 	matched := []*clusterapiv1.ManagedCluster{}
+	rejected := map[string]string{}
 	for _, cluster := range clusters {
-		for _, cs := range clusterSelectors {
-			if ok := cs.Matches(ctx, cluster); !ok {
+		var reasons []string
+		for i, cs := range clusterSelectors {
+			if reason := cs.MismatchReason(ctx, cluster); len(reason) > 0 {
+				reasons = append(reasons, fmt.Sprintf("predicate[%d]: %s", i, reason))
 				continue
 			}
+			reasons = nil
 			matched = append(matched, cluster)
 			break
 		}
+		if len(reasons) > 0 {
+			rejected[cluster.Name] = strings.Join(reasons, "; ")
+		}
 	}
 
 	return plugins.PluginFilterResult{
 		Filtered: matched,
+		Rejected: rejected,
 	}, status
 }
 
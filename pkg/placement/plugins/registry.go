@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// FilterFactory builds a Filter plugin with the given handle.
+type FilterFactory func(handle Handle) Filter
+
+// PrioritizerFactory builds a Prioritizer plugin with the given handle.
+type PrioritizerFactory func(handle Handle) Prioritizer
+
+// PrioritizerResolver returns the prioritizer factory of a name which is not registered, so that a
+// family of prioritizers sharing the same implementation, such as the prioritizers of the extended
+// resources, can be referenced by name without registering each of them.
+type PrioritizerResolver func(name string) (PrioritizerFactory, bool)
+
+// Registry is a collection of filter and prioritizer factories keyed by plugin name. It allows
+// plugins that are not part of this repo to be registered and then referenced by name from
+// a scheduler profile or from the BuiltIn field of a placement's ScoreCoordinate.
+type Registry struct {
+	lock         sync.RWMutex
+	filters      map[string]FilterFactory
+	prioritizers map[string]PrioritizerFactory
+	resolvers    []PrioritizerResolver
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		filters:      map[string]FilterFactory{},
+		prioritizers: map[string]PrioritizerFactory{},
+	}
+}
+
+// RegisterFilter adds a filter factory with the given name. It returns an error if a filter
+// with the same name has already been registered.
+func (r *Registry) RegisterFilter(name string, factory FilterFactory) error {
+	if len(name) == 0 {
+		return fmt.Errorf("filter name should not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("filter factory of %q should not be nil", name)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.filters[name]; ok {
+		return fmt.Errorf("filter %q is already registered", name)
+	}
+	r.filters[name] = factory
+	return nil
+}
+
+// RegisterPrioritizer adds a prioritizer factory with the given name. It returns an error if a
+// prioritizer with the same name has already been registered.
+func (r *Registry) RegisterPrioritizer(name string, factory PrioritizerFactory) error {
+	if len(name) == 0 {
+		return fmt.Errorf("prioritizer name should not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("prioritizer factory of %q should not be nil", name)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.prioritizers[name]; ok {
+		return fmt.Errorf("prioritizer %q is already registered", name)
+	}
+	r.prioritizers[name] = factory
+	return nil
+}
+
+// RegisterPrioritizerResolver adds a resolver which is used when no prioritizer is registered with
+// the requested name. The resolvers are called in the order they are registered.
+func (r *Registry) RegisterPrioritizerResolver(resolver PrioritizerResolver) error {
+	if resolver == nil {
+		return fmt.Errorf("prioritizer resolver should not be nil")
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.resolvers = append(r.resolvers, resolver)
+	return nil
+}
+
+// Merge registers all the factories of the other registry into this one. It returns an error
+// if any of the names conflicts with an already registered plugin.
+func (r *Registry) Merge(other *Registry) error {
+	if other == nil {
+		return nil
+	}
+
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+	for name, factory := range other.filters {
+		if err := r.RegisterFilter(name, factory); err != nil {
+			return err
+		}
+	}
+	for name, factory := range other.prioritizers {
+		if err := r.RegisterPrioritizer(name, factory); err != nil {
+			return err
+		}
+	}
+	for _, resolver := range other.resolvers {
+		if err := r.RegisterPrioritizerResolver(resolver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Filter returns the filter factory registered with the given name.
+func (r *Registry) Filter(name string) (FilterFactory, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	factory, ok := r.filters[name]
+	return factory, ok
+}
+
+// Prioritizer returns the prioritizer factory registered with the given name, or resolved by the
+// resolvers if it is not registered.
+func (r *Registry) Prioritizer(name string) (PrioritizerFactory, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if factory, ok := r.prioritizers[name]; ok {
+		return factory, ok
+	}
+	for _, resolver := range r.resolvers {
+		if factory, ok := resolver(name); ok {
+			return factory, ok
+		}
+	}
+	return nil, false
+}
+
+// FilterNames returns the sorted names of all the registered filters.
+func (r *Registry) FilterNames() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.filters))
+	for name := range r.filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PrioritizerNames returns the sorted names of all the registered prioritizers. The names resolved by
+// the resolvers are not included.
+func (r *Registry) PrioritizerNames() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.prioritizers))
+	for name := range r.prioritizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,132 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	"open-cluster-management.io/ocm/pkg/placement/plugins"
+)
+
+var _ plugins.Filter = &MinAvailable{}
+
+const (
+	// MinAvailableAnnotation is the annotation on a placement to define the minimal free capacity of
+	// the resources that a cluster must have. The value is a json encoded map with the resource name
+	// as the key and the quantity as the value, for example {"cpu":"2","nvidia.com/gpu":"1"}.
+	MinAvailableAnnotation = "cluster.open-cluster-management.io/resource-min-available"
+
+	minAvailableDescription = `
+	ResourceMinAvailable filter keeps the clusters whose free capacity of each resource in the
+	placement annotation is not less than the given quantity. The free capacity is reported by
+	the agent, and the cluster without the free capacity of the resource is filtered out.
+	`
+)
+
+type MinAvailable struct {
+	handle plugins.Handle
+}
+
+func NewMinAvailable(handle plugins.Handle) *MinAvailable {
+	return &MinAvailable{
+		handle: handle,
+	}
+}
+
+func (m *MinAvailable) Name() string {
+	return "Resource" + reflect.TypeOf(*m).Name()
+}
+
+func (m *MinAvailable) Description() string {
+	return minAvailableDescription
+}
+
+// GetMinAvailable returns the minimal free capacity defined on the placement, or nil if it is not defined.
+func GetMinAvailable(placement *clusterapiv1beta1.Placement) (map[clusterapiv1.ResourceName]resource.Quantity, error) {
+	value, ok := placement.Annotations[MinAvailableAnnotation]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+
+	quantities := map[string]string{}
+	if err := json.Unmarshal([]byte(value), &quantities); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %w", MinAvailableAnnotation, err)
+	}
+
+	minAvailable := map[clusterapiv1.ResourceName]resource.Quantity{}
+	for name, quantity := range quantities {
+		q, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of %s in annotation %s: %w", name, MinAvailableAnnotation, err)
+		}
+		minAvailable[clusterapiv1.ResourceName(name)] = q
+	}
+	return minAvailable, nil
+}
+
+func (m *MinAvailable) Filter(ctx context.Context, placement *clusterapiv1beta1.Placement,
+	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginFilterResult, *framework.Status) {
+	minAvailable, err := GetMinAvailable(placement)
+	if err != nil {
+		return plugins.PluginFilterResult{}, framework.NewStatus(m.Name(), framework.Misconfigured, err.Error())
+	}
+	if len(minAvailable) == 0 {
+		return plugins.PluginFilterResult{
+			Filtered: clusters,
+		}, framework.NewStatus(m.Name(), framework.Success, "")
+	}
+
+	// check the resources in a stable order so that the rejection reason is stable.
+	var names []clusterapiv1.ResourceName
+	for name := range minAvailable {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+
+	filtered := []*clusterapiv1.ManagedCluster{}
+	rejected := map[string]string{}
+	for _, cluster := range clusters {
+		if reason := insufficientReason(cluster, names, minAvailable); len(reason) > 0 {
+			rejected[cluster.Name] = reason
+			continue
+		}
+		filtered = append(filtered, cluster)
+	}
+
+	return plugins.PluginFilterResult{
+		Filtered: filtered,
+		Rejected: rejected,
+	}, framework.NewStatus(m.Name(), framework.Success, "")
+}
+
+func (m *MinAvailable) RequeueAfter(ctx context.Context, placement *clusterapiv1beta1.Placement) (plugins.PluginRequeueResult, *framework.Status) {
+	return plugins.PluginRequeueResult{}, framework.NewStatus(m.Name(), framework.Success, "")
+}
+
+// insufficientReason returns the reason why the free capacity of the cluster is insufficient, or an empty
+// string if the cluster has enough free capacity of all the resources.
+func insufficientReason(cluster *clusterapiv1.ManagedCluster, names []clusterapiv1.ResourceName,
+	minAvailable map[clusterapiv1.ResourceName]resource.Quantity) string {
+	for _, name := range names {
+		required := minAvailable[name]
+		free, ok := cluster.Status.Capacity[helpers.FreeResourceName(name)]
+		if !ok {
+			return fmt.Sprintf("free %s is not reported", name)
+		}
+		if free.Cmp(required) < 0 {
+			return fmt.Sprintf("free %s is less than %s", name, required.String())
+		}
+	}
+	return ""
+}
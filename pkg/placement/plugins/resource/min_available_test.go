@@ -0,0 +1,89 @@
+package resource
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
+	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
+
+	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
+	testinghelpers "open-cluster-management.io/ocm/pkg/placement/helpers/testing"
+)
+
+func TestMinAvailableFilter(t *testing.T) {
+	newPlacement := func(value string) *clusterapiv1beta1.Placement {
+		return testinghelpers.NewPlacementWithAnnotations("test", "test", map[string]string{
+			MinAvailableAnnotation: value,
+		}).Build()
+	}
+
+	cases := []struct {
+		name             string
+		placement        *clusterapiv1beta1.Placement
+		expectedClusters []string
+		expectedRejected map[string]string
+		expectedCode     framework.Code
+	}{
+		{
+			name:             "no annotation",
+			placement:        testinghelpers.NewPlacement("test", "test").Build(),
+			expectedClusters: []string{"cluster1", "cluster2", "cluster3"},
+			expectedCode:     framework.Success,
+		},
+		{
+			name:             "min available cpu",
+			placement:        newPlacement(`{"cpu":"2"}`),
+			expectedClusters: []string{"cluster2"},
+			expectedRejected: map[string]string{
+				"cluster1": "free cpu is less than 2",
+				"cluster3": "free cpu is not reported",
+			},
+			expectedCode: framework.Success,
+		},
+		{
+			name:             "min available extended resource",
+			placement:        newPlacement(`{"cpu":"500m","nvidia.com/gpu":"1"}`),
+			expectedClusters: []string{"cluster1"},
+			expectedRejected: map[string]string{
+				"cluster2": "free nvidia.com/gpu is not reported",
+				"cluster3": "free cpu is not reported",
+			},
+			expectedCode: framework.Success,
+		},
+		{
+			name:         "invalid quantity",
+			placement:    newPlacement(`{"cpu":"two"}`),
+			expectedCode: framework.Misconfigured,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			clusters := []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithFreeResource(clusterapiv1.ResourceCPU, "1").
+					WithFreeResource("nvidia.com/gpu", "2").Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithFreeResource(clusterapiv1.ResourceCPU, "4").Build(),
+				testinghelpers.NewManagedCluster("cluster3").Build(),
+			}
+
+			p := NewMinAvailable(testinghelpers.NewFakePluginHandle(t, nil))
+			result, status := p.Filter(context.TODO(), c.placement, clusters)
+			if status.Code() != c.expectedCode {
+				t.Errorf("expected code %v, but got %v: %s", c.expectedCode, status.Code(), status.Message())
+			}
+
+			var actual []string
+			for _, cluster := range result.Filtered {
+				actual = append(actual, cluster.Name)
+			}
+			if !reflect.DeepEqual(actual, c.expectedClusters) {
+				t.Errorf("expected clusters %v, but got %v", c.expectedClusters, actual)
+			}
+			if len(c.expectedRejected) > 0 && !reflect.DeepEqual(result.Rejected, c.expectedRejected) {
+				t.Errorf("expected rejected %v, but got %v", c.expectedRejected, result.Rejected)
+			}
+		})
+	}
+}
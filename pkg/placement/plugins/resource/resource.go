@@ -3,12 +3,13 @@ package resource
 import (
 	"context"
 	"fmt"
-	"regexp"
 	"sort"
+	"strings"
 
 	clusterapiv1 "open-cluster-management.io/api/cluster/v1"
 	clusterapiv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/placement/controllers/framework"
 	"open-cluster-management.io/ocm/pkg/placement/plugins"
 )
@@ -16,18 +17,31 @@ import (
 const (
 	placementLabel = clusterapiv1beta1.PlacementLabel
 	description    = `
-	ResourceAllocatableCPU and ResourceAllocatableMemory prioritizer makes the scheduling
-	decisions based on the resource allocatable of managed clusters.
-	The clusters that has the most allocatable are given the highest score,
+	ResourceAllocatable and ResourceFree prioritizers make the scheduling decisions based on
+	the resource allocatable or the free resource of managed clusters. The free resource is
+	the allocatable minus the requests of the pods, which is reported by the agent.
+	The clusters that has the most allocatable or free resource are given the highest score,
 	while the least is given the lowest score.
 	`
+
+	// AlgorithmAllocatable scores the clusters by the allocatable of the resource.
+	AlgorithmAllocatable = "Allocatable"
+	// AlgorithmFree scores the clusters by the free capacity of the resource.
+	AlgorithmFree = "Free"
+
+	prioritizerNamePrefix = "Resource"
+
+	resourceEphemeralStorage clusterapiv1.ResourceName = "ephemeral-storage"
 )
 
 var _ plugins.Prioritizer = &ResourcePrioritizer{}
 
+var algorithms = []string{AlgorithmAllocatable, AlgorithmFree}
+
 var resourceMap = map[string]clusterapiv1.ResourceName{
-	"CPU":    clusterapiv1.ResourceCPU,
-	"Memory": clusterapiv1.ResourceMemory,
+	"CPU":              clusterapiv1.ResourceCPU,
+	"Memory":           clusterapiv1.ResourceMemory,
+	"EphemeralStorage": resourceEphemeralStorage,
 }
 
 type ResourcePrioritizer struct {
@@ -62,15 +76,53 @@ func (r *ResourcePrioritizerBuilder) Build() *ResourcePrioritizer {
 }
 
 // parese prioritizerName to algorithm and resource.
-// For example, prioritizerName ResourceAllocatableCPU will return Allocatable, CPU.
+// For example, prioritizerName ResourceAllocatableCPU will return Allocatable, cpu. An extended resource
+// is appended to the algorithm with a slash, for example, ResourceFree/nvidia.com/gpu will return Free,
+// nvidia.com/gpu.
 func parsePrioritizerName(prioritizerName string) (algorithm string, resource clusterapiv1.ResourceName) {
-	s := regexp.MustCompile("[A-Z]+[a-z]*").FindAllString(prioritizerName, -1)
-	if len(s) == 3 {
-		return s[1], resourceMap[s[2]]
+	if !strings.HasPrefix(prioritizerName, prioritizerNamePrefix) {
+		return "", ""
+	}
+	name := strings.TrimPrefix(prioritizerName, prioritizerNamePrefix)
+	for _, algorithm := range algorithms {
+		if !strings.HasPrefix(name, algorithm) {
+			continue
+		}
+		name = strings.TrimPrefix(name, algorithm)
+		if extended, ok := strings.CutPrefix(name, "/"); ok && len(extended) > 0 {
+			return algorithm, clusterapiv1.ResourceName(extended)
+		}
+		if resource, ok := resourceMap[name]; ok {
+			return algorithm, resource
+		}
 	}
 	return "", ""
 }
 
+// PrioritizerNames returns the names of the prioritizers of the resources in the resourceMap. The prioritizers
+// of the extended resources are resolved by ResolvePrioritizer.
+func PrioritizerNames() []string {
+	var names []string
+	for _, algorithm := range algorithms {
+		for resource := range resourceMap {
+			names = append(names, prioritizerNamePrefix+algorithm+resource)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolvePrioritizer returns the factory of the prioritizer if the name is a valid resource prioritizer name,
+// including the prioritizers of the extended resources, such as ResourceFree/nvidia.com/gpu.
+func ResolvePrioritizer(name string) (plugins.PrioritizerFactory, bool) {
+	if algorithm, _ := parsePrioritizerName(name); len(algorithm) == 0 {
+		return nil, false
+	}
+	return func(handle plugins.Handle) plugins.Prioritizer {
+		return NewResourcePrioritizerBuilder(handle).WithPrioritizerName(name).Build()
+	}, true
+}
+
 func (r *ResourcePrioritizer) Name() string {
 	return r.prioritizerName
 }
@@ -82,8 +134,13 @@ func (r *ResourcePrioritizer) Description() string {
 func (r *ResourcePrioritizer) Score(ctx context.Context, placement *clusterapiv1beta1.Placement,
 	clusters []*clusterapiv1.ManagedCluster) (plugins.PluginScoreResult, *framework.Status) {
 	status := framework.NewStatus(r.Name(), framework.Success, "")
-	if r.algorithm == "Allocatable" {
+	switch r.algorithm {
+	case AlgorithmAllocatable:
 		return mostResourceAllocatableScores(r.resource, clusters), status
+	case AlgorithmFree:
+		return mostResourceScores(clusters, func(cluster *clusterapiv1.ManagedCluster) (float64, error) {
+			return getClusterFreeResource(cluster, r.resource)
+		}), status
 	}
 	return plugins.PluginScoreResult{}, status
 }
@@ -96,10 +153,20 @@ func (r *ResourcePrioritizer) RequeueAfter(ctx context.Context, placement *clust
 // The clusters that has the most allocatable are given the highest score, while the least is given the lowest score.
 // The score range is from -100 to 100.
 func mostResourceAllocatableScores(resourceName clusterapiv1.ResourceName, clusters []*clusterapiv1.ManagedCluster) plugins.PluginScoreResult {
+	return mostResourceScores(clusters, func(cluster *clusterapiv1.ManagedCluster) (float64, error) {
+		allocatable, _, err := getClusterResource(cluster, resourceName)
+		return allocatable, err
+	})
+}
+
+// Calculate clusters scores based on the resource value returned by the getter. The clusters without the
+// resource value are not scored.
+func mostResourceScores(clusters []*clusterapiv1.ManagedCluster,
+	getter func(cluster *clusterapiv1.ManagedCluster) (float64, error)) plugins.PluginScoreResult {
 	scores := map[string]int64{}
 
-	// get resourceName's min and max allocatable among all the clusters
-	minAllocatable, maxAllocatable, err := getClustersMinMaxAllocatableResource(clusters, resourceName)
+	// get the min and max value among all the clusters
+	minValue, maxValue, err := getClustersMinMaxResource(clusters, getter)
 	if err != nil {
 		return plugins.PluginScoreResult{
 			Scores: scores,
@@ -107,15 +174,15 @@ func mostResourceAllocatableScores(resourceName clusterapiv1.ResourceName, clust
 	}
 
 	for _, cluster := range clusters {
-		// get one cluster resourceName's allocatable
-		allocatable, _, err := getClusterResource(cluster, resourceName)
+		// get one cluster resource value
+		value, err := getter(cluster)
 		if err != nil {
 			continue
 		}
 
-		// score = ((resource_x_allocatable - min(resource_x_allocatable)) / (max(resource_x_allocatable) - min(resource_x_allocatable)) - 0.5) * 2 * 100
-		if (maxAllocatable - minAllocatable) != 0 {
-			ratio := (allocatable - minAllocatable) / (maxAllocatable - minAllocatable)
+		// score = ((resource_x_value - min(resource_x_value)) / (max(resource_x_value) - min(resource_x_value)) - 0.5) * 2 * 100
+		if (maxValue - minValue) != 0 {
+			ratio := (value - minValue) / (maxValue - minValue)
 			scores[cluster.Name] = int64((ratio - 0.5) * 2.0 * 100.0)
 		} else {
 			scores[cluster.Name] = 100.0
@@ -144,24 +211,32 @@ func getClusterResource(cluster *clusterapiv1.ManagedCluster, resourceName clust
 	return allocatable, capacity, nil
 }
 
-// Go through all the cluster resources and return the min and max allocatable value of the resourceName.
-func getClustersMinMaxAllocatableResource(clusters []*clusterapiv1.ManagedCluster,
-	resourceName clusterapiv1.ResourceName) (minAllocatable, maxAllocatable float64, err error) {
-	allocatable := sort.Float64Slice{}
+// Get the free capacity of the resourceName reported by the agent in the capacity of the cluster.
+func getClusterFreeResource(cluster *clusterapiv1.ManagedCluster, resourceName clusterapiv1.ResourceName) (float64, error) {
+	if v, exist := cluster.Status.Capacity[helpers.FreeResourceName(resourceName)]; exist {
+		return v.AsApproximateFloat64(), nil
+	}
+	return 0, fmt.Errorf("no free %s found in cluster %s", resourceName, cluster.ObjectMeta.Name)
+}
+
+// Go through all the clusters and return the min and max resource value returned by the getter.
+func getClustersMinMaxResource(clusters []*clusterapiv1.ManagedCluster,
+	getter func(cluster *clusterapiv1.ManagedCluster) (float64, error)) (minValue, maxValue float64, err error) {
+	values := sort.Float64Slice{}
 
-	// get allocatable resources
+	// get resource values
 	for _, cluster := range clusters {
-		if alloc, _, err := getClusterResource(cluster, resourceName); err == nil {
-			allocatable = append(allocatable, alloc)
+		if value, err := getter(cluster); err == nil {
+			values = append(values, value)
 		}
 	}
 
-	// return err if no allocatable resource
-	if len(allocatable) == 0 {
-		return 0, 0, fmt.Errorf("no allocatable %s found in clusters", resourceName)
+	// return err if no resource value
+	if len(values) == 0 {
+		return 0, 0, fmt.Errorf("no resource found in clusters")
 	}
 
 	// sort to get min and max
-	sort.Float64s(allocatable)
-	return allocatable[0], allocatable[len(allocatable)-1], nil
+	sort.Float64s(values)
+	return values[0], values[len(values)-1], nil
 }
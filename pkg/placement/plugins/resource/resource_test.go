@@ -119,6 +119,35 @@ func TestScoreClusterWithResource(t *testing.T) {
 			},
 			expectedScores: map[string]int64{},
 		},
+		{
+			name:      "scores of ResourceFreeCPU",
+			resource:  clusterapiv1.ResourceCPU,
+			algorithm: "Free",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").WithResource(clusterapiv1.ResourceCPU, "10", "10").
+					WithFreeResource(clusterapiv1.ResourceCPU, "2").Build(),
+				testinghelpers.NewManagedCluster("cluster2").WithResource(clusterapiv1.ResourceCPU, "6", "10").
+					WithFreeResource(clusterapiv1.ResourceCPU, "4").Build(),
+				testinghelpers.NewManagedCluster("cluster3").WithResource(clusterapiv1.ResourceCPU, "2", "10").
+					WithFreeResource(clusterapiv1.ResourceCPU, "6").Build(),
+				testinghelpers.NewManagedCluster("cluster4").WithResource(clusterapiv1.ResourceCPU, "2", "10").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": 0, "cluster3": 100},
+		},
+		{
+			name:      "scores of ResourceFree of extended resource",
+			resource:  "nvidia.com/gpu",
+			algorithm: "Free",
+			placement: testinghelpers.NewPlacement("test", "test").Build(),
+			clusters: []*clusterapiv1.ManagedCluster{
+				testinghelpers.NewManagedCluster("cluster1").
+					WithFreeResource("nvidia.com/gpu", "1").Build(),
+				testinghelpers.NewManagedCluster("cluster2").
+					WithFreeResource("nvidia.com/gpu", "3").Build(),
+			},
+			expectedScores: map[string]int64{"cluster1": -100, "cluster2": 100},
+		},
 	}
 
 	for _, c := range cases {
@@ -143,3 +172,33 @@ func TestScoreClusterWithResource(t *testing.T) {
 		})
 	}
 }
+
+func TestParsePrioritizerName(t *testing.T) {
+	cases := []struct {
+		name              string
+		expectedAlgorithm string
+		expectedResource  clusterapiv1.ResourceName
+	}{
+		{name: "ResourceAllocatableCPU", expectedAlgorithm: "Allocatable", expectedResource: clusterapiv1.ResourceCPU},
+		{name: "ResourceAllocatableMemory", expectedAlgorithm: "Allocatable", expectedResource: clusterapiv1.ResourceMemory},
+		{name: "ResourceFreeEphemeralStorage", expectedAlgorithm: "Free", expectedResource: "ephemeral-storage"},
+		{name: "ResourceFree/nvidia.com/gpu", expectedAlgorithm: "Free", expectedResource: "nvidia.com/gpu"},
+		{name: "ResourceAllocatable/nvidia.com/gpu", expectedAlgorithm: "Allocatable", expectedResource: "nvidia.com/gpu"},
+		{name: "ResourceFree/"},
+		{name: "ResourceFreeGPU"},
+		{name: "ResourceRatioCPU"},
+		{name: "Balance"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			algorithm, resource := parsePrioritizerName(c.name)
+			if algorithm != c.expectedAlgorithm || resource != c.expectedResource {
+				t.Errorf("expected %q, %q, but got %q, %q", c.expectedAlgorithm, c.expectedResource, algorithm, resource)
+			}
+			if _, ok := ResolvePrioritizer(c.name); ok != (len(c.expectedAlgorithm) > 0) {
+				t.Errorf("expected prioritizer %q resolved: %v", c.name, len(c.expectedAlgorithm) > 0)
+			}
+		})
+	}
+}
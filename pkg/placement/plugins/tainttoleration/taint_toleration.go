@@ -77,14 +77,19 @@ func (pl *TaintToleration) Filter(ctx context.Context, placement *clusterapiv1be
 
 	// filter the clusters
 	matched := []*clusterapiv1.ManagedCluster{}
+	rejected := map[string]string{}
 	for _, cluster := range clusters {
-		if tolerated, _, _ := isClusterTolerated(cluster, placement.Spec.Tolerations, decisionClusterNames.Has(cluster.Name)); tolerated {
+		inDecision := decisionClusterNames.Has(cluster.Name)
+		if tolerated, _, _ := isClusterTolerated(cluster, placement.Spec.Tolerations, inDecision); tolerated {
 			matched = append(matched, cluster)
+			continue
 		}
+		rejected[cluster.Name] = untoleratedReason(cluster, placement.Spec.Tolerations, inDecision)
 	}
 
 	return plugins.PluginFilterResult{
 		Filtered: matched,
+		Rejected: rejected,
 	}, status
 }
 
@@ -128,6 +133,17 @@ func isClusterTolerated(cluster *clusterapiv1.ManagedCluster, tolerations []clus
 	return true, minRequeue, ""
 }
 
+// untoleratedReason returns the reason why a cluster is not tolerated, which is the first taint of
+// the cluster not tolerated by the given toleration array.
+func untoleratedReason(cluster *clusterapiv1.ManagedCluster, tolerations []clusterapiv1beta1.Toleration, inDecision bool) string {
+	for _, taint := range cluster.Spec.Taints {
+		if tolerated, _, _ := isTaintTolerated(taint, tolerations, inDecision); !tolerated {
+			return fmt.Sprintf("taint %s=%s:%s is not tolerated", taint.Key, taint.Value, taint.Effect)
+		}
+	}
+	return ""
+}
+
 // isTaintTolerated returns true if a taint is tolerated by the given toleration array
 func isTaintTolerated(taint clusterapiv1.Taint, tolerations []clusterapiv1beta1.Toleration, inDecision bool) (bool, *plugins.PluginRequeueResult, string) {
 	message := ""
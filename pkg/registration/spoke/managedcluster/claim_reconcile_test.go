@@ -212,6 +212,7 @@ func TestSync(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				clusterPropertyInformerFactory.About().V1alpha1().ClusterProperties(),
 				kubeInformerFactory.Core().V1().Nodes(),
+				nil,
 				20,
 				[]string{},
 				eventstesting.NewTestingEventRecorder(t),
@@ -588,6 +589,7 @@ func TestExposeClaims(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				clusterPropertyInformerFactory.About().V1alpha1().ClusterProperties(),
 				kubeInformerFactory.Core().V1().Nodes(),
+				nil,
 				c.maxCustomClusterClaims,
 				c.reservedClusterClaimSuffixes,
 				eventstesting.NewTestingEventRecorder(t),
@@ -100,6 +100,7 @@ func TestSyncManagedCluster(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				clusterPropertyInformerFactory.About().V1alpha1().ClusterProperties(),
 				kubeInformerFactory.Core().V1().Nodes(),
+				nil,
 				20,
 				[]string{},
 				eventstesting.NewTestingEventRecorder(t),
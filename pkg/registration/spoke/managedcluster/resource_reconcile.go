@@ -4,20 +4,31 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/discovery"
 	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
 )
 
 type resoureReconcile struct {
 	managedClusterDiscoveryClient discovery.DiscoveryInterface
 	nodeLister                    corev1lister.NodeLister
+	// podLister is used to calculate the free capacity of the cluster, the free capacity is not reported if it is nil.
+	podLister corev1lister.PodLister
+	// podsSynced returns true once the pod informer is synced, the free capacity is not calculated before that
+	// since the pod requests are undercounted.
+	podsSynced cache.InformerSynced
 }
 
 func (r *resoureReconcile) reconcile(ctx context.Context, cluster *clusterv1.ManagedCluster) (*clusterv1.ManagedCluster, reconcileState, error) {
@@ -37,8 +48,12 @@ func (r *resoureReconcile) reconcile(ctx context.Context, cluster *clusterv1.Man
 		}
 
 		// we allow other components update the cluster capacity, so we need merge the capacity to this updated, if
-		// one current capacity entry does not exist in this updated capacity, we add it back.
+		// one current capacity entry does not exist in this updated capacity, we add it back. The free capacity is
+		// only maintained by the agent, so it is not added back unless the pods are not synced yet.
 		for key, val := range cluster.Status.Capacity {
+			if strings.HasPrefix(string(key), helpers.FreeResourcePrefix) && !r.freeCapacityPending() {
+				continue
+			}
 			if _, ok := capacity[key]; !ok {
 				capacity[key] = val
 			}
@@ -105,6 +120,7 @@ func (r *resoureReconcile) getClusterResources() (capacity, allocatable clusterv
 
 	capacityList := make(map[clusterv1.ResourceName]resource.Quantity)
 	allocatableList := make(map[clusterv1.ResourceName]resource.Quantity)
+	schedulableNodes := sets.New[string]()
 
 	for _, node := range nodes {
 		for key, value := range node.Status.Capacity {
@@ -120,6 +136,7 @@ func (r *resoureReconcile) getClusterResources() (capacity, allocatable clusterv
 		if node.Spec.Unschedulable {
 			continue
 		}
+		schedulableNodes.Insert(node.Name)
 
 		for key, value := range node.Status.Allocatable {
 			if allocatable, exist := allocatableList[clusterv1.ResourceName(key)]; exist {
@@ -131,5 +148,102 @@ func (r *resoureReconcile) getClusterResources() (capacity, allocatable clusterv
 		}
 	}
 
+	if r.podLister == nil || r.freeCapacityPending() {
+		return capacityList, allocatableList, nil
+	}
+
+	requested, err := r.getRequestedResources(schedulableNodes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the free capacity is reported in the capacity since the allocatable is the total of the schedulable nodes.
+	for key, value := range allocatableList {
+		free := value.DeepCopy()
+		if request, ok := requested[key]; ok {
+			free.Sub(request)
+		}
+		if free.Sign() < 0 {
+			free = *resource.NewQuantity(0, value.Format)
+		}
+		capacityList[helpers.FreeResourceName(key)] = free
+	}
+
 	return capacityList, allocatableList, nil
 }
+
+// freeCapacityPending returns true if the free capacity is reported but the pod informer is not synced yet.
+func (r *resoureReconcile) freeCapacityPending() bool {
+	return r.podLister != nil && !r.podsSynced()
+}
+
+// getRequestedResources returns the total resource requests of the pods running on the given nodes.
+func (r *resoureReconcile) getRequestedResources(nodes sets.Set[string]) (map[clusterv1.ResourceName]resource.Quantity, error) {
+	pods, err := r.podLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[clusterv1.ResourceName]resource.Quantity)
+	for _, pod := range pods {
+		if !nodes.Has(pod.Spec.NodeName) {
+			continue
+		}
+		// the terminated pods do not occupy the resources
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for key, value := range podRequests(pod) {
+			if request, exist := requested[clusterv1.ResourceName(key)]; exist {
+				request.Add(value)
+				requested[clusterv1.ResourceName(key)] = request
+			} else {
+				requested[clusterv1.ResourceName(key)] = value
+			}
+		}
+	}
+	return requested, nil
+}
+
+// podRequests returns the resource requests of a pod in the way the kube-scheduler calculates it, which is
+// the max of the total requests of the containers and sidecars and the request of each init container, plus
+// the pod overhead.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResourceList(requests, container.Resources.Requests)
+	}
+
+	// the sidecars keep running with the containers, while an init container runs with the sidecars started
+	// before it.
+	sidecars := corev1.ResourceList{}
+	for _, container := range pod.Spec.InitContainers {
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			addResourceList(sidecars, container.Resources.Requests)
+			addResourceList(requests, container.Resources.Requests)
+			continue
+		}
+
+		initRequests := sidecars.DeepCopy()
+		addResourceList(initRequests, container.Resources.Requests)
+		for key, value := range initRequests {
+			if request, exist := requests[key]; !exist || value.Cmp(request) > 0 {
+				requests[key] = value
+			}
+		}
+	}
+
+	addResourceList(requests, pod.Spec.Overhead)
+	return requests
+}
+
+func addResourceList(list, added corev1.ResourceList) {
+	for key, value := range added {
+		if quantity, exist := list[key]; exist {
+			quantity.Add(value)
+			list[key] = quantity
+		} else {
+			list[key] = value.DeepCopy()
+		}
+	}
+}
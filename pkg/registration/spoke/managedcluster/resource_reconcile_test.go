@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -334,6 +335,7 @@ func TestHealthCheck(t *testing.T) {
 				clusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 				clusterPropertyInformerFactory.About().V1alpha1().ClusterProperties(),
 				kubeInformerFactory.Core().V1().Nodes(),
+				nil,
 				20,
 				[]string{},
 				eventstesting.NewTestingEventRecorder(t),
@@ -348,3 +350,85 @@ func TestHealthCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestGetClusterResourcesWithFreeResources(t *testing.T) {
+	newPod := func(name, nodeName string, phase corev1.PodPhase, containers, initContainers []corev1.Container) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       corev1.PodSpec{NodeName: nodeName, Containers: containers, InitContainers: initContainers},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	newContainer := func(cpu string) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		}}
+	}
+	always := corev1.ContainerRestartPolicyAlways
+	sidecar := newContainer("1")
+	sidecar.RestartPolicy = &always
+
+	unschedulable := testinghelpers.NewNode("node2", testinghelpers.NewResourceList(16, 32), testinghelpers.NewResourceList(16, 32))
+	unschedulable.Spec.Unschedulable = true
+	nodes := []runtime.Object{
+		testinghelpers.NewNode("node1", testinghelpers.NewResourceList(16, 32), testinghelpers.NewResourceList(16, 32)),
+		unschedulable,
+	}
+	pods := []runtime.Object{
+		// requests 2 cpu
+		newPod("pod1", "node1", corev1.PodRunning, []corev1.Container{newContainer("1"), newContainer("1")}, nil),
+		// requests 4 cpu by the init container
+		newPod("pod2", "node1", corev1.PodPending, []corev1.Container{newContainer("1")}, []corev1.Container{newContainer("4")}),
+		// requests 3 cpu with the sidecar
+		newPod("pod3", "node1", corev1.PodRunning, []corev1.Container{newContainer("2")}, []corev1.Container{sidecar}),
+		// terminated pod is ignored
+		newPod("pod4", "node1", corev1.PodSucceeded, []corev1.Container{newContainer("8")}, nil),
+		// pod on unschedulable node is ignored
+		newPod("pod5", "node2", corev1.PodRunning, []corev1.Container{newContainer("8")}, nil),
+	}
+
+	kubeClient := kubefake.NewSimpleClientset(append(nodes, pods...)...)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Minute*10)
+	for _, node := range nodes {
+		if err := kubeInformerFactory.Core().V1().Nodes().Informer().GetStore().Add(node); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pod := range pods {
+		if err := kubeInformerFactory.Core().V1().Pods().Informer().GetStore().Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	synced := false
+	r := &resoureReconcile{
+		nodeLister: kubeInformerFactory.Core().V1().Nodes().Lister(),
+		podLister:  kubeInformerFactory.Core().V1().Pods().Lister(),
+		podsSynced: func() bool { return synced },
+	}
+
+	// the free capacity is not reported before the pods are synced
+	capacity, _, err := r.getClusterResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := capacity[helpers.FreeResourceName(clusterv1.ResourceCPU)]; ok {
+		t.Errorf("expected no free cpu before the pods are synced, but got %v", capacity)
+	}
+
+	synced = true
+	capacity, allocatable, err := r.getClusterResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	freeCPU := capacity[helpers.FreeResourceName(clusterv1.ResourceCPU)]
+	if freeCPU.Cmp(resource.MustParse("7")) != 0 {
+		t.Errorf("expected free cpu 7, but got %s", freeCPU.String())
+	}
+	freeMemory := capacity[helpers.FreeResourceName(clusterv1.ResourceMemory)]
+	allocatableMemory := allocatable[clusterv1.ResourceMemory]
+	if freeMemory.Cmp(allocatableMemory) != 0 {
+		t.Errorf("expected free memory %s, but got %s", allocatableMemory.String(), freeMemory.String())
+	}
+}
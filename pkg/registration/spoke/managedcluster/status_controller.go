@@ -13,6 +13,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/discovery"
 	corev1informers "k8s.io/client-go/informers/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	kevents "k8s.io/client-go/tools/events"
 	aboutv1alpha1informer "sigs.k8s.io/about-api/pkg/generated/informers/externalversions/apis/v1alpha1"
 
@@ -58,6 +60,7 @@ func NewManagedClusterStatusController(
 	claimInformer clusterv1alpha1informer.ClusterClaimInformer,
 	propertyInformer aboutv1alpha1informer.ClusterPropertyInformer,
 	nodeInformer corev1informers.NodeInformer,
+	podInformer corev1informers.PodInformer,
 	maxCustomClusterClaims int,
 	reservedClusterClaimSuffixes []string,
 	resyncInterval time.Duration,
@@ -71,6 +74,7 @@ func NewManagedClusterStatusController(
 		claimInformer,
 		propertyInformer,
 		nodeInformer,
+		podInformer,
 		maxCustomClusterClaims,
 		reservedClusterClaimSuffixes,
 		recorder,
@@ -99,10 +103,19 @@ func newManagedClusterStatusController(
 	claimInformer clusterv1alpha1informer.ClusterClaimInformer,
 	propertyInformer aboutv1alpha1informer.ClusterPropertyInformer,
 	nodeInformer corev1informers.NodeInformer,
+	podInformer corev1informers.PodInformer,
 	maxCustomClusterClaims int,
 	reservedClusterClaimSuffixes []string,
 	recorder events.Recorder,
 	hubEventRecorder kevents.EventRecorder) *managedClusterStatusController {
+	// the free capacity is reported only if the pod informer is provided. The pod changes do not trigger
+	// the sync, the free capacity is refreshed periodically.
+	var podLister corev1lister.PodLister
+	var podsSynced cache.InformerSynced
+	if podInformer != nil {
+		podLister = podInformer.Lister()
+		podsSynced = podInformer.Informer().HasSynced
+	}
 	return &managedClusterStatusController{
 		clusterName: clusterName,
 		patcher: patcher.NewPatcher[
@@ -110,7 +123,8 @@ func newManagedClusterStatusController(
 			hubClusterClient.ClusterV1().ManagedClusters()),
 		reconcilers: []statusReconcile{
 			&joiningReconcile{recorder: recorder},
-			&resoureReconcile{managedClusterDiscoveryClient: managedClusterDiscoveryClient, nodeLister: nodeInformer.Lister()},
+			&resoureReconcile{managedClusterDiscoveryClient: managedClusterDiscoveryClient,
+				nodeLister: nodeInformer.Lister(), podLister: podLister, podsSynced: podsSynced},
 			&claimReconcile{claimLister: claimInformer.Lister(), recorder: recorder,
 				maxCustomClusterClaims:       maxCustomClusterClaims,
 				reservedClusterClaimSuffixes: reservedClusterClaimSuffixes,
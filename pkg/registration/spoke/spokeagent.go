@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -358,6 +359,11 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 	if err != nil {
 		return fmt.Errorf("failed to create event recorder: %w", err)
 	}
+
+	var podInformer corev1informers.PodInformer
+	if features.SpokeMutableFeatureGate.Enabled(features.FreeResources) {
+		podInformer = spokeKubeInformerFactory.Core().V1().Pods()
+	}
 	// create NewManagedClusterStatusController to update the spoke cluster status
 	managedClusterHealthCheckController := managedcluster.NewManagedClusterStatusController(
 		o.agentOptions.SpokeClusterName,
@@ -367,6 +373,7 @@ func (o *SpokeAgentConfig) RunSpokeAgentWithSpokeInformers(ctx context.Context,
 		spokeClusterInformerFactory.Cluster().V1alpha1().ClusterClaims(),
 		aboutInformers.About().V1alpha1().ClusterProperties(),
 		spokeKubeInformerFactory.Core().V1().Nodes(),
+		podInformer,
 		o.registrationOption.MaxCustomClusterClaims,
 		o.registrationOption.ReservedClusterClaimSuffixes,
 		o.registrationOption.ClusterHealthCheckPeriod,
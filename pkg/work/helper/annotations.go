@@ -0,0 +1,271 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+	ocmcellibrary "open-cluster-management.io/sdk-go/pkg/cel/library"
+)
+
+// The annotations below configure the alpha features of the work agent. They are defined here instead of the
+// agent controllers, so the hub webhook could validate them without depending on the agent packages.
+const (
+	// DependsOnAnnotationKey is the annotation key on a manifest to declare the manifests in the same work it
+	// depends on. The value is a comma separated list of <group>/<kind>/<namespace>/<name>, the group is empty for
+	// the core resources and the namespace is empty for the cluster scoped resources, for example
+	// "/Namespace//app,apps/Deployment/app/web". The manifest is only applied after all of them are ready.
+	DependsOnAnnotationKey = "work.open-cluster-management.io/depends-on"
+
+	// ApplyWaveReadyConditionAnnotationKey is the annotation key on the ManifestWork to set the condition type
+	// of a manifest which must be true before the manifests in the later waves or depending on it are applied.
+	// The value is Applied by default, and could be Available or the condition type of a ConditionRule which is
+	// evaluated by the status controller.
+	ApplyWaveReadyConditionAnnotationKey = "work.open-cluster-management.io/apply-wave-ready-condition"
+
+	// DriftDetectionAnnotationKey is the annotation key on the ManifestWork to enable the drift detection of the
+	// manifests applied with the Update or ServerSideApply strategy. The value is one of
+	//   - Enabled: the drift is reported and reverted by applying the manifest again.
+	//   - ReportOnly: the drift is reported but not reverted, the manifest is not applied until the drift is
+	//     resolved or the manifest is changed.
+	DriftDetectionAnnotationKey = "work.open-cluster-management.io/drift-detection"
+	DriftDetectionEnabled       = "Enabled"
+	DriftDetectionReportOnly    = "ReportOnly"
+
+	// CELFeedbackRulesAnnotationKey is the annotation on the manifestwork to define the feedback values returned
+	// by the CEL expressions, e.g.
+	//
+	//	[{"resourceIdentifier": {"group": "apps", "resource": "deployments", "namespace": "default", "name": "app"},
+	//	  "expressions": [{"name": "notReadyContainers", "expression": "..."}]}]
+	//
+	// The values are synced together with the values of the feedback rules in the manifest configs.
+	CELFeedbackRulesAnnotationKey = "work.open-cluster-management.io/cel-feedback-rules"
+
+	// HealthCheckAnnotationKey is the annotation key on the ManifestWork to enable the health evaluation of the
+	// manifests. When the value is Enabled, the health of the resources of the common kinds is evaluated with the
+	// built-in health checks, and the health of the other resources is evaluated with the condition rule of the
+	// Healthy condition. The Available condition of an unhealthy manifest is False, and the Healthy, Progressing
+	// and Degraded conditions are aggregated on the ManifestWork.
+	HealthCheckAnnotationKey = "work.open-cluster-management.io/health-check"
+	HealthCheckEnabled       = "Enabled"
+
+	// DryRunAnnotationKey is the annotation key on the ManifestWork to apply the manifests in the server side dry
+	// run mode, the manifests are validated and mutated by the admission of the managed cluster but not persisted,
+	// and the resources are not owned by the work. The value is All to dry run all manifests, or a json list of
+	// the resource identifiers of the manifests to dry run, e.g.
+	//   [{"group":"apps","resource":"deployments","namespace":"default","name":"*"}]
+	// The manifests with the ReadOnly strategy are not affected since they are never applied.
+	DryRunAnnotationKey = "work.open-cluster-management.io/dry-run"
+	DryRunAll           = "All"
+
+	// SignatureAnnotationKey is the annotation key on the ManifestWork of the base64 encoded detached signature
+	// over the canonicalized spec.workload. The signature is an ed25519 signature, or an ASN.1 ECDSA or PKCS #1
+	// v1.5 RSA signature of the SHA-256 digest. The ManifestWorks delivered by the cloudevents drivers carry the
+	// annotation in the metadata extension.
+	SignatureAnnotationKey = "work.open-cluster-management.io/signature"
+)
+
+// ManifestApplyWave returns the apply wave of the manifest, false is returned if the wave is not set.
+func ManifestApplyWave(obj *unstructured.Unstructured) (int, bool, error) {
+	value, ok := obj.GetAnnotations()[ApplyWaveAnnotationKey]
+	if !ok {
+		return 0, false, nil
+	}
+	wave, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid annotation %s: %w", ApplyWaveAnnotationKey, err)
+	}
+	return wave, true, nil
+}
+
+// ManifestDependencies returns the manifests the manifest depends on, false is returned if the dependencies are
+// not set.
+func ManifestDependencies(obj *unstructured.Unstructured) ([]string, bool, error) {
+	value, ok := obj.GetAnnotations()[DependsOnAnnotationKey]
+	if !ok {
+		return nil, false, nil
+	}
+	var dependencies []string
+	for _, dependency := range strings.Split(value, ",") {
+		dependency = strings.TrimSpace(dependency)
+		if len(dependency) == 0 {
+			continue
+		}
+		if len(strings.Split(dependency, "/")) != 4 {
+			return nil, true, fmt.Errorf("invalid dependency %q, the format is <group>/<kind>/<namespace>/<name>", dependency)
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependencies, true, nil
+}
+
+// ValidateApplyWaveAnnotations validates the apply wave and dependency annotations of the manifests of the
+// ManifestWork.
+func ValidateApplyWaveAnnotations(manifestWork *workapiv1.ManifestWork) error {
+	for index, manifest := range manifestWork.Spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			// the manifests are validated by the manifest validator.
+			continue
+		}
+		if _, _, err := ManifestApplyWave(obj); err != nil {
+			return fmt.Errorf("manifest %d: %w", index, err)
+		}
+		if _, _, err := ManifestDependencies(obj); err != nil {
+			return fmt.Errorf("manifest %d: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// ValidateDriftDetectionAnnotation validates the drift detection annotation of the ManifestWork.
+func ValidateDriftDetectionAnnotation(manifestWork *workapiv1.ManifestWork) error {
+	value, ok := manifestWork.Annotations[DriftDetectionAnnotationKey]
+	if !ok || value == DriftDetectionEnabled || value == DriftDetectionReportOnly {
+		return nil
+	}
+	return fmt.Errorf("invalid annotation %s %q, the value should be %s or %s",
+		DriftDetectionAnnotationKey, value, DriftDetectionEnabled, DriftDetectionReportOnly)
+}
+
+// CELFeedbackRule is the CEL expressions to get the feedback values of the resources matched by the identifier.
+type CELFeedbackRule struct {
+	ResourceIdentifier workapiv1.ResourceIdentifier `json:"resourceIdentifier"`
+	Expressions        []CELFeedbackExpression      `json:"expressions"`
+}
+
+// CELFeedbackExpression is a CEL expression evaluated with the resource as the `object` variable, the result of
+// the expression is returned as the feedback value with the name.
+type CELFeedbackExpression struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
+
+// GetCELFeedbackRules returns the CEL feedback rules defined in the annotation of the ManifestWork.
+func GetCELFeedbackRules(manifestWork *workapiv1.ManifestWork) ([]CELFeedbackRule, error) {
+	value, ok := manifestWork.Annotations[CELFeedbackRulesAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var rules []CELFeedbackRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %v", CELFeedbackRulesAnnotationKey, err)
+	}
+	for _, rule := range rules {
+		for _, expression := range rule.Expressions {
+			if len(expression.Name) == 0 || len(expression.Expression) == 0 {
+				return nil, fmt.Errorf("invalid annotation %s: the name and expression are required",
+					CELFeedbackRulesAnnotationKey)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// NewFeedbackCELEnv returns the environment of the CEL expressions of the feedback rules.
+func NewFeedbackCELEnv() (*cel.Env, error) {
+	return cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{cel.Variable("object", cel.DynType)},
+		ocmcelcommon.BaseEnvOpts,
+		[]cel.EnvOption{ocmcellibrary.ConditionsLib()},
+	)...)
+}
+
+// ValidateCELFeedbackRulesAnnotation validates the CEL feedback rules annotation of the ManifestWork, including
+// the compilation of the expressions.
+func ValidateCELFeedbackRulesAnnotation(manifestWork *workapiv1.ManifestWork) error {
+	rules, err := GetCELFeedbackRules(manifestWork)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	env, err := NewFeedbackCELEnv()
+	if err != nil {
+		return fmt.Errorf("failed to create CEL environment: %v", err)
+	}
+	for _, rule := range rules {
+		for _, expression := range rule.Expressions {
+			if _, iss := env.Compile(expression.Expression); iss.Err() != nil {
+				return fmt.Errorf("invalid annotation %s: failed to compile the expression of %s: %v",
+					CELFeedbackRulesAnnotationKey, expression.Name, iss.Err())
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateHealthCheckAnnotation validates the health check annotation of the ManifestWork.
+func ValidateHealthCheckAnnotation(manifestWork *workapiv1.ManifestWork) error {
+	value, ok := manifestWork.Annotations[HealthCheckAnnotationKey]
+	if !ok || value == HealthCheckEnabled {
+		return nil
+	}
+	return fmt.Errorf("invalid annotation %s %q, the value should be %s", HealthCheckAnnotationKey, value, HealthCheckEnabled)
+}
+
+// DryRunSelector selects the manifests applied in the dry run mode, a nil selector selects nothing.
+type DryRunSelector struct {
+	All       bool
+	Resources []workapiv1.ResourceIdentifier
+}
+
+// GetDryRunSelector returns the selector of the manifests applied in the dry run mode of the ManifestWork.
+func GetDryRunSelector(manifestWork *workapiv1.ManifestWork) (*DryRunSelector, error) {
+	value, ok := manifestWork.Annotations[DryRunAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+	if value == DryRunAll {
+		return &DryRunSelector{All: true}, nil
+	}
+
+	selector := &DryRunSelector{}
+	if err := json.Unmarshal([]byte(value), &selector.Resources); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s %q, the value should be %s or a list of resource identifiers: %v",
+			DryRunAnnotationKey, value, DryRunAll, err)
+	}
+	return selector, nil
+}
+
+// Matches returns true if the manifest is applied in the dry run mode.
+func (s *DryRunSelector) Matches(resourceMeta workapiv1.ManifestResourceMeta) bool {
+	if s == nil {
+		return false
+	}
+	if s.All {
+		return true
+	}
+	for _, resource := range s.Resources {
+		if ResourceMatch(resourceMeta, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateDryRunAnnotation validates the dry run annotation of the ManifestWork.
+func ValidateDryRunAnnotation(manifestWork *workapiv1.ManifestWork) error {
+	_, err := GetDryRunSelector(manifestWork)
+	return err
+}
+
+// ValidateSignatureAnnotation validates the encoding of the signature annotation of the ManifestWork, the
+// signature itself could only be verified on the managed cluster with the trusted keys.
+func ValidateSignatureAnnotation(manifestWork *workapiv1.ManifestWork) error {
+	value, ok := manifestWork.Annotations[SignatureAnnotationKey]
+	if !ok {
+		return nil
+	}
+	if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+		return fmt.Errorf("invalid annotation %s, the value should be base64 encoded: %v", SignatureAnnotationKey, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// ApplyWaveAnnotationKey is the annotation key on a manifest to set the apply wave of the manifest. The value
+	// is an integer and 0 by default. The resources are deleted in the reverse order of the apply waves, the
+	// resources in a wave are only deleted after all the resources in the later waves are finalized.
+	ApplyWaveAnnotationKey = "work.open-cluster-management.io/apply-wave"
+
+	// DeletionPropagationPolicyAnnotationKey is the annotation key on a manifest to set the propagation policy
+	// used when the resource is deleted by the work agent. The value is Foreground or Background, and it is
+	// Background by default. It is different from the DeleteOption of the ManifestWork, which decides whether
+	// the resource is deleted or orphaned.
+	DeletionPropagationPolicyAnnotationKey = "work.open-cluster-management.io/deletion-propagation-policy"
+
+	// WorkDeleting is the condition type of the ManifestWork to show the progress of deleting the applied
+	// resources in waves when the ManifestWork is deleting.
+	WorkDeleting = "Deleting"
+
+	// DeletingInWavesReason is the reason of the Deleting condition.
+	DeletingInWavesReason = "DeletingInWaves"
+)
+
+// DeletionProgress is the progress of deleting the applied resources in waves.
+type DeletionProgress struct {
+	// Wave is the wave being deleted.
+	Wave int
+	// Finalizing is the number of the resources in the wave which are deleted and pending for finalization.
+	Finalizing int
+	// Waiting is the number of the resources in the earlier waves which are waiting for the wave to be finalized.
+	Waiting int
+}
+
+func (p *DeletionProgress) String() string {
+	if p.Finalizing == 0 && p.Waiting == 0 {
+		return "all resources are deleted"
+	}
+	return fmt.Sprintf("deleting wave %d: %d resources pending finalization, %d resources waiting",
+		p.Wave, p.Finalizing, p.Waiting)
+}
+
+// deletionWave returns the apply wave of the resource, or 0 if it is not set or invalid.
+func deletionWave(obj *unstructured.Unstructured) int {
+	value, ok := obj.GetAnnotations()[ApplyWaveAnnotationKey]
+	if !ok {
+		return 0
+	}
+	wave, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		klog.Warningf("ignore the invalid annotation %s of %s %s/%s: %v",
+			ApplyWaveAnnotationKey, obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		return 0
+	}
+	return wave
+}
+
+// deletionPropagationPolicy returns the propagation policy to delete the resource, it is Background by default.
+func deletionPropagationPolicy(obj *unstructured.Unstructured) metav1.DeletionPropagation {
+	value, ok := obj.GetAnnotations()[DeletionPropagationPolicyAnnotationKey]
+	if !ok {
+		return metav1.DeletePropagationBackground
+	}
+	switch policy := metav1.DeletionPropagation(value); policy {
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground:
+		return policy
+	default:
+		klog.Warningf("ignore the invalid annotation %s of %s %s/%s: %q",
+			DeletionPropagationPolicyAnnotationKey, obj.GetKind(), obj.GetNamespace(), obj.GetName(), value)
+		return metav1.DeletePropagationBackground
+	}
+}
+
+// ValidateDeletionAnnotations validates the deletion propagation policy annotations of the manifests of the
+// ManifestWork, the apply wave annotations are validated with the apply waves.
+func ValidateDeletionAnnotations(manifestWork *workapiv1.ManifestWork) error {
+	for index, manifest := range manifestWork.Spec.Workload.Manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			// the manifests are validated by the manifest validator.
+			continue
+		}
+		value, ok := obj.GetAnnotations()[DeletionPropagationPolicyAnnotationKey]
+		if !ok {
+			continue
+		}
+		switch metav1.DeletionPropagation(value) {
+		case metav1.DeletePropagationForeground, metav1.DeletePropagationBackground:
+		default:
+			return fmt.Errorf("invalid annotation %s %q of manifest %d, the value should be %s or %s",
+				DeletionPropagationPolicyAnnotationKey, value, index,
+				metav1.DeletePropagationForeground, metav1.DeletePropagationBackground)
+		}
+	}
+	return nil
+}
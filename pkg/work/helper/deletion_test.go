@@ -0,0 +1,148 @@
+package helper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func newWaveSecret(name string, terminated bool, annotations map[string]string) *corev1.Secret {
+	secret := newSecret("ns1", name, terminated, name, metav1.OwnerReference{Name: "n1", UID: "a"})
+	secret.Annotations = annotations
+	return secret
+}
+
+func newSecretResource(name string) workapiv1.AppliedManifestResourceMeta {
+	return workapiv1.AppliedManifestResourceMeta{
+		Version:            "v1",
+		ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "secrets", Namespace: "ns1", Name: name},
+		UID:                name,
+	}
+}
+
+func TestDeleteAppliedResourcesInWaves(t *testing.T) {
+	cases := []struct {
+		name              string
+		existingResources []runtime.Object
+		expectedDeleted   []string
+		expectedPending   []string
+		expectedProgress  DeletionProgress
+	}{
+		{
+			name: "delete the latest wave at first",
+			existingResources: []runtime.Object{
+				newWaveSecret("crd", false, nil),
+				newWaveSecret("cr", false, map[string]string{
+					ApplyWaveAnnotationKey:                 "1",
+					DeletionPropagationPolicyAnnotationKey: "Foreground",
+				}),
+				newWaveSecret("deploy", false, map[string]string{ApplyWaveAnnotationKey: "0"}),
+			},
+			expectedDeleted:  []string{"cr"},
+			expectedPending:  []string{"crd", "cr", "deploy"},
+			expectedProgress: DeletionProgress{Wave: 1, Finalizing: 1, Waiting: 2},
+		},
+		{
+			name: "wait for the latest wave to be finalized",
+			existingResources: []runtime.Object{
+				newWaveSecret("crd", false, nil),
+				newWaveSecret("cr", true, map[string]string{ApplyWaveAnnotationKey: "1"}),
+			},
+			expectedPending:  []string{"crd", "cr"},
+			expectedProgress: DeletionProgress{Wave: 1, Finalizing: 1, Waiting: 1},
+		},
+		{
+			name: "delete the earlier wave after the later wave is finalized",
+			existingResources: []runtime.Object{
+				newWaveSecret("crd", false, nil),
+				newWaveSecret("deploy", false, nil),
+			},
+			expectedDeleted:  []string{"crd", "deploy"},
+			expectedPending:  []string{"crd", "deploy"},
+			expectedProgress: DeletionProgress{Wave: 0, Finalizing: 2},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(scheme, c.existingResources...)
+			resources := []workapiv1.AppliedManifestResourceMeta{
+				newSecretResource("crd"), newSecretResource("cr"), newSecretResource("deploy")}
+			pending, progress, errs := DeleteAppliedResourcesInWaves(context.TODO(), resources, "testing",
+				fakeDynamicClient, eventstesting.NewTestingEventRecorder(t), metav1.OwnerReference{Name: "n1", UID: "a"})
+			if len(errs) != 0 {
+				t.Errorf("unexpected errs: %v", errs)
+			}
+
+			var deleted []string
+			for _, action := range fakeDynamicClient.Actions() {
+				if deleteAction, ok := action.(clienttesting.DeleteActionImpl); ok {
+					deleted = append(deleted, deleteAction.Name)
+				}
+			}
+			if !reflect.DeepEqual(deleted, c.expectedDeleted) {
+				t.Errorf("expected deleted %v, but got %v", c.expectedDeleted, deleted)
+			}
+
+			var pendingNames []string
+			for _, resource := range pending {
+				pendingNames = append(pendingNames, resource.Name)
+			}
+			if !reflect.DeepEqual(pendingNames, c.expectedPending) {
+				t.Errorf("expected pending %v, but got %v", c.expectedPending, pendingNames)
+			}
+			if *progress != c.expectedProgress {
+				t.Errorf("expected progress %v, but got %v", c.expectedProgress, *progress)
+			}
+		})
+	}
+}
+
+func TestDeletionPropagationPolicy(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expected    metav1.DeletionPropagation
+	}{
+		{
+			name:     "background by default",
+			expected: metav1.DeletePropagationBackground,
+		},
+		{
+			name:        "foreground",
+			annotations: map[string]string{DeletionPropagationPolicyAnnotationKey: "Foreground"},
+			expected:    metav1.DeletePropagationForeground,
+		},
+		{
+			name:        "invalid policy",
+			annotations: map[string]string{DeletionPropagationPolicyAnnotationKey: "Orphan"},
+			expected:    metav1.DeletePropagationBackground,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			obj := testingcommon.NewUnstructured("v1", "Secret", "ns1", "n1")
+			obj.SetAnnotations(c.annotations)
+			if actual := deletionPropagationPolicy(obj); actual != c.expected {
+				t.Errorf("expected %s, but got %s", c.expected, actual)
+			}
+		})
+	}
+}
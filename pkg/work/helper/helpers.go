@@ -174,6 +174,21 @@ func DeleteAppliedResources(
 	dynamicClient dynamic.Interface,
 	recorder events.Recorder,
 	owner metav1.OwnerReference) ([]workapiv1.AppliedManifestResourceMeta, []error) {
+	resourcesPendingFinalization, _, errs := DeleteAppliedResourcesInWaves(ctx, resources, reason, dynamicClient, recorder, owner)
+	return resourcesPendingFinalization, errs
+}
+
+// DeleteAppliedResourcesInWaves deletes the given applied resources in the reverse order of their apply waves,
+// and returns those pending for finalization or waiting for the later waves to be finalized, together with the
+// deletion progress. The resources in a wave are only deleted after all the resources in the later waves are
+// finalized. If the uid recorded in resources is different from what we get by client, ignore the deletion.
+func DeleteAppliedResourcesInWaves(
+	ctx context.Context,
+	resources []workapiv1.AppliedManifestResourceMeta,
+	reason string,
+	dynamicClient dynamic.Interface,
+	recorder events.Recorder,
+	owner metav1.OwnerReference) ([]workapiv1.AppliedManifestResourceMeta, *DeletionProgress, []error) {
 	var resourcesPendingFinalization []workapiv1.AppliedManifestResourceMeta
 	var errs []error
 
@@ -181,12 +196,11 @@ func DeleteAppliedResources(
 	ownerCopy := owner.DeepCopy()
 	ownerCopy.UID = types.UID(fmt.Sprintf("%s-", owner.UID))
 
-	// We hard coded the delete policy to Background
-	// TODO: reivist if user needs to set other options. Setting to Orphan may not make sense, since when
-	// the manifestwork is removed, there is no way to track the orphaned resource any more.
-	deletePolicy := metav1.DeletePropagationBackground
-
-	for _, resource := range resources {
+	// the resources to be deleted by this agent, and the wave of each of them.
+	var toDelete []*unstructured.Unstructured
+	waves := map[int]int{}
+	progress := &DeletionProgress{}
+	for index, resource := range resources {
 		gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
 		u, err := dynamicClient.
 			Resource(gvr).
@@ -194,6 +208,7 @@ func DeleteAppliedResources(
 			Get(ctx, resource.Name, metav1.GetOptions{})
 		if errors.IsNotFound(err) {
 			klog.V(2).Infof("Resource %v with key %s/%s is removed Successfully", gvr, resource.Namespace, resource.Name)
+			toDelete = append(toDelete, nil)
 			continue
 		}
 
@@ -201,6 +216,7 @@ func DeleteAppliedResources(
 			errs = append(errs, fmt.Errorf(
 				"failed to get resource %v with key %s/%s: %w",
 				gvr, resource.Namespace, resource.Name, err))
+			toDelete = append(toDelete, nil)
 			continue
 		}
 
@@ -208,6 +224,7 @@ func DeleteAppliedResources(
 
 		// If it is not owned by us, skip
 		if !IsOwnedBy(owner, existingOwner) {
+			toDelete = append(toDelete, nil)
 			continue
 		}
 
@@ -219,23 +236,47 @@ func DeleteAppliedResources(
 					"failed to remove owner from resource %v with key %s/%s: %w",
 					gvr, resource.Namespace, resource.Name, err))
 			}
-
+			toDelete = append(toDelete, nil)
 			continue
 		}
 
 		if resource.UID != string(u.GetUID()) {
 			// the traced instance has been deleted, and forget this item.
+			toDelete = append(toDelete, nil)
+			continue
+		}
+
+		toDelete = append(toDelete, u)
+		waves[index] = deletionWave(u)
+		if len(waves) == 1 || waves[index] > progress.Wave {
+			progress.Wave = waves[index]
+		}
+	}
+
+	for index, resource := range resources {
+		u := toDelete[index]
+		if u == nil {
+			continue
+		}
+		gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
+
+		// wait for the resources in the later waves to be finalized
+		if waves[index] < progress.Wave {
+			resourcesPendingFinalization = append(resourcesPendingFinalization, resource)
+			progress.Waiting++
 			continue
 		}
 
 		if u.GetDeletionTimestamp() != nil && !u.GetDeletionTimestamp().IsZero() {
 			resourcesPendingFinalization = append(resourcesPendingFinalization, resource)
+			progress.Finalizing++
 			continue
 		}
 
 		// delete the resource which is not deleted yet
+		deletePolicy := deletionPropagationPolicy(u)
 		uid := types.UID(resource.UID)
-		err = dynamicClient.
+		err := dynamicClient.
 			Resource(gvr).
 			Namespace(resource.Namespace).
 			Delete(context.TODO(), resource.Name, metav1.DeleteOptions{
@@ -257,12 +298,12 @@ func DeleteAppliedResources(
 				gvr, resource.Namespace, resource.Name, err))
 			continue
 		}
-
 		resourcesPendingFinalization = append(resourcesPendingFinalization, resource)
+		progress.Finalizing++
 		recorder.Eventf("ResourceDeleted", "Deleted resource %v with key %s/%s because %s.", gvr, resource.Namespace, resource.Name, reason)
 	}
 
-	return resourcesPendingFinalization, errs
+	return resourcesPendingFinalization, progress, errs
 }
 
 // existOtherAppliedManifestWorkOwners check existingOwners for other appliedManifestWork owners other than myOwner
@@ -354,7 +395,9 @@ func NewAppliedManifestWorkOwner(appliedWork *workapiv1.AppliedManifestWork) *me
 	}
 }
 
-func resourceMatch(resourceMeta workapiv1.ManifestResourceMeta, resource workapiv1.ResourceIdentifier) bool {
+// ResourceMatch returns true if the resource meta matches the resource identifier, the namespace and name of the
+// identifier could be a wildcard.
+func ResourceMatch(resourceMeta workapiv1.ManifestResourceMeta, resource workapiv1.ResourceIdentifier) bool {
 	return resourceMeta.Group == resource.Group &&
 		resourceMeta.Resource == resource.Resource &&
 		wildcardMatch(resourceMeta.Namespace, resource.Namespace) &&
@@ -375,7 +418,7 @@ func FindManifestConfiguration(resourceMeta workapiv1.ManifestResourceMeta,
 
 	for i := 0; i < len(manifestOptions); i++ {
 		option := manifestOptions[i]
-		if !resourceMatch(resourceMeta, option.ResourceIdentifier) {
+		if !ResourceMatch(resourceMeta, option.ResourceIdentifier) {
 			continue
 		}
 
@@ -549,3 +592,26 @@ func wildcardMatch(resource, target string) bool {
 
 	return re.MatchString(resource)
 }
+
+// FindPodSpec returns the pod spec of a pod, or the pod template spec of a workload. It returns nil if the
+// object has no pod spec.
+func FindPodSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	var fields []string
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Pod"}:
+		fields = []string{"spec"}
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}, schema.GroupKind{Group: "apps", Kind: "StatefulSet"},
+		schema.GroupKind{Group: "apps", Kind: "DaemonSet"}, schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+		schema.GroupKind{Group: "batch", Kind: "Job"}:
+		fields = []string{"spec", "template", "spec"}
+	case schema.GroupKind{Group: "batch", Kind: "CronJob"}:
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+	spec, found, err := unstructured.NestedMap(obj.Object, fields...)
+	if err != nil || !found {
+		return nil
+	}
+	return spec
+}
@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	// PausedAnnotationKey is the annotation key on the ManifestWork to pause applying the manifests on the
+	// managed cluster. The work is paused when the value is true, the manifests are not applied and the
+	// resources removed from the work are not deleted until the annotation is removed, while the status of the
+	// resources is still synced. The resources are still deleted when the work is deleted.
+	PausedAnnotationKey = "work.open-cluster-management.io/paused"
+
+	// SyncNowAnnotationKey is the annotation key on the ManifestWork to trigger the agent to apply the manifests
+	// and sync the status of the resources immediately instead of waiting for the next resync. Any new value,
+	// e.g. the current timestamp, triggers a sync. It takes no effect when the work is paused.
+	SyncNowAnnotationKey = "work.open-cluster-management.io/sync-now"
+
+	// WorkPaused is the condition type of a ManifestWork which is paused by the PausedAnnotationKey. It only
+	// exists when the work is paused.
+	WorkPaused = "Paused"
+)
+
+// syncControlAnnotationKeys are the annotations set on the ManifestWork by the users to control the sync.
+var syncControlAnnotationKeys = []string{PausedAnnotationKey, SyncNowAnnotationKey}
+
+// KeepSyncControlAnnotations copies the sync control annotations of the existing ManifestWork to the required
+// ManifestWork, so they are not removed when the ManifestWork is updated by a controller.
+func KeepSyncControlAnnotations(required, existing *workapiv1.ManifestWork) {
+	for _, key := range syncControlAnnotationKeys {
+		value, ok := existing.Annotations[key]
+		if !ok {
+			continue
+		}
+		if required.Annotations == nil {
+			required.Annotations = map[string]string{}
+		}
+		required.Annotations[key] = value
+	}
+}
+
+// IsWorkPaused returns true if the ManifestWork is paused by the annotation.
+func IsWorkPaused(manifestWork *workapiv1.ManifestWork) bool {
+	return strings.EqualFold(manifestWork.Annotations[PausedAnnotationKey], "true")
+}
+
+// ValidateSyncControlAnnotations validates the sync control annotations of the ManifestWork, the value of the
+// SyncNowAnnotationKey could be any string.
+func ValidateSyncControlAnnotations(manifestWork *workapiv1.ManifestWork) error {
+	value, ok := manifestWork.Annotations[PausedAnnotationKey]
+	if !ok || strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+		return nil
+	}
+	return fmt.Errorf("invalid annotation %s %q, the value should be true or false", PausedAnnotationKey, value)
+}
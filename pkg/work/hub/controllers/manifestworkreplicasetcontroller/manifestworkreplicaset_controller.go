@@ -16,9 +16,13 @@ import (
 	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	appsinformers "k8s.io/client-go/informers/apps/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	clusterinformerv1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1"
 	clusterinformerv1beta1 "open-cluster-management.io/api/client/cluster/informers/externalversions/cluster/v1beta1"
 	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	workinformerv1 "open-cluster-management.io/api/client/work/informers/externalversions/work/v1"
@@ -50,12 +54,15 @@ const (
 
 	// maxRequeueTime is the same as the informer resync period
 	maxRequeueTime = 30 * time.Minute
+
+	manifestWorkReplicaSetControllerName = "ManifestWorkReplicaSetController"
 )
 
 type ManifestWorkReplicaSetController struct {
 	workClient                    workclientset.Interface
 	manifestWorkReplicaSetLister  worklisterv1alpha1.ManifestWorkReplicaSetLister
 	manifestWorkReplicaSetIndexer cache.Indexer
+	manifestWorkLister            worklisterv1.ManifestWorkLister
 
 	reconcilers []ManifestWorkReplicaSetReconcile
 }
@@ -75,31 +82,57 @@ const (
 
 func NewManifestWorkReplicaSetController(
 	recorder events.Recorder,
+	kubeClient kubernetes.Interface,
 	workClient workclientset.Interface,
 	workApplier *workapplier.WorkApplier,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
 	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	controllerRevisionInformer appsinformers.ControllerRevisionInformer,
 ) factory.Controller {
+	syncCtx := factory.NewSyncContext(manifestWorkReplicaSetControllerName, recorder)
+
 	controller := newController(
+		recorder,
+		kubeClient,
 		workClient,
 		workApplier,
 		manifestWorkReplicaSetInformer,
 		manifestWorkInformer,
 		placementInformer,
 		placeDecisionInformer,
+		clusterInformer,
+		configMapInformer,
+		controllerRevisionInformer,
 	)
 
 	err := manifestWorkReplicaSetInformer.Informer().AddIndexers(
 		cache.Indexers{
-			manifestWorkReplicaSetByPlacement: indexManifestWorkReplicaSetByPlacement,
+			manifestWorkReplicaSetByPlacement:         indexManifestWorkReplicaSetByPlacement,
+			manifestWorkReplicaSetByTemplateOverrides: indexManifestWorkReplicaSetByTemplateOverrides,
 		})
 	if err != nil {
 		utilruntime.HandleError(err)
 	}
 
+	// the templates are rendered with the labels and claims of the clusters, so the ManifestWorkReplicaSets
+	// with the templating enabled are enqueued once the labels or claims of a cluster change.
+	_, err = clusterInformer.Informer().AddEventHandler(&cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			for _, key := range controller.clusterQueueKeysFunc(oldObj, newObj) {
+				syncCtx.Queue().Add(key)
+			}
+		},
+	})
+	if err != nil {
+		utilruntime.HandleError(err)
+	}
+
 	return factory.New().
+		WithSyncContext(syncCtx).
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaNamespaceName, manifestWorkReplicaSetInformer.Informer()).
 		WithFilteredEventsInformersQueueKeyFunc(func(obj runtime.Object) string {
 			accessor, _ := meta.Accessor(obj)
@@ -117,21 +150,39 @@ func NewManifestWorkReplicaSetController(
 			manifestWorkInformer.Informer()).
 		WithInformersQueueKeysFunc(controller.placementDecisionQueueKeysFunc, placeDecisionInformer.Informer()).
 		WithInformersQueueKeysFunc(controller.placementQueueKeysFunc, placementInformer.Informer()).
-		WithSync(controller.sync).ToController("ManifestWorkReplicaSetController", recorder)
+		WithInformersQueueKeysFunc(controller.templateOverridesQueueKeysFunc, configMapInformer.Informer()).
+		WithBareInformers(clusterInformer.Informer(), controllerRevisionInformer.Informer()).
+		WithSync(controller.sync).ToController(manifestWorkReplicaSetControllerName, recorder)
 }
 
 func newController(
+	recorder events.Recorder,
+	kubeClient kubernetes.Interface,
 	workClient workclientset.Interface,
 	workApplier *workapplier.WorkApplier,
 	manifestWorkReplicaSetInformer workinformerv1alpha1.ManifestWorkReplicaSetInformer,
 	manifestWorkInformer workinformerv1.ManifestWorkInformer,
 	placementInformer clusterinformerv1beta1.PlacementInformer,
 	placeDecisionInformer clusterinformerv1beta1.PlacementDecisionInformer,
+	clusterInformer clusterinformerv1.ManagedClusterInformer,
+	configMapInformer corev1informers.ConfigMapInformer,
+	controllerRevisionInformer appsinformers.ControllerRevisionInformer,
 ) *ManifestWorkReplicaSetController {
+	renderer := templateRenderer{
+		configMapLister: configMapInformer.Lister(),
+		clusterLister:   clusterInformer.Lister(),
+	}
+	// the revisions of the ManifestWorkTemplates are kept as ControllerRevisions.
+	revisions := &revisionHistory{
+		kubeClient:     kubeClient,
+		revisionLister: controllerRevisionInformer.Lister(),
+		recorder:       recorder,
+	}
 	return &ManifestWorkReplicaSetController{
 		workClient:                    workClient,
 		manifestWorkReplicaSetLister:  manifestWorkReplicaSetInformer.Lister(),
 		manifestWorkReplicaSetIndexer: manifestWorkReplicaSetInformer.Informer().GetIndexer(),
+		manifestWorkLister:            manifestWorkInformer.Lister(),
 
 		reconcilers: []ManifestWorkReplicaSetReconcile{
 			&finalizeReconciler{
@@ -142,13 +193,24 @@ func newController(
 			&addFinalizerReconciler{
 				workClient: workClient,
 			},
+			&revisionReconciler{
+				workClient: workClient,
+				history:    revisions,
+			},
 			&deployReconciler{
+				workClient:          workClient,
 				workApplier:         workApplier,
 				manifestWorkLister:  manifestWorkInformer.Lister(),
 				placementLister:     placementInformer.Lister(),
 				placeDecisionLister: placeDecisionInformer.Lister(),
+				renderer:            renderer,
+				revisions:           revisions,
+			},
+			&statusReconciler{
+				manifestWorkLister: manifestWorkInformer.Lister(),
+				renderer:           renderer,
+				revisions:          revisions,
 			},
-			&statusReconciler{manifestWorkLister: manifestWorkInformer.Lister()},
 		},
 	}
 }
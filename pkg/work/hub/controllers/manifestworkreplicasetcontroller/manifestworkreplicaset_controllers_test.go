@@ -8,8 +8,11 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 
 	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
@@ -236,13 +239,20 @@ func TestManifestWorkReplicaSetControllerPatchStatus(t *testing.T) {
 				t.Fatal(err)
 			}
 
+			kubeClient := kubefake.NewSimpleClientset()
+			kubeInformers := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute)
 			ctrl := newController(
+				eventstesting.NewTestingEventRecorder(t),
+				kubeClient,
 				fakeClient,
 				workapplier.NewWorkApplierWithTypedClient(fakeClient, workInformers.Work().V1().ManifestWorks().Lister()),
 				workInformers.Work().V1alpha1().ManifestWorkReplicaSets(),
 				workInformers.Work().V1().ManifestWorks(),
 				clusterInformers.Cluster().V1beta1().Placements(),
 				clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+				clusterInformers.Cluster().V1().ManagedClusters(),
+				kubeInformers.Core().V1().ConfigMaps(),
+				kubeInformers.Apps().V1().ControllerRevisions(),
 			)
 
 			controllerContext := testingcommon.NewFakeSyncContext(t, c.mwrSet.Namespace+"/"+c.mwrSet.Name)
@@ -11,11 +11,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	clusterlister "open-cluster-management.io/api/client/cluster/listers/cluster/v1beta1"
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
 	worklisterv1 "open-cluster-management.io/api/client/work/listers/work/v1"
 	workv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
 
 	"open-cluster-management.io/ocm/pkg/common/helpers"
 	"open-cluster-management.io/ocm/pkg/work/helper"
@@ -23,10 +25,14 @@ import (
 
 // deployReconciler is to manage ManifestWork based on the placement.
 type deployReconciler struct {
+	workClient          workclientset.Interface
 	workApplier         *workapplier.WorkApplier
 	manifestWorkLister  worklisterv1.ManifestWorkLister
 	placeDecisionLister clusterlister.PlacementDecisionLister
 	placementLister     clusterlister.PlacementLister
+	renderer            templateRenderer
+	// revisions is nil if the revision history is not kept.
+	revisions *revisionHistory
 }
 
 func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
@@ -36,6 +42,44 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	var plcsSummary []workapiv1alpha1.PlacementSummary
 	minRequeue := maxRequeueTime
 	count, total := 0, 0
+
+	overrides, err := d.renderer.overrides(mwrSet)
+	if err != nil {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(
+			ManifestWorkReplicaSetConditionTemplateRendered, ReasonTemplateRenderFailed, err.Error(), metav1.ConditionFalse))
+		return mwrSet, reconcileContinue, err
+	}
+	// render errors keyed by cluster name
+	renderErrs := map[string]error{}
+	// clusters failed or timed out in the rollout of the current ManifestWorkTemplate
+	failedClusters := sets.New[string]()
+
+	// the rollout is gated by the success criteria if they are defined. The invalid criteria are reported by the
+	// condition only, since they are not fixed by retrying.
+	criteria, err := newRolloutCriteria(mwrSet)
+	setRolloutCriteriaVerified(mwrSet, criteria, err)
+	if err != nil {
+		criteria = invalidRolloutCriteria()
+	}
+	// the ManifestWorks whose record of the time since when the criteria hold is changed, keyed by cluster name
+	criteriaHeldUpdates := map[string]*workv1.ManifestWork{}
+	rolloutStatusFunc := func(clusterName string, manifestWork workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, error) {
+		status, err := d.clusterRolloutStatusFunc(clusterName, manifestWork)
+		if err != nil {
+			return status, err
+		}
+		var updated *workv1.ManifestWork
+		if criteria != nil {
+			status, updated, err = criteria.rolloutStatus(ctx, status, &manifestWork)
+		} else {
+			updated, err = setCriteriaHeld(&manifestWork, nil)
+		}
+		if updated != nil {
+			criteriaHeldUpdates[clusterName] = updated
+		}
+		return status, err
+	}
+
 	// Getting the placements and the created ManifestWorks related to each placement
 	for _, placementRef := range mwrSet.Spec.PlacementRefs {
 		var existingRolloutClsStatus []clustersdkv1alpha1.ClusterRolloutStatus
@@ -58,9 +102,14 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 
 		for _, mw := range manifestWorks {
 			// Check if ManifestWorkTemplate changes, ManifestWork will need to be updated.
+			spec, err := d.renderer.render(mwrSet, mw.Namespace, overrides)
+			if err != nil {
+				renderErrs[mw.Namespace] = err
+				continue
+			}
 			newMW := &workv1.ManifestWork{}
 			mw.ObjectMeta.DeepCopyInto(&newMW.ObjectMeta)
-			mwrSet.Spec.ManifestWorkTemplate.DeepCopyInto(&newMW.Spec)
+			newMW.Spec = *spec
 
 			// TODO: Create NeedToApply function by workApplier to check the manifestWork->spec hash value from the cache.
 			if !workapplier.ManifestWorkEqual(newMW, mw) {
@@ -68,7 +117,7 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			}
 
 			existingClusterNames.Insert(mw.Namespace)
-			rolloutClusterStatus, err := d.clusterRolloutStatusFunc(mw.Namespace, *mw)
+			rolloutClusterStatus, err := rolloutStatusFunc(mw.Namespace, *mw)
 
 			if err != nil {
 				errs = append(errs, err)
@@ -78,7 +127,7 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		}
 
 		placeTracker := helper.GetPlacementTracker(d.placeDecisionLister, placement, existingClusterNames)
-		rolloutHandler, err := clustersdkv1alpha1.NewRolloutHandler(placeTracker, d.clusterRolloutStatusFunc)
+		rolloutHandler, err := clustersdkv1alpha1.NewRolloutHandler(placeTracker, rolloutStatusFunc)
 		if err != nil {
 			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementDecisionVerified(workapiv1alpha1.ReasonNotAsExpected, ""))
 
@@ -91,7 +140,13 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			continue
 		}
 
-		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(placementRef.RolloutStrategy, existingRolloutClsStatus)
+		rolloutStrategy := placementRef.RolloutStrategy
+		if criteria != nil {
+			rolloutStrategy = criteria.strategy(rolloutStrategy)
+			criteria.applyThreshold(placeTracker.ExistingClusterGroupsBesides(), existingRolloutClsStatus, rolloutMinSuccessTime(rolloutStrategy))
+		}
+
+		_, rolloutResult, err := rolloutHandler.GetRolloutCluster(rolloutStrategy, existingRolloutClsStatus)
 
 		if err != nil {
 			errs = append(errs, err)
@@ -100,6 +155,15 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			continue
 		}
 
+		for _, status := range existingRolloutClsStatus {
+			if status.Status == clustersdkv1alpha1.Failed {
+				failedClusters.Insert(status.ClusterName)
+			}
+		}
+		for _, status := range rolloutResult.ClustersTimeOut {
+			failedClusters.Insert(status.ClusterName)
+		}
+
 		if rolloutResult.RecheckAfter != nil && *rolloutResult.RecheckAfter < minRequeue {
 			minRequeue = *rolloutResult.RecheckAfter
 		}
@@ -107,12 +171,24 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		// Create ManifestWorks
 		for _, rolloutStatue := range rolloutResult.ClustersToRollout {
 			if rolloutStatue.Status == clustersdkv1alpha1.ToApply {
-				mw, err := CreateManifestWork(mwrSet, rolloutStatue.ClusterName, placementRef.Name)
+				mw, err := d.renderer.renderedManifestWork(mwrSet, rolloutStatue.ClusterName, placementRef.Name, overrides)
 				if err != nil {
+					renderErrs[rolloutStatue.ClusterName] = err
+					continue
+				}
+
+				// keep the sync control annotations set on the ManifestWork by the users, e.g. the paused one.
+				existing, err := d.manifestWorkLister.ManifestWorks(mw.Namespace).Get(mw.Name)
+				switch {
+				case err == nil:
+					helper.KeepSyncControlAnnotations(mw, existing)
+				case !errors.IsNotFound(err):
 					errs = append(errs, err)
 					continue
 				}
 
+				// the record of the criteria is removed by the apply
+				delete(criteriaHeldUpdates, rolloutStatue.ClusterName)
 				_, err = d.workApplier.Apply(ctx, mw)
 				if err != nil {
 					fmt.Printf("err is %v\n", err)
@@ -125,6 +201,7 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 
 		for _, cls := range rolloutResult.ClustersRemoved {
 			// Delete manifestWork for removed clusters
+			delete(criteriaHeldUpdates, cls.ClusterName)
 			err = d.workApplier.Delete(ctx, cls.ClusterName, mwrSet.Name)
 			if err != nil {
 				errs = append(errs, err)
@@ -150,6 +227,12 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	// Set the placements summary
 	mwrSet.Status.PlacementsSummary = plcsSummary
 
+	for _, updated := range criteriaHeldUpdates {
+		if err := d.updateCriteriaHeld(ctx, updated); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// Set the Summary
 	if mwrSet.Status.Summary == (workapiv1alpha1.ManifestWorkReplicaSetSummary{}) {
 		mwrSet.Status.Summary = workapiv1alpha1.ManifestWorkReplicaSetSummary{}
@@ -172,6 +255,21 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetPlacementRollOut(workapiv1alpha1.ReasonProgressing, ""))
 	}
 
+	if templateEnabled(mwrSet) {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetTemplateRendered(renderErrs))
+	} else {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionTemplateRendered)
+	}
+	if len(renderErrs) > 0 {
+		errs = append(errs, fmt.Errorf("failed to render the ManifestWorkTemplate for %d clusters", len(renderErrs)))
+	}
+
+	if d.revisions != nil && failedClusters.Len() > 0 {
+		if err := d.revisions.rollback(ctx, mwrSet, failedClusters.Len(), total); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	if len(errs) > 0 {
 		return mwrSet, reconcileContinue, utilerrors.NewAggregate(errs)
 	}
@@ -183,6 +281,37 @@ func (d *deployReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	return mwrSet, reconcileContinue, nil
 }
 
+// updateCriteriaHeld patches the record of the time since when the rollout success criteria hold on the
+// ManifestWork.
+func (d *deployReconciler) updateCriteriaHeld(ctx context.Context, updated *workv1.ManifestWork) error {
+	existing, err := d.manifestWorkLister.ManifestWorks(updated.Namespace).Get(updated.Name)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	// only the record is patched, the other annotations might be changed since the ManifestWork is evaluated.
+	required := existing.ObjectMeta.DeepCopy()
+	if value, ok := updated.Annotations[rolloutCriteriaHeldAnnotationKey]; ok {
+		if required.Annotations == nil {
+			required.Annotations = map[string]string{}
+		}
+		required.Annotations[rolloutCriteriaHeldAnnotationKey] = value
+	} else {
+		delete(required.Annotations, rolloutCriteriaHeldAnnotationKey)
+	}
+
+	workPatcher := patcher.NewPatcher[*workv1.ManifestWork, workv1.ManifestWorkSpec, workv1.ManifestWorkStatus](
+		d.workClient.WorkV1().ManifestWorks(updated.Namespace))
+	_, err = workPatcher.PatchLabelAnnotations(ctx, existing, *required, existing.ObjectMeta)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (d *deployReconciler) clusterRolloutStatusFunc(clusterName string, manifestWork workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, error) {
 	clsRolloutStatus := clustersdkv1alpha1.ClusterRolloutStatus{
 		ClusterName:        clusterName,
@@ -191,6 +320,12 @@ func (d *deployReconciler) clusterRolloutStatusFunc(clusterName string, manifest
 		Status: clustersdkv1alpha1.ToApply,
 	}
 
+	// The paused work is not progressing, skip it so it neither blocks nor times out the rollout.
+	if isWorkPaused(&manifestWork) {
+		clsRolloutStatus.Status = clustersdkv1alpha1.Skip
+		return clsRolloutStatus, nil
+	}
+
 	appliedCondition := apimeta.FindStatusCondition(manifestWork.Status.Conditions, workv1.WorkApplied)
 
 	// Applied condition not exist return status as ToApply.
@@ -224,6 +359,12 @@ func (d *deployReconciler) clusterRolloutStatusFunc(clusterName string, manifest
 	return clsRolloutStatus, nil
 }
 
+// isWorkPaused returns true if the ManifestWork is paused by the annotation, or the agent has not resumed it yet.
+func isWorkPaused(manifestWork *workv1.ManifestWork) bool {
+	return helper.IsWorkPaused(manifestWork) ||
+		apimeta.IsStatusConditionTrue(manifestWork.Status.Conditions, helper.WorkPaused)
+}
+
 // GetManifestworkApplied return only True status if there all clusters have manifests applied as expected
 func GetManifestworkApplied(reason string, message string) metav1.Condition {
 	if reason == workapiv1alpha1.ReasonAsExpected {
@@ -19,9 +19,11 @@ import (
 	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
 	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
 
 	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
 )
 
@@ -629,3 +631,107 @@ func TestRequeueWithProgressDeadline(t *testing.T) {
 		t.Errorf("expect to get err %t", err)
 	}
 }
+
+func TestClusterRolloutStatusFunc(t *testing.T) {
+	applied := metav1.Condition{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue}
+	available := metav1.Condition{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue}
+	paused := metav1.Condition{Type: helper.WorkPaused, Status: metav1.ConditionTrue}
+
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		conditions     []metav1.Condition
+		expectedStatus clustersdkv1alpha1.RolloutStatus
+	}{
+		{
+			name:           "not applied",
+			expectedStatus: clustersdkv1alpha1.ToApply,
+		},
+		{
+			name:           "applied",
+			conditions:     []metav1.Condition{applied},
+			expectedStatus: clustersdkv1alpha1.Progressing,
+		},
+		{
+			name:           "available",
+			conditions:     []metav1.Condition{applied, available},
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+		},
+		{
+			name:           "paused by annotation",
+			annotations:    map[string]string{helper.PausedAnnotationKey: "true"},
+			conditions:     []metav1.Condition{applied},
+			expectedStatus: clustersdkv1alpha1.Skip,
+		},
+		{
+			name:           "not resumed by the agent yet",
+			conditions:     []metav1.Condition{applied, paused},
+			expectedStatus: clustersdkv1alpha1.Skip,
+		},
+	}
+
+	d := &deployReconciler{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mw := workapiv1.ManifestWork{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "cls1", Annotations: c.annotations},
+				Status:     workapiv1.ManifestWorkStatus{Conditions: c.conditions},
+			}
+			status, err := d.clusterRolloutStatusFunc("cls1", mw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, c.expectedStatus, status.Status)
+		})
+	}
+}
+
+func TestDeployKeepSyncControlAnnotations(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mw, _ := CreateManifestWork(mwrSet, "cls1", "place-test")
+	mw.Annotations = map[string]string{
+		helper.PausedAnnotationKey:              "true",
+		helper.SyncNowAnnotationKey:             "token",
+		"test.open-cluster-management.io/other": "value",
+	}
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet, mw)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+	if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+		t.Fatal(err)
+	}
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1")
+	fClusterClient := fakeclusterclient.NewSimpleClientset(placement, placementDecision)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fClusterClient, 1*time.Second)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:  mwLister,
+		placeDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+		placementLister:     clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+	}
+
+	// change the template of the paused cluster
+	mwTemplate := helpertest.CreateTestManifestWorkSpecWithSecret("v2", "test", "ns-test", "name-test")
+	mwTemplate.DeepCopyInto(&mwrSet.Spec.ManifestWorkTemplate)
+	if _, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := fWorkClient.WorkV1().ManifestWorks("cls1").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, string(mwTemplate.Workload.Manifests[0].Raw), string(updated.Spec.Workload.Manifests[0].Raw))
+	assert.Equal(t, map[string]string{
+		helper.PausedAnnotationKey:  "true",
+		helper.SyncNowAnnotationKey: "token",
+	}, updated.Annotations)
+}
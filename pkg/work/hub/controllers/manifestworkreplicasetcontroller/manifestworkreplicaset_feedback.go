@@ -0,0 +1,318 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// ManifestWorkReplicaSetFeedbackAggregationAnnotationKey is the annotation key on the ManifestWorkReplicaSet
+	// to define the rules aggregating the status feedback values of the ManifestWorks across the clusters. The
+	// value is a json list of FeedbackAggregationRule, for example
+	//   [{"name":"readyReplicas","feedbackName":"ReadyReplicas","type":"Sum",
+	//     "resourceIdentifier":{"group":"apps","resource":"deployments","namespace":"default","name":"app"}}]
+	ManifestWorkReplicaSetFeedbackAggregationAnnotationKey = "work.open-cluster-management.io/feedback-aggregation"
+
+	// ManifestWorkReplicaSetConditionFeedbackAggregated is the condition type of the ManifestWorkReplicaSet which
+	// reports whether the status feedback values are aggregated. It only exists when the aggregation rules are
+	// defined, and the message of the condition is the json encoded AggregatedFeedback once the aggregation
+	// succeeds.
+	ManifestWorkReplicaSetConditionFeedbackAggregated = "FeedbackAggregated"
+
+	// ReasonInvalidAggregationRules is the reason of the FeedbackAggregated condition when the aggregation rules
+	// are invalid.
+	ReasonInvalidAggregationRules = "InvalidAggregationRules"
+
+	// ReasonAggregatedFeedbackTooLarge is the reason of the FeedbackAggregated condition when the aggregated
+	// feedback exceeds the max length of the condition message even if no failing cluster is listed.
+	ReasonAggregatedFeedbackTooLarge = "AggregatedFeedbackTooLarge"
+
+	// maxFailingClusters is the max number of the failing clusters listed in the AggregatedFeedback.
+	maxFailingClusters = 20
+	// maxFailingClusterMessageLength is the max length of the message of a failing cluster.
+	maxFailingClusterMessageLength = 512
+	// maxAggregatedFeedbackLength is the max length of the json encoded AggregatedFeedback, it is kept under the
+	// max length of the condition message, which is 32768.
+	maxAggregatedFeedbackLength = 32000
+)
+
+// AggregationType is the type of the aggregation of the status feedback values.
+type AggregationType string
+
+const (
+	// AggregationSum sums the integer values.
+	AggregationSum AggregationType = "Sum"
+	// AggregationMin returns the minimum of the integer values.
+	AggregationMin AggregationType = "Min"
+	// AggregationMax returns the maximum of the integer values.
+	AggregationMax AggregationType = "Max"
+	// AggregationCountEqual counts the values equal to the value of the rule, the integer and boolean values are
+	// compared in their string form.
+	AggregationCountEqual AggregationType = "CountEqual"
+)
+
+// FeedbackAggregationRule defines how the status feedback values of the ManifestWorks are aggregated.
+type FeedbackAggregationRule struct {
+	// Name is the name of the aggregated value.
+	Name string `json:"name"`
+	// ResourceIdentifier selects the manifests whose feedback values are aggregated, the namespace and name could
+	// be a wildcard. All manifests are selected if it is not set.
+	ResourceIdentifier *workapiv1.ResourceIdentifier `json:"resourceIdentifier,omitempty"`
+	// FeedbackName is the name of the status feedback value.
+	FeedbackName string `json:"feedbackName"`
+	// Type is the type of the aggregation.
+	Type AggregationType `json:"type"`
+	// Value is the value compared by the CountEqual aggregation.
+	Value string `json:"value,omitempty"`
+}
+
+// AggregatedFeedback is the aggregated status feedback values of the ManifestWorks of a ManifestWorkReplicaSet.
+// Only the ManifestWorks with the latest ManifestWorkTemplate are aggregated, and a value is absent if there is
+// nothing to aggregate.
+type AggregatedFeedback struct {
+	// Total is the aggregated values of all the placements keyed by the name of the rule.
+	Total map[string]int64 `json:"total,omitempty"`
+	// Placements is the aggregated values of each placement keyed by the placement name.
+	Placements map[string]map[string]int64 `json:"placements,omitempty"`
+	// FailingClusters lists the clusters whose ManifestWork is not applied, not available or degraded. The list
+	// is truncated to keep the AggregatedFeedback under the max length of the condition message.
+	FailingClusters []FailingCluster `json:"failingClusters,omitempty"`
+	// FailingClusterCount is the number of the failing clusters, including the ones not listed.
+	FailingClusterCount int `json:"failingClusterCount,omitempty"`
+}
+
+// FailingCluster is a cluster whose ManifestWork is failing.
+type FailingCluster struct {
+	ClusterName string `json:"clusterName"`
+	Placement   string `json:"placement"`
+	// Message is the type and the message of the failing condition of the ManifestWork, it is truncated if it
+	// is too long.
+	Message string `json:"message"`
+}
+
+// getFeedbackAggregationRules returns the aggregation rules of the ManifestWorkReplicaSet, nil is returned if the
+// aggregation is not enabled.
+func getFeedbackAggregationRules(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) ([]FeedbackAggregationRule, error) {
+	value, ok := mwrSet.Annotations[ManifestWorkReplicaSetFeedbackAggregationAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	rules := []FeedbackAggregationRule{}
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s: %w", ManifestWorkReplicaSetFeedbackAggregationAnnotationKey, err)
+	}
+	names := map[string]bool{}
+	for _, rule := range rules {
+		switch {
+		case len(rule.Name) == 0 || len(rule.FeedbackName) == 0:
+			return nil, fmt.Errorf("invalid annotation %s: the name and feedbackName of the aggregation rule are required",
+				ManifestWorkReplicaSetFeedbackAggregationAnnotationKey)
+		case names[rule.Name]:
+			return nil, fmt.Errorf("invalid annotation %s: duplicated aggregation rule %s",
+				ManifestWorkReplicaSetFeedbackAggregationAnnotationKey, rule.Name)
+		}
+		switch rule.Type {
+		case AggregationSum, AggregationMin, AggregationMax, AggregationCountEqual:
+		default:
+			return nil, fmt.Errorf("invalid annotation %s: unsupported aggregation type %q of rule %s",
+				ManifestWorkReplicaSetFeedbackAggregationAnnotationKey, rule.Type, rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return rules, nil
+}
+
+// ValidateFeedbackAggregationAnnotation validates the feedback aggregation annotation of the
+// ManifestWorkReplicaSet.
+func ValidateFeedbackAggregationAnnotation(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	_, err := getFeedbackAggregationRules(mwrSet)
+	return err
+}
+
+// feedbackAggregator aggregates the status feedback values and collects the failing clusters.
+type feedbackAggregator struct {
+	rules           []FeedbackAggregationRule
+	total           map[string]int64
+	placements      map[string]map[string]int64
+	failingClusters []FailingCluster
+}
+
+func newFeedbackAggregator(rules []FeedbackAggregationRule) *feedbackAggregator {
+	a := &feedbackAggregator{
+		rules:      rules,
+		placements: map[string]map[string]int64{},
+	}
+	a.total = a.initialValues()
+	return a
+}
+
+// initialValues returns the values before aggregating, the count is 0 while the others are absent.
+func (a *feedbackAggregator) initialValues() map[string]int64 {
+	values := map[string]int64{}
+	for _, rule := range a.rules {
+		if rule.Type == AggregationCountEqual {
+			values[rule.Name] = 0
+		}
+	}
+	return values
+}
+
+// add aggregates the status feedback values of the ManifestWork of the placement.
+func (a *feedbackAggregator) add(placement string, mw *workapiv1.ManifestWork) {
+	if _, ok := a.placements[placement]; !ok {
+		a.placements[placement] = a.initialValues()
+	}
+	for _, manifest := range mw.Status.ResourceStatus.Manifests {
+		for _, rule := range a.rules {
+			if rule.ResourceIdentifier != nil && !helper.ResourceMatch(manifest.ResourceMeta, *rule.ResourceIdentifier) {
+				continue
+			}
+			for _, value := range manifest.StatusFeedbacks.Values {
+				if value.Name != rule.FeedbackName {
+					continue
+				}
+				aggregate(a.placements[placement], rule, value.Value)
+				aggregate(a.total, rule, value.Value)
+			}
+		}
+	}
+
+	if message, failing := failingMessage(mw); failing {
+		a.failingClusters = append(a.failingClusters, FailingCluster{
+			ClusterName: mw.Namespace,
+			Placement:   placement,
+			Message:     truncateMessage(message, maxFailingClusterMessageLength),
+		})
+	}
+}
+
+// truncateMessage truncates the message to the max length with an ellipsis appended.
+func truncateMessage(message string, maxLength int) string {
+	if len(message) <= maxLength {
+		return message
+	}
+	return strings.ToValidUTF8(message[:maxLength-3], "") + "..."
+}
+
+func aggregate(values map[string]int64, rule FeedbackAggregationRule, value workapiv1.FieldValue) {
+	current, exists := values[rule.Name]
+	if rule.Type == AggregationCountEqual {
+		if fieldValueString(value) == rule.Value {
+			values[rule.Name] = current + 1
+		}
+		return
+	}
+
+	if value.Integer == nil {
+		return
+	}
+	switch {
+	case !exists:
+		values[rule.Name] = *value.Integer
+	case rule.Type == AggregationSum:
+		values[rule.Name] = current + *value.Integer
+	case rule.Type == AggregationMin && *value.Integer < current, rule.Type == AggregationMax && *value.Integer > current:
+		values[rule.Name] = *value.Integer
+	}
+}
+
+func fieldValueString(value workapiv1.FieldValue) string {
+	switch {
+	case value.Integer != nil:
+		return strconv.FormatInt(*value.Integer, 10)
+	case value.Boolean != nil:
+		return strconv.FormatBool(*value.Boolean)
+	case value.String != nil:
+		return *value.String
+	case value.JsonRaw != nil:
+		return *value.JsonRaw
+	}
+	return ""
+}
+
+// failingMessage returns the message of the failing condition if the ManifestWork is not applied, not available
+// or degraded.
+func failingMessage(mw *workapiv1.ManifestWork) (string, bool) {
+	for _, condition := range []struct {
+		conditionType string
+		status        metav1.ConditionStatus
+	}{
+		{conditionType: workapiv1.WorkApplied, status: metav1.ConditionFalse},
+		{conditionType: workapiv1.WorkDegraded, status: metav1.ConditionTrue},
+		{conditionType: workapiv1.WorkAvailable, status: metav1.ConditionFalse},
+	} {
+		c := apimeta.FindStatusCondition(mw.Status.Conditions, condition.conditionType)
+		if c != nil && c.Status == condition.status {
+			return fmt.Sprintf("%s: %s", c.Type, c.Message), true
+		}
+	}
+	return "", false
+}
+
+// result returns the AggregatedFeedback, the failing clusters are sorted by the cluster name.
+func (a *feedbackAggregator) result() AggregatedFeedback {
+	sort.Slice(a.failingClusters, func(i, j int) bool {
+		if a.failingClusters[i].ClusterName != a.failingClusters[j].ClusterName {
+			return a.failingClusters[i].ClusterName < a.failingClusters[j].ClusterName
+		}
+		return a.failingClusters[i].Placement < a.failingClusters[j].Placement
+	})
+
+	result := AggregatedFeedback{
+		Total:               a.total,
+		Placements:          a.placements,
+		FailingClusters:     a.failingClusters,
+		FailingClusterCount: len(a.failingClusters),
+	}
+	if len(result.FailingClusters) > maxFailingClusters {
+		result.FailingClusters = result.FailingClusters[:maxFailingClusters]
+	}
+	return result
+}
+
+// setAggregatedFeedback sets the FeedbackAggregated condition of the ManifestWorkReplicaSet with the aggregated
+// feedback as its message, the condition is removed if the aggregation is not enabled.
+func setAggregatedFeedback(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, aggregator *feedbackAggregator, rulesErr error) error {
+	switch {
+	case rulesErr != nil:
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionFeedbackAggregated,
+			ReasonInvalidAggregationRules, rulesErr.Error(), metav1.ConditionFalse))
+		return nil
+	case aggregator == nil:
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionFeedbackAggregated)
+		return nil
+	}
+
+	// the failing clusters are dropped from the end of the list until the aggregated feedback fits in the
+	// condition message.
+	result := aggregator.result()
+	data, err := json.Marshal(result)
+	for err == nil && len(data) > maxAggregatedFeedbackLength && len(result.FailingClusters) > 0 {
+		result.FailingClusters = result.FailingClusters[:len(result.FailingClusters)-1]
+		data, err = json.Marshal(result)
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) > maxAggregatedFeedbackLength {
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionFeedbackAggregated,
+			ReasonAggregatedFeedbackTooLarge, fmt.Sprintf("The aggregated feedback of %d placements exceeds %d bytes",
+				len(result.Placements), maxAggregatedFeedbackLength), metav1.ConditionFalse))
+		return nil
+	}
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionFeedbackAggregated,
+		workapiv1alpha1.ReasonAsExpected, string(data), metav1.ConditionTrue))
+	return nil
+}
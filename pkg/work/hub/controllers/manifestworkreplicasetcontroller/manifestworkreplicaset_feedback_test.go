@@ -0,0 +1,285 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func TestGetFeedbackAggregationRules(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedRules []FeedbackAggregationRule
+		expectedErr   bool
+	}{
+		{
+			name: "no annotation",
+		},
+		{
+			name: "valid rules",
+			annotations: map[string]string{ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[
+				{"name":"replicas","feedbackName":"ReadyReplicas","type":"Sum"},
+				{"name":"ready","feedbackName":"Ready","type":"CountEqual","value":"true"}]`},
+			expectedRules: []FeedbackAggregationRule{
+				{Name: "replicas", FeedbackName: "ReadyReplicas", Type: AggregationSum},
+				{Name: "ready", FeedbackName: "Ready", Type: AggregationCountEqual, Value: "true"},
+			},
+		},
+		{
+			name:        "invalid json",
+			annotations: map[string]string{ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `{`},
+			expectedErr: true,
+		},
+		{
+			name: "missing feedback name",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[{"name":"replicas","type":"Sum"}]`},
+			expectedErr: true,
+		},
+		{
+			name: "duplicated rules",
+			annotations: map[string]string{ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[
+				{"name":"replicas","feedbackName":"ReadyReplicas","type":"Sum"},
+				{"name":"replicas","feedbackName":"Replicas","type":"Max"}]`},
+			expectedErr: true,
+		},
+		{
+			name: "unsupported type",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[{"name":"replicas","feedbackName":"ReadyReplicas","type":"Avg"}]`},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := getFeedbackAggregationRules(newTestMWRSet("test", c.annotations))
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedRules, rules)
+		})
+	}
+}
+
+func newFeedbackTestWork(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, cluster, placement string,
+	replicas int64, ready bool, conditions ...metav1.Condition) *workapiv1.ManifestWork {
+	mw, _ := CreateManifestWork(mwrSet, cluster, placement)
+	mw.Status.Conditions = conditions
+	mw.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{
+		{
+			ResourceMeta: workapiv1.ManifestResourceMeta{
+				Group: "apps", Resource: "deployments", Namespace: "default", Name: "app"},
+			StatusFeedbacks: workapiv1.StatusFeedbackResult{Values: []workapiv1.FeedbackValue{
+				{Name: "ReadyReplicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: ptr.To(replicas)}},
+				{Name: "Ready", Value: workapiv1.FieldValue{Type: workapiv1.Boolean, Boolean: ptr.To(ready)}},
+			}},
+		},
+		{
+			ResourceMeta: workapiv1.ManifestResourceMeta{
+				Group: "apps", Resource: "deployments", Namespace: "default", Name: "other"},
+			StatusFeedbacks: workapiv1.StatusFeedbackResult{Values: []workapiv1.FeedbackValue{
+				{Name: "ReadyReplicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: ptr.To(int64(100))}},
+			}},
+		},
+	}
+	return mw
+}
+
+func TestStatusReconcileFeedbackAggregation(t *testing.T) {
+	rules := `[
+		{"name":"sum","feedbackName":"ReadyReplicas","type":"Sum",
+		 "resourceIdentifier":{"group":"apps","resource":"deployments","namespace":"default","name":"app"}},
+		{"name":"min","feedbackName":"ReadyReplicas","type":"Min",
+		 "resourceIdentifier":{"group":"apps","resource":"deployments","namespace":"*","name":"app"}},
+		{"name":"max","feedbackName":"ReadyReplicas","type":"Max"},
+		{"name":"ready","feedbackName":"Ready","type":"CountEqual","value":"true"}]`
+	applied := metav1.Condition{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue}
+	notAvailable := metav1.Condition{Type: workapiv1.WorkAvailable, Status: metav1.ConditionFalse, Message: "not ready"}
+	degraded := metav1.Condition{Type: workapiv1.WorkDegraded, Status: metav1.ConditionTrue, Message: "crashing"}
+
+	mwrSet := newTestMWRSet("test", map[string]string{ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: rules})
+	mwrSet.Status.Summary.Total = 4
+	mwrSet.Status.PlacementsSummary = []workapiv1alpha1.PlacementSummary{
+		{Name: "place1", Summary: workapiv1alpha1.ManifestWorkReplicaSetSummary{Total: 2}},
+		{Name: "place2", Summary: workapiv1alpha1.ManifestWorkReplicaSetSummary{Total: 2}},
+	}
+	works := []*workapiv1.ManifestWork{
+		newFeedbackTestWork(mwrSet, "cls1", "place1", 3, true, applied),
+		newFeedbackTestWork(mwrSet, "cls2", "place1", 1, false, applied, notAvailable),
+		newFeedbackTestWork(mwrSet, "cls3", "place2", 2, true, applied, degraded),
+	}
+	// the work with an outdated template is not aggregated
+	outdated := newFeedbackTestWork(newTestMWRSet("outdated", nil), "cls4", "place2", 10, true, applied, notAvailable)
+	outdated.Name = mwrSet.Name
+	works = append(works, outdated)
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Second)
+	for _, mw := range works {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reconciler := statusReconciler{manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister()}
+
+	mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionFeedbackAggregated)
+	if condition == nil {
+		t.Fatal("expected the FeedbackAggregated condition")
+	}
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	feedback := AggregatedFeedback{}
+	if err := json.Unmarshal([]byte(condition.Message), &feedback); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, AggregatedFeedback{
+		Total: map[string]int64{"sum": 6, "min": 1, "max": 100, "ready": 2},
+		Placements: map[string]map[string]int64{
+			"place1": {"sum": 4, "min": 1, "max": 100, "ready": 1},
+			"place2": {"sum": 2, "min": 2, "max": 100, "ready": 1},
+		},
+		FailingClusters: []FailingCluster{
+			{ClusterName: "cls2", Placement: "place1", Message: "Available: not ready"},
+			{ClusterName: "cls3", Placement: "place2", Message: "Degraded: crashing"},
+		},
+		FailingClusterCount: 2,
+	}, feedback)
+
+	// the aggregation is disabled once the rules are removed
+	delete(mwrSet.Annotations, ManifestWorkReplicaSetFeedbackAggregationAnnotationKey)
+	mwrSet, _, err = reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionFeedbackAggregated))
+}
+
+func TestStatusReconcileInvalidAggregationRules(t *testing.T) {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrset-test", "default", "place-test")
+	mwrSet.Annotations = map[string]string{
+		ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[{"name":"sum"}]`,
+	}
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Second)
+	reconciler := statusReconciler{manifestWorkLister: workInformerFactory.Work().V1().ManifestWorks().Lister()}
+	mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionFeedbackAggregated)
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, ReasonInvalidAggregationRules, condition.Reason)
+}
+
+func TestFailingClustersTruncated(t *testing.T) {
+	mwrSet := newTestMWRSet("test", nil)
+	aggregator := newFeedbackAggregator(nil)
+	for i := 0; i < maxFailingClusters+5; i++ {
+		aggregator.add("place", newFeedbackTestWork(mwrSet, fmt.Sprintf("cls%02d", i), "place", 1, false,
+			metav1.Condition{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Message: "failed"}))
+	}
+
+	result := aggregator.result()
+	assert.Equal(t, maxFailingClusters+5, result.FailingClusterCount)
+	assert.Len(t, result.FailingClusters, maxFailingClusters)
+	assert.Equal(t, "cls00", result.FailingClusters[0].ClusterName)
+	assert.Equal(t, "Applied: failed", result.FailingClusters[0].Message)
+}
+
+func TestAggregatedFeedbackTruncated(t *testing.T) {
+	longMessage := strings.Repeat("failed ", 10000)
+	newAggregator := func(placements, failingClusters int) *feedbackAggregator {
+		mwrSet := newTestMWRSet("test", nil)
+		aggregator := newFeedbackAggregator(nil)
+		for i := 0; i < placements; i++ {
+			aggregator.placements[fmt.Sprintf("placement-%040d", i)] = map[string]int64{"sum": 1}
+		}
+		for i := 0; i < failingClusters; i++ {
+			aggregator.add("place", newFeedbackTestWork(mwrSet, fmt.Sprintf("cls%02d", i), "place", 1, false,
+				metav1.Condition{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Message: longMessage}))
+		}
+		return aggregator
+	}
+
+	cases := []struct {
+		name                    string
+		aggregator              *feedbackAggregator
+		expectedReason          string
+		expectedFailingClusters func(t *testing.T, clusters []FailingCluster)
+	}{
+		{
+			name:           "long messages are truncated",
+			aggregator:     newAggregator(1, maxFailingClusters),
+			expectedReason: workapiv1alpha1.ReasonAsExpected,
+			expectedFailingClusters: func(t *testing.T, clusters []FailingCluster) {
+				assert.Len(t, clusters, maxFailingClusters)
+				for _, cluster := range clusters {
+					assert.Len(t, cluster.Message, maxFailingClusterMessageLength)
+					assert.True(t, strings.HasSuffix(cluster.Message, "..."))
+				}
+			},
+		},
+		{
+			name:           "failing clusters are dropped",
+			aggregator:     newAggregator(400, maxFailingClusters),
+			expectedReason: workapiv1alpha1.ReasonAsExpected,
+			expectedFailingClusters: func(t *testing.T, clusters []FailingCluster) {
+				assert.NotEmpty(t, clusters)
+				assert.Less(t, len(clusters), maxFailingClusters)
+				assert.Equal(t, "cls00", clusters[0].ClusterName)
+			},
+		},
+		{
+			name:           "too many placements",
+			aggregator:     newAggregator(1000, maxFailingClusters),
+			expectedReason: ReasonAggregatedFeedbackTooLarge,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := newTestMWRSet("test", nil)
+			if err := setAggregatedFeedback(mwrSet, c.aggregator, nil); err != nil {
+				t.Fatal(err)
+			}
+			condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionFeedbackAggregated)
+			assert.NotNil(t, condition)
+			assert.Equal(t, c.expectedReason, condition.Reason)
+			assert.LessOrEqual(t, len(condition.Message), maxAggregatedFeedbackLength)
+			if c.expectedFailingClusters == nil {
+				return
+			}
+
+			result := AggregatedFeedback{}
+			if err := json.Unmarshal([]byte(condition.Message), &result); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, maxFailingClusters, result.FailingClusterCount)
+			c.expectedFailingClusters(t, result.FailingClusters)
+		})
+	}
+}
@@ -2,20 +2,26 @@ package manifestworkreplicasetcontroller
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	clusterv1beta1 "open-cluster-management.io/api/cluster/v1beta1"
 	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
 )
 
 const (
-	manifestWorkReplicaSetByPlacement = "manifestWorkReplicaSetByPlacement"
+	manifestWorkReplicaSetByPlacement         = "manifestWorkReplicaSetByPlacement"
+	manifestWorkReplicaSetByTemplateOverrides = "manifestWorkReplicaSetByTemplateOverrides"
 )
 
 func (m *ManifestWorkReplicaSetController) placementQueueKeysFunc(obj runtime.Object) []string {
@@ -65,6 +71,80 @@ func (m *ManifestWorkReplicaSetController) placementDecisionQueueKeysFunc(obj ru
 	return keys
 }
 
+// templateOverridesQueueKeysFunc returns the keys of the ManifestWorkReplicaSets referencing the ConfigMap as
+// the template overrides.
+func (m *ManifestWorkReplicaSetController) templateOverridesQueueKeysFunc(obj runtime.Object) []string {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	objs, err := m.manifestWorkReplicaSetIndexer.ByIndex(manifestWorkReplicaSetByTemplateOverrides, key)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	var keys []string
+	for _, o := range objs {
+		manifestWorkReplicaSet := o.(*workapiv1alpha1.ManifestWorkReplicaSet)
+		klog.V(4).Infof("enqueue manifestWorkReplicaSet %s/%s, because of template overrides %s",
+			manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name, key)
+		keys = append(keys, fmt.Sprintf("%s/%s", manifestWorkReplicaSet.Namespace, manifestWorkReplicaSet.Name))
+	}
+
+	return keys
+}
+
+// clusterQueueKeysFunc returns the keys of the ManifestWorkReplicaSets with the templating enabled which have
+// ManifestWorks on the cluster, if the labels or the claims of the cluster change.
+func (m *ManifestWorkReplicaSetController) clusterQueueKeysFunc(oldObj, newObj interface{}) []string {
+	oldCluster, ok := oldObj.(*clusterv1.ManagedCluster)
+	if !ok {
+		return []string{}
+	}
+	newCluster, ok := newObj.(*clusterv1.ManagedCluster)
+	if !ok {
+		return []string{}
+	}
+	if reflect.DeepEqual(oldCluster.Labels, newCluster.Labels) &&
+		reflect.DeepEqual(oldCluster.Status.ClusterClaims, newCluster.Status.ClusterClaims) {
+		return []string{}
+	}
+
+	req, err := labels.NewRequirement(ManifestWorkReplicaSetControllerNameLabelKey, selection.Exists, []string{})
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+	manifestWorks, err := m.manifestWorkLister.ManifestWorks(newCluster.Name).List(labels.NewSelector().Add(*req))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return []string{}
+	}
+
+	keys := sets.New[string]()
+	for _, mw := range manifestWorks {
+		key := m.manifestWorkQueueKeyFunc(mw)
+		if len(key) == 0 || keys.Has(key) {
+			continue
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		manifestWorkReplicaSet, err := m.manifestWorkReplicaSetLister.ManifestWorkReplicaSets(namespace).Get(name)
+		if err != nil || !templateEnabled(manifestWorkReplicaSet) {
+			continue
+		}
+		klog.V(4).Infof("enqueue manifestWorkReplicaSet %s, because of cluster %s", key, newCluster.Name)
+		keys.Insert(key)
+	}
+
+	return sets.List(keys)
+}
+
 // we will generate manifestwork with a label
 func (m *ManifestWorkReplicaSetController) manifestWorkQueueKeyFunc(obj runtime.Object) string {
 	accessor, _ := meta.Accessor(obj)
@@ -95,6 +175,19 @@ func indexManifestWorkReplicaSetByPlacement(obj interface{}) ([]string, error) {
 	return keys, nil
 }
 
+func indexManifestWorkReplicaSetByTemplateOverrides(obj interface{}) ([]string, error) {
+	manifestWorkReplicaSet, ok := obj.(*workapiv1alpha1.ManifestWorkReplicaSet)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not a ManifestWorkReplicaSet", obj)
+	}
+
+	name := manifestWorkReplicaSet.Annotations[ManifestWorkTemplateOverridesAnnotationKey]
+	if len(name) == 0 {
+		return []string{}, nil
+	}
+	return []string{fmt.Sprintf("%s/%s", manifestWorkReplicaSet.Namespace, name)}, nil
+}
+
 // manifestWorkReplicaSetKey return the value of the key of manifestworkreplicaset, and comply with
 // label value format.
 func manifestWorkReplicaSetKey(mwrs *workapiv1alpha1.ManifestWorkReplicaSet) string {
@@ -1,6 +1,7 @@
 package manifestworkreplicasetcontroller
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -121,3 +122,53 @@ func TestPlaceMWControllerIndex(t *testing.T) {
 		t.Fatal("Expected manifestwork key should not exist ", key)
 	}
 }
+
+func TestTemplateQueueKeysFunc(t *testing.T) {
+	templated := newTemplateManifestWorkReplicaSet(map[string]string{ManifestWorkTemplateOverridesAnnotationKey: "overrides"})
+	plain := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-plain", "default", "place-test")
+	templatedWork, _ := CreateManifestWork(templated, "cls1", "place-test")
+	plainWork, _ := CreateManifestWork(plain, "cls1", "place-test")
+
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fakeworkclient.NewSimpleClientset(), 1*time.Second)
+	mwrSetInformer := workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Informer()
+	if err := mwrSetInformer.AddIndexers(cache.Indexers{
+		manifestWorkReplicaSetByTemplateOverrides: indexManifestWorkReplicaSetByTemplateOverrides}); err != nil {
+		t.Fatal(err)
+	}
+	for _, mwrSet := range []interface{}{templated, plain} {
+		if err := mwrSetInformer.GetStore().Add(mwrSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, mw := range []interface{}{templatedWork, plainWork} {
+		if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	controller := &ManifestWorkReplicaSetController{
+		manifestWorkReplicaSetLister:  workInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets().Lister(),
+		manifestWorkReplicaSetIndexer: mwrSetInformer.GetIndexer(),
+		manifestWorkLister:            workInformerFactory.Work().V1().ManifestWorks().Lister(),
+	}
+	expectedKeys := []string{templated.Namespace + "/" + templated.Name}
+
+	// only the ManifestWorkReplicaSet referencing the configmap is enqueued
+	if keys := controller.templateOverridesQueueKeysFunc(newTemplateOverrides(nil)); !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("expected keys %v of the configmap, but got %v", expectedKeys, keys)
+	}
+
+	cluster := newTemplateCluster("cls1", "us-east")
+	// the ManifestWorkReplicaSets are not enqueued if neither the labels nor the claims of the cluster change
+	if keys := controller.clusterQueueKeysFunc(cluster, cluster.DeepCopy()); len(keys) != 0 {
+		t.Errorf("expected no keys of the unchanged cluster, but got %v", keys)
+	}
+	// only the ManifestWorkReplicaSet with the templating enabled is enqueued
+	if keys := controller.clusterQueueKeysFunc(cluster, newTemplateCluster("cls1", "us-west")); !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("expected keys %v of the cluster, but got %v", expectedKeys, keys)
+	}
+	// the ManifestWorkReplicaSets without the ManifestWorks on the cluster are not enqueued
+	if keys := controller.clusterQueueKeysFunc(newTemplateCluster("cls2", "us-east"), newTemplateCluster("cls2", "us-west")); len(keys) != 0 {
+		t.Errorf("expected no keys of the cluster without ManifestWorks, but got %v", keys)
+	}
+}
@@ -0,0 +1,439 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+
+	workclientset "open-cluster-management.io/api/client/work/clientset/versioned"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey is the annotation key on the ManifestWorkReplicaSet
+	// to set the number of the revisions of the ManifestWorkTemplate kept in the history, it is 10 by default.
+	// Each revision is a ControllerRevision in the namespace of the ManifestWorkReplicaSet.
+	ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey = "work.open-cluster-management.io/revision-history-limit"
+
+	// ManifestWorkReplicaSetRollbackToRevisionAnnotationKey is the annotation key on the ManifestWorkReplicaSet
+	// to roll back the ManifestWorkTemplate to a revision in the history. The ManifestWorkTemplate is replaced
+	// by the template of the revision and the annotation is removed, then the template is rolled out to the
+	// clusters by the rollout strategy.
+	ManifestWorkReplicaSetRollbackToRevisionAnnotationKey = "work.open-cluster-management.io/rollback-to-revision"
+
+	// ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey is the annotation key on the ManifestWorkReplicaSet
+	// to enable the automatic rollback. The value is the number or the percentage of the selected clusters, when
+	// more clusters than that fail or time out in the rollout of a ManifestWorkTemplate, the clusters are rolled
+	// back to the last revision succeeded on all the clusters. The ManifestWorkTemplate itself is not changed,
+	// and it is rolled out again once it is updated.
+	ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey = "work.open-cluster-management.io/auto-rollback-failure-threshold"
+
+	// ManifestWorkReplicaSetConditionRolledBack is the condition type of the ManifestWorkReplicaSet which reports
+	// the rollback of the ManifestWorkTemplate. It is removed once the ManifestWorkTemplate is updated.
+	ManifestWorkReplicaSetConditionRolledBack = "RolledBack"
+
+	ReasonAutoRollback        = "AutoRollback"
+	ReasonManualRollback      = "ManualRollback"
+	ReasonNoSucceededRevision = "NoSucceededRevision"
+	ReasonRevisionNotFound    = "RevisionNotFound"
+
+	defaultRevisionHistoryLimit = 10
+
+	// revisionStateAnnotationKey is the annotation key on the ControllerRevision to record the rollout result of
+	// the revision, the value is Succeeded or Failed.
+	revisionStateAnnotationKey = "work.open-cluster-management.io/revision-state"
+	revisionStateSucceeded     = "Succeeded"
+	revisionStateFailed        = "Failed"
+)
+
+// revisionHistory keeps the revisions of the ManifestWorkTemplate of the ManifestWorkReplicaSets as
+// ControllerRevisions, a revision is named by the hash of the template. The revisions are read from the
+// informer cache, a write based on a stale cache fails and is retried in the next reconcile.
+type revisionHistory struct {
+	kubeClient     kubernetes.Interface
+	revisionLister appslisters.ControllerRevisionLister
+	recorder       events.Recorder
+}
+
+// templateHash returns the hash of the ManifestWorkTemplate.
+func templateHash(template workapiv1.ManifestWorkSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(data)
+	return rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())), nil
+}
+
+func revisionName(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, hash string) string {
+	return fmt.Sprintf("%s-%s", mwrSet.Name, hash)
+}
+
+// list returns the revisions of the ManifestWorkReplicaSet sorted by the revision number.
+func (h *revisionHistory) list(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) ([]appsv1.ControllerRevision, error) {
+	selector := labels.SelectorFromSet(labels.Set{ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet)})
+	revisions, err := h.revisionLister.ControllerRevisions(mwrSet.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]appsv1.ControllerRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		items = append(items, *revision.DeepCopy())
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Revision < items[j].Revision
+	})
+	return items, nil
+}
+
+// record makes sure the current ManifestWorkTemplate is the latest revision in the history, and removes the
+// oldest revisions beyond the history limit. The current revision is returned.
+func (h *revisionHistory) record(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (*appsv1.ControllerRevision, error) {
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := h.list(mwrSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var current *appsv1.ControllerRevision
+	var latest int64
+	for i := range revisions {
+		if revisions[i].Name == revisionName(mwrSet, hash) {
+			current = revisions[i].DeepCopy()
+		}
+		latest = revisions[i].Revision
+	}
+
+	switch {
+	case current == nil:
+		data, err := json.Marshal(mwrSet.Spec.ManifestWorkTemplate)
+		if err != nil {
+			return nil, err
+		}
+		current, err = h.kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Create(ctx, &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      revisionName(mwrSet, hash),
+				Namespace: mwrSet.Namespace,
+				Labels:    map[string]string{ManifestWorkReplicaSetControllerNameLabelKey: manifestWorkReplicaSetKey(mwrSet)},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(mwrSet, workapiv1alpha1.GroupVersion.WithKind("ManifestWorkReplicaSet")),
+				},
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: latest + 1,
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *current)
+	case current.Revision != latest || current.Annotations[revisionStateAnnotationKey] == revisionStateFailed &&
+		!autoRolledBack(mwrSet):
+		// the template is changed back to an earlier revision, or a failed revision is rolled out again.
+		current.Revision = latest + 1
+		delete(current.Annotations, revisionStateAnnotationKey)
+		current, err = h.kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Update(ctx, current, metav1.UpdateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for i := range revisions {
+			if revisions[i].Name == current.Name {
+				revisions = append(revisions[:i], revisions[i+1:]...)
+				break
+			}
+		}
+		revisions = append(revisions, *current)
+	}
+
+	return current, h.truncate(ctx, mwrSet, revisions)
+}
+
+// truncate deletes the oldest revisions beyond the history limit, the current and the last succeeded revisions
+// are always kept.
+func (h *revisionHistory) truncate(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+	revisions []appsv1.ControllerRevision) error {
+	limit, err := revisionHistoryLimit(mwrSet)
+	if err != nil {
+		return err
+	}
+
+	lastSucceeded := lastSucceededRevision(revisions, revisions[len(revisions)-1].Name)
+	excess := len(revisions) - limit
+	for i := 0; i < len(revisions)-1 && excess > 0; i++ {
+		if lastSucceeded != nil && revisions[i].Name == lastSucceeded.Name {
+			continue
+		}
+		excess--
+		err := h.kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Delete(ctx, revisions[i].Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// setState records the rollout result of the revision of the ManifestWorkTemplate.
+func (h *revisionHistory) setState(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, state string) error {
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return err
+	}
+	revision, err := h.revisionLister.ControllerRevisions(mwrSet.Namespace).Get(revisionName(mwrSet, hash))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if revision.Annotations[revisionStateAnnotationKey] == state {
+		return nil
+	}
+	revision = revision.DeepCopy()
+	if revision.Annotations == nil {
+		revision.Annotations = map[string]string{}
+	}
+	revision.Annotations[revisionStateAnnotationKey] = state
+	_, err = h.kubeClient.AppsV1().ControllerRevisions(mwrSet.Namespace).Update(ctx, revision, metav1.UpdateOptions{})
+	return err
+}
+
+// lastSucceededRevision returns the latest revision succeeded on all the clusters except the excluded one.
+func lastSucceededRevision(revisions []appsv1.ControllerRevision, excluded string) *appsv1.ControllerRevision {
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if revisions[i].Name != excluded && revisions[i].Annotations[revisionStateAnnotationKey] == revisionStateSucceeded {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
+
+func revisionTemplate(revision *appsv1.ControllerRevision) (workapiv1.ManifestWorkSpec, error) {
+	template := workapiv1.ManifestWorkSpec{}
+	err := json.Unmarshal(revision.Data.Raw, &template)
+	return template, err
+}
+
+// autoRolledBack returns true if the current ManifestWorkTemplate is rolled back automatically.
+func autoRolledBack(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.Reason == ReasonAutoRollback &&
+		condition.ObservedGeneration == mwrSet.Generation
+}
+
+func rollbackCondition(generation int64, reason, message string, status metav1.ConditionStatus) metav1.Condition {
+	condition := getCondition(ManifestWorkReplicaSetConditionRolledBack, reason, message, status)
+	condition.ObservedGeneration = generation
+	return condition
+}
+
+// revisionHistoryLimit returns the number of the revisions kept in the history.
+func revisionHistoryLimit(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (int, error) {
+	value, ok := mwrSet.Annotations[ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey]
+	if !ok {
+		return defaultRevisionHistoryLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid annotation %s %q, the value should be a positive integer",
+			ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey, value)
+	}
+	return limit, nil
+}
+
+// ValidateRevisionAnnotations validates the revision history, rollback and automatic rollback annotations of the
+// ManifestWorkReplicaSet.
+func ValidateRevisionAnnotations(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	var errs []error
+	if _, err := revisionHistoryLimit(mwrSet); err != nil {
+		errs = append(errs, err)
+	}
+	if value, ok := mwrSet.Annotations[ManifestWorkReplicaSetRollbackToRevisionAnnotationKey]; ok {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			errs = append(errs, fmt.Errorf("invalid annotation %s %q, the value should be a revision number",
+				ManifestWorkReplicaSetRollbackToRevisionAnnotationKey, value))
+		}
+	}
+	if _, _, err := autoRollbackThreshold(mwrSet, 100); err != nil {
+		errs = append(errs, err)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// autoRollbackThreshold returns the max number of the failed clusters before the automatic rollback, false is
+// returned if the automatic rollback is not enabled.
+func autoRollbackThreshold(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, total int) (int, bool, error) {
+	value, ok := mwrSet.Annotations[ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey]
+	if !ok {
+		return 0, false, nil
+	}
+	threshold := intstr.Parse(value)
+	failures, err := intstr.GetScaledValueFromIntOrPercent(&threshold, total, true)
+	if err != nil || failures < 0 {
+		return 0, false, fmt.Errorf("invalid annotation %s %q, the value should be a number or a percentage",
+			ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey, value)
+	}
+	return failures, true, nil
+}
+
+// rollback rolls back the clusters to the last succeeded revision when the current ManifestWorkTemplate failed
+// on more clusters than the threshold of the automatic rollback.
+func (h *revisionHistory) rollback(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, failed, total int) error {
+	threshold, enabled, err := autoRollbackThreshold(mwrSet, total)
+	if err != nil || !enabled || failed <= threshold || autoRolledBack(mwrSet) {
+		return err
+	}
+
+	hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+	if err != nil {
+		return err
+	}
+	revisions, err := h.list(mwrSet)
+	if err != nil {
+		return err
+	}
+	target := lastSucceededRevision(revisions, revisionName(mwrSet, hash))
+	if target == nil {
+		condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+		if condition == nil || condition.Reason != ReasonNoSucceededRevision || condition.ObservedGeneration != mwrSet.Generation {
+			h.recorder.Warningf("RollbackFailed", "ManifestWorkReplicaSet %s/%s failed on %d clusters, but no succeeded revision to roll back to",
+				mwrSet.Namespace, mwrSet.Name, failed)
+		}
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, rollbackCondition(mwrSet.Generation, ReasonNoSucceededRevision,
+			fmt.Sprintf("Failed on %d clusters, but no succeeded revision to roll back to", failed), metav1.ConditionFalse))
+		return nil
+	}
+
+	if err := h.setState(ctx, mwrSet, revisionStateFailed); err != nil {
+		return err
+	}
+	h.recorder.Warningf("RolledBack", "ManifestWorkReplicaSet %s/%s is rolled back to revision %d since it failed on %d clusters",
+		mwrSet.Namespace, mwrSet.Name, target.Revision, failed)
+	apimeta.SetStatusCondition(&mwrSet.Status.Conditions, rollbackCondition(mwrSet.Generation, ReasonAutoRollback,
+		fmt.Sprintf("Rolled back to revision %d since the ManifestWorkTemplate failed on %d clusters", target.Revision, failed),
+		metav1.ConditionTrue))
+	return nil
+}
+
+// revisionReconciler records the revision history of the ManifestWorkTemplate, and handles the rollbacks. When
+// the ManifestWorkTemplate is rolled back automatically, the template of the last succeeded revision replaces
+// the ManifestWorkTemplate of the ManifestWorkReplicaSet for the following reconcilers, only the status of the
+// ManifestWorkReplicaSet is updated so the spec is kept as it is.
+type revisionReconciler struct {
+	workClient workclientset.Interface
+	history    *revisionHistory
+}
+
+func (r *revisionReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
+) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	if r.history == nil {
+		return mwrSet, reconcileContinue, nil
+	}
+
+	// the rollback is no longer effective once the ManifestWorkTemplate is updated.
+	if condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack); condition != nil &&
+		condition.ObservedGeneration != mwrSet.Generation {
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+	}
+
+	if value, ok := mwrSet.Annotations[ManifestWorkReplicaSetRollbackToRevisionAnnotationKey]; ok {
+		return r.rollbackToRevision(ctx, mwrSet, value)
+	}
+
+	current, err := r.history.record(ctx, mwrSet)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	if !autoRolledBack(mwrSet) {
+		return mwrSet, reconcileContinue, nil
+	}
+
+	revisions, err := r.history.list(mwrSet)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	target := lastSucceededRevision(revisions, current.Name)
+	if target == nil {
+		// the succeeded revision is removed from the history, roll out the current ManifestWorkTemplate.
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, rollbackCondition(mwrSet.Generation, ReasonNoSucceededRevision,
+			"The succeeded revision to roll back to is not found", metav1.ConditionFalse))
+		return mwrSet, reconcileContinue, nil
+	}
+	template, err := revisionTemplate(target)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
+	}
+	mwrSet.Spec.ManifestWorkTemplate = template
+	return mwrSet, reconcileContinue, nil
+}
+
+// rollbackToRevision replaces the ManifestWorkTemplate with the template of the revision, and removes the
+// rollback annotation.
+func (r *revisionReconciler) rollbackToRevision(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, value string,
+) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	required := mwrSet.DeepCopy()
+	delete(required.Annotations, ManifestWorkReplicaSetRollbackToRevisionAnnotationKey)
+
+	var target *appsv1.ControllerRevision
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err == nil {
+		revisions, err := r.history.list(mwrSet)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+		for i := range revisions {
+			if revisions[i].Revision == number {
+				target = &revisions[i]
+			}
+		}
+	}
+
+	var condition metav1.Condition
+	if target == nil {
+		r.history.recorder.Warningf("RollbackFailed", "ManifestWorkReplicaSet %s/%s failed to roll back: revision %q is not found",
+			mwrSet.Namespace, mwrSet.Name, value)
+		condition = rollbackCondition(mwrSet.Generation, ReasonRevisionNotFound,
+			fmt.Sprintf("Revision %q is not found", value), metav1.ConditionFalse)
+	} else {
+		template, err := revisionTemplate(target)
+		if err != nil {
+			return mwrSet, reconcileContinue, err
+		}
+		required.Spec.ManifestWorkTemplate = template
+	}
+
+	updated, err := r.workClient.WorkV1alpha1().ManifestWorkReplicaSets(mwrSet.Namespace).Update(ctx, required, metav1.UpdateOptions{})
+	if err != nil {
+		return mwrSet, reconcileStop, err
+	}
+	if target != nil {
+		r.history.recorder.Eventf("RolledBack", "ManifestWorkReplicaSet %s/%s is rolled back to revision %d",
+			mwrSet.Namespace, mwrSet.Name, target.Revision)
+		condition = rollbackCondition(updated.Generation, ReasonManualRollback,
+			fmt.Sprintf("Rolled back to revision %d", target.Revision), metav1.ConditionTrue)
+	}
+
+	// update the status on the updated ManifestWorkReplicaSet, the rollout is continued in the next reconcile.
+	updated.Status = mwrSet.Status
+	apimeta.SetStatusCondition(&updated.Status.Conditions, condition)
+	return updated, reconcileStop, nil
+}
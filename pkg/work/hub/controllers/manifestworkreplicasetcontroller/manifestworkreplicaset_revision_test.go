@@ -0,0 +1,266 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+func newTestMWRSet(secretName string, annotations map[string]string) *workapiv1alpha1.ManifestWorkReplicaSet {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrset-test", "default", "place-test")
+	mwrSet.Annotations = annotations
+	mwrSet.Generation = 1
+	mwrSet.Spec.ManifestWorkTemplate = newTestTemplate(secretName)
+	return mwrSet
+}
+
+func newTestTemplate(secretName string) workapiv1.ManifestWorkSpec {
+	return workapiv1.ManifestWorkSpec{
+		Workload: workapiv1.ManifestsTemplate{
+			Manifests: []workapiv1.Manifest{{RawExtension: runtime.RawExtension{
+				Raw: []byte(fmt.Sprintf(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":%q,"namespace":"default"}}`, secretName)),
+			}}},
+		},
+	}
+}
+
+// newTestRevisionHistory returns a revisionHistory whose lister is updated synchronously with the writes.
+func newTestRevisionHistory(t *testing.T, revisions ...runtime.Object) *revisionHistory {
+	kubeClient := kubefake.NewSimpleClientset(revisions...)
+	informer := kubeinformers.NewSharedInformerFactory(kubeClient, 10*time.Minute).Apps().V1().ControllerRevisions()
+	store := informer.Informer().GetStore()
+	for _, revision := range revisions {
+		if err := store.Add(revision); err != nil {
+			t.Fatal(err)
+		}
+	}
+	kubeClient.PrependReactor("*", "controllerrevisions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		var err error
+		switch action := action.(type) {
+		case clienttesting.CreateAction:
+			err = store.Add(action.GetObject())
+		case clienttesting.UpdateAction:
+			err = store.Update(action.GetObject())
+		case clienttesting.DeleteAction:
+			err = store.Delete(&appsv1.ControllerRevision{
+				ObjectMeta: metav1.ObjectMeta{Namespace: action.GetNamespace(), Name: action.GetName()}})
+		}
+		return false, nil, err
+	})
+	return &revisionHistory{
+		kubeClient:     kubeClient,
+		revisionLister: informer.Lister(),
+		recorder:       eventstesting.NewTestingEventRecorder(t),
+	}
+}
+
+func newTestRevision(t *testing.T, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, revision int64, state string) *appsv1.ControllerRevision {
+	history := newTestRevisionHistory(t)
+	current, err := history.record(context.TODO(), mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Revision = revision
+	if len(state) > 0 {
+		current.Annotations = map[string]string{revisionStateAnnotationKey: state}
+	}
+	return current
+}
+
+func listRevisions(t *testing.T, history *revisionHistory, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) map[string]int64 {
+	revisions, err := history.list(mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]int64{}
+	for _, revision := range revisions {
+		names[revision.Name] = revision.Revision
+	}
+	return names
+}
+
+func TestRevisionRecord(t *testing.T) {
+	v1 := newTestMWRSet("v1", nil)
+	v2 := newTestMWRSet("v2", nil)
+	v3 := newTestMWRSet("v3", map[string]string{ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey: "2"})
+	name := func(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) string {
+		hash, err := templateHash(mwrSet.Spec.ManifestWorkTemplate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revisionName(mwrSet, hash)
+	}
+
+	history := newTestRevisionHistory(t)
+	steps := []struct {
+		mwrSet            *workapiv1alpha1.ManifestWorkReplicaSet
+		expectedRevisions map[string]int64
+	}{
+		{mwrSet: v1, expectedRevisions: map[string]int64{name(v1): 1}},
+		{mwrSet: v1, expectedRevisions: map[string]int64{name(v1): 1}},
+		{mwrSet: v2, expectedRevisions: map[string]int64{name(v1): 1, name(v2): 2}},
+		// the template is changed back to an earlier revision
+		{mwrSet: v1, expectedRevisions: map[string]int64{name(v1): 3, name(v2): 2}},
+		// the oldest revision is removed beyond the history limit
+		{mwrSet: v3, expectedRevisions: map[string]int64{name(v1): 3, name(v3): 4}},
+	}
+	for i, step := range steps {
+		if _, err := history.record(context.TODO(), step.mwrSet); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, step.expectedRevisions, listRevisions(t, history, step.mwrSet), "step %d", i)
+	}
+}
+
+func TestAutoRollback(t *testing.T) {
+	threshold := map[string]string{ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey: "25%"}
+	cases := []struct {
+		name              string
+		annotations       map[string]string
+		succeeded         bool
+		failed            int
+		expectedCondition *metav1.Condition
+	}{
+		{
+			name:      "auto rollback is not enabled",
+			succeeded: true,
+			failed:    4,
+		},
+		{
+			name:        "threshold is not exceeded",
+			annotations: threshold,
+			succeeded:   true,
+			failed:      1,
+		},
+		{
+			name:        "rolled back",
+			annotations: threshold,
+			succeeded:   true,
+			failed:      2,
+			expectedCondition: &metav1.Condition{
+				Type: ManifestWorkReplicaSetConditionRolledBack, Status: metav1.ConditionTrue, Reason: ReasonAutoRollback},
+		},
+		{
+			name:        "no succeeded revision",
+			annotations: threshold,
+			failed:      2,
+			expectedCondition: &metav1.Condition{
+				Type: ManifestWorkReplicaSetConditionRolledBack, Status: metav1.ConditionFalse, Reason: ReasonNoSucceededRevision},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			previous := newTestMWRSet("v1", c.annotations)
+			state := ""
+			if c.succeeded {
+				state = revisionStateSucceeded
+			}
+			mwrSet := newTestMWRSet("v2", c.annotations)
+			history := newTestRevisionHistory(t, newTestRevision(t, previous, 1, state))
+			reconciler := &revisionReconciler{history: history}
+			mwrSet, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := history.rollback(context.TODO(), mwrSet, c.failed, 4); err != nil {
+				t.Fatal(err)
+			}
+			condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+			if c.expectedCondition == nil {
+				assert.Nil(t, condition)
+				return
+			}
+			assert.NotNil(t, condition)
+			assert.Equal(t, c.expectedCondition.Status, condition.Status)
+			assert.Equal(t, c.expectedCondition.Reason, condition.Reason)
+
+			// the template of the last succeeded revision is rolled out in the next reconcile.
+			rolledBack, _, err := reconciler.reconcile(context.TODO(), mwrSet.DeepCopy())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.expectedCondition.Status == metav1.ConditionTrue {
+				assert.Equal(t, previous.Spec.ManifestWorkTemplate, rolledBack.Spec.ManifestWorkTemplate)
+			} else {
+				assert.Equal(t, mwrSet.Spec.ManifestWorkTemplate, rolledBack.Spec.ManifestWorkTemplate)
+			}
+
+			// the rollback is not effective once the template is updated.
+			mwrSet.Generation++
+			updated, _, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, apimeta.FindStatusCondition(updated.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack))
+		})
+	}
+}
+
+func TestRollbackToRevision(t *testing.T) {
+	cases := []struct {
+		name              string
+		revision          string
+		expectedTemplate  string
+		expectedCondition metav1.Condition
+	}{
+		{
+			name:             "rollback to revision",
+			revision:         "1",
+			expectedTemplate: "v1",
+			expectedCondition: metav1.Condition{
+				Type: ManifestWorkReplicaSetConditionRolledBack, Status: metav1.ConditionTrue, Reason: ReasonManualRollback},
+		},
+		{
+			name:             "revision not found",
+			revision:         "9",
+			expectedTemplate: "v2",
+			expectedCondition: metav1.Condition{
+				Type: ManifestWorkReplicaSetConditionRolledBack, Status: metav1.ConditionFalse, Reason: ReasonRevisionNotFound},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := newTestMWRSet("v2", map[string]string{ManifestWorkReplicaSetRollbackToRevisionAnnotationKey: c.revision})
+			workClient := fakeworkclient.NewSimpleClientset(mwrSet)
+			reconciler := &revisionReconciler{
+				workClient: workClient,
+				history: newTestRevisionHistory(t,
+					newTestRevision(t, newTestMWRSet("v1", nil), 1, ""),
+					newTestRevision(t, newTestMWRSet("v2", nil), 2, ""),
+				),
+			}
+
+			updated, state, err := reconciler.reconcile(context.TODO(), mwrSet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, reconcileStop, state)
+			assert.Equal(t, newTestTemplate(c.expectedTemplate), updated.Spec.ManifestWorkTemplate)
+			assert.NotContains(t, updated.Annotations, ManifestWorkReplicaSetRollbackToRevisionAnnotationKey)
+			condition := apimeta.FindStatusCondition(updated.Status.Conditions, ManifestWorkReplicaSetConditionRolledBack)
+			assert.NotNil(t, condition)
+			assert.Equal(t, c.expectedCondition.Status, condition.Status)
+			assert.Equal(t, c.expectedCondition.Reason, condition.Reason)
+		})
+	}
+}
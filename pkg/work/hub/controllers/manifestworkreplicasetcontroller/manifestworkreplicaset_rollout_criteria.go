@@ -0,0 +1,430 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/lru"
+
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+	clustersdkv1beta1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+	ocmcellibrary "open-cluster-management.io/sdk-go/pkg/cel/library"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+const (
+	// ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey is the annotation key on the ManifestWorkReplicaSet
+	// to define a CEL expression which must return true before the ManifestWork on a cluster is considered as
+	// succeeded in the rollout, in addition to the ManifestWork being applied and available. The expression is
+	// evaluated with the variables
+	//   - conditions: the status of the ManifestWork conditions keyed by the condition type.
+	//   - manifests: the list of the manifests, each has the group, version, kind, resource, namespace, name,
+	//     the feedback values keyed by the feedback name and the status of the conditions keyed by the type.
+	// For example
+	//   manifests.all(m, m.kind != 'Deployment' || m.feedback.ReadyReplicas == m.feedback.Replicas)
+	ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey = "work.open-cluster-management.io/rollout-success-criteria"
+
+	// ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey is the annotation key on the ManifestWorkReplicaSet to
+	// define how long the success criteria must hold before the next clusters are rolled out, e.g. 10m. It
+	// extends the minSuccessTime of the Progressive and ProgressivePerGroup rollout strategies.
+	ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey = "work.open-cluster-management.io/rollout-soak-time"
+
+	// ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey is the annotation key on the
+	// ManifestWorkReplicaSet to define the number or the percentage of the clusters in a decision group which
+	// must succeed before the rollout advances to the next group. The default is 100%, the clusters still
+	// progressing in the group do not block the rollout once the threshold is reached.
+	ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey = "work.open-cluster-management.io/rollout-success-threshold"
+
+	// ManifestWorkReplicaSetConditionRolloutCriteriaVerified is the condition type of the ManifestWorkReplicaSet
+	// which reports whether the rollout criteria are valid. It only exists when the criteria are defined, and the
+	// rollout does not advance while the criteria are invalid.
+	ManifestWorkReplicaSetConditionRolloutCriteriaVerified = "RolloutCriteriaVerified"
+
+	// ReasonInvalidRolloutCriteria is the reason of the RolloutCriteriaVerified condition when the criteria
+	// are invalid.
+	ReasonInvalidRolloutCriteria = "InvalidRolloutCriteria"
+
+	// rolloutCriteriaHeldAnnotationKey is the annotation key on the ManifestWork set by the controller to record
+	// the generation of the ManifestWork and the time since when the success criteria hold on it, so the soak
+	// time is not restarted when the controller restarts.
+	rolloutCriteriaHeldAnnotationKey = "work.open-cluster-management.io/rollout-criteria-held"
+)
+
+// rolloutCriteriaCacheSize is the max number of the compiled success criteria cached.
+const rolloutCriteriaCacheSize = 1000
+
+var rolloutCriteriaCostBudget = int64(celconfig.RuntimeCELCostBudget)
+
+var rolloutCriteriaEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{
+			cel.Variable("conditions", cel.MapType(cel.StringType, cel.StringType)),
+			cel.Variable("manifests", cel.ListType(cel.DynType)),
+		},
+		ocmcelcommon.BaseEnvOpts,
+	)...)
+})
+
+// rolloutCriteriaPrograms caches the compiled success criteria keyed by the expression, so that the expression
+// is not compiled on every reconcile of the ManifestWorkReplicaSet.
+var rolloutCriteriaPrograms = lru.New(rolloutCriteriaCacheSize)
+
+type compiledRolloutCriteria struct {
+	program cel.Program
+	err     error
+}
+
+// compileRolloutCriteria returns the compiled program of the success criteria, the compilation result,
+// including the error, is cached.
+func compileRolloutCriteria(expression string) (cel.Program, error) {
+	if cached, ok := rolloutCriteriaPrograms.Get(expression); ok {
+		compiled := cached.(compiledRolloutCriteria)
+		return compiled.program, compiled.err
+	}
+
+	env, err := rolloutCriteriaEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	compiled := compiledRolloutCriteria{}
+	ast, iss := env.Compile(expression)
+	switch {
+	case iss.Err() != nil:
+		compiled.err = fmt.Errorf("invalid annotation %s: %w", ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey, iss.Err())
+	case !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType):
+		compiled.err = fmt.Errorf("invalid annotation %s: the expression should return a bool instead of %s",
+			ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey, ast.OutputType())
+	default:
+		compiled.program, err = env.Program(
+			ast,
+			cel.CostLimit(celconfig.PerCallLimit),
+			cel.CostTracking(&ocmcelcommon.BaseEnvCostEstimator{CostEstimator: &ocmcellibrary.CostEstimator{}}),
+			cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+		)
+		if err != nil {
+			compiled.err = fmt.Errorf("invalid annotation %s: %w", ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey, err)
+		}
+	}
+	rolloutCriteriaPrograms.Add(expression, compiled)
+	return compiled.program, compiled.err
+}
+
+// criteriaHeld is the time since when the success criteria hold on the generation of a ManifestWork.
+type criteriaHeld struct {
+	Generation int64       `json:"generation"`
+	Since      metav1.Time `json:"since"`
+}
+
+// getCriteriaHeld returns the time since when the success criteria hold on the current generation of the
+// ManifestWork, false is returned if it is not recorded.
+func getCriteriaHeld(manifestWork *workv1.ManifestWork) (criteriaHeld, bool) {
+	value, ok := manifestWork.Annotations[rolloutCriteriaHeldAnnotationKey]
+	if !ok {
+		return criteriaHeld{}, false
+	}
+	held := criteriaHeld{}
+	if err := json.Unmarshal([]byte(value), &held); err != nil || held.Generation != manifestWork.Generation {
+		return criteriaHeld{}, false
+	}
+	return held, true
+}
+
+// setCriteriaHeld returns a copy of the ManifestWork recording the time since when the success criteria hold,
+// or without the record if held is nil. Nil is returned if the record is not changed.
+func setCriteriaHeld(manifestWork *workv1.ManifestWork, held *criteriaHeld) (*workv1.ManifestWork, error) {
+	value, ok := manifestWork.Annotations[rolloutCriteriaHeldAnnotationKey]
+	if held == nil {
+		if !ok {
+			return nil, nil
+		}
+		updated := manifestWork.DeepCopy()
+		delete(updated.Annotations, rolloutCriteriaHeldAnnotationKey)
+		return updated, nil
+	}
+
+	data, err := json.Marshal(held)
+	if err != nil {
+		return nil, err
+	}
+	if ok && value == string(data) {
+		return nil, nil
+	}
+	updated := manifestWork.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[rolloutCriteriaHeldAnnotationKey] = string(data)
+	return updated, nil
+}
+
+// rolloutCriteria gates the rollout of a ManifestWorkReplicaSet by the success criteria.
+type rolloutCriteria struct {
+	// invalid is true if the criteria are invalid, the criteria never hold so the rollout does not advance.
+	invalid    bool
+	expression string
+	program    cel.Program
+	soakTime   time.Duration
+	threshold  intstr.IntOrString
+}
+
+// newRolloutCriteria returns the rollout criteria of the ManifestWorkReplicaSet, nil is returned if neither the
+// success criteria nor the soak time nor the success threshold is defined.
+func newRolloutCriteria(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (*rolloutCriteria, error) {
+	expression, hasExpression := mwrSet.Annotations[ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey]
+	soakTime, hasSoakTime := mwrSet.Annotations[ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey]
+	threshold, hasThreshold := mwrSet.Annotations[ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey]
+	if !hasExpression && !hasSoakTime && !hasThreshold {
+		return nil, nil
+	}
+
+	criteria := &rolloutCriteria{
+		expression: expression,
+		threshold:  intstr.FromString("100%"),
+	}
+	if hasExpression {
+		program, err := compileRolloutCriteria(expression)
+		if err != nil {
+			return nil, err
+		}
+		criteria.program = program
+	}
+	if hasSoakTime {
+		duration, err := time.ParseDuration(soakTime)
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid annotation %s %q, the value should be a duration",
+				ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey, soakTime)
+		}
+		criteria.soakTime = duration
+	}
+	if hasThreshold {
+		criteria.threshold = intstr.Parse(threshold)
+		if value, err := intstr.GetScaledValueFromIntOrPercent(&criteria.threshold, 100, true); err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid annotation %s %q, the value should be a number or a percentage",
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey, threshold)
+		}
+	}
+	return criteria, nil
+}
+
+// ValidateRolloutCriteriaAnnotations validates the rollout criteria annotations of the ManifestWorkReplicaSet,
+// including the compilation of the success criteria.
+func ValidateRolloutCriteriaAnnotations(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	_, err := newRolloutCriteria(mwrSet)
+	return err
+}
+
+// invalidRolloutCriteria returns the criteria which never hold.
+func invalidRolloutCriteria() *rolloutCriteria {
+	return &rolloutCriteria{invalid: true, threshold: intstr.FromString("100%")}
+}
+
+// setRolloutCriteriaVerified sets the RolloutCriteriaVerified condition of the ManifestWorkReplicaSet, the
+// condition is removed if the criteria are not defined.
+func setRolloutCriteriaVerified(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, criteria *rolloutCriteria, err error) {
+	switch {
+	case err != nil:
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolloutCriteriaVerified,
+			ReasonInvalidRolloutCriteria, err.Error(), metav1.ConditionFalse))
+	case criteria == nil:
+		apimeta.RemoveStatusCondition(&mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutCriteriaVerified)
+	default:
+		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, getCondition(ManifestWorkReplicaSetConditionRolloutCriteriaVerified,
+			workapiv1alpha1.ReasonAsExpected, "", metav1.ConditionTrue))
+	}
+}
+
+// rolloutStatus returns the rollout status of the cluster gated by the success criteria. A succeeded cluster is
+// progressing until the criteria hold, and the transition time is the time since when the criteria hold, so the
+// soak time is counted from it. The time is recorded on the ManifestWork, and the returned ManifestWork is the
+// one whose record needs to be updated, it is nil if the record is not changed.
+func (c *rolloutCriteria) rolloutStatus(ctx context.Context, status clustersdkv1alpha1.ClusterRolloutStatus,
+	manifestWork *workv1.ManifestWork) (clustersdkv1alpha1.ClusterRolloutStatus, *workv1.ManifestWork, error) {
+	if status.Status != clustersdkv1alpha1.Succeeded || !c.evaluate(ctx, manifestWork) {
+		if status.Status == clustersdkv1alpha1.Succeeded {
+			status.Status = clustersdkv1alpha1.Progressing
+		}
+		updated, err := setCriteriaHeld(manifestWork, nil)
+		return status, updated, err
+	}
+
+	held, ok := getCriteriaHeld(manifestWork)
+	if !ok {
+		held = criteriaHeld{Generation: manifestWork.Generation, Since: metav1.NewTime(clustersdkv1alpha1.RolloutClock.Now())}
+	}
+	updated, err := setCriteriaHeld(manifestWork, &held)
+	status.LastTransitionTime = &held.Since
+	return status, updated, err
+}
+
+// evaluate returns true if the success criteria hold on the ManifestWork. The criteria are not evaluated until
+// the agent applies the current generation of the ManifestWork, since the status might be stale.
+func (c *rolloutCriteria) evaluate(ctx context.Context, manifestWork *workv1.ManifestWork) bool {
+	if c.invalid {
+		return false
+	}
+	if c.program == nil {
+		return true
+	}
+	applied := apimeta.FindStatusCondition(manifestWork.Status.Conditions, workv1.WorkApplied)
+	if applied == nil || applied.ObservedGeneration != manifestWork.Generation {
+		return false
+	}
+
+	logger := klog.FromContext(ctx)
+	out, details, err := c.program.ContextEval(ctx, criteriaInput(manifestWork))
+	if details != nil {
+		if ok, _ := helpers.CostCalculation(ctx, details, rolloutCriteriaCostBudget, c.expression); !ok {
+			return false
+		}
+	}
+	if err != nil {
+		logger.V(4).Info("Failed to evaluate the rollout success criteria",
+			"cluster", manifestWork.Namespace, "name", manifestWork.Name, "err", err)
+		return false
+	}
+	result, ok := out.Value().(bool)
+	return ok && result
+}
+
+// criteriaInput converts the status of the ManifestWork to the variables of the success criteria.
+func criteriaInput(manifestWork *workv1.ManifestWork) map[string]any {
+	manifests := []any{}
+	for _, manifest := range manifestWork.Status.ResourceStatus.Manifests {
+		feedback := map[string]any{}
+		for _, value := range manifest.StatusFeedbacks.Values {
+			if v, ok := fieldValue(value.Value); ok {
+				feedback[value.Name] = v
+			}
+		}
+		manifests = append(manifests, map[string]any{
+			"group":      manifest.ResourceMeta.Group,
+			"version":    manifest.ResourceMeta.Version,
+			"kind":       manifest.ResourceMeta.Kind,
+			"resource":   manifest.ResourceMeta.Resource,
+			"namespace":  manifest.ResourceMeta.Namespace,
+			"name":       manifest.ResourceMeta.Name,
+			"feedback":   feedback,
+			"conditions": conditionStatuses(manifest.Conditions),
+		})
+	}
+	return map[string]any{
+		"conditions": conditionStatuses(manifestWork.Status.Conditions),
+		"manifests":  manifests,
+	}
+}
+
+func conditionStatuses(conditions []metav1.Condition) map[string]any {
+	statuses := map[string]any{}
+	for _, condition := range conditions {
+		statuses[condition.Type] = string(condition.Status)
+	}
+	return statuses
+}
+
+func fieldValue(value workv1.FieldValue) (any, bool) {
+	switch {
+	case value.Integer != nil:
+		return *value.Integer, true
+	case value.Boolean != nil:
+		return *value.Boolean, true
+	case value.String != nil:
+		return *value.String, true
+	case value.JsonRaw != nil:
+		var v any
+		if err := json.Unmarshal([]byte(*value.JsonRaw), &v); err != nil {
+			return nil, false
+		}
+		return v, true
+	}
+	return nil, false
+}
+
+// strategy returns the rollout strategy whose minSuccessTime is extended to the soak time.
+func (c *rolloutCriteria) strategy(strategy clusterv1alpha1.RolloutStrategy) clusterv1alpha1.RolloutStrategy {
+	strategy = *strategy.DeepCopy()
+	soak := func(config *clusterv1alpha1.RolloutConfig) {
+		if config.MinSuccessTime.Duration < c.soakTime {
+			config.MinSuccessTime = metav1.Duration{Duration: c.soakTime}
+		}
+	}
+	switch strategy.Type {
+	case clusterv1alpha1.Progressive:
+		if strategy.Progressive == nil {
+			strategy.Progressive = &clusterv1alpha1.RolloutProgressive{}
+		}
+		soak(&strategy.Progressive.RolloutConfig)
+	case clusterv1alpha1.ProgressivePerGroup:
+		if strategy.ProgressivePerGroup == nil {
+			strategy.ProgressivePerGroup = &clusterv1alpha1.RolloutProgressivePerGroup{}
+		}
+		soak(&strategy.ProgressivePerGroup.RolloutConfig)
+	}
+	return strategy
+}
+
+// applyThreshold skips the clusters still progressing in the decision groups where the number of the succeeded
+// clusters reaches the success threshold, so they do not block the rollout of the next groups. A cluster is
+// succeeded once the soak time is over.
+func (c *rolloutCriteria) applyThreshold(groups clustersdkv1beta1.ClusterGroupsMap,
+	statuses []clustersdkv1alpha1.ClusterRolloutStatus, minSuccessTime time.Duration) {
+	if c.threshold == intstr.FromString("100%") {
+		return
+	}
+
+	now := clustersdkv1alpha1.RolloutClock.Now()
+	index := map[string]int{}
+	for i, status := range statuses {
+		index[status.ClusterName] = i
+	}
+	for _, clusters := range groups {
+		// the error is checked when the threshold is parsed.
+		threshold, _ := intstr.GetScaledValueFromIntOrPercent(&c.threshold, clusters.Len(), true)
+		succeeded := 0
+		for cluster := range clusters {
+			i, ok := index[cluster]
+			if ok && statuses[i].Status == clustersdkv1alpha1.Succeeded &&
+				!now.Before(statuses[i].LastTransitionTime.Add(minSuccessTime)) {
+				succeeded++
+			}
+		}
+		if succeeded < threshold {
+			continue
+		}
+		for cluster := range clusters {
+			i, ok := index[cluster]
+			if !ok {
+				continue
+			}
+			switch statuses[i].Status {
+			case clustersdkv1alpha1.ToApply, clustersdkv1alpha1.Progressing, clustersdkv1alpha1.Succeeded:
+				statuses[i].Status = clustersdkv1alpha1.Skip
+			}
+		}
+	}
+}
+
+func rolloutMinSuccessTime(strategy clusterv1alpha1.RolloutStrategy) time.Duration {
+	switch {
+	case strategy.Type == clusterv1alpha1.Progressive && strategy.Progressive != nil:
+		return strategy.Progressive.MinSuccessTime.Duration
+	case strategy.Type == clusterv1alpha1.ProgressivePerGroup && strategy.ProgressivePerGroup != nil:
+		return strategy.ProgressivePerGroup.MinSuccessTime.Duration
+	}
+	return 0
+}
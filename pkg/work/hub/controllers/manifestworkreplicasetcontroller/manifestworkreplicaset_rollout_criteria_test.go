@@ -0,0 +1,438 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1alpha1 "open-cluster-management.io/api/cluster/v1alpha1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	clustersdkv1alpha1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1alpha1"
+	clustersdkv1beta1 "open-cluster-management.io/sdk-go/pkg/apis/cluster/v1beta1"
+	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+const testReadyCriteria = "conditions.Available == 'True' && manifests.all(m, m.feedback.ReadyReplicas >= 2)"
+
+func newCriteriaTestWork(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, cluster string, readyReplicas int64) *workapiv1.ManifestWork {
+	mw, _ := CreateManifestWork(mwrSet, cluster, "place-test")
+	mw.Generation = 1
+	mw.Status.Conditions = []metav1.Condition{
+		{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+		{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue, ObservedGeneration: 1},
+	}
+	mw.Status.ResourceStatus.Manifests = []workapiv1.ManifestCondition{{
+		ResourceMeta: workapiv1.ManifestResourceMeta{Group: "apps", Resource: "deployments", Kind: "Deployment", Name: "app"},
+		StatusFeedbacks: workapiv1.StatusFeedbackResult{Values: []workapiv1.FeedbackValue{
+			{Name: "ReadyReplicas", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: ptr.To(readyReplicas)}},
+		}},
+	}}
+	return mw
+}
+
+func TestNewRolloutCriteria(t *testing.T) {
+	cases := []struct {
+		name             string
+		annotations      map[string]string
+		expectedCriteria bool
+		expectedErr      bool
+	}{
+		{
+			name: "no criteria",
+		},
+		{
+			name: "valid criteria",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey:  testReadyCriteria,
+				ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey:         "10m",
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "80%",
+			},
+			expectedCriteria: true,
+		},
+		{
+			name:             "soak time only",
+			annotations:      map[string]string{ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey: "30s"},
+			expectedCriteria: true,
+		},
+		{
+			name:        "invalid expression",
+			annotations: map[string]string{ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: "manifests.all("},
+			expectedErr: true,
+		},
+		{
+			name:        "expression not returning bool",
+			annotations: map[string]string{ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: "size(manifests)"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid soak time",
+			annotations: map[string]string{ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey: "soon"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid threshold",
+			annotations: map[string]string{ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "most"},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			criteria, err := newRolloutCriteria(newTestMWRSet("test", c.annotations))
+			if c.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, c.expectedCriteria, criteria != nil)
+		})
+	}
+}
+
+func newCriteriaHeldTestWork(t *testing.T, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, cluster string,
+	readyReplicas int64, held criteriaHeld) *workapiv1.ManifestWork {
+	mw := newCriteriaTestWork(mwrSet, cluster, readyReplicas)
+	updated, err := setCriteriaHeld(mw, &held)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return updated
+}
+
+func TestRolloutCriteriaStatus(t *testing.T) {
+	mwrSet := newTestMWRSet("test", map[string]string{ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: testReadyCriteria})
+	since := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+	stale := newCriteriaTestWork(mwrSet, "cls4", 3)
+	stale.Status.Conditions[0].ObservedGeneration = 0
+
+	cases := []struct {
+		name           string
+		work           *workapiv1.ManifestWork
+		status         clustersdkv1alpha1.RolloutStatus
+		expectedStatus clustersdkv1alpha1.RolloutStatus
+		expectedSince  *metav1.Time
+		expectedUpdate bool
+	}{
+		{
+			name:           "criteria hold since the last reconcile",
+			work:           newCriteriaHeldTestWork(t, mwrSet, "cls1", 2, criteriaHeld{Generation: 1, Since: since}),
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+			expectedSince:  &since,
+		},
+		{
+			name:           "criteria hold on a new generation",
+			work:           newCriteriaHeldTestWork(t, mwrSet, "cls2", 2, criteriaHeld{Generation: 0, Since: since}),
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+			expectedUpdate: true,
+		},
+		{
+			name:           "criteria start to hold",
+			work:           newCriteriaTestWork(mwrSet, "cls2", 2),
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Succeeded,
+			expectedUpdate: true,
+		},
+		{
+			name:           "criteria do not hold",
+			work:           newCriteriaTestWork(mwrSet, "cls3", 1),
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Progressing,
+		},
+		{
+			name:           "criteria do not hold any more",
+			work:           newCriteriaHeldTestWork(t, mwrSet, "cls3", 1, criteriaHeld{Generation: 1, Since: since}),
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Progressing,
+			expectedUpdate: true,
+		},
+		{
+			name:           "status of the generation is not observed",
+			work:           stale,
+			status:         clustersdkv1alpha1.Succeeded,
+			expectedStatus: clustersdkv1alpha1.Progressing,
+		},
+		{
+			name:           "failed",
+			work:           newCriteriaTestWork(mwrSet, "cls5", 2),
+			status:         clustersdkv1alpha1.Failed,
+			expectedStatus: clustersdkv1alpha1.Failed,
+		},
+	}
+
+	criteria, err := newRolloutCriteria(mwrSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			status, updated, err := criteria.rolloutStatus(context.TODO(), clustersdkv1alpha1.ClusterRolloutStatus{
+				ClusterName:        c.work.Namespace,
+				Status:             c.status,
+				LastTransitionTime: &c.work.CreationTimestamp,
+			}, c.work)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, c.expectedStatus, status.Status)
+			assert.Equal(t, c.expectedUpdate, updated != nil)
+
+			work := c.work
+			if updated != nil {
+				work = updated
+			}
+			held, ok := getCriteriaHeld(work)
+			assert.Equal(t, c.expectedStatus == clustersdkv1alpha1.Succeeded, ok)
+			if c.expectedSince != nil {
+				assert.True(t, c.expectedSince.Equal(&held.Since))
+				assert.True(t, c.expectedSince.Equal(status.LastTransitionTime))
+			}
+		})
+	}
+}
+
+func TestRolloutCriteriaThreshold(t *testing.T) {
+	now := metav1.Now()
+	groups := clustersdkv1beta1.ClusterGroupsMap{
+		{GroupName: "canary", GroupIndex: 0}: sets.New[string]("cls1", "cls2", "cls3", "cls4"),
+		{GroupName: "prod", GroupIndex: 1}:   sets.New[string]("cls5", "cls6"),
+	}
+	newStatuses := func() []clustersdkv1alpha1.ClusterRolloutStatus {
+		return []clustersdkv1alpha1.ClusterRolloutStatus{
+			{ClusterName: "cls1", Status: clustersdkv1alpha1.Succeeded, LastTransitionTime: &now},
+			{ClusterName: "cls2", Status: clustersdkv1alpha1.Succeeded, LastTransitionTime: &now},
+			{ClusterName: "cls3", Status: clustersdkv1alpha1.Progressing, LastTransitionTime: &now},
+			{ClusterName: "cls4", Status: clustersdkv1alpha1.Failed, LastTransitionTime: &now},
+		}
+	}
+	statusOf := func(statuses []clustersdkv1alpha1.ClusterRolloutStatus) map[string]clustersdkv1alpha1.RolloutStatus {
+		result := map[string]clustersdkv1alpha1.RolloutStatus{}
+		for _, status := range statuses {
+			result[status.ClusterName] = status.Status
+		}
+		return result
+	}
+
+	cases := []struct {
+		name             string
+		threshold        string
+		minSuccessTime   time.Duration
+		expectedStatuses map[string]clustersdkv1alpha1.RolloutStatus
+	}{
+		{
+			name:      "threshold is reached",
+			threshold: "50%",
+			expectedStatuses: map[string]clustersdkv1alpha1.RolloutStatus{
+				"cls1": clustersdkv1alpha1.Skip,
+				"cls2": clustersdkv1alpha1.Skip,
+				"cls3": clustersdkv1alpha1.Skip,
+				"cls4": clustersdkv1alpha1.Failed,
+			},
+		},
+		{
+			name:      "threshold is not reached",
+			threshold: "3",
+			expectedStatuses: map[string]clustersdkv1alpha1.RolloutStatus{
+				"cls1": clustersdkv1alpha1.Succeeded,
+				"cls2": clustersdkv1alpha1.Succeeded,
+				"cls3": clustersdkv1alpha1.Progressing,
+				"cls4": clustersdkv1alpha1.Failed,
+			},
+		},
+		{
+			name:           "soak time is not over",
+			threshold:      "50%",
+			minSuccessTime: time.Hour,
+			expectedStatuses: map[string]clustersdkv1alpha1.RolloutStatus{
+				"cls1": clustersdkv1alpha1.Succeeded,
+				"cls2": clustersdkv1alpha1.Succeeded,
+				"cls3": clustersdkv1alpha1.Progressing,
+				"cls4": clustersdkv1alpha1.Failed,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			criteria, err := newRolloutCriteria(newTestMWRSet("test", map[string]string{
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: c.threshold}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			statuses := newStatuses()
+			criteria.applyThreshold(groups, statuses, c.minSuccessTime)
+			assert.Equal(t, c.expectedStatuses, statusOf(statuses))
+		})
+	}
+}
+
+func TestDeployWithRolloutCriteria(t *testing.T) {
+	cases := []struct {
+		name             string
+		annotations      map[string]string
+		held             *criteriaHeld
+		expectedTotal    int
+		expectedRequeue  bool
+		expectedVerified metav1.ConditionStatus
+		expectedHeld     bool
+	}{
+		{
+			name:             "next group is rolled out without criteria",
+			expectedTotal:    4,
+			expectedVerified: "",
+		},
+		{
+			name:             "criteria do not hold on all clusters of the group",
+			annotations:      map[string]string{ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: testReadyCriteria},
+			expectedTotal:    2,
+			expectedVerified: metav1.ConditionTrue,
+			expectedHeld:     true,
+		},
+		{
+			name: "criteria hold on the threshold of the group",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey:  testReadyCriteria,
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "1",
+			},
+			expectedTotal:    4,
+			expectedVerified: metav1.ConditionTrue,
+			expectedHeld:     true,
+		},
+		{
+			name: "soak time is not over",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey:  testReadyCriteria,
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "1",
+				ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey:         "10m",
+			},
+			expectedTotal:    2,
+			expectedRequeue:  true,
+			expectedVerified: metav1.ConditionTrue,
+			expectedHeld:     true,
+		},
+		{
+			name: "soak time recorded on the work is over",
+			annotations: map[string]string{
+				ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey:  testReadyCriteria,
+				ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "1",
+				ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey:         "10m",
+			},
+			held:             &criteriaHeld{Generation: 1, Since: metav1.NewTime(time.Now().Add(-time.Hour))},
+			expectedTotal:    4,
+			expectedRequeue:  true,
+			expectedVerified: metav1.ConditionTrue,
+			expectedHeld:     true,
+		},
+		{
+			name:          "record is removed without criteria",
+			held:          &criteriaHeld{Generation: 1, Since: metav1.NewTime(time.Now().Add(-time.Hour))},
+			expectedTotal: 4,
+		},
+		{
+			name:             "invalid criteria",
+			annotations:      map[string]string{ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: "manifests.all("},
+			expectedTotal:    2,
+			expectedVerified: metav1.ConditionFalse,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			placement, placementDecisions := helpertest.CreateTestPlacementWithDecisionStrategy(
+				"place-test", "default", 2, "cls1", "cls2", "cls3", "cls4")
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(
+				fakeclusterclient.NewSimpleClientset(), 1*time.Second)
+			if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+				t.Fatal(err)
+			}
+			for _, decision := range placementDecisions {
+				if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(decision); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSetWithRollOutStrategy("mwrset-test", "default",
+				map[string]clusterv1alpha1.RolloutStrategy{placement.Name: {Type: clusterv1alpha1.ProgressivePerGroup}})
+			mwrSet.Annotations = c.annotations
+			works := []*workapiv1.ManifestWork{
+				newCriteriaTestWork(mwrSet, "cls1", 2),
+				newCriteriaTestWork(mwrSet, "cls2", 1),
+			}
+			if c.held != nil {
+				works[0] = newCriteriaHeldTestWork(t, mwrSet, "cls1", 2, *c.held)
+			}
+			fWorkClient := fakeworkclient.NewSimpleClientset(works[0], works[1])
+			workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Second)
+			for _, mw := range works {
+				if err := workInformerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(mw); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+			deployController := deployReconciler{
+				workClient:          fWorkClient,
+				workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+				manifestWorkLister:  mwLister,
+				placeDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+				placementLister:     clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+			}
+
+			mwrSet, _, err := deployController.reconcile(context.TODO(), mwrSet)
+			var rqe helpers.RequeueError
+			switch {
+			case c.expectedRequeue:
+				assert.True(t, errors.As(err, &rqe), "expected requeue error, got %v", err)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expectedTotal, mwrSet.Status.Summary.Total)
+
+			// the time since when the criteria hold is recorded on the work
+			work, err := fWorkClient.WorkV1().ManifestWorks("cls1").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, held := getCriteriaHeld(work)
+			assert.Equal(t, c.expectedHeld, held)
+
+			condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionRolloutCriteriaVerified)
+			if c.expectedVerified == "" {
+				assert.Nil(t, condition)
+				return
+			}
+			assert.NotNil(t, condition)
+			assert.Equal(t, c.expectedVerified, condition.Status)
+		})
+	}
+}
+
+func TestCompileRolloutCriteriaCached(t *testing.T) {
+	first, err := compileRolloutCriteria(testReadyCriteria)
+	assert.NoError(t, err)
+	second, err := compileRolloutCriteria(testReadyCriteria)
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+
+	_, err = compileRolloutCriteria("manifests.size()")
+	assert.Error(t, err)
+	cached, ok := rolloutCriteriaPrograms.Get("manifests.size()")
+	assert.True(t, ok)
+	assert.Error(t, cached.(compiledRolloutCriteria).err)
+}
@@ -14,10 +14,20 @@ import (
 // statusReconciler is to update manifestWorkReplicaSet status.
 type statusReconciler struct {
 	manifestWorkLister worklisterv1.ManifestWorkLister
+	renderer           templateRenderer
+	// revisions is nil if the revision history is not kept.
+	revisions *revisionHistory
 }
 
 func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha1.ManifestWorkReplicaSet,
 ) (*workapiv1alpha1.ManifestWorkReplicaSet, reconcileState, error) {
+	// aggregate the status feedback values if the aggregation rules are defined
+	var aggregator *feedbackAggregator
+	rules, rulesErr := getFeedbackAggregationRules(mwrSet)
+	if rules != nil {
+		aggregator = newFeedbackAggregator(rules)
+	}
+
 	// The logic for update manifestWorkReplicaSet status
 	if mwrSet.Status.Summary.Total == 0 {
 		condition := apimeta.FindStatusCondition(mwrSet.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementVerified)
@@ -27,7 +37,12 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonNotAsExpected, ""))
 		}
 
-		return mwrSet, reconcileContinue, nil
+		return mwrSet, reconcileContinue, setAggregatedFeedback(mwrSet, aggregator, rulesErr)
+	}
+
+	overrides, err := d.renderer.overrides(mwrSet)
+	if err != nil {
+		return mwrSet, reconcileContinue, err
 	}
 
 	appliedCount, availableCount, degradCount, processingCount := 0, 0, 0, 0
@@ -44,19 +59,26 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 			}
 
 			// Check if ManifestWorkTemplate changes, ManifestWork will need to be updated.
+			spec, err := d.renderer.render(mwrSet, mw.Namespace, overrides)
+			if err != nil {
+				continue
+			}
 			newMW := &workapiv1.ManifestWork{}
 			mw.ObjectMeta.DeepCopyInto(&newMW.ObjectMeta)
-			mwrSet.Spec.ManifestWorkTemplate.DeepCopyInto(&newMW.Spec)
+			newMW.Spec = *spec
 			if !workapplier.ManifestWorkEqual(newMW, mw) {
 				continue
 			}
+			if aggregator != nil {
+				aggregator.add(plcSummary.Name, mw)
+			}
 
 			// applied condition
 			if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkApplied) {
 				applied++
 			}
-			// Progressing condition
-			if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkProgressing) {
+			// Progressing condition, the paused work is not progressing
+			if apimeta.IsStatusConditionTrue(mw.Status.Conditions, workapiv1.WorkProgressing) && !isWorkPaused(mw) {
 				processing++
 			}
 			// Available condition
@@ -87,11 +109,18 @@ func (d *statusReconciler) reconcile(ctx context.Context, mwrSet *workapiv1alpha
 	if mwrSet.Status.Summary.Available == mwrSet.Status.Summary.Total && //nolint:gocritic
 		mwrSet.Status.Summary.Progressing == 0 && mwrSet.Status.Summary.Degraded == 0 {
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonAsExpected, ""))
+		// the ManifestWorkTemplate is succeeded once it is rolled out and available on all the clusters.
+		rollout := apimeta.FindStatusCondition(mwrSet.Status.Conditions, workapiv1alpha1.ManifestWorkReplicaSetConditionPlacementRolledOut)
+		if d.revisions != nil && rollout != nil && rollout.Reason == workapiv1alpha1.ReasonComplete {
+			if err := d.revisions.setState(ctx, mwrSet, revisionStateSucceeded); err != nil {
+				return mwrSet, reconcileContinue, err
+			}
+		}
 	} else if mwrSet.Status.Summary.Progressing > 0 && mwrSet.Status.Summary.Degraded == 0 {
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonProcessing, ""))
 	} else {
 		apimeta.SetStatusCondition(&mwrSet.Status.Conditions, GetManifestworkApplied(workapiv1alpha1.ReasonNotAsExpected, ""))
 	}
 
-	return mwrSet, reconcileContinue, nil
+	return mwrSet, reconcileContinue, setAggregatedFeedback(mwrSet, aggregator, rulesErr)
 }
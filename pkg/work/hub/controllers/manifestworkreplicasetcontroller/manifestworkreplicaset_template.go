@@ -0,0 +1,240 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/valyala/fasttemplate"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	clusterlisterv1 "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+)
+
+const (
+	// ManifestWorkTemplateEnabledAnnotationKey is the annotation key on the ManifestWorkReplicaSet to enable
+	// the templating of the manifests in the ManifestWorkTemplate when the value is "true". The variables in
+	// the manifests, such as {{CLUSTER_NAME}}, are rendered with the values of each selected cluster.
+	ManifestWorkTemplateEnabledAnnotationKey = "work.open-cluster-management.io/enable-templating"
+
+	// ManifestWorkTemplateOverridesAnnotationKey is the annotation key on the ManifestWorkReplicaSet to reference
+	// a ConfigMap in the same namespace, which defines the per-cluster values of the template variables. The key
+	// of the ConfigMap data is the cluster name, and the value is a json encoded map of the variables, for example
+	// {"REPLICAS":"3","HOSTNAME":"app.cluster1.example.com"}. Setting it also enables the templating. The
+	// ConfigMap must have the ManifestWorkTemplateOverridesLabelKey label.
+	ManifestWorkTemplateOverridesAnnotationKey = "work.open-cluster-management.io/template-overrides"
+
+	// ManifestWorkTemplateOverridesLabelKey is the label key on the ConfigMaps referenced by the
+	// ManifestWorkTemplateOverridesAnnotationKey, the value could be any string. Only the ConfigMaps with the
+	// label are watched by the controller.
+	ManifestWorkTemplateOverridesLabelKey = "work.open-cluster-management.io/template-overrides"
+
+	// ManifestWorkReplicaSetConditionTemplateRendered is the condition type of the ManifestWorkReplicaSet which
+	// reports whether the ManifestWorkTemplate is rendered for all the selected clusters. It only exists when the
+	// templating is enabled.
+	ManifestWorkReplicaSetConditionTemplateRendered = "TemplateRendered"
+
+	// ReasonTemplateRenderFailed is the reason of the TemplateRendered condition when the ManifestWorkTemplate
+	// fails to be rendered for some clusters.
+	ReasonTemplateRenderFailed = "RenderFailed"
+
+	// clusterNameVariable is the variable of the cluster name.
+	clusterNameVariable = "CLUSTER_NAME"
+	// clusterLabelVariablePrefix is the prefix of the variables of the cluster labels, for example
+	// {{CLUSTER_LABEL:topology.kubernetes.io/region}}.
+	clusterLabelVariablePrefix = "CLUSTER_LABEL:"
+	// clusterClaimVariablePrefix is the prefix of the variables of the cluster claims, for example
+	// {{CLUSTER_CLAIM:id.k8s.io}}.
+	clusterClaimVariablePrefix = "CLUSTER_CLAIM:"
+
+	// maxRenderErrorsInCondition is the max number of the clusters listed in the TemplateRendered condition.
+	maxRenderErrorsInCondition = 10
+)
+
+// templateRenderer renders the ManifestWorkTemplate of a ManifestWorkReplicaSet for each selected cluster.
+type templateRenderer struct {
+	configMapLister corev1listers.ConfigMapLister
+	clusterLister   clusterlisterv1.ManagedClusterLister
+}
+
+// templateOverrides is the per-cluster values of the template variables, keyed by cluster name.
+type templateOverrides map[string]map[string]string
+
+// ValidateTemplateAnnotations validates the annotations of the ManifestWorkReplicaSet enabling the templating.
+func ValidateTemplateAnnotations(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) error {
+	if value, ok := mwrSet.Annotations[ManifestWorkTemplateEnabledAnnotationKey]; ok && value != "true" && value != "false" {
+		return fmt.Errorf("invalid annotation %s %q, the value should be true or false",
+			ManifestWorkTemplateEnabledAnnotationKey, value)
+	}
+	if name, ok := mwrSet.Annotations[ManifestWorkTemplateOverridesAnnotationKey]; ok {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid annotation %s %q, the value should be a ConfigMap name: %s",
+				ManifestWorkTemplateOverridesAnnotationKey, name, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func templateEnabled(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) bool {
+	if len(mwrSet.Annotations[ManifestWorkTemplateOverridesAnnotationKey]) > 0 {
+		return true
+	}
+	return mwrSet.Annotations[ManifestWorkTemplateEnabledAnnotationKey] == "true"
+}
+
+// overrides returns the per-cluster values defined in the overrides ConfigMap of the ManifestWorkReplicaSet.
+func (r *templateRenderer) overrides(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet) (templateOverrides, error) {
+	name := mwrSet.Annotations[ManifestWorkTemplateOverridesAnnotationKey]
+	if len(name) == 0 || r.configMapLister == nil {
+		return nil, nil
+	}
+
+	cm, err := r.configMapLister.ConfigMaps(mwrSet.Namespace).Get(name)
+	if errors.IsNotFound(err) {
+		return nil, fmt.Errorf("template overrides configmap %s/%s with label %s is not found",
+			mwrSet.Namespace, name, ManifestWorkTemplateOverridesLabelKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := templateOverrides{}
+	for clusterName, data := range cm.Data {
+		values := map[string]string{}
+		if err := json.Unmarshal([]byte(data), &values); err != nil {
+			return nil, fmt.Errorf("invalid overrides of cluster %s in configmap %s/%s: %w", clusterName, mwrSet.Namespace, name, err)
+		}
+		overrides[clusterName] = values
+	}
+	return overrides, nil
+}
+
+// render returns the ManifestWorkSpec for the cluster. The ManifestWorkTemplate is returned as it is if the
+// templating is not enabled on the ManifestWorkReplicaSet.
+func (r *templateRenderer) render(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterName string,
+	overrides templateOverrides) (*workapiv1.ManifestWorkSpec, error) {
+	spec := mwrSet.Spec.ManifestWorkTemplate.DeepCopy()
+	if !templateEnabled(mwrSet) {
+		return spec, nil
+	}
+
+	var cluster *clusterv1.ManagedCluster
+	if r.clusterLister != nil {
+		var err error
+		cluster, err = r.clusterLister.Get(clusterName)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	for i, manifest := range spec.Workload.Manifests {
+		if len(manifest.Raw) == 0 {
+			continue
+		}
+		t, err := fasttemplate.NewTemplate(string(manifest.Raw), "{{", "}}")
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of manifest %d: %w", i, err)
+		}
+		rendered, err := t.ExecuteFuncStringWithErr(func(w io.Writer, tag string) (int, error) {
+			value, err := templateValue(strings.TrimSpace(tag), clusterName, cluster, overrides[clusterName])
+			if err != nil {
+				return 0, err
+			}
+			return w.Write([]byte(value))
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render manifest %d: %w", i, err)
+		}
+		if !json.Valid([]byte(rendered)) {
+			return nil, fmt.Errorf("rendered manifest %d is not a valid json", i)
+		}
+		spec.Workload.Manifests[i].Raw = []byte(rendered)
+	}
+	return spec, nil
+}
+
+// templateValue returns the json escaped value of the variable, so that the value is always rendered into a
+// valid json string.
+func templateValue(variable, clusterName string, cluster *clusterv1.ManagedCluster, overrides map[string]string) (string, error) {
+	var value string
+	var found bool
+	switch {
+	case variable == clusterNameVariable:
+		value, found = clusterName, true
+	case strings.HasPrefix(variable, clusterLabelVariablePrefix):
+		if cluster == nil {
+			return "", fmt.Errorf("cluster %s is not found", clusterName)
+		}
+		value, found = cluster.Labels[strings.TrimPrefix(variable, clusterLabelVariablePrefix)]
+	case strings.HasPrefix(variable, clusterClaimVariablePrefix):
+		if cluster == nil {
+			return "", fmt.Errorf("cluster %s is not found", clusterName)
+		}
+		claimName := strings.TrimPrefix(variable, clusterClaimVariablePrefix)
+		for _, claim := range cluster.Status.ClusterClaims {
+			if claim.Name == claimName {
+				value, found = claim.Value, true
+				break
+			}
+		}
+	default:
+		value, found = overrides[variable]
+	}
+	if !found {
+		return "", fmt.Errorf("variable %s is not defined", variable)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	// trim the quotes since the variable is expected to be in a json string or to be a number
+	return string(data[1 : len(data)-1]), nil
+}
+
+// renderedManifestWork returns the ManifestWork with the rendered spec for the cluster.
+func (r *templateRenderer) renderedManifestWork(mwrSet *workapiv1alpha1.ManifestWorkReplicaSet, clusterName, placementRefName string,
+	overrides templateOverrides) (*workapiv1.ManifestWork, error) {
+	mw, err := CreateManifestWork(mwrSet, clusterName, placementRefName)
+	if err != nil {
+		return nil, err
+	}
+	spec, err := r.render(mwrSet, clusterName, overrides)
+	if err != nil {
+		return nil, err
+	}
+	mw.Spec = *spec
+	return mw, nil
+}
+
+// GetTemplateRendered returns the TemplateRendered condition with the render errors of the clusters.
+func GetTemplateRendered(renderErrs map[string]error) metav1.Condition {
+	if len(renderErrs) == 0 {
+		return getCondition(ManifestWorkReplicaSetConditionTemplateRendered, workapiv1alpha1.ReasonAsExpected, "", metav1.ConditionTrue)
+	}
+
+	var clusterNames []string
+	for clusterName := range renderErrs {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+
+	var messages []string
+	for _, clusterName := range clusterNames {
+		if len(messages) >= maxRenderErrorsInCondition {
+			messages = append(messages, fmt.Sprintf("and %d more clusters", len(clusterNames)-maxRenderErrorsInCondition))
+			break
+		}
+		messages = append(messages, fmt.Sprintf("%s: %v", clusterName, renderErrs[clusterName]))
+	}
+	return getCondition(ManifestWorkReplicaSetConditionTemplateRendered, ReasonTemplateRenderFailed,
+		strings.Join(messages, "; "), metav1.ConditionFalse)
+}
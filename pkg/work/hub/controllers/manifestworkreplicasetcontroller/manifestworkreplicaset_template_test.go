@@ -0,0 +1,224 @@
+package manifestworkreplicasetcontroller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	fakeclusterclient "open-cluster-management.io/api/client/cluster/clientset/versioned/fake"
+	clusterinformers "open-cluster-management.io/api/client/cluster/informers/externalversions"
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	workapiv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+	workapplier "open-cluster-management.io/sdk-go/pkg/apis/work/v1/applier"
+
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+)
+
+const testTemplateManifest = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"app","namespace":"default"},` +
+	`"data":{"cluster":"{{CLUSTER_NAME}}","region":"{{ CLUSTER_LABEL:region }}","id":"{{CLUSTER_CLAIM:id.k8s.io}}",` +
+	`"hostname":"{{HOSTNAME}}"}}`
+
+func newTemplateManifestWorkReplicaSet(annotations map[string]string) *workapiv1alpha1.ManifestWorkReplicaSet {
+	mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrSet-test", "default", "place-test")
+	mwrSet.Annotations = annotations
+	mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests = []workapiv1.Manifest{
+		{RawExtension: runtime.RawExtension{Raw: []byte(testTemplateManifest)}},
+	}
+	return mwrSet
+}
+
+func newTemplateCluster(name, region string) *clusterv1.ManagedCluster {
+	return &clusterv1.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"region": region},
+		},
+		Status: clusterv1.ManagedClusterStatus{
+			ClusterClaims: []clusterv1.ManagedClusterClaim{{Name: "id.k8s.io", Value: name + "-id"}},
+		},
+	}
+}
+
+func newTemplateOverrides(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "overrides",
+			Labels:    map[string]string{ManifestWorkTemplateOverridesLabelKey: ""},
+		},
+		Data: data,
+	}
+}
+
+func newConfigMapLister(t *testing.T, configMaps ...runtime.Object) corev1listers.ConfigMapLister {
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), 10*time.Minute)
+	for _, cm := range configMaps {
+		if err := kubeInformerFactory.Core().V1().ConfigMaps().Informer().GetStore().Add(cm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return kubeInformerFactory.Core().V1().ConfigMaps().Lister()
+}
+
+func TestTemplateRender(t *testing.T) {
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		configMaps    []runtime.Object
+		clusterName   string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:        "templating is not enabled",
+			clusterName: "cls1",
+			expected:    testTemplateManifest,
+		},
+		{
+			name:        "render with cluster and overrides",
+			annotations: map[string]string{ManifestWorkTemplateOverridesAnnotationKey: "overrides"},
+			configMaps: []runtime.Object{
+				newTemplateOverrides(map[string]string{"cls1": `{"HOSTNAME":"app.\"cls1\".example.com"}`}),
+			},
+			clusterName: "cls1",
+			expected: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"app","namespace":"default"},` +
+				`"data":{"cluster":"cls1","region":"us-east","id":"cls1-id","hostname":"app.\"cls1\".example.com"}}`,
+		},
+		{
+			name:          "variable is not defined",
+			annotations:   map[string]string{ManifestWorkTemplateEnabledAnnotationKey: "true"},
+			clusterName:   "cls1",
+			expectedError: "variable HOSTNAME is not defined",
+		},
+		{
+			name:          "cluster is not found",
+			annotations:   map[string]string{ManifestWorkTemplateEnabledAnnotationKey: "true"},
+			clusterName:   "cls3",
+			expectedError: "cluster cls3 is not found",
+		},
+		{
+			name:        "overrides configmap is not found",
+			annotations: map[string]string{ManifestWorkTemplateOverridesAnnotationKey: "overrides"},
+			clusterName: "cls1",
+			expectedError: "template overrides configmap default/overrides with label " +
+				ManifestWorkTemplateOverridesLabelKey + " is not found",
+		},
+		{
+			name:          "invalid overrides",
+			annotations:   map[string]string{ManifestWorkTemplateOverridesAnnotationKey: "overrides"},
+			configMaps:    []runtime.Object{newTemplateOverrides(map[string]string{"cls1": `{"HOSTNAME":`})},
+			clusterName:   "cls1",
+			expectedError: "invalid overrides of cluster cls1",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cluster := newTemplateCluster("cls1", "us-east")
+			fClusterClient := fakeclusterclient.NewSimpleClientset(cluster)
+			clusterInformerFactory := clusterinformers.NewSharedInformerFactory(fClusterClient, 10*time.Minute)
+			if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+				t.Fatal(err)
+			}
+			renderer := templateRenderer{
+				configMapLister: newConfigMapLister(t, c.configMaps...),
+				clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+			}
+
+			mwrSet := newTemplateManifestWorkReplicaSet(c.annotations)
+			overrides, err := renderer.overrides(mwrSet)
+			if err == nil {
+				var spec *workapiv1.ManifestWorkSpec
+				spec, err = renderer.render(mwrSet, c.clusterName, overrides)
+				if err == nil && string(spec.Workload.Manifests[0].Raw) != c.expected {
+					t.Errorf("expected rendered manifest %s, but got %s", c.expected, string(spec.Workload.Manifests[0].Raw))
+				}
+			}
+
+			switch {
+			case len(c.expectedError) == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			case len(c.expectedError) > 0 && (err == nil || !strings.Contains(err.Error(), c.expectedError)):
+				t.Errorf("expected error %q, but got %v", c.expectedError, err)
+			}
+
+			if string(mwrSet.Spec.ManifestWorkTemplate.Workload.Manifests[0].Raw) != testTemplateManifest {
+				t.Errorf("the ManifestWorkTemplate should not be changed")
+			}
+		})
+	}
+}
+
+func TestDeployReconcileWithTemplate(t *testing.T) {
+	mwrSet := newTemplateManifestWorkReplicaSet(map[string]string{ManifestWorkTemplateOverridesAnnotationKey: "overrides"})
+	fWorkClient := fakeworkclient.NewSimpleClientset(mwrSet)
+	workInformerFactory := workinformers.NewSharedInformerFactoryWithOptions(fWorkClient, 1*time.Minute)
+	mwLister := workInformerFactory.Work().V1().ManifestWorks().Lister()
+
+	placement, placementDecision := helpertest.CreateTestPlacement("place-test", "default", "cls1", "cls2")
+	clusters := []*clusterv1.ManagedCluster{newTemplateCluster("cls1", "us-east"), newTemplateCluster("cls2", "us-west")}
+	fClusterClient := fakeclusterclient.NewSimpleClientset(placement, placementDecision)
+	clusterInformerFactory := clusterinformers.NewSharedInformerFactoryWithOptions(fClusterClient, 1*time.Minute)
+	if err := clusterInformerFactory.Cluster().V1beta1().Placements().Informer().GetStore().Add(placement); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Informer().GetStore().Add(placementDecision); err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range clusters {
+		if err := clusterInformerFactory.Cluster().V1().ManagedClusters().Informer().GetStore().Add(cluster); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pmwDeployController := deployReconciler{
+		workApplier:         workapplier.NewWorkApplierWithTypedClient(fWorkClient, mwLister),
+		manifestWorkLister:  mwLister,
+		placeDecisionLister: clusterInformerFactory.Cluster().V1beta1().PlacementDecisions().Lister(),
+		placementLister:     clusterInformerFactory.Cluster().V1beta1().Placements().Lister(),
+		renderer: templateRenderer{
+			// only cls1 has the overrides, so the template fails to be rendered for cls2
+			configMapLister: newConfigMapLister(t, newTemplateOverrides(map[string]string{"cls1": `{"HOSTNAME":"cls1.example.com"}`})),
+			clusterLister:   clusterInformerFactory.Cluster().V1().ManagedClusters().Lister(),
+		},
+	}
+
+	mwrSet, _, err := pmwDeployController.reconcile(context.TODO(), mwrSet)
+	if err == nil {
+		t.Fatal("expected render error, but got nil")
+	}
+
+	mw, err := fWorkClient.WorkV1().ManifestWorks("cls1").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(mw.Spec.Workload.Manifests[0].Raw), `"hostname":"cls1.example.com"`) {
+		t.Errorf("expected rendered manifest, but got %s", string(mw.Spec.Workload.Manifests[0].Raw))
+	}
+	if _, err := fWorkClient.WorkV1().ManifestWorks("cls2").Get(context.TODO(), mwrSet.Name, metav1.GetOptions{}); err == nil {
+		t.Errorf("expected no manifestwork for cls2")
+	}
+
+	if mwrSet.Status.Summary.Total != 1 {
+		t.Errorf("expected total 1, but got %d", mwrSet.Status.Summary.Total)
+	}
+	cond := apimeta.FindStatusCondition(mwrSet.Status.Conditions, ManifestWorkReplicaSetConditionTemplateRendered)
+	if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != ReasonTemplateRenderFailed {
+		t.Fatalf("expected TemplateRendered condition to be false, but got %v", cond)
+	}
+	if cond.Message != "cls2: failed to render manifest 0: variable HOSTNAME is not defined" {
+		t.Errorf("unexpected message %q", cond.Message)
+	}
+}
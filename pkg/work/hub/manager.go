@@ -6,6 +6,8 @@ import (
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
 	clusterclientset "open-cluster-management.io/api/client/cluster/clientset/versioned"
@@ -117,9 +119,15 @@ func (c *WorkHubManagerConfig) RunWorkHubManager(ctx context.Context, controller
 		watcherStore.SetInformer(informer.Informer())
 	}
 
+	kubeClient, err := kubernetes.NewForConfig(controllerContext.KubeConfig)
+	if err != nil {
+		return err
+	}
+
 	return RunControllerManagerWithInformers(
 		ctx,
 		controllerContext,
+		kubeClient,
 		replicaSetsClient,
 		workClient,
 		informer,
@@ -130,25 +138,60 @@ func (c *WorkHubManagerConfig) RunWorkHubManager(ctx context.Context, controller
 func RunControllerManagerWithInformers(
 	ctx context.Context,
 	controllerContext *controllercmd.ControllerContext,
+	kubeClient kubernetes.Interface,
 	replicaSetClient workclientset.Interface,
 	workClient workclientset.Interface,
 	workInformer workv1informer.ManifestWorkInformer,
 	clusterInformers clusterinformers.SharedInformerFactory,
 ) error {
 	replicaSetInformerFactory := workinformers.NewSharedInformerFactory(replicaSetClient, 30*time.Minute)
+	// only the ConfigMaps of the template overrides are watched.
+	configMapInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+		kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      manifestworkreplicasetcontroller.ManifestWorkTemplateOverridesLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		}),
+	)
+	// only the ControllerRevisions of the ManifestWorkReplicaSets are watched.
+	revisionInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 30*time.Minute,
+		kubeinformers.WithTweakListOptions(func(listOptions *metav1.ListOptions) {
+			selector := &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      manifestworkreplicasetcontroller.ManifestWorkReplicaSetControllerNameLabelKey,
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			}
+			listOptions.LabelSelector = metav1.FormatLabelSelector(selector)
+		}),
+	)
 
 	manifestWorkReplicaSetController := manifestworkreplicasetcontroller.NewManifestWorkReplicaSetController(
 		controllerContext.EventRecorder,
+		kubeClient,
 		replicaSetClient,
 		workapplier.NewWorkApplierWithTypedClient(workClient, workInformer.Lister()),
 		replicaSetInformerFactory.Work().V1alpha1().ManifestWorkReplicaSets(),
 		workInformer,
 		clusterInformers.Cluster().V1beta1().Placements(),
 		clusterInformers.Cluster().V1beta1().PlacementDecisions(),
+		clusterInformers.Cluster().V1().ManagedClusters(),
+		configMapInformerFactory.Core().V1().ConfigMaps(),
+		revisionInformerFactory.Apps().V1().ControllerRevisions(),
 	)
 
 	go clusterInformers.Start(ctx.Done())
 	go replicaSetInformerFactory.Start(ctx.Done())
+	go configMapInformerFactory.Start(ctx.Done())
+	go revisionInformerFactory.Start(ctx.Done())
 	go manifestWorkReplicaSetController.Run(ctx, 5)
 
 	go workInformer.Informer().Run(ctx.Done())
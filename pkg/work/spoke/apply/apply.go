@@ -25,7 +25,9 @@ type Applier interface {
 }
 
 type Appliers struct {
-	appliers map[workapiv1.UpdateStrategyType]Applier
+	appliers      map[workapiv1.UpdateStrategyType]Applier
+	driftDetector *DriftDetector
+	dryRunApplier *DryRunApply
 }
 
 func NewAppliers(dynamicClient dynamic.Interface, kubeclient kubernetes.Interface, apiExtensionClient apiextensionsclient.Interface) *Appliers {
@@ -36,9 +38,19 @@ func NewAppliers(dynamicClient dynamic.Interface, kubeclient kubernetes.Interfac
 			workapiv1.UpdateStrategyTypeUpdate:          NewUpdateApply(dynamicClient, kubeclient, apiExtensionClient),
 			workapiv1.UpdateStrategyTypeReadOnly:        NewReadOnlyApply(),
 		},
+		driftDetector: NewDriftDetector(dynamicClient),
+		dryRunApplier: NewDryRunApply(dynamicClient),
 	}
 }
 
 func (a *Appliers) GetApplier(strategy workapiv1.UpdateStrategyType) Applier {
 	return a.appliers[strategy]
 }
+
+func (a *Appliers) GetDriftDetector() *DriftDetector {
+	return a.driftDetector
+}
+
+func (a *Appliers) GetDryRunApplier() *DryRunApply {
+	return a.dryRunApplier
+}
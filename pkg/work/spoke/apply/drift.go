@@ -0,0 +1,295 @@
+package apply
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// maxDriftedFieldsInSummary is the max number of the field paths listed in the drift summary.
+const maxDriftedFieldsInSummary = 10
+
+// Drift is the drift of a resource on the managed cluster from the last applied state.
+type Drift struct {
+	// Fields is the sorted paths of the drifted fields, such as spec.replicas.
+	Fields []string
+	// Managers is the field managers which modified the resource after it was applied. It is only set for
+	// the resources applied with ServerSideApply.
+	Managers []string
+}
+
+// Summary returns a bounded summary of the drifted fields.
+func (d *Drift) Summary() string {
+	summary := fieldsSummary(d.Fields)
+	if len(d.Managers) > 0 {
+		summary = fmt.Sprintf("%s modified by %s", summary, strings.Join(d.Managers, ", "))
+	}
+	return summary
+}
+
+// fieldsSummary returns the field paths in a bounded length.
+func fieldsSummary(fields []string) string {
+	if len(fields) > maxDriftedFieldsInSummary {
+		fields = append(fields[:maxDriftedFieldsInSummary:maxDriftedFieldsInSummary],
+			fmt.Sprintf("and %d more", len(fields)-maxDriftedFieldsInSummary))
+	}
+	return fmt.Sprintf("fields %s", strings.Join(fields, ", "))
+}
+
+// DriftDetector detects whether a resource applied by the work agent is modified on the managed cluster.
+type DriftDetector struct {
+	client dynamic.Interface
+	// cache records the hash of the required and the resourceVersion of the resource after it was applied
+	// with the Update strategy.
+	cache *resourceCache
+}
+
+func NewDriftDetector(client dynamic.Interface) *DriftDetector {
+	return &DriftDetector{
+		client: client,
+		// TODO we did not gc resources in cache, which may cause more memory usage. It
+		// should be refactored in the future.
+		cache: NewResourceCache(),
+	}
+}
+
+// Detect compares the existing resource with the required, and returns the drift and the existing resource.
+// A nil drift is returned if the resource is not found, has not been applied by the agent, or is not modified.
+//
+// For the Update strategy, the resource is drifted if the required is the same as the last applied one but the
+// resourceVersion of the resource is changed since then, and the required fields are not equal. Since the last
+// applied state is kept in memory, the drift happened before the agent restarts is not detected.
+//
+// For the ServerSideApply strategy, the resource is drifted if other field managers updated the resource after
+// the field manager of the agent applied it, and the required fields are not equal. The ignored fields are not
+// compared.
+func (d *DriftDetector) Detect(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	required *unstructured.Unstructured,
+	applyOption *workapiv1.ManifestConfigOption) (*Drift, *unstructured.Unstructured, error) {
+	existing, err := d.client.Resource(gvr).Namespace(required.GetNamespace()).Get(ctx, required.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	drift := &Drift{}
+	var ignoreFields []string
+	if applyOption != nil && applyOption.UpdateStrategy != nil &&
+		applyOption.UpdateStrategy.Type == workapiv1.UpdateStrategyTypeServerSideApply {
+		fieldManager := workapiv1.DefaultFieldManager
+		if ssa := applyOption.UpdateStrategy.ServerSideApply; ssa != nil {
+			if len(ssa.FieldManager) > 0 {
+				fieldManager = ssa.FieldManager
+			}
+			for _, field := range ssa.IgnoreFields {
+				for _, path := range field.JSONPaths {
+					ignoreFields = append(ignoreFields, strings.TrimPrefix(path, "."))
+				}
+			}
+		}
+		drift.Managers = modifiedByManagers(existing, fieldManager)
+		if len(drift.Managers) == 0 {
+			return nil, existing, nil
+		}
+	} else if !d.cache.ModifiedSinceApplied(required, existing) {
+		return nil, existing, nil
+	}
+
+	drift.Fields = driftedFields(required.Object, existing.Object, "", ignoreFields)
+	if len(drift.Fields) == 0 {
+		return nil, existing, nil
+	}
+	sort.Strings(drift.Fields)
+	return drift, existing, nil
+}
+
+// Applied records the required and the resource returned by the apply as the last applied state.
+func (d *DriftDetector) Applied(required, actual runtime.Object) {
+	d.cache.UpdateCachedResourceMetadata(required, actual)
+}
+
+// modifiedByManagers returns the sorted names of the field managers which updated the resource after the field
+// manager applied it. Nil is returned if the resource has not been applied by the field manager.
+func modifiedByManagers(existing *unstructured.Unstructured, fieldManager string) []string {
+	var applied *metav1.ManagedFieldsEntry
+	managedFields := existing.GetManagedFields()
+	for i := range managedFields {
+		if managedFields[i].Manager == fieldManager && managedFields[i].Operation == metav1.ManagedFieldsOperationApply {
+			applied = &managedFields[i]
+			break
+		}
+	}
+	if applied == nil {
+		return nil
+	}
+
+	managers := map[string]bool{}
+	for _, entry := range managedFields {
+		if entry.Manager == fieldManager || entry.Operation != metav1.ManagedFieldsOperationUpdate {
+			continue
+		}
+		// the status is not applied by the field manager.
+		if entry.Subresource == "status" {
+			continue
+		}
+		if entry.Time != nil && applied.Time != nil && entry.Time.Before(applied.Time) {
+			continue
+		}
+		managers[entry.Manager] = true
+	}
+
+	var names []string
+	for name := range managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// driftedFields returns the paths of the fields in the required which are not equal to the existing. The
+// metadata except labels and annotations and the status are not compared. Only the fields set in the required
+// are compared, so the fields defaulted or added by the managed cluster are not considered as drifted, see
+// driftedValue for how the lists are compared.
+func driftedFields(required, existing map[string]interface{}, prefix string, ignoreFields []string) []string {
+	var fields []string
+	for key, requiredValue := range required {
+		path := key
+		if len(prefix) > 0 {
+			path = prefix + "." + key
+		}
+		if ignored(path, ignoreFields) {
+			continue
+		}
+		if len(prefix) == 0 && key == "status" {
+			continue
+		}
+		if path == "metadata" {
+			requiredMetadata, _ := requiredValue.(map[string]interface{})
+			existingMetadata, _ := existing["metadata"].(map[string]interface{})
+			for _, metadataKey := range []string{"labels", "annotations"} {
+				requiredMap, _ := requiredMetadata[metadataKey].(map[string]interface{})
+				existingMap, _ := existingMetadata[metadataKey].(map[string]interface{})
+				fields = append(fields, driftedFields(requiredMap, existingMap, "metadata."+metadataKey, ignoreFields)...)
+			}
+			continue
+		}
+
+		existingValue, found := existing[key]
+		if !found {
+			fields = append(fields, path)
+			continue
+		}
+		fields = append(fields, driftedValue(requiredValue, existingValue, path, ignoreFields)...)
+	}
+	return fields
+}
+
+// driftedValue returns the paths of the drifted fields of a value. The maps are compared by the fields set in
+// the required. The items of the lists of maps are matched by the merge key "name" if all the items of the
+// required have it, and by the index otherwise, and the matched items are compared in the same way, so the
+// fields defaulted in the items, such as imagePullPolicy of a container, are not considered as drifted. The
+// items only in the existing are ignored when they are matched by the merge key. The other lists are compared
+// as a whole.
+func driftedValue(requiredValue, existingValue interface{}, path string, ignoreFields []string) []string {
+	requiredMap, requiredIsMap := requiredValue.(map[string]interface{})
+	existingMap, existingIsMap := existingValue.(map[string]interface{})
+	if requiredIsMap && existingIsMap {
+		return driftedFields(requiredMap, existingMap, path, ignoreFields)
+	}
+
+	requiredList, requiredIsList := requiredValue.([]interface{})
+	existingList, existingIsList := existingValue.([]interface{})
+	if requiredIsList && existingIsList && isMapList(requiredList) && isMapList(existingList) {
+		if keyed(requiredList) {
+			return driftedKeyedList(requiredList, existingList, path, ignoreFields)
+		}
+		if len(requiredList) == len(existingList) {
+			var fields []string
+			for i := range requiredList {
+				fields = append(fields, driftedValue(requiredList[i], existingList[i], fmt.Sprintf("%s[%d]", path, i), ignoreFields)...)
+			}
+			return fields
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(requiredValue, existingValue) {
+		return []string{path}
+	}
+	return nil
+}
+
+// listMergeKey is the key to match the items of a list of maps, which is the merge key of most of the lists in
+// the kubernetes apis, such as containers, env and volumes.
+const listMergeKey = "name"
+
+// driftedKeyedList matches the items of the lists by the merge key, and returns the paths of the drifted fields
+// of the matched items, and the paths of the items of the required which are not found in the existing.
+func driftedKeyedList(requiredList, existingList []interface{}, path string, ignoreFields []string) []string {
+	existingItems := map[interface{}]interface{}{}
+	for _, item := range existingList {
+		if key, ok := item.(map[string]interface{})[listMergeKey]; ok {
+			existingItems[key] = item
+		}
+	}
+
+	var fields []string
+	for _, item := range requiredList {
+		key := item.(map[string]interface{})[listMergeKey]
+		itemPath := fmt.Sprintf("%s[%v]", path, key)
+		existingItem, ok := existingItems[key]
+		if !ok {
+			fields = append(fields, itemPath)
+			continue
+		}
+		fields = append(fields, driftedValue(item, existingItem, itemPath, ignoreFields)...)
+	}
+	return fields
+}
+
+func isMapList(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// keyed returns true if all the items have a scalar merge key.
+func keyed(list []interface{}) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		switch item.(map[string]interface{})[listMergeKey].(type) {
+		case string, int64, bool, float64:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func ignored(path string, ignoreFields []string) bool {
+	for _, field := range ignoreFields {
+		if path == field || strings.HasPrefix(path, field+".") || strings.HasPrefix(path, field+"[") {
+			return true
+		}
+	}
+	return false
+}
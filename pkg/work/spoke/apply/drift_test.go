@@ -0,0 +1,227 @@
+package apply
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func newDriftDeployment(replicas int64, image, resourceVersion string, managedFields ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", "deploy", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "app", "image": image},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	})
+	obj.SetLabels(map[string]string{"app": "deploy"})
+	obj.SetResourceVersion(resourceVersion)
+	obj.SetManagedFields(managedFields)
+	return obj
+}
+
+// newDefaultedDriftDeployment returns the deployment defaulted by the api server, with the revision annotation
+// set by the kube-controller-manager.
+func newDefaultedDriftDeployment(resourceVersion string, managedFields ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	obj := newDriftDeployment(1, "app:v1", resourceVersion, managedFields...)
+	obj.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "1"})
+	obj.Object["spec"] = map[string]interface{}{
+		"replicas":                int64(1),
+		"revisionHistoryLimit":    int64(10),
+		"progressDeadlineSeconds": int64(600),
+		"strategy": map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"creationTimestamp": nil},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{
+						"name":                     "app",
+						"image":                    "app:v1",
+						"imagePullPolicy":          "IfNotPresent",
+						"resources":                map[string]interface{}{},
+						"terminationMessagePath":   "/dev/termination-log",
+						"terminationMessagePolicy": "File",
+					},
+				},
+				"dnsPolicy":                     "ClusterFirst",
+				"restartPolicy":                 "Always",
+				"schedulerName":                 "default-scheduler",
+				"securityContext":               map[string]interface{}{},
+				"terminationGracePeriodSeconds": int64(30),
+			},
+		},
+	}
+	return obj
+}
+
+func newManagedFieldsEntry(manager string, operation metav1.ManagedFieldsOperationType, t time.Time) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{Manager: manager, Operation: operation, Time: &metav1.Time{Time: t}}
+}
+
+func TestDetectDrift(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	now := time.Now()
+	ssaOption := &workapiv1.ManifestConfigOption{
+		UpdateStrategy: &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeServerSideApply},
+	}
+
+	cases := []struct {
+		name             string
+		existing         *unstructured.Unstructured
+		applied          *unstructured.Unstructured
+		option           *workapiv1.ManifestConfigOption
+		expectedFields   []string
+		expectedManagers []string
+	}{
+		{
+			name: "resource is not found",
+		},
+		{
+			name:     "resource is not applied by the agent",
+			existing: newDriftDeployment(2, "app:v2", "2"),
+		},
+		{
+			name:     "resource is not modified since applied",
+			existing: newDriftDeployment(1, "app:v1", "1"),
+			applied:  newDriftDeployment(1, "app:v1", "1"),
+		},
+		{
+			name:     "only status is modified since applied",
+			existing: newDriftDeployment(1, "app:v1", "2"),
+			applied:  newDriftDeployment(1, "app:v1", "1"),
+		},
+		{
+			name:           "resource is modified since applied",
+			existing:       newDriftDeployment(3, "app:v2", "2"),
+			applied:        newDriftDeployment(1, "app:v1", "1"),
+			expectedFields: []string{"spec.replicas", "spec.template.spec.containers[app].image"},
+		},
+		{
+			name:     "defaulted resource is updated by the status writes",
+			existing: newDefaultedDriftDeployment("3"),
+			applied:  newDefaultedDriftDeployment("1"),
+		},
+		{
+			name: "defaulted resource is updated by the kube-controller-manager",
+			existing: newDefaultedDriftDeployment("2",
+				newManagedFieldsEntry(workapiv1.DefaultFieldManager, metav1.ManagedFieldsOperationApply, now),
+				newManagedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, now.Add(time.Minute))),
+			option: ssaOption,
+		},
+		{
+			name: "container of the defaulted resource is modified",
+			existing: func() *unstructured.Unstructured {
+				obj := newDefaultedDriftDeployment("2",
+					newManagedFieldsEntry(workapiv1.DefaultFieldManager, metav1.ManagedFieldsOperationApply, now),
+					newManagedFieldsEntry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, now.Add(time.Minute)))
+				containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+				containers[0].(map[string]interface{})["image"] = "app:v2"
+				containers = append(containers, map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"})
+				_ = unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+				return obj
+			}(),
+			option:           ssaOption,
+			expectedFields:   []string{"spec.template.spec.containers[app].image"},
+			expectedManagers: []string{"kubectl-edit"},
+		},
+		{
+			name: "resource is not updated by other managers",
+			existing: newDriftDeployment(3, "app:v1", "2",
+				newManagedFieldsEntry(workapiv1.DefaultFieldManager, metav1.ManagedFieldsOperationApply, now),
+				newManagedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, now.Add(-time.Minute))),
+			option: ssaOption,
+		},
+		{
+			name: "resource is updated by other managers",
+			existing: newDriftDeployment(3, "app:v1", "2",
+				newManagedFieldsEntry(workapiv1.DefaultFieldManager, metav1.ManagedFieldsOperationApply, now),
+				newManagedFieldsEntry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, now.Add(time.Minute))),
+			option:           ssaOption,
+			expectedFields:   []string{"spec.replicas"},
+			expectedManagers: []string{"kubectl-edit"},
+		},
+		{
+			name: "drifted fields are ignored",
+			existing: newDriftDeployment(3, "app:v1", "2",
+				newManagedFieldsEntry(workapiv1.DefaultFieldManager, metav1.ManagedFieldsOperationApply, now),
+				newManagedFieldsEntry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, now.Add(time.Minute))),
+			option: &workapiv1.ManifestConfigOption{
+				UpdateStrategy: &workapiv1.UpdateStrategy{
+					Type: workapiv1.UpdateStrategyTypeServerSideApply,
+					ServerSideApply: &workapiv1.ServerSideApplyConfig{
+						IgnoreFields: []workapiv1.IgnoreField{{JSONPaths: []string{".spec.replicas"}}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if c.existing != nil {
+				objects = append(objects, c.existing)
+			}
+			detector := NewDriftDetector(fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...))
+			required := newDriftDeployment(1, "app:v1", "")
+			required.SetManagedFields(nil)
+			if c.applied != nil {
+				detector.Applied(required, c.applied)
+			}
+
+			drift, _, err := detector.Detect(context.TODO(), gvr, required, c.option)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(c.expectedFields) == 0 {
+				if drift != nil {
+					t.Errorf("expected no drift, but got %v", drift)
+				}
+				return
+			}
+			if drift == nil {
+				t.Fatalf("expected drift, but got nil")
+			}
+			if !reflect.DeepEqual(drift.Fields, c.expectedFields) {
+				t.Errorf("expected fields %v, but got %v", c.expectedFields, drift.Fields)
+			}
+			if !reflect.DeepEqual(drift.Managers, c.expectedManagers) {
+				t.Errorf("expected managers %v, but got %v", c.expectedManagers, drift.Managers)
+			}
+		})
+	}
+}
+
+func TestDriftSummary(t *testing.T) {
+	drift := &Drift{
+		Fields:   []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"},
+		Managers: []string{"kubectl-edit"},
+	}
+	expected := "fields a, b, c, d, e, f, g, h, i, j, and 2 more modified by kubectl-edit"
+	if summary := drift.Summary(); summary != expected {
+		t.Errorf("expected summary %q, but got %q", expected, summary)
+	}
+	if len(drift.Fields) != 12 {
+		t.Errorf("the fields should not be changed, but got %v", drift.Fields)
+	}
+}
@@ -0,0 +1,153 @@
+package apply
+
+import (
+	"context"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// DryRunOperation is the operation that applying the manifest would perform on the managed cluster.
+type DryRunOperation string
+
+const (
+	DryRunCreate   DryRunOperation = "Create"
+	DryRunUpdate   DryRunOperation = "Update"
+	DryRunNoChange DryRunOperation = "NoChange"
+)
+
+// DryRunResult is the outcome of applying a manifest in the dry run mode.
+type DryRunResult struct {
+	Operation DryRunOperation
+	// Fields is the sorted paths of the fields which would be changed by an update.
+	Fields []string
+}
+
+// Summary returns a bounded summary of the fields which would be changed.
+func (r *DryRunResult) Summary() string {
+	return fieldsSummary(r.Fields)
+}
+
+// DryRunApply applies the manifest with the same update strategy as the appliers, but with the DryRun All
+// option, so the request is validated and mutated by the admission of the managed cluster without being
+// persisted. The owner reference of the AppliedManifestWork is not added since the resource is not owned by
+// the work.
+type DryRunApply struct {
+	client dynamic.Interface
+}
+
+func NewDryRunApply(client dynamic.Interface) *DryRunApply {
+	return &DryRunApply{client: client}
+}
+
+// DryRun returns the resource that the apply would result in and the operation it would perform. The error
+// returned by the api server, such as the rejection of an admission webhook, is returned as is.
+func (d *DryRunApply) DryRun(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	required *unstructured.Unstructured,
+	applyOption *workapiv1.ManifestConfigOption) (*unstructured.Unstructured, *DryRunResult, error) {
+	existing, err := d.client.Resource(gvr).Namespace(required.GetNamespace()).Get(ctx, required.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return nil, nil, err
+	}
+
+	strategy := workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeUpdate}
+	if applyOption != nil && applyOption.UpdateStrategy != nil {
+		strategy = *applyOption.UpdateStrategy
+	}
+
+	var actual *unstructured.Unstructured
+	switch {
+	case strategy.Type == workapiv1.UpdateStrategyTypeServerSideApply:
+		actual, err = d.serverSideApply(ctx, gvr, required, existing, strategy.ServerSideApply)
+	case existing == nil:
+		actual, err = d.client.Resource(gvr).Namespace(required.GetNamespace()).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(required.DeepCopy()).(*unstructured.Unstructured),
+			metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	case strategy.Type == workapiv1.UpdateStrategyTypeCreateOnly:
+		return existing, &DryRunResult{Operation: DryRunNoChange}, nil
+	default:
+		actual, err = d.update(ctx, gvr, required, existing)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if existing == nil {
+		return actual, &DryRunResult{Operation: DryRunCreate}, nil
+	}
+	fields := sets.New(driftedFields(actual.Object, existing.Object, "", nil)...).
+		Insert(driftedFields(existing.Object, actual.Object, "", nil)...)
+	if fields.Len() == 0 {
+		return actual, &DryRunResult{Operation: DryRunNoChange}, nil
+	}
+	return actual, &DryRunResult{Operation: DryRunUpdate, Fields: sets.List(fields)}, nil
+}
+
+// serverSideApply applies the required with the field manager of the strategy, the ignored fields are removed
+// from the required if the resource exists.
+func (d *DryRunApply) serverSideApply(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	required, existing *unstructured.Unstructured,
+	strategy *workapiv1.ServerSideApplyConfig) (*unstructured.Unstructured, error) {
+	logger := klog.FromContext(ctx)
+	required = required.DeepCopy()
+	removeCreationTimeFromMetadata(required.Object, logger)
+
+	force := false
+	fieldManager := workapiv1.DefaultFieldManager
+	if strategy != nil {
+		force = strategy.Force
+		if len(strategy.FieldManager) > 0 {
+			fieldManager = strategy.FieldManager
+		}
+		for _, field := range strategy.IgnoreFields {
+			if existing == nil || field.Condition == workapiv1.IgnoreFieldsConditionOnSpokeChange {
+				continue
+			}
+			for _, path := range field.JSONPaths {
+				removeFieldByJSONPath(required.UnstructuredContent(), path, logger)
+			}
+		}
+	}
+
+	return d.client.Resource(gvr).Namespace(required.GetNamespace()).Apply(ctx, required.GetName(), required,
+		metav1.ApplyOptions{FieldManager: fieldManager, Force: force, DryRun: []string{metav1.DryRunAll}})
+}
+
+// update updates the existing with the required in the same way as UpdateApply, the labels, annotations and
+// owner references of the existing are kept.
+func (d *DryRunApply) update(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	required, existing *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	required = required.DeepCopy()
+
+	labels := existing.GetLabels()
+	annotations := existing.GetAnnotations()
+	resourcemerge.MergeMap(pointer.Bool(false), &labels, required.GetLabels())
+	resourcemerge.MergeMap(pointer.Bool(false), &annotations, required.GetAnnotations())
+
+	required.SetLabels(labels)
+	required.SetAnnotations(annotations)
+	required.SetOwnerReferences(existing.GetOwnerReferences())
+	required.SetFinalizers(existing.GetFinalizers())
+	required.SetResourceVersion(existing.GetResourceVersion())
+
+	return d.client.Resource(gvr).Namespace(required.GetNamespace()).Update(
+		ctx, required, metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}})
+}
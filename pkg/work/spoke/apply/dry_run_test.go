@@ -0,0 +1,104 @@
+package apply
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func TestDryRun(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newConfigMap := func(data map[string]interface{}) *unstructured.Unstructured {
+		return testingcommon.NewUnstructuredWithContent("v1", "ConfigMap", "ns1", "test", map[string]interface{}{"data": data})
+	}
+
+	cases := []struct {
+		name              string
+		strategy          workapiv1.UpdateStrategyType
+		existing          *unstructured.Unstructured
+		required          *unstructured.Unstructured
+		expectedOperation DryRunOperation
+		expectedFields    []string
+		expectedVerbs     []string
+	}{
+		{
+			name:              "create",
+			strategy:          workapiv1.UpdateStrategyTypeUpdate,
+			required:          newConfigMap(map[string]interface{}{"key1": "val1"}),
+			expectedOperation: DryRunCreate,
+			expectedVerbs:     []string{"get", "create"},
+		},
+		{
+			name:              "update",
+			strategy:          workapiv1.UpdateStrategyTypeUpdate,
+			existing:          newConfigMap(map[string]interface{}{"key1": "val1", "key2": "val2"}),
+			required:          newConfigMap(map[string]interface{}{"key1": "val2"}),
+			expectedOperation: DryRunUpdate,
+			expectedFields:    []string{"data.key1", "data.key2"},
+			expectedVerbs:     []string{"get", "update"},
+		},
+		{
+			name:              "create only with existing resource",
+			strategy:          workapiv1.UpdateStrategyTypeCreateOnly,
+			existing:          newConfigMap(map[string]interface{}{"key1": "val1"}),
+			required:          newConfigMap(map[string]interface{}{"key1": "val2"}),
+			expectedOperation: DryRunNoChange,
+			expectedVerbs:     []string{"get"},
+		},
+		{
+			name:              "server side apply without change",
+			strategy:          workapiv1.UpdateStrategyTypeServerSideApply,
+			existing:          newConfigMap(map[string]interface{}{"key1": "val1"}),
+			required:          newConfigMap(map[string]interface{}{"key1": "val1"}),
+			expectedOperation: DryRunNoChange,
+			expectedVerbs:     []string{"get", "patch"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if c.existing != nil {
+				objects = append(objects, c.existing)
+			}
+			client := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+			// the fake client does not support dry run, return the object without persisting it.
+			reactor := func(action clienttesting.Action) (bool, runtime.Object, error) {
+				switch a := action.(type) {
+				case clienttesting.CreateAction:
+					return true, a.GetObject(), nil
+				case clienttesting.UpdateAction:
+					return true, a.GetObject(), nil
+				default:
+					return true, c.required, nil
+				}
+			}
+			for _, verb := range []string{"create", "update", "patch"} {
+				client.PrependReactor(verb, "configmaps", reactor)
+			}
+
+			option := &workapiv1.ManifestConfigOption{UpdateStrategy: &workapiv1.UpdateStrategy{Type: c.strategy}}
+			_, result, err := NewDryRunApply(client).DryRun(context.TODO(), gvr, c.required, option)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Operation != c.expectedOperation {
+				t.Errorf("expected operation %s, but got %s", c.expectedOperation, result.Operation)
+			}
+			if !reflect.DeepEqual(result.Fields, c.expectedFields) {
+				t.Errorf("expected fields %v, but got %v", c.expectedFields, result.Fields)
+			}
+			testingcommon.AssertActions(t, client.Actions(), c.expectedVerbs...)
+		})
+	}
+}
@@ -155,6 +155,42 @@ func (c *resourceCache) SafeToSkipApply(required runtime.Object, existing runtim
 	return false
 }
 
+// ModifiedSinceApplied returns true if the 'required' is the same one which was previously applied for a given
+// (name, kind, namespace), but the existing resource has been modified since then. It returns false if the
+// required has not been applied or has been changed.
+func (c *resourceCache) ModifiedSinceApplied(required runtime.Object, existing runtime.Object) bool {
+	if c == nil {
+		return false
+	}
+	if required == nil || existing == nil {
+		return false
+	}
+	kind, name, namespace, resourceHash, err := getResourceMetadata(required)
+	if err != nil {
+		return false
+	}
+	cacheKey := cachedVersionKey{
+		name:      name,
+		namespace: namespace,
+		kind:      kind,
+	}
+
+	resourceVersion, err := getResourceVersion(existing)
+	if err != nil {
+		return false
+	}
+
+	value, ok := c.cache.Load(cacheKey)
+	if !ok {
+		return false
+	}
+	cached, ok := value.(cachedResource)
+	if !ok {
+		return false
+	}
+	return cached.resourceHash == resourceHash && cached.resourceVersion != resourceVersion
+}
+
 // TODO find  way to create a registry of these based on struct mapping or some such that forces users to get this right
 //
 //	for creating an ApplyGeneric
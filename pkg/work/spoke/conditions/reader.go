@@ -48,6 +48,11 @@ func NewConditionReader() (*ConditionReader, error) {
 	}, nil
 }
 
+func (s *ConditionReader) WithWellKnownConditions(resolver rules.WellKnownConditionRuleResolver) *ConditionReader {
+	s.wellKnownConditions = resolver
+	return s
+}
+
 func (s *ConditionReader) EvaluateConditions(ctx context.Context, obj *unstructured.Unstructured, rules []workapiv1.ConditionRule) []metav1.Condition {
 	var conditionResults []metav1.Condition
 	remainingBudget := globalCostBudget
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
@@ -49,3 +51,35 @@ func (w *defaultWellKnownConditionResolver) GetRuleByKindCondition(gvk schema.Gr
 	}
 	return workapiv1.ConditionRule{}
 }
+
+// ConfigurableWellKnownConditionResolver resolves the well known condition rules from the default rules and the
+// custom rules which could be updated at runtime. The custom rule of a kind and condition overrides the default one.
+type ConfigurableWellKnownConditionResolver struct {
+	defaults WellKnownConditionRuleResolver
+
+	lock   sync.RWMutex
+	custom map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule
+}
+
+func NewConfigurableWellKnownConditionResolver() *ConfigurableWellKnownConditionResolver {
+	return &ConfigurableWellKnownConditionResolver{
+		defaults: DefaultWellKnownConditionResolver(),
+		custom:   map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule{},
+	}
+}
+
+// SetCustomRules replaces the custom rules.
+func (w *ConfigurableWellKnownConditionResolver) SetCustomRules(rules map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.custom = rules
+}
+
+func (w *ConfigurableWellKnownConditionResolver) GetRuleByKindCondition(gvk schema.GroupVersionKind, condition string) workapiv1.ConditionRule {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if rule, ok := w.custom[gvk][condition]; ok {
+		return rule
+	}
+	return w.defaults.GetRuleByKindCondition(gvk, condition)
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/openshift/library-go/pkg/controller/factory"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/util/workqueue"
@@ -31,6 +33,11 @@ type AppliedManifestWorkFinalizeController struct {
 	appliedManifestWorkLister worklister.AppliedManifestWorkLister
 	spokeDynamicClient        dynamic.Interface
 	rateLimiter               workqueue.RateLimiter
+	// workPatcher and manifestWorkLister are used to show the deletion progress on the ManifestWork of the
+	// hub with the hubHash.
+	workPatcher        patcher.Patcher[*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus]
+	manifestWorkLister worklister.ManifestWorkNamespaceLister
+	hubHash            string
 }
 
 func NewAppliedManifestWorkFinalizeController(
@@ -38,7 +45,9 @@ func NewAppliedManifestWorkFinalizeController(
 	spokeDynamicClient dynamic.Interface,
 	appliedManifestWorkClient workv1client.AppliedManifestWorkInterface,
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
-	agentID string,
+	manifestWorkClient workv1client.ManifestWorkInterface,
+	manifestWorkLister worklister.ManifestWorkNamespaceLister,
+	agentID, hubHash string,
 ) factory.Controller {
 
 	controller := &AppliedManifestWorkFinalizeController{
@@ -48,6 +57,11 @@ func NewAppliedManifestWorkFinalizeController(
 		appliedManifestWorkLister: appliedManifestWorkInformer.Lister(),
 		spokeDynamicClient:        spokeDynamicClient,
 		rateLimiter:               workqueue.NewItemExponentialFailureRateLimiter(5*time.Millisecond, 1000*time.Second),
+		workPatcher: patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			manifestWorkClient),
+		manifestWorkLister: manifestWorkLister,
+		hubHash:            hubHash,
 	}
 
 	return factory.New().
@@ -94,7 +108,7 @@ func (m *AppliedManifestWorkFinalizeController) syncAppliedManifestWork(ctx cont
 	// We still need to run delete for every resource even with ownerref on it, since ownerref does not handle cluster
 	// scoped resource correctly.
 	reason := fmt.Sprintf("manifestwork %s is terminating", appliedManifestWork.Spec.ManifestWorkName)
-	resourcesPendingFinalization, errs := helper.DeleteAppliedResources(
+	resourcesPendingFinalization, progress, errs := helper.DeleteAppliedResourcesInWaves(
 		ctx, appliedManifestWork.Status.AppliedResources, reason, m.spokeDynamicClient, controllerContext.Recorder(), *owner)
 	appliedManifestWork.Status.AppliedResources = resourcesPendingFinalization
 	updatedAppliedManifestWork, err := m.patcher.PatchStatus(ctx, appliedManifestWork, appliedManifestWork.Status, originalManifestWork.Status)
@@ -103,6 +117,14 @@ func (m *AppliedManifestWorkFinalizeController) syncAppliedManifestWork(ctx cont
 			"failed to update status of AppliedManifestWork %s: %w", originalManifestWork.Name, err))
 	}
 
+	// show the deletion progress on the ManifestWork if the resources are deleted in waves
+	if len(resourcesPendingFinalization) != 0 {
+		if err := m.updateDeletionProgress(ctx, appliedManifestWork, progress); err != nil {
+			errs = append(errs, fmt.Errorf(
+				"failed to update deletion progress of ManifestWork %s: %w", appliedManifestWork.Spec.ManifestWorkName, err))
+		}
+	}
+
 	// return quickly when there is update event or err
 	if updatedAppliedManifestWork || len(errs) != 0 {
 		return utilerrors.NewAggregate(errs)
@@ -123,3 +145,37 @@ func (m *AppliedManifestWorkFinalizeController) syncAppliedManifestWork(ctx cont
 	}
 	return nil
 }
+
+// updateDeletionProgress sets the Deleting condition of the ManifestWork with the deletion progress. The condition
+// is only added when the resources are deleted in more than one wave, and it is skipped if the ManifestWork is
+// not found since the AppliedManifestWork is evicted.
+func (m *AppliedManifestWorkFinalizeController) updateDeletionProgress(ctx context.Context,
+	appliedManifestWork *workapiv1.AppliedManifestWork, progress *helper.DeletionProgress) error {
+	if m.manifestWorkLister == nil || appliedManifestWork.Spec.HubHash != m.hubHash {
+		return nil
+	}
+
+	manifestWork, err := m.manifestWorkLister.Get(appliedManifestWork.Spec.ManifestWorkName)
+	switch {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	inWaves := progress.Wave != 0 || progress.Waiting != 0
+	if !inWaves && meta.FindStatusCondition(manifestWork.Status.Conditions, helper.WorkDeleting) == nil {
+		return nil
+	}
+
+	newManifestWork := manifestWork.DeepCopy()
+	meta.SetStatusCondition(&newManifestWork.Status.Conditions, metav1.Condition{
+		Type:               helper.WorkDeleting,
+		Status:             metav1.ConditionTrue,
+		Reason:             helper.DeletingInWavesReason,
+		Message:            progress.String(),
+		ObservedGeneration: manifestWork.Generation,
+	})
+	_, err = m.workPatcher.PatchStatus(ctx, newManifestWork, newManifestWork.Status, manifestWork.Status)
+	return err
+}
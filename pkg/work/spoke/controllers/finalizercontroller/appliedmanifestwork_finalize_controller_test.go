@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -17,6 +18,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 	"open-cluster-management.io/sdk-go/pkg/patcher"
 
@@ -209,3 +211,91 @@ func TestFinalize(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateDeletionProgress(t *testing.T) {
+	deleting := metav1.Condition{Type: helper.WorkDeleting, Status: metav1.ConditionTrue, Reason: helper.DeletingInWavesReason}
+
+	cases := []struct {
+		name               string
+		hubHash            string
+		existingConditions []metav1.Condition
+		progress           helper.DeletionProgress
+		noManifestWork     bool
+		expectedMessage    string
+	}{
+		{
+			name:            "resources are deleted in waves",
+			hubHash:         "test",
+			progress:        helper.DeletionProgress{Wave: 1, Finalizing: 1, Waiting: 2},
+			expectedMessage: "deleting wave 1: 1 resources pending finalization, 2 resources waiting",
+		},
+		{
+			name:     "resources are not deleted in waves",
+			hubHash:  "test",
+			progress: helper.DeletionProgress{Finalizing: 2},
+		},
+		{
+			name:               "the last wave is deleting",
+			hubHash:            "test",
+			existingConditions: []metav1.Condition{deleting},
+			progress:           helper.DeletionProgress{Finalizing: 2},
+			expectedMessage:    "deleting wave 0: 2 resources pending finalization, 0 resources waiting",
+		},
+		{
+			name:           "appliedmanifestwork is evicted",
+			hubHash:        "test",
+			progress:       helper.DeletionProgress{Wave: 1, Finalizing: 1},
+			noManifestWork: true,
+		},
+		{
+			name:     "appliedmanifestwork of another hub",
+			hubHash:  "another",
+			progress: helper.DeletionProgress{Wave: 1, Finalizing: 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, types.UID("test"))
+			work, _ := spoketesting.NewManifestWork(0)
+			work.Status.Conditions = c.existingConditions
+
+			var objects []runtime.Object
+			if !c.noManifestWork {
+				objects = append(objects, work)
+			}
+			fakeClient := fakeworkclient.NewSimpleClientset(objects...)
+			informerFactory := workinformers.NewSharedInformerFactory(fakeClient, 5*time.Minute)
+			for _, obj := range objects {
+				if err := informerFactory.Work().V1().ManifestWorks().Informer().GetStore().Add(obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			controller := AppliedManifestWorkFinalizeController{
+				workPatcher: patcher.NewPatcher[
+					*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+					fakeClient.WorkV1().ManifestWorks(work.Namespace)),
+				manifestWorkLister: informerFactory.Work().V1().ManifestWorks().Lister().ManifestWorks(work.Namespace),
+				hubHash:            c.hubHash,
+			}
+			if err := controller.updateDeletionProgress(context.TODO(), appliedWork, &c.progress); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(c.expectedMessage) == 0 {
+				testingcommon.AssertNoActions(t, fakeClient.Actions())
+				return
+			}
+			testingcommon.AssertActions(t, fakeClient.Actions(), "patch")
+			patched := &workapiv1.ManifestWork{}
+			if err := json.Unmarshal(fakeClient.Actions()[0].(clienttesting.PatchActionImpl).Patch, patched); err != nil {
+				t.Fatal(err)
+			}
+			cond := meta.FindStatusCondition(patched.Status.Conditions, helper.WorkDeleting)
+			if cond == nil || cond.Message != c.expectedMessage {
+				t.Errorf("expected Deleting condition with message %q, but got %v", c.expectedMessage, cond)
+			}
+		})
+	}
+}
@@ -36,6 +36,11 @@ func (m *appliedManifestWorkReconciler) reconcile(
 		return manifestWork, appliedManifestWork, nil
 	}
 
+	// keep the applied resources of the paused work, none of them is deleted until the work is resumed.
+	if helper.IsWorkPaused(manifestWork) {
+		return manifestWork, appliedManifestWork, nil
+	}
+
 	// In a case where a managed cluster switches to a new hub with the same hub hash, the same manifestworks
 	// will be created for this cluster on the new hub without any condition. Once the work agent connects to
 	// the new hub, the applied resources of those manifestwork on this managed cluster should not be removed
@@ -60,6 +65,15 @@ func (m *appliedManifestWorkReconciler) reconcile(
 			continue
 		}
 
+		// the resource of a manifest applied in the dry run mode is not owned by the work, it is still tracked
+		// only if it was applied before, so it is not deleted as an untracked resource.
+		if isDryRun(resourceStatus) {
+			if applied := findAppliedResource(appliedManifestWork.Status.AppliedResources, resourceStatus.ResourceMeta); applied != nil {
+				appliedResources = append(appliedResources, *applied)
+			}
+			continue
+		}
+
 		u, err := m.spokeDynamicClient.
 			Resource(gvr).
 			Namespace(resourceStatus.ResourceMeta.Namespace).
@@ -147,3 +161,17 @@ func (m *appliedManifestWorkReconciler) reconcile(
 	appliedManifestWork.Status.AppliedResources = appliedResources
 	return manifestWork, appliedManifestWork, nil
 }
+
+// findAppliedResource returns the applied resource of the resource meta regardless of the version.
+func findAppliedResource(
+	appliedResources []workapiv1.AppliedManifestResourceMeta,
+	resourceMeta workapiv1.ManifestResourceMeta) *workapiv1.AppliedManifestResourceMeta {
+	for i := range appliedResources {
+		identifier := appliedResources[i].ResourceIdentifier
+		if identifier.Group == resourceMeta.Group && identifier.Resource == resourceMeta.Resource &&
+			identifier.Namespace == resourceMeta.Namespace && identifier.Name == resourceMeta.Name {
+			return &appliedResources[i]
+		}
+	}
+	return nil
+}
@@ -200,6 +200,30 @@ func TestSyncManifestWork(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:    "resources of dry run manifests are not tracked unless applied before",
+			applied: true,
+			existingResources: []runtime.Object{
+				testingcommon.NewUnstructuredSecret("ns1", "n1", false, "ns1-n1", *owner),
+				testingcommon.NewUnstructuredSecret("ns2", "n2", false, "ns2-n2"),
+			},
+			appliedResources: []workapiv1.AppliedManifestResourceMeta{
+				{Version: "v1", ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "secrets", Namespace: "ns1", Name: "n1"}, UID: "ns1-n1"},
+			},
+			manifests: func() []workapiv1.ManifestCondition {
+				manifests := []workapiv1.ManifestCondition{
+					newManifest("", "v1", "secrets", "ns1", "n1"),
+					newManifest("", "v1", "secrets", "ns2", "n2"),
+				}
+				for i := range manifests {
+					manifests[i].Conditions = []metav1.Condition{
+						{Type: workapiv1.ManifestApplied, Status: metav1.ConditionFalse, Reason: ManifestDryRunReason},
+					}
+				}
+				return manifests
+			}(),
+			validateAppliedManifestWorkActions: testingcommon.AssertNoActions,
+		},
 	}
 
 	for _, c := range cases {
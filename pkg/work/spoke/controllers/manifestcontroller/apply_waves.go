@@ -0,0 +1,202 @@
+package manifestcontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// ApplyWaveAnnotationKey is the annotation key on a manifest to set the apply wave of the manifest. The value
+	// is an integer and 0 by default. The manifests in a wave are only applied after all the manifests in the
+	// earlier waves are ready, and are deleted in the reverse order.
+	ApplyWaveAnnotationKey = helper.ApplyWaveAnnotationKey
+
+	// DependsOnAnnotationKey is the annotation key on a manifest to declare the manifests in the same work it
+	// depends on, see helper.DependsOnAnnotationKey.
+	DependsOnAnnotationKey = helper.DependsOnAnnotationKey
+
+	// ApplyWaveReadyConditionAnnotationKey is the annotation key on the ManifestWork to set the condition type
+	// of a manifest which must be true before the manifests depending on it are applied, see
+	// helper.ApplyWaveReadyConditionAnnotationKey.
+	ApplyWaveReadyConditionAnnotationKey = helper.ApplyWaveReadyConditionAnnotationKey
+
+	// ManifestApplyBlockedReason is the reason of the Applied and Progressing conditions of a manifest which is
+	// blocked by an earlier wave or a dependency.
+	ManifestApplyBlockedReason = "ApplyBlocked"
+)
+
+// waveRequeueInterval is the interval to recheck the blocked manifests.
+var waveRequeueInterval = 10 * time.Second
+
+// applyGate decides whether a manifest could be applied according to the apply waves and dependencies.
+type applyGate struct {
+	waves          []int
+	keys           []string
+	dependsOn      [][]string
+	indexByKey     map[string]int
+	readyCondition string
+	// existing is the existing manifest conditions of the manifests, keyed by the manifest index.
+	existing map[int]workapiv1.ManifestCondition
+}
+
+// newApplyGate returns an applyGate of the ManifestWork, or nil if no manifest of the work uses apply waves
+// or dependencies.
+func newApplyGate(manifestWork *workapiv1.ManifestWork) (*applyGate, error) {
+	manifests := manifestWork.Spec.Workload.Manifests
+	gate := &applyGate{
+		waves:          make([]int, len(manifests)),
+		keys:           make([]string, len(manifests)),
+		dependsOn:      make([][]string, len(manifests)),
+		indexByKey:     map[string]int{},
+		readyCondition: workapiv1.ManifestApplied,
+		existing:       map[int]workapiv1.ManifestCondition{},
+	}
+	if condition := manifestWork.Annotations[ApplyWaveReadyConditionAnnotationKey]; len(condition) > 0 {
+		gate.readyCondition = condition
+	}
+
+	used := false
+	for index, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			// the error is reported when the manifest is applied.
+			continue
+		}
+		gate.keys[index] = manifestKey(obj.GroupVersionKind().Group, obj.GetKind(), obj.GetNamespace(), obj.GetName())
+		gate.indexByKey[gate.keys[index]] = index
+
+		wave, ok, err := helper.ManifestApplyWave(obj)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %d: %w", index, err)
+		}
+		if ok {
+			gate.waves[index] = wave
+			used = true
+		}
+		dependencies, ok, err := helper.ManifestDependencies(obj)
+		if err != nil {
+			return nil, fmt.Errorf("manifest %d: %w", index, err)
+		}
+		if ok {
+			gate.dependsOn[index] = dependencies
+			used = true
+		}
+	}
+	if !used {
+		return nil, nil
+	}
+
+	for _, condition := range manifestWork.Status.ResourceStatus.Manifests {
+		gate.existing[int(condition.ResourceMeta.Ordinal)] = condition
+	}
+	return gate, nil
+}
+
+func manifestKey(group, kind, namespace, name string) string {
+	return strings.Join([]string{group, kind, namespace, name}, "/")
+}
+
+// order returns the manifest indexes sorted by wave.
+func (g *applyGate) order() []int {
+	indexes := make([]int, len(g.waves))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		return g.waves[indexes[i]] < g.waves[indexes[j]]
+	})
+	return indexes
+}
+
+// appliedBefore returns true if the manifest has been applied before, such a manifest is never blocked so
+// that the update of the manifests is not blocked by the status of the other manifests.
+func (g *applyGate) appliedBefore(index int) bool {
+	condition, ok := g.existing[index]
+	if !ok || condition.ResourceMeta.Name == "" {
+		return false
+	}
+	return meta.IsStatusConditionTrue(condition.Conditions, workapiv1.ManifestApplied)
+}
+
+// ready returns true if the manifest is applied in this reconcile and the ready condition is true.
+func (g *applyGate) ready(index int, results []applyResult) bool {
+	if results[index].Result == nil || results[index].Error != nil {
+		return false
+	}
+	if g.readyCondition == workapiv1.ManifestApplied {
+		return true
+	}
+	condition, ok := g.existing[index]
+	if !ok {
+		return false
+	}
+	return meta.IsStatusConditionTrue(condition.Conditions, g.readyCondition)
+}
+
+// blockedReason returns the reason why the manifest is blocked, or an empty string if it could be applied.
+func (g *applyGate) blockedReason(index int, results []applyResult) (string, error) {
+	if g.appliedBefore(index) {
+		return "", nil
+	}
+
+	for i, wave := range g.waves {
+		if wave < g.waves[index] && !g.ready(i, results) {
+			return fmt.Sprintf("waiting for wave %d: manifest %d %s is not %s",
+				wave, i, g.keys[i], g.readyCondition), nil
+		}
+	}
+
+	for _, dependency := range g.dependsOn[index] {
+		i, ok := g.indexByKey[dependency]
+		if !ok {
+			return "", fmt.Errorf("dependency %s is not a manifest of the work", dependency)
+		}
+		if !g.ready(i, results) {
+			return fmt.Sprintf("waiting for dependency: manifest %d %s is not %s",
+				i, dependency, g.readyCondition), nil
+		}
+	}
+	return "", nil
+}
+
+// blockedResult returns the result of a blocked manifest with the resource meta but without the resource,
+// since the resource type might not exist before the earlier waves are applied.
+func blockedResult(index int, manifest workapiv1.Manifest, reason string) applyResult {
+	result := applyResult{blockedReason: reason}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+		result.Error = err
+		return result
+	}
+	result.resourceMeta, _, _ = helper.BuildResourceMeta(index, obj, nil)
+	return result
+}
+
+// buildProgressingStatusCondition returns the Progressing condition of a manifest when apply waves are used.
+func buildProgressingStatusCondition(result applyResult) metav1.Condition {
+	if len(result.blockedReason) > 0 {
+		return metav1.Condition{
+			Type:    workapiv1.ManifestProgressing,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestApplyBlockedReason,
+			Message: result.blockedReason,
+		}
+	}
+
+	return metav1.Condition{
+		Type:    workapiv1.ManifestProgressing,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ApplyUnblocked",
+		Message: "Manifest is not blocked by apply waves or dependencies",
+	}
+}
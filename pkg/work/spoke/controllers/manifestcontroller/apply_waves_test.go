@@ -0,0 +1,209 @@
+package manifestcontroller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clienttesting "k8s.io/client-go/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	commonhelper "open-cluster-management.io/ocm/pkg/common/helpers"
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/statuscontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newWaveSecret(name string, annotations map[string]string) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructured("v1", "Secret", "ns1", name)
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestApplyWaves(t *testing.T) {
+	cases := []struct {
+		name                   string
+		manifests              []*unstructured.Unstructured
+		annotations            map[string]string
+		existing               []workapiv1.ManifestCondition
+		expectedCreated        []string
+		expectedBlocked        []int32
+		expectedWorkConditions []metav1.Condition
+		unexpectedConditions   []string
+		expectedError          bool
+	}{
+		{
+			name: "later wave is applied after earlier wave is applied",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("b", map[string]string{ApplyWaveAnnotationKey: "1"}),
+				newWaveSecret("a", map[string]string{ApplyWaveAnnotationKey: "0"}),
+			},
+			expectedCreated: []string{"a", "b"},
+			expectedWorkConditions: []metav1.Condition{
+				expectedCondition(workapiv1.WorkApplied, metav1.ConditionTrue),
+				expectedCondition(workapiv1.WorkProgressing, metav1.ConditionFalse),
+			},
+		},
+		{
+			name: "later wave is blocked until earlier wave is available",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", nil),
+				newWaveSecret("b", map[string]string{ApplyWaveAnnotationKey: "1"}),
+			},
+			annotations:     map[string]string{ApplyWaveReadyConditionAnnotationKey: workapiv1.ManifestAvailable},
+			expectedCreated: []string{"a"},
+			expectedBlocked: []int32{1},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkBlocked"},
+				expectedCondition(workapiv1.WorkProgressing, metav1.ConditionTrue),
+			},
+		},
+		{
+			name: "later wave is applied when earlier wave is available",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", nil),
+				newWaveSecret("b", map[string]string{ApplyWaveAnnotationKey: "1"}),
+			},
+			annotations: map[string]string{ApplyWaveReadyConditionAnnotationKey: workapiv1.ManifestAvailable},
+			existing: []workapiv1.ManifestCondition{
+				{
+					ResourceMeta: workapiv1.ManifestResourceMeta{Ordinal: 0, Version: "v1", Kind: "Secret", Namespace: "ns1", Name: "a"},
+					Conditions: []metav1.Condition{
+						{Type: workapiv1.ManifestApplied, Status: metav1.ConditionTrue},
+						{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionTrue},
+					},
+				},
+			},
+			expectedCreated: []string{"a", "b"},
+			expectedWorkConditions: []metav1.Condition{
+				expectedCondition(workapiv1.WorkApplied, metav1.ConditionTrue),
+				expectedCondition(workapiv1.WorkProgressing, metav1.ConditionFalse),
+			},
+		},
+		{
+			name: "progressing is owned by the health check when it is enabled",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", nil),
+				newWaveSecret("b", map[string]string{ApplyWaveAnnotationKey: "1"}),
+			},
+			annotations: map[string]string{
+				ApplyWaveReadyConditionAnnotationKey:      workapiv1.ManifestAvailable,
+				statuscontroller.HealthCheckAnnotationKey: "Enabled",
+			},
+			expectedCreated: []string{"a"},
+			expectedBlocked: []int32{1},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkBlocked"},
+			},
+			unexpectedConditions: []string{workapiv1.WorkProgressing},
+		},
+		{
+			name: "dependency in the same wave is applied first",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", map[string]string{DependsOnAnnotationKey: "/Secret/ns1/b"}),
+				newWaveSecret("b", nil),
+			},
+			expectedCreated: []string{"b", "a"},
+			expectedWorkConditions: []metav1.Condition{
+				expectedCondition(workapiv1.WorkApplied, metav1.ConditionTrue),
+			},
+		},
+		{
+			name: "dependencies in a cycle are blocked",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", map[string]string{DependsOnAnnotationKey: "/Secret/ns1/b"}),
+				newWaveSecret("b", map[string]string{DependsOnAnnotationKey: "/Secret/ns1/a"}),
+			},
+			expectedBlocked: []int32{0, 1},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkBlocked"},
+			},
+		},
+		{
+			name: "unknown dependency",
+			manifests: []*unstructured.Unstructured{
+				newWaveSecret("a", map[string]string{DependsOnAnnotationKey: "/Secret/ns1/c"}),
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkFailed"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0, c.manifests...)
+			work.Annotations = c.annotations
+			work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			work.Status.ResourceStatus.Manifests = c.existing
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+				withKubeObject().
+				withUnstructuredObject()
+			controller.toController()
+
+			syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+			work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+
+			var rqe commonhelper.RequeueError
+			switch {
+			case c.expectedError && (err == nil || errors.As(err, &rqe)):
+				t.Errorf("expected error, but got %v", err)
+			case !c.expectedError && len(c.expectedBlocked) > 0 && !errors.As(err, &rqe):
+				t.Errorf("expected requeue error, but got %v", err)
+			case !c.expectedError && len(c.expectedBlocked) == 0 && err != nil:
+				t.Errorf("unexpected error %v", err)
+			}
+
+			var created []string
+			for _, action := range controller.kubeClient.Actions() {
+				if createAction, ok := action.(clienttesting.CreateActionImpl); ok {
+					obj, _ := meta.Accessor(createAction.GetObject())
+					created = append(created, obj.GetName())
+				}
+			}
+			if !reflect.DeepEqual(created, c.expectedCreated) {
+				t.Errorf("expected created %v, but got %v", c.expectedCreated, created)
+			}
+
+			for _, index := range c.expectedBlocked {
+				assertManifestCondition(t, work.Status.ResourceStatus.Manifests, index, metav1.Condition{
+					Type: workapiv1.ManifestApplied, Status: metav1.ConditionFalse, Reason: ManifestApplyBlockedReason})
+				assertManifestCondition(t, work.Status.ResourceStatus.Manifests, index, metav1.Condition{
+					Type: workapiv1.ManifestProgressing, Status: metav1.ConditionTrue, Reason: ManifestApplyBlockedReason})
+			}
+			for _, expected := range c.expectedWorkConditions {
+				assertCondition(t, work.Status.Conditions, expected)
+			}
+			for _, conditionType := range c.unexpectedConditions {
+				if meta.FindStatusCondition(work.Status.Conditions, conditionType) != nil {
+					t.Errorf("unexpected condition %s in %v", conditionType, work.Status.Conditions)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyGateNotUsed(t *testing.T) {
+	work, _ := spoketesting.NewManifestWork(0, newWaveSecret("a", nil), newWaveSecret("b", nil))
+	gate, err := newApplyGate(work)
+	if err != nil || gate != nil {
+		t.Errorf("expected no gate, but got %v, %v", gate, err)
+	}
+
+	work, _ = spoketesting.NewManifestWork(0, newWaveSecret("a", map[string]string{ApplyWaveAnnotationKey: "first"}))
+	if _, err := newApplyGate(work); err == nil {
+		t.Errorf("expected error of invalid wave")
+	}
+
+	work, _ = spoketesting.NewManifestWork(0, newWaveSecret("a", map[string]string{DependsOnAnnotationKey: "Secret/ns1/b"}))
+	if _, err := newApplyGate(work); err == nil {
+		t.Errorf("expected error of invalid dependency")
+	}
+}
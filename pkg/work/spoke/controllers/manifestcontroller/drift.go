@@ -0,0 +1,92 @@
+package manifestcontroller
+
+import (
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
+)
+
+const (
+	// DriftDetectionAnnotationKey is the annotation key on the ManifestWork to enable the drift detection, see
+	// helper.DriftDetectionAnnotationKey.
+	DriftDetectionAnnotationKey = helper.DriftDetectionAnnotationKey
+
+	// ManifestDrifted is the condition type of a manifest which reports whether the resource on the managed
+	// cluster is drifted from the last applied state. It only exists when the drift detection is enabled.
+	ManifestDrifted = "Drifted"
+
+	ManifestDriftReportOnlyReason = "DriftDetected"
+	ManifestDriftRevertedReason   = "DriftReverted"
+	ManifestNoDriftReason         = "NoDrift"
+
+	// manifestDriftedEventReason is the reason of the event recorded when a drift is detected.
+	manifestDriftedEventReason = "ManifestDrifted"
+)
+
+type driftDetectionMode string
+
+const (
+	driftDetectionDisabled   driftDetectionMode = ""
+	driftDetectionEnabled    driftDetectionMode = helper.DriftDetectionEnabled
+	driftDetectionReportOnly driftDetectionMode = helper.DriftDetectionReportOnly
+)
+
+// getDriftDetectionMode returns the drift detection mode of the ManifestWork.
+func getDriftDetectionMode(manifestWork *workapiv1.ManifestWork) (driftDetectionMode, error) {
+	if err := helper.ValidateDriftDetectionAnnotation(manifestWork); err != nil {
+		return driftDetectionDisabled, err
+	}
+	return driftDetectionMode(manifestWork.Annotations[DriftDetectionAnnotationKey]), nil
+}
+
+// driftDetectionSupported returns true if the drift of the manifest applied with the strategy could be detected.
+func driftDetectionSupported(strategy workapiv1.UpdateStrategyType) bool {
+	return strategy == workapiv1.UpdateStrategyTypeUpdate || strategy == workapiv1.UpdateStrategyTypeServerSideApply
+}
+
+// buildDriftedStatusCondition returns the Drifted condition of a manifest, or nil if the drift is not checked.
+func buildDriftedStatusCondition(result applyResult, mode driftDetectionMode) *metav1.Condition {
+	if !result.driftChecked || result.Error != nil {
+		return nil
+	}
+
+	switch {
+	case result.drift == nil:
+		return &metav1.Condition{
+			Type:    ManifestDrifted,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestNoDriftReason,
+			Message: "No drift is detected",
+		}
+	case mode == driftDetectionReportOnly:
+		return &metav1.Condition{
+			Type:    ManifestDrifted,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestDriftReportOnlyReason,
+			Message: fmt.Sprintf("Drift is detected and not reverted: %s", result.drift.Summary()),
+		}
+	default:
+		return &metav1.Condition{
+			Type:    ManifestDrifted,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestDriftRevertedReason,
+			Message: fmt.Sprintf("Drift is detected and reverted: %s", result.drift.Summary()),
+		}
+	}
+}
+
+// recordDrift records an event of the drifted manifest.
+func recordDrift(recorder events.Recorder, resMeta workapiv1.ManifestResourceMeta, drift *apply.Drift, mode driftDetectionMode) {
+	action := "reverted"
+	if mode == driftDetectionReportOnly {
+		action = "not reverted"
+	}
+	recorder.Warningf(manifestDriftedEventReason, "%s %s/%s is drifted and %s: %s",
+		resMeta.Kind, resMeta.Namespace, resMeta.Name, action, drift.Summary())
+}
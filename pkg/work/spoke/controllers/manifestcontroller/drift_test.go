@@ -0,0 +1,129 @@
+package manifestcontroller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newDriftObject(value, resourceVersion string) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1",
+		map[string]interface{}{"spec": map[string]interface{}{"key1": value}})
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
+
+func TestDriftDetection(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "newobjects"}
+	cases := []struct {
+		name              string
+		mode              string
+		drifted           bool
+		expectedCondition *metav1.Condition
+		expectedUpdate    bool
+	}{
+		{
+			name: "drift detection is not enabled",
+			mode: "",
+			// the resource is updated without a drift condition
+			drifted:        true,
+			expectedUpdate: true,
+		},
+		{
+			name: "no drift",
+			mode: string(driftDetectionEnabled),
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDrifted, Status: metav1.ConditionFalse, Reason: ManifestNoDriftReason},
+		},
+		{
+			name:    "drift is reverted",
+			mode:    string(driftDetectionEnabled),
+			drifted: true,
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDrifted, Status: metav1.ConditionTrue, Reason: ManifestDriftRevertedReason},
+			expectedUpdate: true,
+		},
+		{
+			name:    "drift is reported only",
+			mode:    string(driftDetectionReportOnly),
+			drifted: true,
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDrifted, Status: metav1.ConditionTrue, Reason: ManifestDriftReportOnlyReason},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0, newDriftObject("val1", ""))
+			if len(c.mode) > 0 {
+				work.Annotations = map[string]string{DriftDetectionAnnotationKey: c.mode}
+			}
+			work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+				withKubeObject().
+				withUnstructuredObject(newDriftObject("val1", "1"))
+			controller.toController()
+
+			syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+
+			// the first reconcile records the applied state of the resource
+			work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.drifted {
+				if _, err := controller.dynamicClient.Resource(gvr).Namespace("ns1").Update(
+					context.TODO(), newDriftObject("val2", "2"), metav1.UpdateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			controller.dynamicClient.ClearActions()
+
+			work, _, err = controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			updated := false
+			for _, action := range controller.dynamicClient.Actions() {
+				if _, ok := action.(clienttesting.UpdateActionImpl); ok {
+					updated = true
+				}
+			}
+			if updated != c.expectedUpdate {
+				t.Errorf("expected updated %v, but got %v", c.expectedUpdate, updated)
+			}
+
+			assertManifestCondition(t, work.Status.ResourceStatus.Manifests, 0, metav1.Condition{
+				Type: workapiv1.ManifestApplied, Status: metav1.ConditionTrue})
+			if c.expectedCondition == nil {
+				for _, condition := range work.Status.ResourceStatus.Manifests[0].Conditions {
+					if condition.Type == ManifestDrifted {
+						t.Errorf("unexpected drifted condition %v", condition)
+					}
+				}
+				return
+			}
+			assertManifestCondition(t, work.Status.ResourceStatus.Manifests, 0, *c.expectedCondition)
+		})
+	}
+}
+
+func TestGetDriftDetectionMode(t *testing.T) {
+	work, _ := spoketesting.NewManifestWork(0)
+	work.Annotations = map[string]string{DriftDetectionAnnotationKey: "Always"}
+	if _, err := getDriftDetectionMode(work); err == nil {
+		t.Errorf("expected error of invalid mode")
+	}
+}
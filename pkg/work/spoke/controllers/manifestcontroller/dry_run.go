@@ -0,0 +1,151 @@
+package manifestcontroller
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
+)
+
+const (
+	// DryRunAnnotationKey is the annotation key on the ManifestWork to apply the manifests in the server side dry
+	// run mode, see helper.DryRunAnnotationKey.
+	DryRunAnnotationKey = helper.DryRunAnnotationKey
+
+	// ManifestDryRun is the condition type of a manifest which reports the result of the dry run. It only exists
+	// when the manifest is applied in the dry run mode.
+	ManifestDryRun = "DryRun"
+	// WorkDryRun is the condition type of a ManifestWork which aggregates the DryRun conditions of the manifests.
+	WorkDryRun = "DryRun"
+
+	// ManifestDryRunReason is the reason of the Applied condition of a manifest applied in the dry run mode.
+	ManifestDryRunReason = "DryRun"
+
+	ManifestWouldCreateReason       = "WouldCreate"
+	ManifestWouldUpdateReason       = "WouldUpdate"
+	ManifestWouldNotChangeReason    = "WouldNotChange"
+	ManifestAdmissionRejectedReason = "AdmissionRejected"
+	ManifestDryRunFailedReason      = "DryRunFailed"
+
+	WorkDryRunSucceededReason = "DryRunSucceeded"
+	WorkDryRunFailedReason    = "DryRunFailed"
+)
+
+// isAdmissionRejected returns true if the dry run request is rejected by the validation or admission of the
+// managed cluster, which could not be resolved by retrying.
+func isAdmissionRejected(err error) bool {
+	return apierrors.IsInvalid(err) || apierrors.IsForbidden(err) || apierrors.IsBadRequest(err)
+}
+
+// buildDryRunStatusCondition returns the DryRun condition of a manifest, or nil if it is not applied in the dry
+// run mode.
+func buildDryRunStatusCondition(result applyResult) *metav1.Condition {
+	if !result.dryRun {
+		return nil
+	}
+
+	switch {
+	case isAdmissionRejected(result.Error):
+		return &metav1.Condition{
+			Type:    ManifestDryRun,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestAdmissionRejectedReason,
+			Message: fmt.Sprintf("Manifest is rejected: %v", result.Error),
+		}
+	case result.Error != nil:
+		return &metav1.Condition{
+			Type:    ManifestDryRun,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestDryRunFailedReason,
+			Message: fmt.Sprintf("Failed to dry run manifest: %v", result.Error),
+		}
+	}
+
+	switch result.dryRunResult.Operation {
+	case apply.DryRunCreate:
+		return &metav1.Condition{
+			Type:    ManifestDryRun,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestWouldCreateReason,
+			Message: "Resource would be created",
+		}
+	case apply.DryRunUpdate:
+		return &metav1.Condition{
+			Type:    ManifestDryRun,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestWouldUpdateReason,
+			Message: fmt.Sprintf("Resource would be updated: %s", result.dryRunResult.Summary()),
+		}
+	default:
+		return &metav1.Condition{
+			Type:    ManifestDryRun,
+			Status:  metav1.ConditionTrue,
+			Reason:  ManifestWouldNotChangeReason,
+			Message: "Resource would not be changed",
+		}
+	}
+}
+
+// isDryRun returns true if the manifest is applied in the dry run mode in the last reconcile.
+func isDryRun(manifest workapiv1.ManifestCondition) bool {
+	condition := meta.FindStatusCondition(manifest.Conditions, workapiv1.ManifestApplied)
+	return condition != nil && condition.Reason == ManifestDryRunReason
+}
+
+// removeStaleDryRunConditions removes the DryRun conditions of the manifests which are no longer applied in the
+// dry run mode.
+func removeStaleDryRunConditions(manifests []workapiv1.ManifestCondition) {
+	for i := range manifests {
+		if !isDryRun(manifests[i]) {
+			meta.RemoveStatusCondition(&manifests[i].Conditions, ManifestDryRun)
+		}
+	}
+}
+
+// buildWorkDryRunCondition aggregates the DryRun conditions of the manifests, it returns nil if no manifest is
+// applied in the dry run mode.
+func buildWorkDryRunCondition(generation int64, manifests []workapiv1.ManifestCondition) *metav1.Condition {
+	total, failed, create, update := 0, 0, 0, 0
+	for _, manifest := range manifests {
+		condition := meta.FindStatusCondition(manifest.Conditions, ManifestDryRun)
+		if condition == nil || !isDryRun(manifest) {
+			continue
+		}
+		total++
+		switch {
+		case condition.Status != metav1.ConditionTrue:
+			failed++
+		case condition.Reason == ManifestWouldCreateReason:
+			create++
+		case condition.Reason == ManifestWouldUpdateReason:
+			update++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	if failed > 0 {
+		return &metav1.Condition{
+			Type:               WorkDryRun,
+			Status:             metav1.ConditionFalse,
+			Reason:             WorkDryRunFailedReason,
+			ObservedGeneration: generation,
+			Message:            fmt.Sprintf("%d of %d manifests failed the dry run", failed, total),
+		}
+	}
+	return &metav1.Condition{
+		Type:               WorkDryRun,
+		Status:             metav1.ConditionTrue,
+		Reason:             WorkDryRunSucceededReason,
+		ObservedGeneration: generation,
+		Message: fmt.Sprintf("%d of %d manifests would be created, %d would be updated",
+			create, total, update),
+	}
+}
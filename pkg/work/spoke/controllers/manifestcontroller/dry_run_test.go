@@ -0,0 +1,145 @@
+package manifestcontroller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func TestDryRun(t *testing.T) {
+	cases := []struct {
+		name                  string
+		annotation            string
+		existing              []runtime.Object
+		rejected              bool
+		expectedCondition     *metav1.Condition
+		expectedMessage       string
+		expectedWorkCondition *metav1.Condition
+	}{
+		{
+			name:       "would create",
+			annotation: "All",
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDryRun, Status: metav1.ConditionTrue, Reason: ManifestWouldCreateReason},
+			expectedWorkCondition: &metav1.Condition{
+				Type: WorkDryRun, Status: metav1.ConditionTrue, Reason: WorkDryRunSucceededReason},
+		},
+		{
+			name:       "would update",
+			annotation: "All",
+			existing:   []runtime.Object{newDriftObject("val2", "1")},
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDryRun, Status: metav1.ConditionTrue, Reason: ManifestWouldUpdateReason},
+			expectedMessage: "spec.key1",
+			expectedWorkCondition: &metav1.Condition{
+				Type: WorkDryRun, Status: metav1.ConditionTrue, Reason: WorkDryRunSucceededReason},
+		},
+		{
+			name:       "would not change",
+			annotation: `[{"resource":"newobjects","namespace":"*","name":"n1"}]`,
+			existing:   []runtime.Object{newDriftObject("val1", "1")},
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDryRun, Status: metav1.ConditionTrue, Reason: ManifestWouldNotChangeReason},
+			expectedWorkCondition: &metav1.Condition{
+				Type: WorkDryRun, Status: metav1.ConditionTrue, Reason: WorkDryRunSucceededReason},
+		},
+		{
+			name:       "rejected by admission",
+			annotation: "All",
+			rejected:   true,
+			expectedCondition: &metav1.Condition{
+				Type: ManifestDryRun, Status: metav1.ConditionFalse, Reason: ManifestAdmissionRejectedReason},
+			expectedMessage: "denied by webhook",
+			expectedWorkCondition: &metav1.Condition{
+				Type: WorkDryRun, Status: metav1.ConditionFalse, Reason: WorkDryRunFailedReason},
+		},
+		{
+			name:       "manifest is not selected",
+			annotation: `[{"resource":"newobjects","namespace":"ns1","name":"n2"}]`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0, newDriftObject("val1", ""))
+			work.Annotations = map[string]string{DryRunAnnotationKey: c.annotation}
+			work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).
+				withKubeObject().
+				withUnstructuredObject(c.existing...)
+			controller.toController()
+
+			// the fake client does not support dry run, return the object without persisting it.
+			var dryRun []*unstructured.Unstructured
+			dryRunReactor := func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if c.rejected {
+					return true, nil, apierrors.NewForbidden(
+						schema.GroupResource{Resource: "newobjects"}, "n1", errors.New("denied by webhook"))
+				}
+				var obj runtime.Object
+				switch a := action.(type) {
+				case clienttesting.CreateAction:
+					obj = a.GetObject()
+				case clienttesting.UpdateAction:
+					obj = a.GetObject()
+				}
+				dryRun = append(dryRun, obj.(*unstructured.Unstructured))
+				return true, obj, nil
+			}
+			if c.expectedCondition != nil {
+				controller.dynamicClient.PrependReactor("create", "newobjects", dryRunReactor)
+				controller.dynamicClient.PrependReactor("update", "newobjects", dryRunReactor)
+			}
+
+			syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+			work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			conditions := work.Status.ResourceStatus.Manifests[0].Conditions
+			if c.expectedCondition == nil {
+				assertCondition(t, conditions, metav1.Condition{Type: workapiv1.ManifestApplied, Status: metav1.ConditionTrue})
+				if meta.FindStatusCondition(conditions, ManifestDryRun) != nil {
+					t.Errorf("unexpected dry run condition: %v", conditions)
+				}
+				if meta.FindStatusCondition(work.Status.Conditions, WorkDryRun) != nil {
+					t.Errorf("unexpected dry run condition: %v", work.Status.Conditions)
+				}
+				return
+			}
+
+			assertCondition(t, conditions, *c.expectedCondition)
+			assertCondition(t, conditions, metav1.Condition{
+				Type: workapiv1.ManifestApplied, Status: metav1.ConditionFalse, Reason: ManifestDryRunReason})
+			assertCondition(t, work.Status.Conditions, *c.expectedWorkCondition)
+			assertCondition(t, work.Status.Conditions, metav1.Condition{
+				Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkDryRun"})
+			if condition := meta.FindStatusCondition(conditions, ManifestDryRun); !strings.Contains(condition.Message, c.expectedMessage) {
+				t.Errorf("expected message contains %q, but got %q", c.expectedMessage, condition.Message)
+			}
+
+			// the resource is not owned by the work
+			for _, obj := range dryRun {
+				if len(obj.GetOwnerReferences()) > 0 {
+					t.Errorf("unexpected owner references %v", obj.GetOwnerReferences())
+				}
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -29,6 +30,8 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth"
+	"open-cluster-management.io/ocm/pkg/work/spoke/policy"
+	"open-cluster-management.io/ocm/pkg/work/spoke/verify"
 )
 
 var (
@@ -69,7 +72,9 @@ func NewManifestWorkController(
 	appliedManifestWorkInformer workinformer.AppliedManifestWorkInformer,
 	hubHash, agentID string,
 	restMapper meta.RESTMapper,
-	validator auth.ExecutorValidator) factory.Controller {
+	validator auth.ExecutorValidator,
+	policyValidator policy.Validator,
+	workVerifier verify.Verifier) factory.Controller {
 
 	controller := &ManifestWorkController{
 		manifestWorkPatcher: patcher.NewPatcher[
@@ -85,9 +90,11 @@ func NewManifestWorkController(
 		agentID:                   agentID,
 		reconcilers: []workReconcile{
 			&manifestworkReconciler{
-				restMapper: restMapper,
-				appliers:   apply.NewAppliers(spokeDynamicClient, spokeKubeClient, spokeAPIExtensionClient),
-				validator:  validator,
+				restMapper:      restMapper,
+				appliers:        apply.NewAppliers(spokeDynamicClient, spokeKubeClient, spokeAPIExtensionClient),
+				validator:       validator,
+				policyValidator: policyValidator,
+				workVerifier:    workVerifier,
 			},
 			&appliedManifestWorkReconciler{
 				spokeDynamicClient: spokeDynamicClient,
@@ -96,13 +103,36 @@ func NewManifestWorkController(
 		},
 	}
 
-	return factory.New().
+	controllerFactory := factory.New().
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, manifestWorkInformer.Informer()).
 		WithFilteredEventsInformersQueueKeyFunc(
 			helper.AppliedManifestworkQueueKeyFunc(hubHash),
 			helper.AppliedManifestworkHubHashFilter(hubHash),
 			appliedManifestWorkInformer.Informer()).
-		WithSync(controller.sync).ResyncEvery(ResyncInterval).ToController("ManifestWorkAgent", recorder)
+		WithSync(controller.sync).ResyncEvery(ResyncInterval)
+	// the manifests are validated again with the reloaded policies.
+	if reloader, ok := policyValidator.(policy.Reloader); ok {
+		controllerFactory = controllerFactory.WithPostStartHooks(
+			func(ctx context.Context, syncCtx factory.SyncContext) error {
+				reloader.Run(ctx, policy.DefaultReloadInterval, func() {
+					controller.enqueueAll(syncCtx)
+				})
+				return nil
+			})
+	}
+	return controllerFactory.ToController("ManifestWorkAgent", recorder)
+}
+
+// enqueueAll adds all of the manifestworks to the queue.
+func (m *ManifestWorkController) enqueueAll(syncCtx factory.SyncContext) {
+	works, err := m.manifestWorkLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list manifestworks: %v", err)
+		return
+	}
+	for _, work := range works {
+		syncCtx.Queue().Add(work.Name)
+	}
 }
 
 // sync is the main reconcile loop for manifest work. It is triggered in two scenarios
@@ -24,6 +24,9 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth/basic"
+	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/statuscontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/policy"
+	"open-cluster-management.io/ocm/pkg/work/spoke/verify"
 )
 
 type applyResult struct {
@@ -31,12 +34,32 @@ type applyResult struct {
 	Error  error
 
 	resourceMeta workapiv1.ManifestResourceMeta
+	// blockedReason is set when the manifest is not applied since it is blocked by an earlier
+	// apply wave or a dependency.
+	blockedReason string
+	// driftChecked is set when the drift of the manifest is checked, and drift is set when it is drifted.
+	driftChecked bool
+	drift        *apply.Drift
+	// dryRun is set when the manifest is applied in the dry run mode, and dryRunResult is set when it succeeds.
+	dryRun       bool
+	dryRunResult *apply.DryRunResult
 }
 
+// ManifestPolicyDeniedReason is the reason of the Applied condition of a manifest which is denied by a policy.
+const ManifestPolicyDeniedReason = "PolicyDenied"
+
+// WorkVerified is the condition type of a ManifestWork which reports whether the work passes the verification
+// of the agent. It only exists when the verification is enabled.
+const WorkVerified = "Verified"
+
 type manifestworkReconciler struct {
 	restMapper meta.RESTMapper
 	appliers   *apply.Appliers
 	validator  auth.ExecutorValidator
+	// policyValidator validates the manifests before they are applied, it is nil if no policy is configured.
+	policyValidator policy.Validator
+	// workVerifier verifies the work before any manifest is applied, it is nil if the verification is disabled.
+	workVerifier verify.Verifier
 }
 
 func (m *manifestworkReconciler) reconcile(
@@ -47,12 +70,47 @@ func (m *manifestworkReconciler) reconcile(
 	// We creat a ownerref instead of controller ref since multiple controller can declare the ownership of a manifests
 	owner := helper.NewAppliedManifestWorkOwner(appliedManifestWork)
 
+	// do not apply the paused work, the status of its resources is still synced by the status controller.
+	if helper.IsWorkPaused(manifestWork) {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               helper.WorkPaused,
+			Status:             metav1.ConditionTrue,
+			Reason:             "ManifestWorkPaused",
+			ObservedGeneration: manifestWork.Generation,
+			Message: fmt.Sprintf("Applying manifest work is paused by the annotation %s",
+				helper.PausedAnnotationKey),
+		})
+		return manifestWork, appliedManifestWork, nil
+	}
+	meta.RemoveStatusCondition(&manifestWork.Status.Conditions, helper.WorkPaused)
+
+	// refuse the work failing the verification, none of its manifests is applied until the work is changed.
+	if m.workVerifier != nil {
+		verified, err := m.verifyManifestWork(ctx, controllerContext.Recorder(), manifestWork)
+		if !verified {
+			return manifestWork, appliedManifestWork, err
+		}
+	}
+
 	var errs []error
+	gate, err := newApplyGate(manifestWork)
+	if err != nil {
+		return manifestWork, appliedManifestWork, err
+	}
+	driftMode, err := getDriftDetectionMode(manifestWork)
+	if err != nil {
+		return manifestWork, appliedManifestWork, err
+	}
+	dryRun, err := helper.GetDryRunSelector(manifestWork)
+	if err != nil {
+		return manifestWork, appliedManifestWork, err
+	}
+
 	// Apply resources on spoke cluster.
 	resourceResults := make([]applyResult, len(manifestWork.Spec.Workload.Manifests))
-	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		resourceResults = m.applyManifests(
-			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, controllerContext.Recorder(), *owner, resourceResults)
+			ctx, manifestWork.Spec.Workload.Manifests, manifestWork.Spec, controllerContext.Recorder(), *owner, resourceResults, gate, driftMode, dryRun)
 
 		for _, result := range resourceResults {
 			if apierrors.IsConflict(result.Error) {
@@ -68,6 +126,7 @@ func (m *manifestworkReconciler) reconcile(
 
 	var newManifestConditions []workapiv1.ManifestCondition
 	var requeueTime = ResyncInterval
+	blocked, dryRunCount := 0, 0
 	for _, result := range resourceResults {
 		manifestCondition := workapiv1.ManifestCondition{
 			ResourceMeta: result.resourceMeta,
@@ -75,7 +134,32 @@ func (m *manifestworkReconciler) reconcile(
 		}
 
 		// Add applied status condition
-		manifestCondition.Conditions = append(manifestCondition.Conditions, buildAppliedStatusCondition(result))
+		appliedCondition := buildAppliedStatusCondition(result)
+		manifestCondition.Conditions = append(manifestCondition.Conditions, appliedCondition)
+
+		// Add progressing status condition and recheck the blocked manifests later if apply waves are used
+		if gate != nil {
+			manifestCondition.Conditions = append(manifestCondition.Conditions, buildProgressingStatusCondition(result))
+			if len(result.blockedReason) > 0 {
+				blocked++
+				if waveRequeueInterval < requeueTime {
+					requeueTime = waveRequeueInterval
+				}
+			}
+		}
+
+		// Add drifted status condition if the drift is checked
+		if driftedCondition := buildDriftedStatusCondition(result, driftMode); driftedCondition != nil {
+			manifestCondition.Conditions = append(manifestCondition.Conditions, *driftedCondition)
+		}
+
+		// Add dry run status condition if the manifest is applied in the dry run mode
+		if dryRunCondition := buildDryRunStatusCondition(result); dryRunCondition != nil {
+			manifestCondition.Conditions = append(manifestCondition.Conditions, *dryRunCondition)
+		}
+		if result.dryRun {
+			dryRunCount++
+		}
 
 		newManifestConditions = append(newManifestConditions, manifestCondition)
 
@@ -91,6 +175,23 @@ func (m *manifestworkReconciler) reconcile(
 			}
 		}
 
+		// the manifest denied by a policy is not applied until the manifest or the policy is changed, the denial
+		// is reported by the Applied condition of the manifest, and the event is only recorded when it is changed.
+		var deniedError *policy.DeniedError
+		if errors.As(result.Error, &deniedError) {
+			if !hasManifestCondition(manifestWork.Status.ResourceStatus.Manifests, result.resourceMeta, appliedCondition) {
+				controllerContext.Recorder().Warningf(ManifestPolicyDeniedReason, "%s %s/%s of work %s is %v",
+					result.resourceMeta.Kind, result.resourceMeta.Namespace, result.resourceMeta.Name,
+					manifestWork.Name, deniedError)
+			}
+			result.Error = nil
+		}
+
+		// the manifest rejected in the dry run mode is reported by the condition and not retried.
+		if result.dryRun && isAdmissionRejected(result.Error) {
+			result.Error = nil
+		}
+
 		// ignore server side apply conflict error since it cannot be resolved by error fallback.
 		var ssaConflict *apply.ServerSideApplyConflictError
 		if result.Error != nil && !errors.As(result.Error, &ssaConflict) {
@@ -99,6 +200,7 @@ func (m *manifestworkReconciler) reconcile(
 	}
 	manifestWork.Status.ResourceStatus.Manifests = helper.MergeManifestConditions(
 		manifestWork.Status.ResourceStatus.Manifests, newManifestConditions)
+	removeStaleDryRunConditions(manifestWork.Status.ResourceStatus.Manifests)
 	// handle condition type Applied
 	// #1: Applied - work status condition (with type Applied) is applied if all manifest conditions (with type Applied) are applied
 	if inCondition, exists := allInCondition(workapiv1.ManifestApplied, newManifestConditions); exists {
@@ -113,12 +215,49 @@ func (m *manifestworkReconciler) reconcile(
 			appliedCondition.Status = metav1.ConditionTrue
 			appliedCondition.Reason = "AppliedManifestWorkComplete"
 			appliedCondition.Message = "Apply manifest work complete"
+		} else if blocked > 0 && blocked == countNotInCondition(workapiv1.ManifestApplied, newManifestConditions) {
+			appliedCondition.Reason = "AppliedManifestWorkBlocked"
+			appliedCondition.Message = fmt.Sprintf("%d manifests are blocked by apply waves or dependencies", blocked)
+		} else if dryRunCount > 0 && dryRunCount == countNotInCondition(workapiv1.ManifestApplied, newManifestConditions) {
+			appliedCondition.Reason = "AppliedManifestWorkDryRun"
+			appliedCondition.Message = fmt.Sprintf("%d manifests are not applied in the dry run mode", dryRunCount)
 		}
 		meta.SetStatusCondition(&manifestWork.Status.Conditions, appliedCondition)
 	}
 
+	// handle condition type DryRun if any manifest is applied in the dry run mode
+	if dryRunCondition := buildWorkDryRunCondition(manifestWork.Generation, newManifestConditions); dryRunCondition != nil {
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, *dryRunCondition)
+	} else {
+		meta.RemoveStatusCondition(&manifestWork.Status.Conditions, WorkDryRun)
+	}
+
+	// handle condition type Progressing if apply waves are used, the work is progressing when any manifest is blocked.
+	// The condition is owned by the status controller if the health check is enabled, which also counts the blocked
+	// manifests as progressing.
+	if gate != nil && !statuscontroller.IsHealthCheckEnabled(manifestWork) {
+		progressingCondition := metav1.Condition{
+			Type:               workapiv1.WorkProgressing,
+			ObservedGeneration: manifestWork.Generation,
+			Status:             metav1.ConditionFalse,
+			Reason:             "ApplyWavesCompleted",
+			Message:            "No manifest is blocked by apply waves or dependencies",
+		}
+		if blocked > 0 {
+			progressingCondition.Status = metav1.ConditionTrue
+			progressingCondition.Reason = "ApplyWavesInProgress"
+			progressingCondition.Message = fmt.Sprintf("%d manifests are blocked by apply waves or dependencies", blocked)
+		}
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, progressingCondition)
+	}
+
 	if len(errs) > 0 {
 		err = utilerrors.NewAggregate(errs)
+	} else if blocked > 0 {
+		err = commonhelper.NewRequeueError(
+			fmt.Sprintf("requeue work %s due to blocked manifests", manifestWork.Name),
+			requeueTime,
+		)
 	} else if requeueTime != ResyncInterval {
 		err = commonhelper.NewRequeueError(
 			fmt.Sprintf("requeu work %s due to authorization err", manifestWork.Name),
@@ -129,35 +268,107 @@ func (m *manifestworkReconciler) reconcile(
 	return manifestWork, appliedManifestWork, err
 }
 
+// verifyManifestWork sets the Verified condition of the work, and returns false if the work fails the
+// verification. The Applied condition of the refused work is set to False.
+func (m *manifestworkReconciler) verifyManifestWork(
+	ctx context.Context, recorder events.Recorder, manifestWork *workapiv1.ManifestWork) (bool, error) {
+	err := m.workVerifier.Verify(ctx, manifestWork)
+	var verificationError *verify.VerificationError
+	switch {
+	case errors.As(err, &verificationError):
+	case err != nil:
+		return false, err
+	default:
+		meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+			Type:               WorkVerified,
+			Status:             metav1.ConditionTrue,
+			Reason:             verify.VerificationPassedReason,
+			ObservedGeneration: manifestWork.Generation,
+			Message:            "Manifest work passed the verification",
+		})
+		return true, nil
+	}
+
+	recorder.Warningf("ManifestWorkRefused", "work %s is refused: %v", manifestWork.Name, verificationError)
+	meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+		Type:               WorkVerified,
+		Status:             metav1.ConditionFalse,
+		Reason:             verificationError.Reason,
+		ObservedGeneration: manifestWork.Generation,
+		Message:            verificationError.Message,
+	})
+	meta.SetStatusCondition(&manifestWork.Status.Conditions, metav1.Condition{
+		Type:               workapiv1.WorkApplied,
+		Status:             metav1.ConditionFalse,
+		Reason:             "AppliedManifestWorkRefused",
+		ObservedGeneration: manifestWork.Generation,
+		Message:            fmt.Sprintf("Manifest work is refused: %s", verificationError.Message),
+	})
+	return false, nil
+}
+
 func (m *manifestworkReconciler) applyManifests(
 	ctx context.Context,
 	manifests []workapiv1.Manifest,
 	workSpec workapiv1.ManifestWorkSpec,
 	recorder events.Recorder,
 	owner metav1.OwnerReference,
-	existingResults []applyResult) []applyResult {
-
-	for index, manifest := range manifests {
-		switch {
-		case existingResults[index].Result == nil:
-			// Apply if there is no result.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
-		case apierrors.IsConflict(existingResults[index].Error):
-			// Apply if there is a resource conflict error.
-			existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner)
+	existingResults []applyResult,
+	gate *applyGate,
+	driftMode driftDetectionMode,
+	dryRun *helper.DryRunSelector) []applyResult {
+
+	if gate == nil {
+		for index, manifest := range manifests {
+			if needApply(existingResults[index]) {
+				existingResults[index] = m.applyOneManifest(ctx, index, manifest, workSpec, recorder, owner, driftMode, dryRun)
+			}
+		}
+		return existingResults
+	}
+
+	// apply the manifests wave by wave, a manifest blocked by a dependency in the same wave is rechecked
+	// until no more manifest could be applied.
+	pending := gate.order()
+	for progress := true; progress; {
+		progress = false
+		var stillPending []int
+		for _, index := range pending {
+			if !needApply(existingResults[index]) {
+				continue
+			}
+			reason, err := gate.blockedReason(index, existingResults)
+			switch {
+			case err != nil:
+				existingResults[index] = applyResult{Error: err, resourceMeta: blockedResult(index, manifests[index], "").resourceMeta}
+			case len(reason) > 0:
+				existingResults[index] = blockedResult(index, manifests[index], reason)
+				stillPending = append(stillPending, index)
+			default:
+				existingResults[index] = m.applyOneManifest(ctx, index, manifests[index], workSpec, recorder, owner, driftMode, dryRun)
+				progress = true
+			}
 		}
+		pending = stillPending
 	}
 
 	return existingResults
 }
 
+// needApply returns true if there is no result or the result is a resource conflict error.
+func needApply(result applyResult) bool {
+	return result.Result == nil || apierrors.IsConflict(result.Error)
+}
+
 func (m *manifestworkReconciler) applyOneManifest(
 	ctx context.Context,
 	index int,
 	manifest workapiv1.Manifest,
 	workSpec workapiv1.ManifestWorkSpec,
 	recorder events.Recorder,
-	owner metav1.OwnerReference) applyResult {
+	owner metav1.OwnerReference,
+	driftMode driftDetectionMode,
+	dryRun *helper.DryRunSelector) applyResult {
 
 	result := applyResult{}
 
@@ -202,8 +413,56 @@ func (m *manifestworkReconciler) applyOneManifest(
 		strategy = *option.UpdateStrategy
 	}
 
+	// check the manifest against the local policies before writing it, the read only manifest is not checked.
+	if m.policyValidator != nil && strategy.Type != workapiv1.UpdateStrategyTypeReadOnly {
+		if err := m.policyValidator.Validate(ctx, gvr, required); err != nil {
+			result.Error = err
+			return result
+		}
+	}
+
+	// apply the manifest in the dry run mode, the resource is not owned by the work and the drift is not checked.
+	if dryRun.Matches(resMeta) && strategy.Type != workapiv1.UpdateStrategyTypeReadOnly {
+		result.dryRun = true
+		obj, dryRunResult, err := m.appliers.GetDryRunApplier().DryRun(ctx, gvr, required, option)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		result.Result, result.dryRunResult = obj, dryRunResult
+		return result
+	}
+
+	// detect the drift before the resource is applied, the drift is not reverted in the ReportOnly mode.
+	var driftDetector *apply.DriftDetector
+	if driftMode != driftDetectionDisabled && driftDetectionSupported(strategy.Type) {
+		driftDetector = m.appliers.GetDriftDetector()
+	}
+	var applied *unstructured.Unstructured
+	if driftDetector != nil {
+		drift, existing, err := driftDetector.Detect(ctx, gvr, required, option)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		result.driftChecked = true
+		result.drift = drift
+		if drift != nil {
+			recordDrift(recorder, resMeta, drift, driftMode)
+			if driftMode == driftDetectionReportOnly {
+				result.Result = existing
+				return result
+			}
+		}
+		// keep the required since it might be changed by the applier.
+		applied = required.DeepCopy()
+	}
+
 	applier := m.appliers.GetApplier(strategy.Type)
 	result.Result, result.Error = applier.Apply(ctx, gvr, required, requiredOwner, option, recorder)
+	if driftDetector != nil && result.Error == nil {
+		driftDetector.Applied(applied, result.Result)
+	}
 
 	return result
 }
@@ -226,7 +485,63 @@ func allInCondition(conditionType string, manifests []workapiv1.ManifestConditio
 	return exists, exists
 }
 
+// hasManifestCondition returns true if the manifest of the resource already has the condition with the same
+// status, reason and message.
+func hasManifestCondition(manifests []workapiv1.ManifestCondition, resourceMeta workapiv1.ManifestResourceMeta,
+	expected metav1.Condition) bool {
+	for _, manifest := range manifests {
+		existingMeta := manifest.ResourceMeta
+		existingMeta.Ordinal = resourceMeta.Ordinal
+		if existingMeta != resourceMeta {
+			continue
+		}
+		condition := meta.FindStatusCondition(manifest.Conditions, expected.Type)
+		return condition != nil && condition.Status == expected.Status &&
+			condition.Reason == expected.Reason && condition.Message == expected.Message
+	}
+	return false
+}
+
+// countNotInCondition returns the number of the manifests whose condition with the condition type is false.
+func countNotInCondition(conditionType string, manifests []workapiv1.ManifestCondition) int {
+	count := 0
+	for _, manifest := range manifests {
+		if meta.IsStatusConditionFalse(manifest.Conditions, conditionType) {
+			count++
+		}
+	}
+	return count
+}
+
 func buildAppliedStatusCondition(result applyResult) metav1.Condition {
+	if len(result.blockedReason) > 0 {
+		return metav1.Condition{
+			Type:    workapiv1.ManifestApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestApplyBlockedReason,
+			Message: fmt.Sprintf("Manifest is not applied: %s", result.blockedReason),
+		}
+	}
+
+	if result.dryRun {
+		return metav1.Condition{
+			Type:    workapiv1.ManifestApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestDryRunReason,
+			Message: "Manifest is not applied in the dry run mode",
+		}
+	}
+
+	var deniedError *policy.DeniedError
+	if errors.As(result.Error, &deniedError) {
+		return metav1.Condition{
+			Type:    workapiv1.ManifestApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  ManifestPolicyDeniedReason,
+			Message: fmt.Sprintf("Manifest is not applied: %v", deniedError),
+		}
+	}
+
 	if result.Error != nil {
 		return metav1.Condition{
 			Type:    workapiv1.ManifestApplied,
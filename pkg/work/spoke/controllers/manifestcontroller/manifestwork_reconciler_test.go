@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/openshift/library-go/pkg/operator/events"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -19,6 +20,7 @@ import (
 	fakedynamic "k8s.io/client-go/dynamic/fake"
 	fakekube "k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/clock"
 
 	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
 	workinformers "open-cluster-management.io/api/client/work/informers/externalversions"
@@ -29,7 +31,9 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/apply"
 	"open-cluster-management.io/ocm/pkg/work/spoke/auth/basic"
+	"open-cluster-management.io/ocm/pkg/work/spoke/policy"
 	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/spoke/verify"
 	"open-cluster-management.io/ocm/test/integration/util"
 )
 
@@ -820,3 +824,217 @@ func TestManageOwner(t *testing.T) {
 		})
 	}
 }
+
+type denyNamespaceValidator struct {
+	namespace string
+}
+
+func (v *denyNamespaceValidator) Validate(_ context.Context, _ schema.GroupVersionResource, required *unstructured.Unstructured) error {
+	if required.GetNamespace() == v.namespace {
+		return &policy.DeniedError{Policy: "protected-namespaces", Message: "namespace is protected"}
+	}
+	return nil
+}
+
+type recordingSyncContext struct {
+	*testingcommon.FakeSyncContext
+	recorder events.InMemoryRecorder
+}
+
+func (c recordingSyncContext) Recorder() events.Recorder { return c.recorder }
+
+func TestPolicyDenied(t *testing.T) {
+	work, workKey := spoketesting.NewManifestWork(0,
+		testingcommon.NewUnstructured("v1", "Secret", "ns1", "test"),
+		testingcommon.NewUnstructured("v1", "Secret", "ns2", "test"),
+		testingcommon.NewUnstructured("v1", "Secret", "ns2", "readonly"))
+	work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+	work.Spec.ManifestConfigs = []workapiv1.ManifestConfigOption{
+		{
+			ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "secrets", Namespace: "ns2", Name: "readonly"},
+			UpdateStrategy:     &workapiv1.UpdateStrategy{Type: workapiv1.UpdateStrategyTypeReadOnly},
+		},
+	}
+	controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject(
+		testingcommon.NewUnstructured("v1", "Secret", "ns2", "readonly"))
+	controller.mwReconciler.policyValidator = &denyNamespaceValidator{namespace: "ns2"}
+	controller.toController()
+
+	syncContext := recordingSyncContext{
+		FakeSyncContext: testingcommon.NewFakeSyncContext(t, workKey),
+		recorder:        events.NewInMemoryRecorder("test", clock.RealClock{}),
+	}
+	appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+	work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+	if err != nil {
+		t.Errorf("the denied manifest should not return error, but got %v", err)
+	}
+
+	// only the allowed secret is created
+	created := 0
+	for _, action := range controller.kubeClient.Actions() {
+		if action.GetVerb() == "create" {
+			created++
+			if action.GetNamespace() != "ns1" {
+				t.Errorf("unexpected create action in namespace %s", action.GetNamespace())
+			}
+		}
+	}
+	if created != 1 {
+		t.Errorf("expected 1 secret created, but got %d", created)
+	}
+
+	assertManifestCondition(t, work.Status.ResourceStatus.Manifests, 0, metav1.Condition{
+		Type: workapiv1.ManifestApplied, Status: metav1.ConditionTrue})
+	assertManifestCondition(t, work.Status.ResourceStatus.Manifests, 1, metav1.Condition{
+		Type: workapiv1.ManifestApplied, Status: metav1.ConditionFalse, Reason: ManifestPolicyDeniedReason})
+	// the read only manifest is not validated by the policies
+	assertManifestCondition(t, work.Status.ResourceStatus.Manifests, 2, metav1.Condition{
+		Type: workapiv1.ManifestApplied, Status: metav1.ConditionTrue})
+	assertCondition(t, work.Status.Conditions, metav1.Condition{
+		Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse})
+
+	// the denial is only recorded once if it is not changed
+	if _, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork); err != nil {
+		t.Errorf("the denied manifest should not return error, but got %v", err)
+	}
+	denied := 0
+	for _, event := range syncContext.recorder.Events() {
+		if event.Reason == ManifestPolicyDeniedReason {
+			denied++
+		}
+	}
+	if denied != 1 {
+		t.Errorf("expected 1 denied event, but got %d", denied)
+	}
+}
+
+type fakeWorkVerifier struct {
+	err error
+}
+
+func (v *fakeWorkVerifier) Verify(_ context.Context, _ *workapiv1.ManifestWork) error {
+	return v.err
+}
+
+func TestWorkVerification(t *testing.T) {
+	cases := []struct {
+		name               string
+		verifyErr          error
+		expectedCreate     int
+		expectedConditions []metav1.Condition
+	}{
+		{
+			name:           "work is verified",
+			expectedCreate: 1,
+			expectedConditions: []metav1.Condition{
+				{Type: WorkVerified, Status: metav1.ConditionTrue, Reason: verify.VerificationPassedReason},
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue},
+			},
+		},
+		{
+			name:      "work is refused",
+			verifyErr: &verify.VerificationError{Reason: verify.SignatureInvalidReason, Message: "invalid signature"},
+			expectedConditions: []metav1.Condition{
+				{Type: WorkVerified, Status: metav1.ConditionFalse, Reason: verify.SignatureInvalidReason},
+				{Type: workapiv1.WorkApplied, Status: metav1.ConditionFalse, Reason: "AppliedManifestWorkRefused"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "Secret", "ns1", "test"))
+			work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+			controller.mwReconciler.workVerifier = &fakeWorkVerifier{err: c.verifyErr}
+			controller.toController()
+
+			syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+			work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			created := 0
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() == "create" {
+					created++
+				}
+			}
+			if created != c.expectedCreate {
+				t.Errorf("expected %d secret created, but got %d", c.expectedCreate, created)
+			}
+			for _, expected := range c.expectedConditions {
+				assertCondition(t, work.Status.Conditions, expected)
+			}
+		})
+	}
+}
+
+func TestPauseWork(t *testing.T) {
+	cases := []struct {
+		name           string
+		annotations    map[string]string
+		conditions     []metav1.Condition
+		expectedCreate int
+		expectedPaused bool
+	}{
+		{
+			name:           "work is paused",
+			annotations:    map[string]string{helper.PausedAnnotationKey: "true"},
+			expectedPaused: true,
+		},
+		{
+			name:           "work is resumed",
+			conditions:     []metav1.Condition{{Type: helper.WorkPaused, Status: metav1.ConditionTrue, Reason: "ManifestWorkPaused"}},
+			expectedCreate: 1,
+		},
+		{
+			name:           "sync now",
+			annotations:    map[string]string{helper.SyncNowAnnotationKey: "2024-01-01T00:00:00Z"},
+			expectedCreate: 1,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, workKey := spoketesting.NewManifestWork(0, testingcommon.NewUnstructured("v1", "Secret", "ns1", "test"))
+			work.Annotations = c.annotations
+			work.Status.Conditions = c.conditions
+			work.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			controller := newController(t, work, nil, spoketesting.NewFakeRestMapper()).withKubeObject().withUnstructuredObject()
+			controller.toController()
+
+			syncContext := testingcommon.NewFakeSyncContext(t, workKey)
+			appliedWork := spoketesting.NewAppliedManifestWork("test", 0, "uid")
+			work, _, err := controller.mwReconciler.reconcile(context.TODO(), syncContext, work, appliedWork)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			created := 0
+			for _, action := range controller.kubeClient.Actions() {
+				if action.GetVerb() == "create" {
+					created++
+				}
+			}
+			if created != c.expectedCreate {
+				t.Errorf("expected %d secret created, but got %d", c.expectedCreate, created)
+			}
+			if !c.expectedPaused {
+				if meta.FindStatusCondition(work.Status.Conditions, helper.WorkPaused) != nil {
+					t.Errorf("unexpected paused condition: %v", work.Status.Conditions)
+				}
+				assertCondition(t, work.Status.Conditions, metav1.Condition{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue})
+				return
+			}
+			assertCondition(t, work.Status.Conditions, metav1.Condition{
+				Type: helper.WorkPaused, Status: metav1.ConditionTrue, Reason: "ManifestWorkPaused"})
+			if meta.FindStatusCondition(work.Status.Conditions, workapiv1.WorkApplied) != nil {
+				t.Errorf("unexpected applied condition: %v", work.Status.Conditions)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package statuscontroller
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
@@ -12,10 +13,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
 	"k8s.io/klog/v2"
 
 	workv1client "open-cluster-management.io/api/client/work/clientset/versioned/typed/work/v1"
@@ -28,7 +31,11 @@ import (
 	"open-cluster-management.io/ocm/pkg/common/queue"
 	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/conditions"
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
+	"open-cluster-management.io/ocm/pkg/work/spoke/health"
+	"open-cluster-management.io/ocm/pkg/work/spoke/rulesconfig"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
 )
 
 const statusFeedbackConditionType = "StatusFeedbackSynced"
@@ -43,23 +50,44 @@ type AvailableStatusController struct {
 	spokeDynamicClient dynamic.Interface
 	statusReader       *statusfeedback.StatusReader
 	conditionReader    *conditions.ConditionReader
+	healthChecker      *health.Checker
 	syncInterval       time.Duration
+	// watcher is set when the status is synced by watching the resources instead of polling them.
+	watcher *resourceWatcher
 }
 
-// NewAvailableStatusController returns a AvailableStatusController
+// NewAvailableStatusController returns a AvailableStatusController. The status of the resources is polled at the
+// syncInterval if the spokeMetadataClient is nil, otherwise the resources are watched with the metadata-only
+// informers and the status is only synced when the resources are changed. The well known status and condition
+// rules in the wellKnownRulesFile are merged into the default rules, and reloaded when the file is changed.
 func NewAvailableStatusController(
 	recorder events.Recorder,
 	spokeDynamicClient dynamic.Interface,
+	spokeMetadataClient metadata.Interface,
 	manifestWorkClient workv1client.ManifestWorkInterface,
 	manifestWorkInformer workinformer.ManifestWorkInformer,
 	manifestWorkLister worklister.ManifestWorkNamespaceLister,
 	maxJSONRawLength int32,
 	syncInterval time.Duration,
+	wellKnownRulesFile string,
 ) (factory.Controller, error) {
 	conditionReader, err := conditions.NewConditionReader()
 	if err != nil {
 		return nil, err
 	}
+	statusReader := statusfeedback.NewStatusReader().WithMaxJsonRawLength(maxJSONRawLength)
+
+	var rulesLoader *rulesconfig.Loader
+	if len(wellKnownRulesFile) > 0 {
+		statusResolver := statusrules.NewConfigurableWellKnownStatusResolver()
+		conditionResolver := conditionrules.NewConfigurableWellKnownConditionResolver()
+		rulesLoader = rulesconfig.NewLoader(wellKnownRulesFile, statusResolver, conditionResolver)
+		if _, err := rulesLoader.Load(); err != nil {
+			return nil, err
+		}
+		statusReader = statusReader.WithWellKnownStatus(statusResolver)
+		conditionReader = conditionReader.WithWellKnownConditions(conditionResolver)
+	}
 
 	controller := &AvailableStatusController{
 		patcher: patcher.NewPatcher[
@@ -68,13 +96,47 @@ func NewAvailableStatusController(
 		manifestWorkLister: manifestWorkLister,
 		spokeDynamicClient: spokeDynamicClient,
 		syncInterval:       syncInterval,
-		statusReader:       statusfeedback.NewStatusReader().WithMaxJsonRawLength(maxJSONRawLength),
+		statusReader:       statusReader,
 		conditionReader:    conditionReader,
+		healthChecker:      health.NewChecker(spokeDynamicClient),
 	}
 
-	return factory.New().
+	syncCtx := factory.NewSyncContext("AvailableStatusController", recorder)
+	if spokeMetadataClient != nil {
+		controller.watcher = newResourceWatcher(spokeMetadataClient, func(workName string, after time.Duration) {
+			syncCtx.Queue().AddAfter(workName, after)
+		})
+	}
+
+	controllerFactory := factory.New().
+		WithSyncContext(syncCtx).
 		WithInformersQueueKeysFunc(queue.QueueKeyByMetaName, manifestWorkInformer.Informer()).
-		WithSync(controller.sync).ToController("AvailableStatusController", recorder), nil
+		WithSync(controller.sync)
+	if rulesLoader != nil {
+		controllerFactory = controllerFactory.WithPostStartHooks(
+			func(ctx context.Context, syncCtx factory.SyncContext) error {
+				rulesLoader.Run(ctx, rulesconfig.DefaultReloadInterval, func() {
+					controller.resyncAll(syncCtx)
+				})
+				return nil
+			})
+	}
+	return controllerFactory.ToController("AvailableStatusController", recorder), nil
+}
+
+// resyncAll syncs the status of all manifestworks again, it is called when the well known rules are changed.
+func (c *AvailableStatusController) resyncAll(syncCtx factory.SyncContext) {
+	works, err := c.manifestWorkLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list manifestworks: %v", err)
+		return
+	}
+	for _, work := range works {
+		if c.watcher != nil {
+			c.watcher.forget(work.Name)
+		}
+		syncCtx.Queue().Add(work.Name)
+	}
 }
 
 func (c *AvailableStatusController) sync(ctx context.Context, controllerContext factory.SyncContext) error {
@@ -82,7 +144,10 @@ func (c *AvailableStatusController) sync(ctx context.Context, controllerContext
 	// sync a particular manifestwork
 	manifestWork, err := c.manifestWorkLister.Get(manifestWorkName)
 	if errors.IsNotFound(err) {
-		// work not found, could have been deleted, do nothing.
+		// work not found, could have been deleted, stop watching its resources.
+		if c.watcher != nil {
+			c.watcher.untrack(manifestWorkName)
+		}
 		return nil
 	}
 	if err != nil {
@@ -94,11 +159,27 @@ func (c *AvailableStatusController) sync(ctx context.Context, controllerContext
 		return fmt.Errorf("unable to sync manifestwork %q: %w", manifestWork.Name, err)
 	}
 
+	// the manifestwork is requeued by the watcher when its resources are changed, and it is still resynced
+	// periodically if the health check is enabled, or any informer of its resources is not synced yet, since
+	// the changes of those resources are not watched.
+	if c.watcher != nil && !IsHealthCheckEnabled(manifestWork) && c.watcher.synced(manifestWorkName) {
+		return nil
+	}
+
 	// requeue with a certain jitter
 	controllerContext.Queue().AddAfter(manifestWorkName, wait.Jitter(c.syncInterval, 0.9))
 	return nil
 }
 
+// workRevision returns the revision of the manifestwork which the synced status is based on, it is changed when
+// the spec of the manifestwork is changed or a sync is requested by the sync-now annotation.
+func workRevision(manifestWork *workapiv1.ManifestWork) string {
+	if token, ok := manifestWork.Annotations[helper.SyncNowAnnotationKey]; ok {
+		return fmt.Sprintf("%d-%s", manifestWork.Generation, token)
+	}
+	return strconv.FormatInt(manifestWork.Generation, 10)
+}
+
 func (c *AvailableStatusController) syncManifestWork(ctx context.Context, originalManifestWork *workapiv1.ManifestWork) error {
 	klog.V(5).Infof("Reconciling ManifestWork %q", originalManifestWork.Name)
 	manifestWork := originalManifestWork.DeepCopy()
@@ -113,13 +194,38 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 		return nil
 	}
 
+	// watch the resources of the manifestwork, and only sync the status of the changed resources.
+	observedVersions := map[resourceKey]string{}
+	if c.watcher != nil {
+		c.watcher.track(manifestWork.Name, manifestWork.Status.ResourceStatus.Manifests)
+	}
+
+	// the feedback rules of CEL expressions defined in the annotation
+	celRules, celRulesErr := helper.GetCELFeedbackRules(manifestWork)
+	healthCheck := IsHealthCheckEnabled(manifestWork)
+
 	// handle status condition of manifests
 	// TODO revist this controller since this might bring races when user change the manifests in spec.
 	for index, manifest := range manifestWork.Status.ResourceStatus.Manifests {
+		if c.watcher != nil {
+			if key, ok := newResourceKey(manifest.ResourceMeta); ok {
+				// the health of some resources depends on the other resources, e.g. a service depends on its
+				// endpointslices, so the resources are synced at each resync if the health check is enabled.
+				changed, version := c.watcher.changed(manifestWork.Name, workRevision(manifestWork), key)
+				if !changed && !healthCheck {
+					continue
+				}
+				observedVersions[key] = version
+			}
+		}
+
 		obj, availableStatusCondition, err := buildAvailableStatusCondition(manifest.ResourceMeta, c.spokeDynamicClient)
 		manifestConditions := &manifestWork.Status.ResourceStatus.Manifests[index].Conditions
 		meta.SetStatusCondition(manifestConditions, availableStatusCondition)
 		if err != nil {
+			if healthCheck {
+				setUnavailableHealthCondition(manifestConditions, availableStatusCondition)
+			}
 			// skip getting status values if resource is not available.
 			continue
 		}
@@ -127,7 +233,16 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 		option := helper.FindManifestConfiguration(manifest.ResourceMeta, manifestWork.Spec.ManifestConfigs)
 
 		// Read status of the resource according to feedback rules.
-		values, statusFeedbackCondition := c.getFeedbackValues(obj, option)
+		values, statusFeedbackCondition := c.getFeedbackValues(
+			obj, withCELFeedbackRules(manifest.ResourceMeta, option, celRules))
+		if celRulesErr != nil {
+			statusFeedbackCondition = metav1.Condition{
+				Type:    statusFeedbackConditionType,
+				Reason:  "StatusFeedbackSyncFailed",
+				Status:  metav1.ConditionFalse,
+				Message: fmt.Sprintf("Sync status feedback failed with error %v", celRulesErr),
+			}
+		}
 		meta.SetStatusCondition(manifestConditions, statusFeedbackCondition)
 		manifestWork.Status.ResourceStatus.Manifests[index].StatusFeedbacks.Values = values
 
@@ -136,23 +251,56 @@ func (c *AvailableStatusController) syncManifestWork(ctx context.Context, origin
 		for _, condition := range conditions {
 			meta.SetStatusCondition(manifestConditions, condition)
 		}
+
+		// Evaluate the health of the resource
+		if healthCheck {
+			c.setHealthConditions(ctx, obj, conditions, manifestConditions)
+		} else {
+			removeHealthConditions(manifestConditions, ManifestHealthy, manifestHealthReasons)
+		}
 	}
 
 	// aggregate ManifestConditions and update work status condition
 	workAvailableStatusCondition := aggregateManifestConditions(manifestWork.Generation, manifestWork.Status.ResourceStatus.Manifests)
 	meta.SetStatusCondition(&manifestWork.Status.Conditions, workAvailableStatusCondition)
+	if healthCheck {
+		for _, condition := range aggregateHealthConditions(manifestWork.Generation, manifestWork.Status.ResourceStatus.Manifests) {
+			meta.SetStatusCondition(&manifestWork.Status.Conditions, condition)
+		}
+	} else {
+		for _, conditionType := range []string{WorkHealthy, workapiv1.WorkProgressing, workapiv1.WorkDegraded} {
+			removeHealthConditions(&manifestWork.Status.Conditions, conditionType, workHealthReasons)
+		}
+	}
 
 	// no work if the status of manifestwork does not change
 	if equality.Semantic.DeepEqual(originalManifestWork.Status.ResourceStatus, manifestWork.Status.ResourceStatus) &&
 		equality.Semantic.DeepEqual(originalManifestWork.Status.Conditions, manifestWork.Status.Conditions) {
+		c.observe(manifestWork.Name, observedVersions)
 		return nil
 	}
 
+	// the versions are not observed until the patched status is synced back to the manifestwork, so
+	// all of the resources are synced again when the manifestwork is updated.
+	if c.watcher != nil {
+		c.watcher.forget(manifestWork.Name)
+	}
+
 	// update status of manifestwork. if this conflicts, try again later
 	_, err := c.patcher.PatchStatus(ctx, manifestWork, manifestWork.Status, originalManifestWork.Status)
 	return err
 }
 
+// observe records the versions of the resources whose status is already in the manifestwork.
+func (c *AvailableStatusController) observe(workName string, versions map[resourceKey]string) {
+	if c.watcher == nil {
+		return
+	}
+	for key, version := range versions {
+		c.watcher.observe(workName, key, version)
+	}
+}
+
 // aggregateManifestConditions aggregates status conditions of manifests and returns a status
 // condition for manifestwork
 func aggregateManifestConditions(generation int64, manifests []workapiv1.ManifestCondition) metav1.Condition {
@@ -0,0 +1,44 @@
+package statuscontroller
+
+import (
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+)
+
+// CELFeedbackRulesAnnotationKey is the annotation on the manifestwork to define the feedback values returned by the
+// CEL expressions, see helper.CELFeedbackRulesAnnotationKey.
+const CELFeedbackRulesAnnotationKey = helper.CELFeedbackRulesAnnotationKey
+
+// withCELFeedbackRules returns the manifest config option with the feedback rule of the CEL expressions of the
+// first rule matching the resource appended.
+func withCELFeedbackRules(resourceMeta workapiv1.ManifestResourceMeta, option *workapiv1.ManifestConfigOption,
+	celRules []helper.CELFeedbackRule) *workapiv1.ManifestConfigOption {
+	var configs []workapiv1.ManifestConfigOption
+	for _, rule := range celRules {
+		configs = append(configs, workapiv1.ManifestConfigOption{
+			ResourceIdentifier: rule.ResourceIdentifier,
+			FeedbackRules:      []workapiv1.FeedbackRule{{Type: statusfeedback.CELType, JsonPaths: celJsonPaths(rule)}},
+		})
+	}
+	matched := helper.FindManifestConfiguration(resourceMeta, configs)
+	if matched == nil {
+		return option
+	}
+
+	if option == nil {
+		return matched
+	}
+	option = option.DeepCopy()
+	option.FeedbackRules = append(option.FeedbackRules, matched.FeedbackRules...)
+	return option
+}
+
+func celJsonPaths(rule helper.CELFeedbackRule) []workapiv1.JsonPath {
+	var paths []workapiv1.JsonPath
+	for _, expression := range rule.Expressions {
+		paths = append(paths, workapiv1.JsonPath{Name: expression.Name, Path: expression.Expression})
+	}
+	return paths
+}
@@ -0,0 +1,100 @@
+package statuscontroller
+
+import (
+	"testing"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+)
+
+func TestCELFeedbackRules(t *testing.T) {
+	resourceMeta := workapiv1.ManifestResourceMeta{
+		Group: "apps", Version: "v1", Resource: "deployments", Namespace: "ns1", Name: "deploy1"}
+	wellKnownStatus := &workapiv1.ManifestConfigOption{
+		ResourceIdentifier: workapiv1.ResourceIdentifier{
+			Group: "apps", Resource: "deployments", Namespace: "ns1", Name: "deploy1"},
+		FeedbackRules: []workapiv1.FeedbackRule{{Type: workapiv1.WellKnownStatusType}},
+	}
+
+	cases := []struct {
+		name          string
+		annotation    string
+		option        *workapiv1.ManifestConfigOption
+		expectErr     bool
+		expectedTypes []workapiv1.FeedBackType
+	}{
+		{
+			name:          "no annotation",
+			option:        wellKnownStatus,
+			expectedTypes: []workapiv1.FeedBackType{workapiv1.WellKnownStatusType},
+		},
+		{
+			name:       "invalid annotation",
+			annotation: `{"resourceIdentifier": {}}`,
+			expectErr:  true,
+		},
+		{
+			name:       "expression is missing",
+			annotation: `[{"resourceIdentifier": {"resource": "deployments", "name": "*", "namespace": "*"}, "expressions": [{"name": "ready"}]}]`,
+			expectErr:  true,
+		},
+		{
+			name: "rule is not matched",
+			annotation: `[{"resourceIdentifier": {"resource": "pods", "name": "*", "namespace": "*"}, ` +
+				`"expressions": [{"name": "ready", "expression": "true"}]}]`,
+			option:        wellKnownStatus,
+			expectedTypes: []workapiv1.FeedBackType{workapiv1.WellKnownStatusType},
+		},
+		{
+			name: "rule is appended",
+			annotation: `[{"resourceIdentifier": {"group": "apps", "resource": "deployments", "name": "*", "namespace": "ns1"}, ` +
+				`"expressions": [{"name": "ready", "expression": "true"}]}]`,
+			option:        wellKnownStatus,
+			expectedTypes: []workapiv1.FeedBackType{workapiv1.WellKnownStatusType, statusfeedback.CELType},
+		},
+		{
+			name: "rule without manifest config",
+			annotation: `[{"resourceIdentifier": {"group": "apps", "resource": "deployments", "name": "deploy1", "namespace": "ns1"}, ` +
+				`"expressions": [{"name": "ready", "expression": "true"}]}]`,
+			expectedTypes: []workapiv1.FeedBackType{statusfeedback.CELType},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work, _ := spoketesting.NewManifestWork(0)
+			if len(c.annotation) > 0 {
+				work.Annotations = map[string]string{CELFeedbackRulesAnnotationKey: c.annotation}
+			}
+			rules, err := helper.GetCELFeedbackRules(work)
+			if c.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, but got %v", c.expectErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			option := withCELFeedbackRules(resourceMeta, c.option, rules)
+			var types []workapiv1.FeedBackType
+			if option != nil {
+				for _, rule := range option.FeedbackRules {
+					types = append(types, rule.Type)
+				}
+			}
+			if len(types) != len(c.expectedTypes) {
+				t.Fatalf("expected feedback rule types %v, but got %v", c.expectedTypes, types)
+			}
+			for i := range types {
+				if types[i] != c.expectedTypes[i] {
+					t.Errorf("expected feedback rule types %v, but got %v", c.expectedTypes, types)
+				}
+			}
+			if c.option != nil && len(c.option.FeedbackRules) != 1 {
+				t.Errorf("the manifest config option should not be changed")
+			}
+		})
+	}
+}
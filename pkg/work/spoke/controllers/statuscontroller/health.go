@@ -0,0 +1,200 @@
+package statuscontroller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/spoke/health"
+)
+
+const (
+	// HealthCheckAnnotationKey is the annotation key on the ManifestWork to enable the health evaluation of the
+	// manifests, see helper.HealthCheckAnnotationKey.
+	HealthCheckAnnotationKey = helper.HealthCheckAnnotationKey
+
+	// ManifestHealthy is the condition type of a manifest which reports whether the resource reaches the desired
+	// state. It could also be evaluated by a condition rule for the kinds without built-in health checks.
+	ManifestHealthy = "Healthy"
+	// WorkHealthy is the condition type of a ManifestWork which reports whether all of its resources are healthy.
+	WorkHealthy = "Healthy"
+
+	ManifestHealthyReason       = "ResourceHealthy"
+	ManifestProgressingReason   = "ResourceProgressing"
+	ManifestDegradedReason      = "ResourceDegraded"
+	ManifestHealthUnknownReason = "HealthCheckFailed"
+
+	WorkHealthyReason       = "ResourcesHealthy"
+	WorkProgressingReason   = "ResourcesProgressing"
+	WorkDegradedReason      = "ResourcesDegraded"
+	WorkHealthUnknownReason = "ResourcesHealthUnknown"
+
+	healthCheckEnabled = helper.HealthCheckEnabled
+)
+
+var (
+	manifestHealthReasons = sets.New(ManifestHealthyReason, ManifestProgressingReason, ManifestDegradedReason,
+		ManifestHealthUnknownReason, "ResourceNotAvailable", "IncompletedResourceMeta", "FetchingResourceFailed")
+	workHealthReasons = sets.New(WorkHealthyReason, WorkProgressingReason, WorkDegradedReason, WorkHealthUnknownReason)
+)
+
+// IsHealthCheckEnabled returns true if the health of the manifests is evaluated. The Progressing condition of the
+// ManifestWork is owned by the health evaluation in this case.
+func IsHealthCheckEnabled(manifestWork *workapiv1.ManifestWork) bool {
+	return manifestWork.Annotations[HealthCheckAnnotationKey] == healthCheckEnabled
+}
+
+// buildHealthCondition returns the Healthy condition of the resource evaluated by the built-in health check, or
+// the Healthy condition evaluated by the condition rules. It returns nil if the health could not be evaluated.
+func (c *AvailableStatusController) buildHealthCondition(ctx context.Context, obj *unstructured.Unstructured,
+	ruleConditions []metav1.Condition) *metav1.Condition {
+	result, ok, err := c.healthChecker.Check(ctx, obj)
+	switch {
+	case err != nil:
+		return &metav1.Condition{
+			Type:    ManifestHealthy,
+			Status:  metav1.ConditionUnknown,
+			Reason:  ManifestHealthUnknownReason,
+			Message: fmt.Sprintf("Failed to check the health: %v", err),
+		}
+	case !ok:
+		return meta.FindStatusCondition(ruleConditions, ManifestHealthy)
+	}
+
+	condition := &metav1.Condition{
+		Type:    ManifestHealthy,
+		Status:  metav1.ConditionFalse,
+		Message: result.Message,
+	}
+	switch result.Status {
+	case health.Healthy:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ManifestHealthyReason
+	case health.Progressing:
+		condition.Reason = ManifestProgressingReason
+	default:
+		condition.Reason = ManifestDegradedReason
+	}
+	return condition
+}
+
+// setHealthConditions sets the Healthy condition of the manifest, and sets the Available condition to False if
+// the resource is not healthy.
+func (c *AvailableStatusController) setHealthConditions(ctx context.Context, obj *unstructured.Unstructured,
+	ruleConditions []metav1.Condition, manifestConditions *[]metav1.Condition) {
+	condition := c.buildHealthCondition(ctx, obj, ruleConditions)
+	if condition == nil {
+		removeHealthConditions(manifestConditions, ManifestHealthy, manifestHealthReasons)
+		return
+	}
+
+	meta.SetStatusCondition(manifestConditions, *condition)
+	if condition.Status != metav1.ConditionFalse {
+		return
+	}
+	meta.SetStatusCondition(manifestConditions, metav1.Condition{
+		Type:    workapiv1.ManifestAvailable,
+		Status:  metav1.ConditionFalse,
+		Reason:  condition.Reason,
+		Message: condition.Message,
+	})
+}
+
+// setUnavailableHealthCondition sets the Healthy condition of the manifest whose resource is not available.
+func setUnavailableHealthCondition(manifestConditions *[]metav1.Condition, availableCondition metav1.Condition) {
+	meta.SetStatusCondition(manifestConditions, metav1.Condition{
+		Type:    ManifestHealthy,
+		Status:  availableCondition.Status,
+		Reason:  availableCondition.Reason,
+		Message: availableCondition.Message,
+	})
+}
+
+// removeHealthConditions removes the condition only if it is set by the health evaluation, so the conditions with
+// the same type set by the condition rules are kept.
+func removeHealthConditions(conditions *[]metav1.Condition, conditionType string, reasons sets.Set[string]) {
+	if condition := meta.FindStatusCondition(*conditions, conditionType); condition != nil && reasons.Has(condition.Reason) {
+		meta.RemoveStatusCondition(conditions, conditionType)
+	}
+}
+
+// aggregateHealthConditions aggregates the Healthy conditions of the manifests and returns the Healthy,
+// Progressing and Degraded conditions of the ManifestWork. The manifests without Healthy condition are ignored,
+// and the manifests which are still progressing to be applied, e.g. blocked by apply waves, are progressing.
+func aggregateHealthConditions(generation int64, manifests []workapiv1.ManifestCondition) []metav1.Condition {
+	healthy, progressing, degraded, unknown := 0, 0, 0, 0
+	for _, manifest := range manifests {
+		condition := meta.FindStatusCondition(manifest.Conditions, ManifestHealthy)
+		switch {
+		case meta.IsStatusConditionTrue(manifest.Conditions, workapiv1.ManifestProgressing):
+			progressing += 1
+		case condition == nil:
+		case condition.Status == metav1.ConditionTrue:
+			healthy += 1
+		case condition.Status == metav1.ConditionUnknown:
+			unknown += 1
+		case condition.Reason == ManifestProgressingReason:
+			progressing += 1
+		default:
+			degraded += 1
+		}
+	}
+	total := healthy + progressing + degraded + unknown
+
+	healthyCondition := metav1.Condition{
+		Type:               WorkHealthy,
+		Status:             metav1.ConditionTrue,
+		Reason:             WorkHealthyReason,
+		ObservedGeneration: generation,
+		Message:            fmt.Sprintf("%d of %d resources are healthy", healthy, total),
+	}
+	switch {
+	case degraded > 0:
+		healthyCondition.Status = metav1.ConditionFalse
+		healthyCondition.Reason = WorkDegradedReason
+		healthyCondition.Message = fmt.Sprintf("%d of %d resources are degraded", degraded, total)
+	case progressing > 0:
+		healthyCondition.Status = metav1.ConditionFalse
+		healthyCondition.Reason = WorkProgressingReason
+		healthyCondition.Message = fmt.Sprintf("%d of %d resources are progressing", progressing, total)
+	case unknown > 0:
+		healthyCondition.Status = metav1.ConditionUnknown
+		healthyCondition.Reason = WorkHealthUnknownReason
+		healthyCondition.Message = fmt.Sprintf("%d of %d resources have unknown health", unknown, total)
+	}
+
+	progressingCondition := metav1.Condition{
+		Type:               workapiv1.WorkProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             WorkHealthyReason,
+		ObservedGeneration: generation,
+		Message:            "No resource is progressing",
+	}
+	if progressing > 0 {
+		progressingCondition.Status = metav1.ConditionTrue
+		progressingCondition.Reason = WorkProgressingReason
+		progressingCondition.Message = fmt.Sprintf("%d of %d resources are progressing", progressing, total)
+	}
+
+	degradedCondition := metav1.Condition{
+		Type:               workapiv1.WorkDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             WorkHealthyReason,
+		ObservedGeneration: generation,
+		Message:            "No resource is degraded",
+	}
+	if degraded > 0 {
+		degradedCondition.Status = metav1.ConditionTrue
+		degradedCondition.Reason = WorkDegradedReason
+		degradedCondition.Message = fmt.Sprintf("%d of %d resources are degraded", degraded, total)
+	}
+
+	return []metav1.Condition{healthyCondition, progressingCondition, degradedCondition}
+}
@@ -0,0 +1,235 @@
+package statuscontroller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/conditions"
+	"open-cluster-management.io/ocm/pkg/work/spoke/health"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback"
+)
+
+func newDeployment(name string, available int64) runtime.Object {
+	return testingcommon.NewUnstructuredWithContent("apps/v1", "Deployment", "ns1", name, map[string]any{
+		"spec": map[string]any{"replicas": int64(1)},
+		"status": map[string]any{
+			"updatedReplicas":   int64(1),
+			"replicas":          int64(1),
+			"availableReplicas": available,
+			"conditions": []any{
+				map[string]any{"type": "Progressing", "status": "True", "reason": "NewReplicaSetAvailable"},
+			},
+		},
+	})
+}
+
+func TestHealthCheck(t *testing.T) {
+	healthyRule := workapiv1.ConditionRule{
+		Type:           workapiv1.CelConditionExpressionsType,
+		Condition:      ManifestHealthy,
+		CelExpressions: []string{"object.spec.key1 == 'val1'"},
+	}
+
+	cases := []struct {
+		name                       string
+		annotations                map[string]string
+		existingResources          []runtime.Object
+		manifests                  []workapiv1.ManifestCondition
+		workConditions             []metav1.Condition
+		expectedManifestConditions [][]metav1.Condition
+		expectedWorkConditions     []metav1.Condition
+		unexpectedWorkConditions   []string
+	}{
+		{
+			name:              "all resources are healthy",
+			annotations:       map[string]string{HealthCheckAnnotationKey: "Enabled"},
+			existingResources: []runtime.Object{newDeployment("deploy1", 1), testingcommon.NewUnstructuredSecret("ns1", "n1", false, "ns1-n1")},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+				newManifest("", "v1", "secrets", "ns1", "n1"),
+			},
+			expectedManifestConditions: [][]metav1.Condition{
+				{
+					{Type: ManifestHealthy, Status: metav1.ConditionTrue, Reason: ManifestHealthyReason},
+					{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionTrue},
+				},
+				{
+					{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionTrue},
+				},
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue},
+				{Type: WorkHealthy, Status: metav1.ConditionTrue, Reason: WorkHealthyReason},
+				{Type: workapiv1.WorkProgressing, Status: metav1.ConditionFalse},
+				{Type: workapiv1.WorkDegraded, Status: metav1.ConditionFalse},
+			},
+		},
+		{
+			name:              "crash looping deployment is not available",
+			annotations:       map[string]string{HealthCheckAnnotationKey: "Enabled"},
+			existingResources: []runtime.Object{newDeployment("deploy1", 0)},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+			},
+			expectedManifestConditions: [][]metav1.Condition{
+				{
+					{Type: ManifestHealthy, Status: metav1.ConditionFalse, Reason: ManifestDegradedReason},
+					{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionFalse, Reason: ManifestDegradedReason},
+				},
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkAvailable, Status: metav1.ConditionFalse},
+				{Type: WorkHealthy, Status: metav1.ConditionFalse, Reason: WorkDegradedReason},
+				{Type: workapiv1.WorkDegraded, Status: metav1.ConditionTrue},
+			},
+		},
+		{
+			name:        "health is evaluated by condition rules",
+			annotations: map[string]string{HealthCheckAnnotationKey: "Enabled"},
+			existingResources: []runtime.Object{testingcommon.NewUnstructuredWithContent("v1", "NewObject", "ns1", "n1",
+				map[string]any{"spec": map[string]any{"key1": "val2"}})},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("", "v1", "newobjects", "ns1", "n1"),
+			},
+			expectedManifestConditions: [][]metav1.Condition{
+				{
+					{Type: ManifestHealthy, Status: metav1.ConditionFalse, Reason: workapiv1.ConditionRuleEvaluated},
+					{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionFalse},
+				},
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: WorkHealthy, Status: metav1.ConditionFalse, Reason: WorkDegradedReason},
+			},
+		},
+		{
+			name:              "manifest blocked by apply waves is progressing",
+			annotations:       map[string]string{HealthCheckAnnotationKey: "Enabled"},
+			existingResources: []runtime.Object{newDeployment("deploy1", 1)},
+			manifests: []workapiv1.ManifestCondition{
+				newManifest("apps", "v1", "deployments", "ns1", "deploy1"),
+				func() workapiv1.ManifestCondition {
+					manifest := newManifest("apps", "v1", "deployments", "ns1", "deploy2")
+					manifest.Conditions = []metav1.Condition{
+						{Type: workapiv1.ManifestProgressing, Status: metav1.ConditionTrue, Reason: "ApplyBlocked"}}
+					return manifest
+				}(),
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: WorkHealthy, Status: metav1.ConditionFalse, Reason: WorkProgressingReason},
+				{Type: workapiv1.WorkProgressing, Status: metav1.ConditionTrue, Reason: WorkProgressingReason},
+				{Type: workapiv1.WorkDegraded, Status: metav1.ConditionFalse},
+			},
+		},
+		{
+			name:              "health conditions are removed when health check is disabled",
+			existingResources: []runtime.Object{newDeployment("deploy1", 0)},
+			manifests: []workapiv1.ManifestCondition{
+				func() workapiv1.ManifestCondition {
+					manifest := newManifest("apps", "v1", "deployments", "ns1", "deploy1")
+					manifest.Conditions = []metav1.Condition{
+						{Type: ManifestHealthy, Status: metav1.ConditionFalse, Reason: ManifestDegradedReason}}
+					return manifest
+				}(),
+			},
+			workConditions: []metav1.Condition{
+				{Type: WorkHealthy, Status: metav1.ConditionFalse, Reason: WorkDegradedReason},
+				{Type: workapiv1.WorkDegraded, Status: metav1.ConditionTrue, Reason: WorkDegradedReason},
+			},
+			expectedManifestConditions: [][]metav1.Condition{
+				{
+					{Type: workapiv1.ManifestAvailable, Status: metav1.ConditionTrue},
+				},
+			},
+			expectedWorkConditions: []metav1.Condition{
+				{Type: workapiv1.WorkAvailable, Status: metav1.ConditionTrue},
+			},
+			unexpectedWorkConditions: []string{WorkHealthy, workapiv1.WorkDegraded},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			testingWork, _ := spoketesting.NewManifestWork(0)
+			testingWork.Annotations = c.annotations
+			testingWork.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+			testingWork.Spec.ManifestConfigs = []workapiv1.ManifestConfigOption{
+				{
+					ResourceIdentifier: workapiv1.ResourceIdentifier{Resource: "newobjects", Namespace: "ns1", Name: "n1"},
+					ConditionRules:     []workapiv1.ConditionRule{healthyRule},
+				},
+			}
+			testingWork.Status = workapiv1.ManifestWorkStatus{
+				ResourceStatus: workapiv1.ManifestResourceStatus{Manifests: c.manifests},
+				Conditions:     append([]metav1.Condition{{Type: workapiv1.WorkApplied}}, c.workConditions...),
+			}
+
+			fakeClient := fakeworkclient.NewSimpleClientset(testingWork)
+			fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), c.existingResources...)
+			conditionReader, err := conditions.NewConditionReader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			controller := AvailableStatusController{
+				spokeDynamicClient: fakeDynamicClient,
+				statusReader:       statusfeedback.NewStatusReader(),
+				conditionReader:    conditionReader,
+				healthChecker:      health.NewChecker(fakeDynamicClient),
+				patcher: patcher.NewPatcher[
+					*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+					fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
+			}
+
+			if err := controller.syncManifestWork(context.TODO(), testingWork); err != nil {
+				t.Fatal(err)
+			}
+
+			actions := fakeClient.Actions()
+			testingcommon.AssertActions(t, actions, "patch")
+			work := &workapiv1.ManifestWork{}
+			if err := json.Unmarshal(actions[0].(clienttesting.PatchActionImpl).Patch, work); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expectedConditions := range c.expectedManifestConditions {
+				manifestConditions := work.Status.ResourceStatus.Manifests[i].Conditions
+				for _, expected := range expectedConditions {
+					assertCondition(t, manifestConditions, expected)
+				}
+				if len(expectedConditions) == 1 && meta.FindStatusCondition(manifestConditions, ManifestHealthy) != nil {
+					t.Errorf("unexpected healthy condition %s", spew.Sdump(manifestConditions))
+				}
+			}
+			for _, expected := range c.expectedWorkConditions {
+				assertCondition(t, work.Status.Conditions, expected)
+			}
+			for _, conditionType := range c.unexpectedWorkConditions {
+				if meta.FindStatusCondition(work.Status.Conditions, conditionType) != nil {
+					t.Errorf("unexpected condition %s in %s", conditionType, spew.Sdump(work.Status.Conditions))
+				}
+			}
+		})
+	}
+}
+
+func assertCondition(t *testing.T, conditions []metav1.Condition, expected metav1.Condition) {
+	condition := meta.FindStatusCondition(conditions, expected.Type)
+	if condition == nil || condition.Status != expected.Status ||
+		(len(expected.Reason) > 0 && condition.Reason != expected.Reason) {
+		t.Errorf("expected condition %s %s %s, but got %s",
+			expected.Type, expected.Status, expected.Reason, spew.Sdump(conditions))
+	}
+}
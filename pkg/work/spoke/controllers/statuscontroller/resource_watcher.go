@@ -0,0 +1,296 @@
+package statuscontroller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+// watchDebounceInterval is the interval to collect the changes of the resources before a manifestwork is synced.
+var watchDebounceInterval = 1 * time.Second
+
+type resourceKey struct {
+	gvr       schema.GroupVersionResource
+	namespace string
+	name      string
+}
+
+func newResourceKey(resourceMeta workapiv1.ManifestResourceMeta) (resourceKey, bool) {
+	if len(resourceMeta.Resource) == 0 || len(resourceMeta.Version) == 0 || len(resourceMeta.Name) == 0 {
+		return resourceKey{}, false
+	}
+	return resourceKey{
+		gvr: schema.GroupVersionResource{
+			Group:    resourceMeta.Group,
+			Version:  resourceMeta.Version,
+			Resource: resourceMeta.Resource,
+		},
+		namespace: resourceMeta.Namespace,
+		name:      resourceMeta.Name,
+	}, true
+}
+
+type gvrInformer struct {
+	informer cache.SharedIndexInformer
+	stopCh   chan struct{}
+}
+
+// resourceWatcher watches the resources of the manifestworks with the metadata-only informers, so the status of a
+// manifestwork is only synced when its resources are changed. An informer is started for a GVR when a manifestwork
+// has a resource of the GVR, and is stopped when no manifestwork has the resource of the GVR any more.
+type resourceWatcher struct {
+	metadataClient metadata.Interface
+	enqueue        func(workName string, after time.Duration)
+
+	lock      sync.Mutex
+	informers map[schema.GroupVersionResource]*gvrInformer
+	// resources is the resources of each manifestwork, keyed by the manifestwork name.
+	resources map[string]sets.Set[resourceKey]
+	// works is the manifestworks of each resource.
+	works map[resourceKey]sets.Set[string]
+	// observed is the observed version of the resources of each manifestwork when the status is synced last time.
+	observed map[string]map[resourceKey]string
+}
+
+func newResourceWatcher(metadataClient metadata.Interface, enqueue func(workName string, after time.Duration)) *resourceWatcher {
+	return &resourceWatcher{
+		metadataClient: metadataClient,
+		enqueue:        enqueue,
+		informers:      map[schema.GroupVersionResource]*gvrInformer{},
+		resources:      map[string]sets.Set[resourceKey]{},
+		works:          map[resourceKey]sets.Set[string]{},
+		observed:       map[string]map[resourceKey]string{},
+	}
+}
+
+// track updates the resources of the manifestwork, and starts or stops the informers accordingly.
+func (w *resourceWatcher) track(workName string, manifests []workapiv1.ManifestCondition) {
+	keys := sets.New[resourceKey]()
+	for _, manifest := range manifests {
+		if key, ok := newResourceKey(manifest.ResourceMeta); ok {
+			keys.Insert(key)
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for key := range w.resources[workName].Difference(keys) {
+		w.removeWork(key, workName)
+		delete(w.observed[workName], key)
+	}
+	for key := range keys {
+		if _, ok := w.works[key]; !ok {
+			w.works[key] = sets.New[string]()
+		}
+		w.works[key].Insert(workName)
+		w.startInformer(key.gvr)
+	}
+	w.resources[workName] = keys
+	w.stopUnusedInformers()
+}
+
+// untrack removes the manifestwork and stops the informers which are not used any more.
+func (w *resourceWatcher) untrack(workName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for key := range w.resources[workName] {
+		w.removeWork(key, workName)
+	}
+	delete(w.resources, workName)
+	delete(w.observed, workName)
+	w.stopUnusedInformers()
+}
+
+func (w *resourceWatcher) removeWork(key resourceKey, workName string) {
+	works, ok := w.works[key]
+	if !ok {
+		return
+	}
+	works.Delete(workName)
+	if works.Len() == 0 {
+		delete(w.works, key)
+	}
+}
+
+func (w *resourceWatcher) startInformer(gvr schema.GroupVersionResource) {
+	if _, ok := w.informers[gvr]; ok {
+		return
+	}
+
+	// the informer watches the resources of the GVR in all namespaces, so the memory of the agent grows with the
+	// number of the resources of the GVR on the managed cluster, not only the ones applied by the manifestworks.
+	informer := metadatainformer.NewFilteredMetadataInformer(
+		w.metadataClient, gvr, metav1.NamespaceAll, 0, cache.Indexers{}, nil).Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { w.onChange(gvr, obj) },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.onChange(gvr, newObj)
+		},
+		DeleteFunc: func(obj interface{}) { w.onChange(gvr, obj) },
+	})
+	if err != nil {
+		klog.Errorf("failed to add event handler for %s: %v", gvr, err)
+		return
+	}
+
+	stopCh := make(chan struct{})
+	gvrInformer := &gvrInformer{informer: informer, stopCh: stopCh}
+	err = informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(r, err)
+		w.onWatchError(gvr, gvrInformer)
+	})
+	if err != nil {
+		klog.Errorf("failed to set watch error handler for %s: %v", gvr, err)
+		return
+	}
+
+	w.informers[gvr] = gvrInformer
+	go informer.Run(stopCh)
+	klog.V(4).Infof("Started the metadata informer for %s", gvr)
+}
+
+// onWatchError stops the informer if it fails to list the resources before it is synced, e.g. the agent is not
+// allowed to list the resources of the GVR, so it does not retry forever. The informer is started again when the
+// manifestworks are resynced, which happens periodically until all of their informers are synced.
+func (w *resourceWatcher) onWatchError(gvr schema.GroupVersionResource, informer *gvrInformer) {
+	if informer.informer.HasSynced() {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.informers[gvr] != informer {
+		return
+	}
+	close(informer.stopCh)
+	delete(w.informers, gvr)
+	klog.Infof("Stopped the metadata informer for %s since it failed to list the resources", gvr)
+}
+
+func (w *resourceWatcher) stopUnusedInformers() {
+	used := sets.New[schema.GroupVersionResource]()
+	for key := range w.works {
+		used.Insert(key.gvr)
+	}
+	for gvr, informer := range w.informers {
+		if used.Has(gvr) {
+			continue
+		}
+		close(informer.stopCh)
+		delete(w.informers, gvr)
+		klog.V(4).Infof("Stopped the metadata informer for %s", gvr)
+	}
+}
+
+// synced returns true if the informers of all the resources of the manifestwork are synced.
+func (w *resourceWatcher) synced(workName string) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for key := range w.resources[workName] {
+		informer, ok := w.informers[key.gvr]
+		if !ok || !informer.informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// onChange enqueues the manifestworks of the changed resource after the debounce interval, so the changes
+// in the interval are synced to the hub in one status patch.
+func (w *resourceWatcher) onChange(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	key := resourceKey{gvr: gvr, namespace: accessor.GetNamespace(), name: accessor.GetName()}
+
+	w.lock.Lock()
+	workNames := sets.List(w.works[key])
+	w.lock.Unlock()
+
+	for _, workName := range workNames {
+		w.enqueue(workName, watchDebounceInterval)
+	}
+}
+
+// version returns the version of the resource in the informer cache, it returns false if the informer of the
+// resource is not synced yet.
+func (w *resourceWatcher) version(key resourceKey) (string, bool) {
+	w.lock.Lock()
+	informer, ok := w.informers[key.gvr]
+	w.lock.Unlock()
+	if !ok || !informer.informer.HasSynced() {
+		return "", false
+	}
+
+	cacheKey := key.name
+	if len(key.namespace) > 0 {
+		cacheKey = fmt.Sprintf("%s/%s", key.namespace, key.name)
+	}
+	obj, exists, err := informer.informer.GetStore().GetByKey(cacheKey)
+	if err != nil {
+		return "", false
+	}
+	if !exists {
+		// the resource does not exist
+		return "-", true
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", false
+	}
+	return accessor.GetResourceVersion(), true
+}
+
+// changed returns true if the resource of the manifestwork is changed since the version is observed last time,
+// together with the current version of the resource. The revision of the manifestwork is part of the version,
+// since the status should be synced when the feedback rules or condition rules are changed, or a sync is
+// requested.
+func (w *resourceWatcher) changed(workName, workRevision string, key resourceKey) (bool, string) {
+	resourceVersion, ok := w.version(key)
+	if !ok {
+		return true, ""
+	}
+	version := fmt.Sprintf("%s/%s", workRevision, resourceVersion)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.observed[workName][key] != version, version
+}
+
+// observe records the version of the resource of the manifestwork whose status is synced.
+func (w *resourceWatcher) observe(workName string, key resourceKey, version string) {
+	if len(version) == 0 {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.observed[workName]; !ok {
+		w.observed[workName] = map[resourceKey]string{}
+	}
+	w.observed[workName][key] = version
+}
+
+// forget removes the observed versions of the manifestwork, so all of its resources are synced next time.
+func (w *resourceWatcher) forget(workName string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	delete(w.observed, workName)
+}
@@ -0,0 +1,229 @@
+package statuscontroller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakemetadata "k8s.io/client-go/metadata/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	fakeworkclient "open-cluster-management.io/api/client/work/clientset/versioned/fake"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/sdk-go/pkg/patcher"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/spoke/health"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+var secretGVR = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+func newSecretMetadata(namespace, name, resourceVersion string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ResourceVersion: resourceVersion},
+	}
+}
+
+type enqueued struct {
+	lock  sync.Mutex
+	names []string
+}
+
+func (e *enqueued) add(workName string, _ time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.names = append(e.names, workName)
+}
+
+func (e *enqueued) len() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return len(e.names)
+}
+
+func waitForSynced(t *testing.T, watcher *resourceWatcher, key resourceKey) {
+	if err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			_, synced := watcher.version(key)
+			return synced, nil
+		}); err != nil {
+		t.Fatalf("informer of %v is not synced: %v", key.gvr, err)
+	}
+}
+
+func TestResourceWatcher(t *testing.T) {
+	scheme := fakemetadata.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme, newSecretMetadata("ns1", "n1", "1"))
+	queue := &enqueued{}
+	watcher := newResourceWatcher(metadataClient, queue.add)
+
+	manifests := []workapiv1.ManifestCondition{newManifest("", "v1", "secrets", "ns1", "n1")}
+	key := resourceKey{gvr: secretGVR, namespace: "ns1", name: "n1"}
+	watcher.track("work1", manifests)
+	watcher.track("work2", manifests)
+	waitForSynced(t, watcher, key)
+
+	changed, version := watcher.changed("work1", "1", key)
+	if !changed || version != "1/1" {
+		t.Errorf("expected changed with version 1/1, but got %v, %s", changed, version)
+	}
+	watcher.observe("work1", key, version)
+	if changed, _ := watcher.changed("work1", "1", key); changed {
+		t.Errorf("expected not changed")
+	}
+	if changed, _ := watcher.changed("work1", "2", key); !changed {
+		t.Errorf("expected changed when the generation of the work is changed")
+	}
+	if changed, _ := watcher.changed("work1", "1-token", key); !changed {
+		t.Errorf("expected changed when a sync is requested")
+	}
+
+	// both works are enqueued when the resource is changed.
+	if _, err := metadataClient.Resource(secretGVR).Namespace("ns1").(fakemetadata.MetadataClient).UpdateFake(
+		newSecretMetadata("ns1", "n1", "2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			return queue.len() >= 3, nil
+		}); err != nil {
+		t.Errorf("expected works to be enqueued, but got %v", queue.names)
+	}
+	if changed, _ := watcher.changed("work1", "1", key); !changed {
+		t.Errorf("expected changed after the resource is updated")
+	}
+
+	// the informer is stopped when no work has the resource.
+	watcher.untrack("work1")
+	watcher.track("work2", nil)
+	if len(watcher.informers) != 0 || len(watcher.works) != 0 || len(watcher.resources["work2"]) != 0 {
+		t.Errorf("expected no informers, but got %v", watcher.informers)
+	}
+}
+
+func TestSyncManifestWorkWithWatch(t *testing.T) {
+	scheme := fakemetadata.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme, newSecretMetadata("ns1", "n1", "1"))
+
+	testingWork, _ := spoketesting.NewManifestWork(0)
+	testingWork.Finalizers = []string{workapiv1.ManifestWorkFinalizer}
+	testingWork.Status = workapiv1.ManifestWorkStatus{
+		Conditions: []metav1.Condition{{Type: workapiv1.WorkApplied, Status: metav1.ConditionTrue}},
+		ResourceStatus: workapiv1.ManifestResourceStatus{
+			Manifests: []workapiv1.ManifestCondition{newManifest("", "v1", "secrets", "ns1", "n1")},
+		},
+	}
+
+	fakeClient := fakeworkclient.NewSimpleClientset(testingWork)
+	fakeDynamicClient := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(),
+		testingcommon.NewUnstructuredSecret("ns1", "n1", false, "ns1-n1"))
+	controller := AvailableStatusController{
+		spokeDynamicClient: fakeDynamicClient,
+		patcher: patcher.NewPatcher[
+			*workapiv1.ManifestWork, workapiv1.ManifestWorkSpec, workapiv1.ManifestWorkStatus](
+			fakeClient.WorkV1().ManifestWorks(testingWork.Namespace)),
+		watcher: newResourceWatcher(metadataClient, (&enqueued{}).add),
+	}
+	controller.watcher.track(testingWork.Name, testingWork.Status.ResourceStatus.Manifests)
+	defer controller.watcher.untrack(testingWork.Name)
+	waitForSynced(t, controller.watcher, resourceKey{gvr: secretGVR, namespace: "ns1", name: "n1"})
+
+	// the resource is got and the status is patched at the first time
+	if err := controller.syncManifestWork(context.TODO(), testingWork); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fakeDynamicClient.Actions(), "get")
+	testingcommon.AssertActions(t, fakeClient.Actions(), "patch")
+
+	// the resource is got again when the patched status is synced back
+	fakeDynamicClient.ClearActions()
+	fakeClient.ClearActions()
+	syncedWork, err := fakeClient.WorkV1().ManifestWorks(testingWork.Namespace).Get(
+		context.TODO(), testingWork.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.syncManifestWork(context.TODO(), syncedWork); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fakeDynamicClient.Actions(), "get")
+	testingcommon.AssertActions(t, fakeClient.Actions(), "get")
+
+	// the resource is not got again if it is not changed
+	fakeDynamicClient.ClearActions()
+	if err := controller.syncManifestWork(context.TODO(), syncedWork); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertNoActions(t, fakeDynamicClient.Actions())
+
+	// the resource is got at each resync if the health check is enabled
+	syncedWork.Annotations = map[string]string{HealthCheckAnnotationKey: "Enabled"}
+	controller.healthChecker = health.NewChecker(fakeDynamicClient)
+	if err := controller.syncManifestWork(context.TODO(), syncedWork); err != nil {
+		t.Fatal(err)
+	}
+	testingcommon.AssertActions(t, fakeDynamicClient.Actions(), "get")
+}
+
+func TestResourceWatcherListFailure(t *testing.T) {
+	scheme := fakemetadata.NewTestScheme()
+	if err := metav1.AddMetaToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	metadataClient := fakemetadata.NewSimpleMetadataClient(scheme, newSecretMetadata("ns1", "n1", "1"))
+	forbidden := &atomic.Bool{}
+	forbidden.Store(true)
+	metadataClient.PrependReactor("list", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if forbidden.Load() {
+			return true, nil, errors.NewForbidden(secretGVR.GroupResource(), "", fmt.Errorf("not allowed"))
+		}
+		return false, nil, nil
+	})
+	watcher := newResourceWatcher(metadataClient, (&enqueued{}).add)
+	defer watcher.untrack("work1")
+
+	manifests := []workapiv1.ManifestCondition{newManifest("", "v1", "secrets", "ns1", "n1")}
+	key := resourceKey{gvr: secretGVR, namespace: "ns1", name: "n1"}
+
+	// the informer is stopped when it fails to list the resources.
+	watcher.track("work1", manifests)
+	if err := wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			watcher.lock.Lock()
+			defer watcher.lock.Unlock()
+			return len(watcher.informers) == 0, nil
+		}); err != nil {
+		t.Fatalf("expected the informer to be stopped, but got %v", watcher.informers)
+	}
+	if watcher.synced("work1") {
+		t.Errorf("expected work1 not synced")
+	}
+	if changed, version := watcher.changed("work1", "1", key); !changed || len(version) != 0 {
+		t.Errorf("expected changed without version, but got %v, %s", changed, version)
+	}
+
+	// the informer is started again when the work is resynced.
+	forbidden.Store(false)
+	watcher.track("work1", manifests)
+	waitForSynced(t, watcher, key)
+	if !watcher.synced("work1") {
+		t.Errorf("expected work1 synced")
+	}
+}
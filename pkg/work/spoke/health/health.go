@@ -0,0 +1,274 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Status is the health status of a resource.
+type Status string
+
+const (
+	// Healthy means the resource reaches the desired state, e.g. the rollout of a deployment is finished.
+	Healthy Status = "Healthy"
+	// Progressing means the resource is still progressing to the desired state.
+	Progressing Status = "Progressing"
+	// Degraded means the resource fails to reach the desired state, e.g. the replicas of a deployment are crash
+	// looping or a job is failed.
+	Degraded Status = "Degraded"
+)
+
+// Result is the health status of a resource with a human readable message.
+type Result struct {
+	Status  Status
+	Message string
+}
+
+type checkFunc func(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) (Result, error)
+
+var builtinChecks = map[schema.GroupKind]checkFunc{
+	{Group: "apps", Kind: "Deployment"}:        checkDeployment,
+	{Group: "apps", Kind: "StatefulSet"}:       checkStatefulSet,
+	{Group: "apps", Kind: "DaemonSet"}:         checkDaemonSet,
+	{Group: "batch", Kind: "Job"}:              checkJob,
+	{Group: "", Kind: "Pod"}:                   checkPod,
+	{Group: "", Kind: "PersistentVolumeClaim"}: checkPersistentVolumeClaim,
+	{Group: "", Kind: "Service"}:               checkService,
+}
+
+// Checker checks the health of the resources with the built-in health checks of the common kinds.
+type Checker struct {
+	client dynamic.Interface
+}
+
+func NewChecker(client dynamic.Interface) *Checker {
+	return &Checker{client: client}
+}
+
+// Check returns the health of the object, it returns false if there is no built-in health check of the kind.
+func (c *Checker) Check(ctx context.Context, obj *unstructured.Unstructured) (Result, bool, error) {
+	check, ok := builtinChecks[obj.GroupVersionKind().GroupKind()]
+	if !ok {
+		return Result{}, false, nil
+	}
+	result, err := check(ctx, c.client, obj)
+	return result, true, err
+}
+
+// observed returns false if the latest spec of the object is not observed by its controller yet.
+func observed(obj *unstructured.Unstructured) bool {
+	observedGeneration, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	return !found || observedGeneration >= obj.GetGeneration()
+}
+
+func nestedInt64(obj *unstructured.Unstructured, fields ...string) int64 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return value
+}
+
+func findCondition(obj *unstructured.Unstructured, conditionType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == conditionType {
+			return condition
+		}
+	}
+	return nil
+}
+
+func conditionIsTrue(obj *unstructured.Unstructured, conditionType string) bool {
+	condition := findCondition(obj, conditionType)
+	return condition != nil && condition["status"] == string(metav1.ConditionTrue)
+}
+
+func checkDeployment(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	if !observed(obj) {
+		return Result{Status: Progressing, Message: "Waiting for the deployment spec to be observed"}, nil
+	}
+
+	progressing := findCondition(obj, "Progressing")
+	if progressing != nil && progressing["reason"] == "ProgressDeadlineExceeded" {
+		return Result{Status: Degraded, Message: "Deployment exceeded its progress deadline"}, nil
+	}
+
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	updated := nestedInt64(obj, "status", "updatedReplicas")
+	available := nestedInt64(obj, "status", "availableReplicas")
+	switch {
+	case updated < replicas:
+		return Result{Status: Progressing,
+			Message: fmt.Sprintf("%d of %d replicas are updated", updated, replicas)}, nil
+	case nestedInt64(obj, "status", "replicas") > updated:
+		return Result{Status: Progressing, Message: "Old replicas are pending termination"}, nil
+	case available < updated:
+		message := fmt.Sprintf("%d of %d updated replicas are available", available, updated)
+		// the replicas are not available after the rollout is finished, e.g. the pods are crash looping.
+		if progressing != nil && progressing["reason"] == "NewReplicaSetAvailable" {
+			return Result{Status: Degraded, Message: message}, nil
+		}
+		return Result{Status: Progressing, Message: message}, nil
+	}
+	return Result{Status: Healthy, Message: "Deployment is rolled out"}, nil
+}
+
+func checkStatefulSet(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	if !observed(obj) {
+		return Result{Status: Progressing, Message: "Waiting for the statefulset spec to be observed"}, nil
+	}
+
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	ready := nestedInt64(obj, "status", "readyReplicas")
+	if ready < replicas {
+		return Result{Status: Progressing, Message: fmt.Sprintf("%d of %d replicas are ready", ready, replicas)}, nil
+	}
+
+	strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+	if strategy == "OnDelete" {
+		return Result{Status: Healthy, Message: "StatefulSet is ready"}, nil
+	}
+	partition, _, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+	if updated := nestedInt64(obj, "status", "updatedReplicas"); updated < replicas-partition {
+		return Result{Status: Progressing,
+			Message: fmt.Sprintf("%d of %d replicas are updated", updated, replicas-partition)}, nil
+	}
+	currentRevision, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+	updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+	if partition == 0 && currentRevision != updateRevision {
+		return Result{Status: Progressing, Message: "Waiting for the statefulset update to be finished"}, nil
+	}
+	return Result{Status: Healthy, Message: "StatefulSet is ready"}, nil
+}
+
+func checkDaemonSet(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	if !observed(obj) {
+		return Result{Status: Progressing, Message: "Waiting for the daemonset spec to be observed"}, nil
+	}
+
+	desired := nestedInt64(obj, "status", "desiredNumberScheduled")
+	if updated := nestedInt64(obj, "status", "updatedNumberScheduled"); updated < desired {
+		return Result{Status: Progressing, Message: fmt.Sprintf("%d of %d pods are updated", updated, desired)}, nil
+	}
+	if available := nestedInt64(obj, "status", "numberAvailable"); available < desired {
+		return Result{Status: Progressing, Message: fmt.Sprintf("%d of %d pods are available", available, desired)}, nil
+	}
+	return Result{Status: Healthy, Message: "DaemonSet is rolled out"}, nil
+}
+
+func checkJob(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	switch {
+	case conditionIsTrue(obj, "Complete"):
+		return Result{Status: Healthy, Message: "Job is succeeded"}, nil
+	case conditionIsTrue(obj, "Failed"):
+		return Result{Status: Degraded, Message: "Job is failed"}, nil
+	}
+	return Result{Status: Progressing, Message: "Job is not finished"}, nil
+}
+
+// the waiting reasons of the containers which could not recover without a change.
+var degradedWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"CreateContainerConfigError": true,
+	"InvalidImageName":           true,
+}
+
+func checkPod(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Succeeded":
+		return Result{Status: Healthy, Message: "Pod is succeeded"}, nil
+	case "Failed":
+		return Result{Status: Degraded, Message: "Pod is failed"}, nil
+	}
+
+	statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", "containerStatuses")
+	for _, s := range statuses {
+		status, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		reason, _, _ := unstructured.NestedString(status, "state", "waiting", "reason")
+		if degradedWaitingReasons[reason] {
+			return Result{Status: Degraded, Message: fmt.Sprintf("Container %v is in %s", status["name"], reason)}, nil
+		}
+	}
+
+	if phase == "Running" && conditionIsTrue(obj, "Ready") {
+		return Result{Status: Healthy, Message: "Pod is ready"}, nil
+	}
+	return Result{Status: Progressing, Message: fmt.Sprintf("Pod is not ready in phase %s", phase)}, nil
+}
+
+func checkPersistentVolumeClaim(_ context.Context, _ dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Bound":
+		return Result{Status: Healthy, Message: "PersistentVolumeClaim is bound"}, nil
+	case "Lost":
+		return Result{Status: Degraded, Message: "PersistentVolumeClaim lost its volume"}, nil
+	}
+	return Result{Status: Progressing, Message: "PersistentVolumeClaim is not bound"}, nil
+}
+
+var endpointSliceGVR = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+
+func checkService(ctx context.Context, client dynamic.Interface, obj *unstructured.Unstructured) (Result, error) {
+	serviceType, _, _ := unstructured.NestedString(obj.Object, "spec", "type")
+	if serviceType == "ExternalName" {
+		return Result{Status: Healthy, Message: "Service is an external name"}, nil
+	}
+	if serviceType == "LoadBalancer" {
+		ingress, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+		if len(ingress) == 0 {
+			return Result{Status: Progressing, Message: "Waiting for the load balancer ingress"}, nil
+		}
+	}
+
+	// the endpoints of the service without selector are managed by users.
+	selector, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "selector")
+	if len(selector) == 0 {
+		return Result{Status: Healthy, Message: "Service is ready"}, nil
+	}
+
+	slices, err := client.Resource(endpointSliceGVR).Namespace(obj.GetNamespace()).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("kubernetes.io/service-name=%s", obj.GetName()),
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to list endpointslices of service %s/%s: %w",
+			obj.GetNamespace(), obj.GetName(), err)
+	}
+
+	notReady := 0
+	for _, slice := range slices.Items {
+		endpoints, _, _ := unstructured.NestedSlice(slice.Object, "endpoints")
+		for _, e := range endpoints {
+			endpoint, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// an endpoint is ready if the ready condition is not set
+			ready, found, _ := unstructured.NestedBool(endpoint, "conditions", "ready")
+			if !found || ready {
+				return Result{Status: Healthy, Message: "Service has ready endpoints"}, nil
+			}
+			notReady++
+		}
+	}
+	if notReady > 0 {
+		return Result{Status: Progressing, Message: fmt.Sprintf("%d endpoints of the service are not ready", notReady)}, nil
+	}
+	return Result{Status: Degraded, Message: "Service has no endpoints"}, nil
+}
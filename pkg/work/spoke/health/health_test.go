@@ -0,0 +1,219 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+func newObject(apiVersion, kind string, content map[string]interface{}) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructuredWithContent(apiVersion, kind, "ns1", "n1", content)
+	obj.SetGeneration(2)
+	return obj
+}
+
+func newEndpointSlice(ready bool) *unstructured.Unstructured {
+	obj := testingcommon.NewUnstructuredWithContent("discovery.k8s.io/v1", "EndpointSlice", "ns1", "n1-abc",
+		map[string]interface{}{
+			"endpoints": []interface{}{
+				map[string]interface{}{"conditions": map[string]interface{}{"ready": ready}},
+			},
+		})
+	obj.SetLabels(map[string]string{"kubernetes.io/service-name": "n1"})
+	return obj
+}
+
+func TestCheck(t *testing.T) {
+	deploymentStatus := func(progressingReason string, observed, updated, replicas, available int64) map[string]interface{} {
+		return map[string]interface{}{
+			"spec": map[string]interface{}{"replicas": int64(2)},
+			"status": map[string]interface{}{
+				"observedGeneration": observed,
+				"updatedReplicas":    updated,
+				"replicas":           replicas,
+				"availableReplicas":  available,
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Progressing", "status": "True", "reason": progressingReason},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name              string
+		obj               *unstructured.Unstructured
+		existingResources []runtime.Object
+		expectedSupported bool
+		expectedStatus    Status
+	}{
+		{
+			name: "unsupported kind",
+			obj:  newObject("v1", "ConfigMap", map[string]interface{}{}),
+		},
+		{
+			name:              "deployment spec is not observed",
+			obj:               newObject("apps/v1", "Deployment", deploymentStatus("NewReplicaSetAvailable", 1, 2, 2, 2)),
+			expectedSupported: true,
+			expectedStatus:    Progressing,
+		},
+		{
+			name:              "deployment is rolling out",
+			obj:               newObject("apps/v1", "Deployment", deploymentStatus("ReplicaSetUpdated", 2, 1, 3, 2)),
+			expectedSupported: true,
+			expectedStatus:    Progressing,
+		},
+		{
+			name:              "deployment is rolled out",
+			obj:               newObject("apps/v1", "Deployment", deploymentStatus("NewReplicaSetAvailable", 2, 2, 2, 2)),
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name:              "deployment is crash looping",
+			obj:               newObject("apps/v1", "Deployment", deploymentStatus("NewReplicaSetAvailable", 2, 2, 2, 0)),
+			expectedSupported: true,
+			expectedStatus:    Degraded,
+		},
+		{
+			name:              "deployment exceeded its progress deadline",
+			obj:               newObject("apps/v1", "Deployment", deploymentStatus("ProgressDeadlineExceeded", 2, 1, 2, 1)),
+			expectedSupported: true,
+			expectedStatus:    Degraded,
+		},
+		{
+			name: "statefulset is updating",
+			obj: newObject("apps/v1", "StatefulSet", map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2), "readyReplicas": int64(2), "updatedReplicas": int64(1),
+					"currentRevision": "r1", "updateRevision": "r2",
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Progressing,
+		},
+		{
+			name: "statefulset is ready",
+			obj: newObject("apps/v1", "StatefulSet", map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2), "readyReplicas": int64(2), "updatedReplicas": int64(2),
+					"currentRevision": "r2", "updateRevision": "r2",
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name: "daemonset is rolled out",
+			obj: newObject("apps/v1", "DaemonSet", map[string]interface{}{
+				"status": map[string]interface{}{
+					"observedGeneration": int64(2), "desiredNumberScheduled": int64(3),
+					"updatedNumberScheduled": int64(3), "numberAvailable": int64(3),
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name: "job is failed",
+			obj: newObject("batch/v1", "Job", map[string]interface{}{
+				"status": map[string]interface{}{
+					"conditions": []interface{}{map[string]interface{}{"type": "Failed", "status": "True"}},
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Degraded,
+		},
+		{
+			name: "job is succeeded",
+			obj: newObject("batch/v1", "Job", map[string]interface{}{
+				"status": map[string]interface{}{
+					"conditions": []interface{}{map[string]interface{}{"type": "Complete", "status": "True"}},
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name: "pod is crash looping",
+			obj: newObject("v1", "Pod", map[string]interface{}{
+				"status": map[string]interface{}{
+					"phase": "Running",
+					"containerStatuses": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}},
+						},
+					},
+				},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Degraded,
+		},
+		{
+			name: "pvc is pending",
+			obj: newObject("v1", "PersistentVolumeClaim", map[string]interface{}{
+				"status": map[string]interface{}{"phase": "Pending"},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Progressing,
+		},
+		{
+			name: "pvc is bound",
+			obj: newObject("v1", "PersistentVolumeClaim", map[string]interface{}{
+				"status": map[string]interface{}{"phase": "Bound"},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name: "service has no endpoints",
+			obj: newObject("v1", "Service", map[string]interface{}{
+				"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "n1"}},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Degraded,
+		},
+		{
+			name: "service has ready endpoints",
+			obj: newObject("v1", "Service", map[string]interface{}{
+				"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "n1"}},
+			}),
+			existingResources: []runtime.Object{newEndpointSlice(true)},
+			expectedSupported: true,
+			expectedStatus:    Healthy,
+		},
+		{
+			name: "load balancer service is waiting for ingress",
+			obj: newObject("v1", "Service", map[string]interface{}{
+				"spec": map[string]interface{}{"type": "LoadBalancer"},
+			}),
+			expectedSupported: true,
+			expectedStatus:    Progressing,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{endpointSliceGVR: "EndpointSliceList"}, c.existingResources...)
+			result, supported, err := NewChecker(client).Check(context.TODO(), c.obj)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if supported != c.expectedSupported {
+				t.Fatalf("expected supported %v, but got %v", c.expectedSupported, supported)
+			}
+			if result.Status != c.expectedStatus {
+				t.Errorf("expected status %q, but got %q: %s", c.expectedStatus, result.Status, result.Message)
+			}
+		})
+	}
+}
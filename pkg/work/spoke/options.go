@@ -8,13 +8,26 @@ import (
 
 const (
 	defaultUserAgent = "work-agent"
+
+	// StatusSyncModePoll polls the status of the applied resources at the status sync interval.
+	StatusSyncModePoll = "Poll"
+	// StatusSyncModeWatch watches the applied resources and only syncs the status of the changed resources. A
+	// metadata-only informer is started in all namespaces for each GVR of the applied resources, so the agent
+	// caches the metadata of all the resources of those GVRs on the managed cluster, which costs more memory and
+	// requires the permission to list and watch them cluster wide.
+	StatusSyncModeWatch = "Watch"
 )
 
 // WorkloadAgentOptions defines the flags for workload agent
 type WorkloadAgentOptions struct {
 	StatusSyncInterval                     time.Duration
+	StatusSyncMode                         string
 	AppliedManifestWorkEvictionGracePeriod time.Duration
 	MaxJSONRawLength                       int32
+	WellKnownRulesFile                     string
+	PolicyFile                             string
+	SigningKeysFile                        string
+	RequireImageDigest                     bool
 	WorkloadSourceDriver                   string
 	WorkloadSourceConfig                   string
 	CloudEventsClientID                    string
@@ -27,6 +40,7 @@ func NewWorkloadAgentOptions() *WorkloadAgentOptions {
 	return &WorkloadAgentOptions{
 		MaxJSONRawLength:                       1024,
 		StatusSyncInterval:                     10 * time.Second,
+		StatusSyncMode:                         StatusSyncModePoll,
 		AppliedManifestWorkEvictionGracePeriod: 60 * time.Minute,
 		WorkloadSourceDriver:                   "kube",
 		WorkloadSourceConfig:                   "/spoke/hub-kubeconfig/kubeconfig",
@@ -38,8 +52,24 @@ func NewWorkloadAgentOptions() *WorkloadAgentOptions {
 func (o *WorkloadAgentOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.Int32Var(&o.MaxJSONRawLength, "max-json-raw-length",
 		o.MaxJSONRawLength, "The maximum size of the JSON raw string returned from status feedback")
+	fs.StringVar(&o.WellKnownRulesFile, "well-known-rules-file", o.WellKnownRulesFile,
+		"The file of the well known status and condition rules merged into the default rules, it is reloaded "+
+			"when changed so the rules could be updated without restarting the agent.")
+	fs.StringVar(&o.PolicyFile, "policy-file", o.PolicyFile,
+		"The file of the policies to validate the manifests before they are applied, the denied manifests are "+
+			"not applied. It is reloaded when changed, and the agent fails to start if the file does not exist.")
+	fs.StringVar(&o.SigningKeysFile, "signing-keys-file", o.SigningKeysFile,
+		"The file of the PEM encoded public keys trusted to sign the ManifestWorks. If it is set, the ManifestWorks "+
+			"without a valid signature of the workload are refused.")
+	fs.BoolVar(&o.RequireImageDigest, "require-image-digest", o.RequireImageDigest,
+		"Refuse the ManifestWorks with container images which are not pinned by digest.")
 	fs.DurationVar(&o.StatusSyncInterval, "status-sync-interval",
 		o.StatusSyncInterval, "Interval to sync resource status to hub.")
+	fs.StringVar(&o.StatusSyncMode, "status-sync-mode", o.StatusSyncMode,
+		"The mode to sync resource status to hub, Poll or Watch. In Watch mode, the resources are watched with "+
+			"metadata-only informers in all namespaces, so the metadata of all the resources of the applied kinds "+
+			"is cached, and the status sync interval is only used to resync the manifestworks with the health check "+
+			"enabled or with the informers not synced yet.")
 	fs.DurationVar(&o.AppliedManifestWorkEvictionGracePeriod, "appliedmanifestwork-eviction-grace-period",
 		o.AppliedManifestWorkEvictionGracePeriod, "Grace period for appliedmanifestwork eviction")
 	fs.StringVar(&o.WorkloadSourceDriver, "workload-source-driver",
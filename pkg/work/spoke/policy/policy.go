@@ -0,0 +1,336 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+	ocmcellibrary "open-cluster-management.io/sdk-go/pkg/cel/library"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+// DefaultReloadInterval is the interval to check whether the policy file is changed.
+var DefaultReloadInterval = 30 * time.Second
+
+// Validator validates the manifest before it is applied on the managed cluster.
+type Validator interface {
+	Validate(ctx context.Context, gvr schema.GroupVersionResource, required *unstructured.Unstructured) error
+}
+
+// Reloader is a Validator whose policies are reloaded at the interval until the context is done, onChange is
+// called when the policies are changed so the manifests are validated again.
+type Reloader interface {
+	Validator
+	Run(ctx context.Context, interval time.Duration, onChange func())
+}
+
+// DeniedError is returned when the manifest is denied by a policy.
+type DeniedError struct {
+	Policy  string
+	Message string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("denied by policy %s: %s", e.Policy, e.Message)
+}
+
+// FailurePolicy defines how the errors of evaluating the expressions are handled.
+type FailurePolicy string
+
+const (
+	// Fail denies the manifest if an expression fails to be evaluated.
+	Fail FailurePolicy = "Fail"
+	// Ignore skips the expression failed to be evaluated.
+	Ignore FailurePolicy = "Ignore"
+)
+
+// Config is the policies validating the manifests before they are applied, in the style of the
+// ValidatingAdmissionPolicy. It is usually stored in a configmap mounted on the work agent, e.g.
+//
+//	policies:
+//	- name: disallow-privileged
+//	  match:
+//	    kinds: ["Pod", "Deployment", "StatefulSet", "DaemonSet", "Job"]
+//	  validations:
+//	  - expression: >-
+//	      podSpec == null || !podSpec.containers.exists(c,
+//	      has(c.securityContext) && has(c.securityContext.privileged) && c.securityContext.privileged)
+//	    message: privileged containers are not allowed
+//
+// The expressions are evaluated with the variable `object` which is the manifest, and `podSpec` which is the pod
+// spec of a pod or the pod template spec of a workload, or null if the manifest has no pod spec.
+type Config struct {
+	Policies []Policy `json:"policies,omitempty"`
+}
+
+// Policy is a set of validations of the matched manifests, a manifest is denied if any validation returns false.
+type Policy struct {
+	Name          string        `json:"name"`
+	Match         Match         `json:"match,omitempty"`
+	Validations   []Validation  `json:"validations"`
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// Match selects the manifests validated by the policy, an empty list matches all, and "*" matches any value.
+type Match struct {
+	APIGroups          []string `json:"apiGroups,omitempty"`
+	Kinds              []string `json:"kinds,omitempty"`
+	Namespaces         []string `json:"namespaces,omitempty"`
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+}
+
+// Validation is a CEL expression returns a bool, the message is returned when the result is false.
+type Validation struct {
+	Expression        string `json:"expression"`
+	Message           string `json:"message,omitempty"`
+	MessageExpression string `json:"messageExpression,omitempty"`
+}
+
+type compiledValidation struct {
+	Validation
+	program        cel.Program
+	messageProgram cel.Program
+}
+
+type compiledPolicy struct {
+	Policy
+	validations []compiledValidation
+}
+
+// RuleValidator validates the manifests with the policies loaded from a file, and reloads the policies when the
+// file is changed.
+type RuleValidator struct {
+	file string
+	env  *cel.Env
+
+	lock     sync.RWMutex
+	policies []compiledPolicy
+	hash     string
+}
+
+func NewRuleValidator(file string) (*RuleValidator, error) {
+	env, err := cel.NewEnv(slices.Concat(
+		[]cel.EnvOption{cel.Variable("object", cel.DynType), cel.Variable("podSpec", cel.DynType)},
+		ocmcelcommon.BaseEnvOpts,
+		[]cel.EnvOption{ocmcellibrary.ConditionsLib()},
+	)...)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleValidator{file: file, env: env}, nil
+}
+
+// Load loads the policies if the file is changed since it is loaded last time, and returns true if the policies
+// are changed. An error is returned and the loaded policies are kept if the file does not exist or is invalid, so
+// the manifests are never applied without the policies because the file is removed or being replaced.
+func (v *RuleValidator) Load() (bool, error) {
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read the policy file %s: %w", v.file, err)
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	v.lock.RLock()
+	unchanged := hash == v.hash
+	v.lock.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	policies, err := v.compile(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid policy file %s: %w", v.file, err)
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.policies = policies
+	v.hash = hash
+	klog.Infof("Loaded %d policies from %s", len(policies), v.file)
+	return true, nil
+}
+
+// Run reloads the policy file at the interval until the context is done, and calls onChange when the policies
+// are changed.
+func (v *RuleValidator) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		changed, err := v.Load()
+		if err != nil {
+			klog.Errorf("failed to reload the policies: %v", err)
+			return
+		}
+		if changed && onChange != nil {
+			onChange()
+		}
+	}, interval)
+}
+
+func (v *RuleValidator) compile(data []byte) ([]compiledPolicy, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse the policies: %w", err)
+	}
+
+	var errs []error
+	var policies []compiledPolicy
+	for _, policy := range config.Policies {
+		if len(policy.Name) == 0 {
+			errs = append(errs, fmt.Errorf("the name of the policy is required"))
+			continue
+		}
+		switch policy.FailurePolicy {
+		case "":
+			policy.FailurePolicy = Fail
+		case Fail, Ignore:
+		default:
+			errs = append(errs, fmt.Errorf("invalid failure policy %q of policy %s", policy.FailurePolicy, policy.Name))
+			continue
+		}
+
+		compiled := compiledPolicy{Policy: policy}
+		for _, validation := range policy.Validations {
+			program, err := v.program(validation.Expression)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid expression of policy %s: %w", policy.Name, err))
+				continue
+			}
+			compiledValidation := compiledValidation{Validation: validation, program: program}
+			if len(validation.MessageExpression) > 0 {
+				compiledValidation.messageProgram, err = v.program(validation.MessageExpression)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid message expression of policy %s: %w", policy.Name, err))
+					continue
+				}
+			}
+			compiled.validations = append(compiled.validations, compiledValidation)
+		}
+		policies = append(policies, compiled)
+	}
+
+	if len(errs) > 0 {
+		return nil, utilerrors.NewAggregate(errs)
+	}
+	return policies, nil
+}
+
+func (v *RuleValidator) program(expression string) (cel.Program, error) {
+	ast, iss := v.env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	return v.env.Program(
+		ast,
+		cel.CostLimit(celconfig.PerCallLimit),
+		cel.CostTracking(&ocmcelcommon.BaseEnvCostEstimator{CostEstimator: &ocmcellibrary.CostEstimator{}}),
+		cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+	)
+}
+
+// Validate returns a DeniedError if the manifest is denied by any policy.
+func (v *RuleValidator) Validate(ctx context.Context, gvr schema.GroupVersionResource, required *unstructured.Unstructured) error {
+	v.lock.RLock()
+	policies := v.policies
+	v.lock.RUnlock()
+
+	input := map[string]any{"object": required.Object, "podSpec": podSpec(required)}
+	budget := int64(celconfig.RuntimeCELCostBudget)
+	for _, policy := range policies {
+		if !policy.Match.matches(gvr.Group, required) {
+			continue
+		}
+		for _, validation := range policy.validations {
+			allowed, message, remaining, err := validation.evaluate(ctx, input, budget)
+			budget = remaining
+			if err != nil {
+				if policy.FailurePolicy == Ignore {
+					klog.V(4).Infof("Ignore the failed expression of policy %s: %v", policy.Name, err)
+					continue
+				}
+				return &DeniedError{Policy: policy.Name, Message: fmt.Sprintf("failed to evaluate the expression: %v", err)}
+			}
+			if !allowed {
+				return &DeniedError{Policy: policy.Name, Message: message}
+			}
+		}
+	}
+	return nil
+}
+
+func (m Match) matches(group string, obj *unstructured.Unstructured) bool {
+	return matchValue(m.APIGroups, group) &&
+		matchValue(m.Kinds, obj.GetKind()) &&
+		matchValue(m.Namespaces, obj.GetNamespace()) &&
+		(len(m.ExcludedNamespaces) == 0 || !matchValue(m.ExcludedNamespaces, obj.GetNamespace()))
+}
+
+func matchValue(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, "*") || slices.Contains(values, value)
+}
+
+func (v compiledValidation) evaluate(ctx context.Context, input map[string]any, budget int64) (bool, string, int64, error) {
+	out, budget, err := evaluate(ctx, v.program, v.Expression, input, budget)
+	if err != nil {
+		return false, "", budget, err
+	}
+	allowed, ok := out.(bool)
+	if !ok {
+		return false, "", budget, fmt.Errorf("expected bool result of expression %q, got %T", v.Expression, out)
+	}
+	if allowed {
+		return true, "", budget, nil
+	}
+
+	message := v.Message
+	if v.messageProgram != nil {
+		// fall back to the message if the message expression fails to be evaluated
+		if out, remaining, err := evaluate(ctx, v.messageProgram, v.MessageExpression, input, budget); err == nil {
+			budget = remaining
+			if value, ok := out.(string); ok && len(value) > 0 {
+				message = value
+			}
+		}
+	}
+	if len(message) == 0 {
+		message = fmt.Sprintf("failed expression: %s", v.Expression)
+	}
+	return false, message, budget, nil
+}
+
+func evaluate(ctx context.Context, program cel.Program, expression string, input map[string]any, budget int64) (any, int64, error) {
+	out, details, err := program.ContextEval(ctx, input)
+	if details != nil {
+		ok, cost := helpers.CostCalculation(ctx, details, budget, expression)
+		if !ok {
+			return nil, -1, fmt.Errorf("CEL evaluation budget exceeded")
+		}
+		budget -= cost
+	}
+	if err != nil {
+		return nil, budget, err
+	}
+	return out.Value(), budget, nil
+}
+
+// podSpec returns the pod spec of a pod or a workload, or nil so it is null in the expressions.
+func podSpec(obj *unstructured.Unstructured) any {
+	if spec := helper.FindPodSpec(obj); spec != nil {
+		return spec
+	}
+	return nil
+}
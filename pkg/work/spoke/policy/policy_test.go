@@ -0,0 +1,201 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+)
+
+const testPolicies = `
+policies:
+- name: disallow-privileged
+  match:
+    kinds: ["Pod", "Deployment"]
+  validations:
+  - expression: >-
+      podSpec == null || !podSpec.containers.exists(c,
+      has(c.securityContext) && has(c.securityContext.privileged) && c.securityContext.privileged)
+    message: privileged containers are not allowed
+- name: approved-registries
+  match:
+    apiGroups: ["apps"]
+    excludedNamespaces: ["dev"]
+  validations:
+  - expression: podSpec.containers.all(c, c.image.startsWith('quay.io/'))
+    messageExpression: "'images must be from quay.io, got ' + podSpec.containers.map(c, c.image).join(', ')"
+- name: protected-namespaces
+  match:
+    namespaces: ["kube-system"]
+  validations:
+  - expression: "false"
+    message: kube-system is protected
+- name: ignore-failure
+  failurePolicy: Ignore
+  match:
+    kinds: ["ConfigMap"]
+  validations:
+  - expression: object.data.missing == 'value'
+`
+
+func newWorkload(kind, namespace, image string, privileged bool) *unstructured.Unstructured {
+	container := map[string]interface{}{
+		"name":            "app",
+		"image":           image,
+		"securityContext": map[string]interface{}{"privileged": privileged},
+	}
+	spec := map[string]interface{}{"containers": []interface{}{container}}
+	if kind == "Pod" {
+		return testingcommon.NewUnstructuredWithContent("v1", kind, namespace, "test", map[string]interface{}{"spec": spec})
+	}
+	return testingcommon.NewUnstructuredWithContent("apps/v1", kind, namespace, "test", map[string]interface{}{
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": spec}},
+	})
+}
+
+func TestValidate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(file, []byte(testPolicies), 0600); err != nil {
+		t.Fatal(err)
+	}
+	validator, err := NewRuleValidator(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validator.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name            string
+		gvr             schema.GroupVersionResource
+		obj             *unstructured.Unstructured
+		expectedPolicy  string
+		expectedMessage string
+	}{
+		{
+			name: "allowed pod",
+			gvr:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			obj:  newWorkload("Pod", "default", "docker.io/app", false),
+		},
+		{
+			name:            "privileged pod",
+			gvr:             schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			obj:             newWorkload("Pod", "default", "quay.io/app", true),
+			expectedPolicy:  "disallow-privileged",
+			expectedMessage: "privileged containers are not allowed",
+		},
+		{
+			name:            "privileged deployment",
+			gvr:             schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			obj:             newWorkload("Deployment", "default", "quay.io/app", true),
+			expectedPolicy:  "disallow-privileged",
+			expectedMessage: "privileged containers are not allowed",
+		},
+		{
+			name:            "unapproved registry",
+			gvr:             schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			obj:             newWorkload("Deployment", "default", "docker.io/app", false),
+			expectedPolicy:  "approved-registries",
+			expectedMessage: "images must be from quay.io, got docker.io/app",
+		},
+		{
+			name: "unapproved registry in excluded namespace",
+			gvr:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			obj:  newWorkload("Deployment", "dev", "docker.io/app", false),
+		},
+		{
+			name:            "protected namespace",
+			gvr:             schema.GroupVersionResource{Version: "v1", Resource: "secrets"},
+			obj:             testingcommon.NewUnstructured("v1", "Secret", "kube-system", "test"),
+			expectedPolicy:  "protected-namespaces",
+			expectedMessage: "kube-system is protected",
+		},
+		{
+			name: "failure is ignored",
+			gvr:  schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			obj:  testingcommon.NewUnstructured("v1", "ConfigMap", "default", "test"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validator.Validate(context.TODO(), c.gvr, c.obj)
+			if len(c.expectedPolicy) == 0 {
+				if err != nil {
+					t.Errorf("expected no error, but got %v", err)
+				}
+				return
+			}
+			var deniedError *DeniedError
+			if !errors.As(err, &deniedError) {
+				t.Fatalf("expected denied error, but got %v", err)
+			}
+			if deniedError.Policy != c.expectedPolicy || deniedError.Message != c.expectedMessage {
+				t.Errorf("expected denied by %s with %q, but got %v", c.expectedPolicy, c.expectedMessage, deniedError)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policies.yaml")
+	validator, err := NewRuleValidator(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := newWorkload("Pod", "default", "quay.io/app", true)
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	// an error is returned if the file does not exist
+	if _, err := validator.Load(); err == nil {
+		t.Errorf("expected error of the missing file")
+	}
+
+	if err := os.WriteFile(file, []byte(testPolicies), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := validator.Load(); err != nil || !changed {
+		t.Fatalf("expected the policies to be changed, but got %v, %v", changed, err)
+	}
+	if changed, err := validator.Load(); err != nil || changed {
+		t.Errorf("expected the policies not to be changed, but got %v, %v", changed, err)
+	}
+	if err := validator.Validate(context.TODO(), gvr, pod); err == nil {
+		t.Errorf("expected the pod to be denied")
+	}
+
+	// the loaded policies are kept if the file is invalid
+	invalid := `
+policies:
+- name: invalid
+  validations:
+  - expression: "object.spec.("
+`
+	if err := os.WriteFile(file, []byte(invalid), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validator.Load(); err == nil {
+		t.Errorf("expected error of invalid expression")
+	}
+	if err := validator.Validate(context.TODO(), gvr, pod); err == nil {
+		t.Errorf("expected the pod to be denied by the loaded policies")
+	}
+
+	// the loaded policies are kept if the file is removed
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validator.Load(); err == nil {
+		t.Errorf("expected error of the missing file")
+	}
+	if err := validator.Validate(context.TODO(), gvr, pod); err == nil {
+		t.Errorf("expected the pod to be denied by the loaded policies")
+	}
+}
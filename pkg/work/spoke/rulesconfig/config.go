@@ -0,0 +1,199 @@
+package rulesconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
+)
+
+// DefaultReloadInterval is the interval to check whether the rules file is changed.
+var DefaultReloadInterval = 30 * time.Second
+
+// Config is the well known status and condition rules of the kinds not covered by the default rules, or overriding
+// the default rules. It is usually stored in a configmap mounted on the work agent, e.g.
+//
+//	statusRules:
+//	- group: apps
+//	  version: v1
+//	  kind: StatefulSet
+//	  jsonPaths:
+//	  - name: ReadyReplicas
+//	    path: .status.readyReplicas
+//	conditionRules:
+//	- group: cert-manager.io
+//	  version: v1
+//	  kind: Certificate
+//	  rules:
+//	  - condition: Available
+//	    type: CEL
+//	    celExpressions:
+//	    - object.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')
+type Config struct {
+	StatusRules    []StatusRule    `json:"statusRules,omitempty"`
+	ConditionRules []ConditionRule `json:"conditionRules,omitempty"`
+}
+
+// StatusRule is the json paths of the well known status of a kind.
+type StatusRule struct {
+	Group     string               `json:"group,omitempty"`
+	Version   string               `json:"version"`
+	Kind      string               `json:"kind"`
+	JsonPaths []workapiv1.JsonPath `json:"jsonPaths"`
+}
+
+// ConditionRule is the well known condition rules of a kind.
+type ConditionRule struct {
+	Group   string                    `json:"group,omitempty"`
+	Version string                    `json:"version"`
+	Kind    string                    `json:"kind"`
+	Rules   []workapiv1.ConditionRule `json:"rules"`
+}
+
+// Parse parses and validates the rules config.
+func Parse(data []byte) (map[schema.GroupVersionKind][]workapiv1.JsonPath,
+	map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the rules config: %w", err)
+	}
+
+	var errs []error
+	statusRules := map[schema.GroupVersionKind][]workapiv1.JsonPath{}
+	for _, rule := range config.StatusRules {
+		gvk := schema.GroupVersionKind{Group: rule.Group, Version: rule.Version, Kind: rule.Kind}
+		if err := validateGVK(gvk); err != nil {
+			errs = append(errs, fmt.Errorf("invalid status rule: %w", err))
+			continue
+		}
+		for _, path := range rule.JsonPaths {
+			if len(path.Name) == 0 {
+				errs = append(errs, fmt.Errorf("the name of the json path %q of %s is empty", path.Path, gvk))
+				continue
+			}
+			if err := jsonpath.New(path.Name).Parse(fmt.Sprintf("{%s}", path.Path)); err != nil {
+				errs = append(errs, fmt.Errorf("invalid json path %q of %s: %w", path.Path, gvk, err))
+				continue
+			}
+			statusRules[gvk] = append(statusRules[gvk], path)
+		}
+	}
+
+	conditionRules := map[schema.GroupVersionKind]map[string]workapiv1.ConditionRule{}
+	for _, rule := range config.ConditionRules {
+		gvk := schema.GroupVersionKind{Group: rule.Group, Version: rule.Version, Kind: rule.Kind}
+		if err := validateGVK(gvk); err != nil {
+			errs = append(errs, fmt.Errorf("invalid condition rule: %w", err))
+			continue
+		}
+		for _, conditionRule := range rule.Rules {
+			if len(conditionRule.Condition) == 0 {
+				errs = append(errs, fmt.Errorf("the condition of the rule of %s is empty", gvk))
+				continue
+			}
+			// the well known rule of a condition is only referenced by the rules of WellKnownConditions type.
+			if conditionRule.Type != workapiv1.CelConditionExpressionsType || len(conditionRule.CelExpressions) == 0 {
+				errs = append(errs, fmt.Errorf("the rule of condition %s of %s should have CEL expressions",
+					conditionRule.Condition, gvk))
+				continue
+			}
+			if _, ok := conditionRules[gvk]; !ok {
+				conditionRules[gvk] = map[string]workapiv1.ConditionRule{}
+			}
+			conditionRules[gvk][conditionRule.Condition] = conditionRule
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, utilerrors.NewAggregate(errs)
+	}
+	return statusRules, conditionRules, nil
+}
+
+func validateGVK(gvk schema.GroupVersionKind) error {
+	if len(gvk.Version) == 0 || len(gvk.Kind) == 0 {
+		return fmt.Errorf("the version and kind of %q are required", gvk)
+	}
+	return nil
+}
+
+// Loader loads the rules from the file into the resolvers, and reloads them when the file is changed, so the
+// rules could be changed without restarting the agent.
+type Loader struct {
+	file               string
+	statusResolver     *statusrules.ConfigurableWellKnownStatusResolver
+	conditionsResolver *conditionrules.ConfigurableWellKnownConditionResolver
+
+	lock sync.Mutex
+	hash string
+}
+
+func NewLoader(file string,
+	statusResolver *statusrules.ConfigurableWellKnownStatusResolver,
+	conditionsResolver *conditionrules.ConfigurableWellKnownConditionResolver) *Loader {
+	return &Loader{
+		file:               file,
+		statusResolver:     statusResolver,
+		conditionsResolver: conditionsResolver,
+	}
+}
+
+// Load loads the rules if the file is changed since it is loaded last time, and returns true if the rules are
+// changed. The custom rules are cleared if the file does not exist, and the loaded rules are kept if the file is
+// invalid.
+func (l *Loader) Load() (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	data, err := os.ReadFile(l.file)
+	if os.IsNotExist(err) {
+		data = nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to read the rules file %s: %w", l.file, err)
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256(data))
+	if hash == l.hash {
+		return false, nil
+	}
+
+	statusRules, conditionRules, err := Parse(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid rules file %s: %w", l.file, err)
+	}
+	l.statusResolver.SetCustomRules(statusRules)
+	l.conditionsResolver.SetCustomRules(conditionRules)
+	l.hash = hash
+	klog.Infof("Loaded %d well known status rules and %d well known condition rules from %s",
+		len(statusRules), len(conditionRules), l.file)
+	return true, nil
+}
+
+// Run reloads the rules file at the interval until the context is done, onChange is called after the rules are
+// changed.
+func (l *Loader) Run(ctx context.Context, interval time.Duration, onChange func()) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		changed, err := l.Load()
+		if err != nil {
+			klog.Errorf("failed to reload the well known rules: %v", err)
+			return
+		}
+		if changed && onChange != nil {
+			onChange()
+		}
+	}, interval)
+}
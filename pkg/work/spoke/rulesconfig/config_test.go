@@ -0,0 +1,177 @@
+package rulesconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	conditionrules "open-cluster-management.io/ocm/pkg/work/spoke/conditions/rules"
+	statusrules "open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
+)
+
+var (
+	statefulSetGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}
+	deploymentGVK  = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+	jobGVK         = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}
+)
+
+const validRules = `
+statusRules:
+- group: apps
+  version: v1
+  kind: StatefulSet
+  jsonPaths:
+  - name: ReadyReplicas
+    path: .status.readyReplicas
+- group: apps
+  version: v1
+  kind: Deployment
+  jsonPaths:
+  - name: UpdatedReplicas
+    path: .status.updatedReplicas
+conditionRules:
+- group: cert-manager.io
+  version: v1
+  kind: Certificate
+  rules:
+  - condition: Available
+    type: CEL
+    celExpressions:
+    - object.status.conditions.exists(c, c.type == 'Ready' && c.status == 'True')
+`
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        string
+		expectedErr bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "valid rules",
+			data: validRules,
+		},
+		{
+			name:        "unknown field",
+			data:        "statusRule: []",
+			expectedErr: true,
+		},
+		{
+			name: "kind is missing",
+			data: `
+statusRules:
+- version: v1
+  jsonPaths:
+  - name: Ready
+    path: .status.ready
+`,
+			expectedErr: true,
+		},
+		{
+			name: "invalid json path",
+			data: `
+statusRules:
+- version: v1
+  kind: Service
+  jsonPaths:
+  - name: Ingress
+    path: .status.loadBalancer.ingress[
+`,
+			expectedErr: true,
+		},
+		{
+			name: "condition rule without expressions",
+			data: `
+conditionRules:
+- version: v1
+  kind: Service
+  rules:
+  - condition: Available
+    type: CEL
+`,
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, _, err := Parse([]byte(c.data))
+			if c.expectedErr != (err != nil) {
+				t.Errorf("expected error %v, but got %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLoader(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rules.yaml")
+	statusResolver := statusrules.NewConfigurableWellKnownStatusResolver()
+	conditionResolver := conditionrules.NewConfigurableWellKnownConditionResolver()
+	loader := NewLoader(file, statusResolver, conditionResolver)
+
+	// the default rules are used if the file does not exist
+	if _, err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if paths := statusResolver.GetPathsByKind(statefulSetGVK); len(paths) != 0 {
+		t.Errorf("expected no rules of statefulset, but got %v", paths)
+	}
+	if paths := statusResolver.GetPathsByKind(deploymentGVK); len(paths) != 3 {
+		t.Errorf("expected default rules of deployment, but got %v", paths)
+	}
+
+	// the rules are loaded and override the default rules
+	if err := os.WriteFile(file, []byte(validRules), 0600); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := loader.Load()
+	if err != nil || !changed {
+		t.Fatalf("expected rules changed, but got %v, %v", changed, err)
+	}
+	if paths := statusResolver.GetPathsByKind(statefulSetGVK); len(paths) != 1 || paths[0].Name != "ReadyReplicas" {
+		t.Errorf("expected rules of statefulset, but got %v", paths)
+	}
+	if paths := statusResolver.GetPathsByKind(deploymentGVK); len(paths) != 1 || paths[0].Name != "UpdatedReplicas" {
+		t.Errorf("expected overridden rules of deployment, but got %v", paths)
+	}
+	if rule := conditionResolver.GetRuleByKindCondition(certificateGVK, workapiv1.ManifestAvailable); len(rule.CelExpressions) != 1 {
+		t.Errorf("expected rule of certificate, but got %v", rule)
+	}
+	if rule := conditionResolver.GetRuleByKindCondition(jobGVK, workapiv1.ManifestComplete); len(rule.CelExpressions) == 0 {
+		t.Errorf("expected default rule of job, but got %v", rule)
+	}
+
+	// nothing is changed if the file is not changed
+	if changed, err := loader.Load(); err != nil || changed {
+		t.Errorf("expected rules not changed, but got %v, %v", changed, err)
+	}
+
+	// the loaded rules are kept if the file is invalid
+	if err := os.WriteFile(file, []byte("statusRules: invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.Load(); err == nil {
+		t.Errorf("expected error of invalid file")
+	}
+	if paths := statusResolver.GetPathsByKind(statefulSetGVK); len(paths) != 1 {
+		t.Errorf("expected rules of statefulset are kept, but got %v", paths)
+	}
+
+	// the custom rules are cleared if the file is removed
+	if err := os.Remove(file); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := loader.Load(); err != nil || !changed {
+		t.Errorf("expected rules changed, but got %v, %v", changed, err)
+	}
+	if paths := statusResolver.GetPathsByKind(statefulSetGVK); len(paths) != 0 {
+		t.Errorf("expected no rules of statefulset, but got %v", paths)
+	}
+}
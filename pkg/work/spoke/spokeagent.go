@@ -2,6 +2,7 @@ package spoke
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/openshift/library-go/pkg/controller/controllercmd"
@@ -9,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
@@ -20,7 +22,6 @@ import (
 	ocmfeature "open-cluster-management.io/api/feature"
 	cloudeventsoptions "open-cluster-management.io/sdk-go/pkg/cloudevents/clients/options"
 	cloudeventswork "open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work"
-	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/agent/codec"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/store"
 	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic"
 
@@ -31,6 +32,8 @@ import (
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/finalizercontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/manifestcontroller"
 	"open-cluster-management.io/ocm/pkg/work/spoke/controllers/statuscontroller"
+	"open-cluster-management.io/ocm/pkg/work/spoke/policy"
+	"open-cluster-management.io/ocm/pkg/work/spoke/verify"
 )
 
 const (
@@ -88,6 +91,17 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 	if err != nil {
 		return err
 	}
+	var spokeMetadataClient metadata.Interface
+	switch o.workOptions.StatusSyncMode {
+	case StatusSyncModePoll:
+	case StatusSyncModeWatch:
+		spokeMetadataClient, err = metadata.NewForConfig(spokeRestConfig)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported status sync mode %q", o.workOptions.StatusSyncMode)
+	}
 
 	// Resyncing at a small interval may cause performance issues when the number of AppliedManifestWorks is large.
 	// Since the resync interval for the ManifestWork informer is set to 24 hours, use a different interval, such as
@@ -124,6 +138,29 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		restMapper,
 	).NewExecutorValidator(ctx, features.SpokeMutableFeatureGate.Enabled(ocmfeature.ExecutorValidatingCaches))
 
+	// the manifests are validated by the local policies before applied if the policy file is set.
+	var policyValidator policy.Validator
+	if len(o.workOptions.PolicyFile) > 0 {
+		ruleValidator, err := policy.NewRuleValidator(o.workOptions.PolicyFile)
+		if err != nil {
+			return err
+		}
+		if _, err := ruleValidator.Load(); err != nil {
+			return err
+		}
+		policyValidator = ruleValidator
+	}
+
+	// the works are verified before applied if the signing keys file is set or the image digest is required.
+	var workVerifier verify.Verifier
+	if len(o.workOptions.SigningKeysFile) > 0 || o.workOptions.RequireImageDigest {
+		verifier, err := verify.NewWorkVerifier(o.workOptions.SigningKeysFile, o.workOptions.RequireImageDigest)
+		if err != nil {
+			return err
+		}
+		workVerifier = verifier
+	}
+
 	manifestWorkController := manifestcontroller.NewManifestWorkController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
@@ -137,6 +174,8 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		hubHash, agentID,
 		restMapper,
 		validator,
+		policyValidator,
+		workVerifier,
 	)
 	addFinalizerController := finalizercontroller.NewAddFinalizerController(
 		controllerContext.EventRecorder,
@@ -149,7 +188,9 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 		spokeDynamicClient,
 		spokeWorkClient.WorkV1().AppliedManifestWorks(),
 		spokeWorkInformerFactory.Work().V1().AppliedManifestWorks(),
-		agentID,
+		hubWorkClient,
+		hubWorkInformer.Lister().ManifestWorks(o.agentOptions.SpokeClusterName),
+		agentID, hubHash,
 	)
 	manifestWorkFinalizeController := finalizercontroller.NewManifestWorkFinalizeController(
 		controllerContext.EventRecorder,
@@ -172,11 +213,13 @@ func (o *WorkAgentConfig) RunWorkloadAgent(ctx context.Context, controllerContex
 	availableStatusController, err := statuscontroller.NewAvailableStatusController(
 		controllerContext.EventRecorder,
 		spokeDynamicClient,
+		spokeMetadataClient,
 		hubWorkClient,
 		hubWorkInformer,
 		hubWorkInformer.Lister().ManifestWorks(o.agentOptions.SpokeClusterName),
 		o.workOptions.MaxJSONRawLength,
 		o.workOptions.StatusSyncInterval,
+		o.workOptions.WellKnownRulesFile,
 	)
 	if err != nil {
 		return err
@@ -233,7 +276,7 @@ func (o *WorkAgentConfig) newWorkClientAndInformer(
 		}
 
 		clientOptions := cloudeventsoptions.NewGenericClientOptions(
-			config, codec.NewManifestBundleCodec(), o.workOptions.CloudEventsClientID).
+			config, verify.NewManifestBundleCodec(), o.workOptions.CloudEventsClientID).
 			WithClusterName(o.agentOptions.SpokeClusterName).
 			WithClientWatcherStore(watcherStore)
 		clientHolder, err := cloudeventswork.NewAgentClientHolder(ctx, clientOptions)
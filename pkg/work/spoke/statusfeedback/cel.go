@@ -0,0 +1,131 @@
+package statusfeedback
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/types/known/structpb"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	ocmcelcommon "open-cluster-management.io/sdk-go/pkg/cel/common"
+	ocmcellibrary "open-cluster-management.io/sdk-go/pkg/cel/library"
+
+	"open-cluster-management.io/ocm/pkg/common/helpers"
+)
+
+// CELType represents that the values are returned by the CEL expressions. The Name of each JsonPath of the rule
+// is the name of the value, and the Path is the CEL expression evaluated with the resource as the `object`
+// variable. The expression could return an integer, string, bool, or any other value returned as json raw string.
+const CELType workapiv1.FeedBackType = "CEL"
+
+var celCostBudget = int64(celconfig.RuntimeCELCostBudget)
+
+// getValuesByCELExpressions evaluates the expressions on the object, the cost of all the expressions of a rule is
+// limited by the same runtime cost budget as the condition rules.
+func (s *StatusReader) getValuesByCELExpressions(
+	obj *unstructured.Unstructured, expressions []workapiv1.JsonPath) ([]workapiv1.FeedbackValue, error) {
+	if s.celEnvErr != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %v", s.celEnvErr)
+	}
+
+	var errs []error
+	var values []workapiv1.FeedbackValue
+	budget := celCostBudget
+	for _, expression := range expressions {
+		if budget <= 0 {
+			errs = append(errs, fmt.Errorf("CEL evaluation budget exceeded before evaluating %s", expression.Name))
+			break
+		}
+
+		value, remainingBudget, err := s.evaluateCELExpression(obj, expression.Path, budget)
+		budget = remainingBudget
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to evaluate CEL expression of %s: %v", expression.Name, err))
+			continue
+		}
+
+		feedbackValue, err := s.toFeedbackValue(expression.Name, value)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if feedbackValue == nil {
+			continue
+		}
+		values = append(values, *feedbackValue)
+	}
+
+	return values, utilerrors.NewAggregate(errs)
+}
+
+// compiledCELProgram is the cached compilation result of a CEL expression.
+type compiledCELProgram struct {
+	program cel.Program
+	err     error
+}
+
+// getCELProgram returns the compiled program of the expression, the expression is compiled only once and the
+// result, including the compilation error, is cached.
+func (s *StatusReader) getCELProgram(expression string) (cel.Program, error) {
+	if cached, ok := s.celPrograms.Get(expression); ok {
+		compiled := cached.(compiledCELProgram)
+		return compiled.program, compiled.err
+	}
+
+	compiled := compiledCELProgram{}
+	ast, iss := s.celEnv.Compile(expression)
+	if iss.Err() != nil {
+		compiled.err = iss.Err()
+	} else {
+		compiled.program, compiled.err = s.celEnv.Program(
+			ast,
+			cel.CostLimit(celconfig.PerCallLimit),
+			cel.CostTracking(&ocmcelcommon.BaseEnvCostEstimator{CostEstimator: &ocmcellibrary.CostEstimator{}}),
+			cel.InterruptCheckFrequency(celconfig.CheckFrequency),
+		)
+	}
+	s.celPrograms.Add(expression, compiled)
+	return compiled.program, compiled.err
+}
+
+func (s *StatusReader) evaluateCELExpression(
+	obj *unstructured.Unstructured, expression string, budget int64) (any, int64, error) {
+	prg, err := s.getCELProgram(expression)
+	if err != nil {
+		return nil, budget, err
+	}
+
+	ctx := context.TODO()
+	out, details, err := prg.ContextEval(ctx, map[string]any{"object": obj.Object})
+	if details != nil {
+		ok, cost := helpers.CostCalculation(ctx, details, budget, expression)
+		if !ok {
+			return nil, -1, fmt.Errorf("CEL evaluation budget exceeded")
+		}
+		budget -= cost
+	}
+	if err != nil {
+		return nil, budget, err
+	}
+
+	switch out.Value().(type) {
+	case int64, string, bool:
+		return out.Value(), budget, nil
+	}
+
+	// convert the other values to the json compatible values, so they are returned as json raw string.
+	native, err := out.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, budget, fmt.Errorf("unsupported result type %s", out.Type().TypeName())
+	}
+	value, ok := native.(*structpb.Value)
+	if !ok {
+		return nil, budget, fmt.Errorf("unsupported result type %s", out.Type().TypeName())
+	}
+	return value.AsInterface(), budget, nil
+}
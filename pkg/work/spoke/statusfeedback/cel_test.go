@@ -0,0 +1,144 @@
+package statusfeedback
+
+import (
+	"fmt"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/utils/pointer"
+
+	ocmfeature "open-cluster-management.io/api/feature"
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/features"
+)
+
+const podWithContainersJson = `
+{
+	"apiVersion": "v1",
+	"kind": "Pod",
+	"metadata": {
+		"name": "test"
+	},
+	"status": {
+		"phase": "Running",
+		"containerStatuses": [
+			{"name": "app", "ready": true, "restartCount": 1},
+			{"name": "sidecar", "ready": false, "restartCount": 3}
+		]
+	}
+}
+`
+
+func TestStatusReaderCEL(t *testing.T) {
+	utilruntime.Must(features.SpokeMutableFeatureGate.Add(ocmfeature.DefaultSpokeWorkFeatureGates))
+	cases := []struct {
+		name          string
+		object        *unstructured.Unstructured
+		expressions   []workapiv1.JsonPath
+		enableRaw     bool
+		expectError   bool
+		expectedValue []workapiv1.FeedbackValue
+	}{
+		{
+			name:   "integer, string and bool values",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "notReady", Path: "object.status.containerStatuses.filter(c, !c.ready).size()"},
+				{Name: "phase", Path: "object.status.phase"},
+				{Name: "running", Path: "object.status.phase == 'Running'"},
+			},
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "notReady", Value: workapiv1.FieldValue{Type: workapiv1.Integer, Integer: pointer.Int64(1)}},
+				{Name: "phase", Value: workapiv1.FieldValue{Type: workapiv1.String, String: pointer.String("Running")}},
+				{Name: "running", Value: workapiv1.FieldValue{Type: workapiv1.Boolean, Boolean: pointer.Bool(true)}},
+			},
+		},
+		{
+			name:   "json value without raw feedback enabled",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "names", Path: "object.status.containerStatuses.map(c, c.name)"},
+			},
+			expectError: true,
+		},
+		{
+			name:   "json value",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "names", Path: "object.status.containerStatuses.map(c, c.name)"},
+			},
+			enableRaw: true,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "names", Value: workapiv1.FieldValue{Type: workapiv1.JsonRaw, JsonRaw: pointer.String(`["app","sidecar"]`)}},
+			},
+		},
+		{
+			name:   "json value exceeds the max length",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "statuses", Path: "object.status.containerStatuses"},
+			},
+			enableRaw:   true,
+			expectError: true,
+		},
+		{
+			name:   "invalid expression",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "invalid", Path: "object.status.("},
+				{Name: "phase", Path: "object.status.phase"},
+			},
+			expectError: true,
+			expectedValue: []workapiv1.FeedbackValue{
+				{Name: "phase", Value: workapiv1.FieldValue{Type: workapiv1.String, String: pointer.String("Running")}},
+			},
+		},
+		{
+			name:   "missing field",
+			object: unstrctureObject(podWithContainersJson),
+			expressions: []workapiv1.JsonPath{
+				{Name: "ip", Path: "object.status.podIP"},
+			},
+			expectError: true,
+		},
+	}
+
+	reader := NewStatusReader().WithMaxJsonRawLength(40)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := features.SpokeMutableFeatureGate.Set(fmt.Sprintf("%s=%t", ocmfeature.RawFeedbackJsonString, c.enableRaw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := reader.GetValuesByRule(c.object, workapiv1.FeedbackRule{Type: CELType, JsonPaths: c.expressions})
+			if err == nil && c.expectError {
+				t.Errorf("Expect error but got no error")
+			}
+			if err != nil && !c.expectError {
+				t.Errorf("Expect no error but got %v", err)
+			}
+			if !apiequality.Semantic.DeepEqual(c.expectedValue, values) {
+				t.Errorf("Expect value %v, but got %v", c.expectedValue, values)
+			}
+		})
+	}
+}
+
+func TestStatusReaderCELProgramCache(t *testing.T) {
+	reader := NewStatusReader()
+	rule := workapiv1.FeedbackRule{Type: CELType, JsonPaths: []workapiv1.JsonPath{
+		{Name: "phase", Path: "object.status.phase"},
+		{Name: "invalid", Path: "object.status.("},
+	}}
+	for i := 0; i < 2; i++ {
+		if _, err := reader.GetValuesByRule(unstrctureObject(podWithContainersJson), rule); err == nil {
+			t.Errorf("Expect error of the invalid expression but got no error")
+		}
+	}
+	if reader.celPrograms.Len() != 2 {
+		t.Errorf("Expect 2 compiled expressions cached, but got %d", reader.celPrograms.Len())
+	}
+}
@@ -5,29 +5,45 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/google/cel-go/cel"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/utils/lru"
 	"k8s.io/utils/pointer"
 
 	ocmfeature "open-cluster-management.io/api/feature"
 	workapiv1 "open-cluster-management.io/api/work/v1"
 
 	"open-cluster-management.io/ocm/pkg/features"
+	"open-cluster-management.io/ocm/pkg/work/helper"
 	"open-cluster-management.io/ocm/pkg/work/spoke/statusfeedback/rules"
 )
 
-const maxJSONRawLength = 1024
+const (
+	maxJSONRawLength = 1024
+
+	// celProgramCacheSize is the max number of the compiled CEL expressions cached by the reader.
+	celProgramCacheSize = 1000
+)
 
 type StatusReader struct {
 	wellKnownStatus  rules.WellKnownStatusRuleResolver
 	maxJSONRawLength int32
+	celEnv           *cel.Env
+	celEnvErr        error
+	// celPrograms caches the compiled CEL expressions, so an expression is not compiled on every status sync.
+	celPrograms *lru.Cache
 }
 
 func NewStatusReader() *StatusReader {
+	env, err := helper.NewFeedbackCELEnv()
 	return &StatusReader{
 		wellKnownStatus:  rules.DefaultWellKnownStatusRule(),
 		maxJSONRawLength: maxJSONRawLength,
+		celEnv:           env,
+		celEnvErr:        err,
+		celPrograms:      lru.New(celProgramCacheSize),
 	}
 }
 
@@ -36,6 +52,11 @@ func (s *StatusReader) WithMaxJsonRawLength(length int32) *StatusReader {
 	return s
 }
 
+func (s *StatusReader) WithWellKnownStatus(resolver rules.WellKnownStatusRuleResolver) *StatusReader {
+	s.wellKnownStatus = resolver
+	return s
+}
+
 func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule workapiv1.FeedbackRule) ([]workapiv1.FeedbackValue, error) {
 	var errs []error
 	var values []workapiv1.FeedbackValue
@@ -76,6 +97,8 @@ func (s *StatusReader) GetValuesByRule(obj *unstructured.Unstructured, rule work
 			}
 			values = append(values, *value)
 		}
+	case CELType:
+		return s.getValuesByCELExpressions(obj, rule.JsonPaths)
 	}
 
 	return values, utilerrors.NewAggregate(errs)
@@ -113,6 +136,12 @@ func (s *StatusReader) getValueByJsonPath(name, path string, obj *unstructured.U
 		value = resultList
 	}
 
+	return s.toFeedbackValue(name, value)
+}
+
+// toFeedbackValue converts the value to a feedback value, the value which is not an integer, string or bool is
+// returned as json raw string only if the RawFeedbackJsonString feature is enabled.
+func (s *StatusReader) toFeedbackValue(name string, value any) (*workapiv1.FeedbackValue, error) {
 	if value == nil {
 		// ignore the result if it is nil
 		return nil, nil
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"sync"
+
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	workapiv1 "open-cluster-management.io/api/work/v1"
@@ -80,3 +82,35 @@ func DefaultWellKnownStatusRule() WellKnownStatusRuleResolver {
 func (w *DefaultWellKnownStatusResolver) GetPathsByKind(gvk schema.GroupVersionKind) []workapiv1.JsonPath {
 	return w.rules[gvk]
 }
+
+// ConfigurableWellKnownStatusResolver resolves the well known status rules from the default rules and the custom
+// rules which could be updated at runtime. The custom rules of a kind override the default rules of the kind.
+type ConfigurableWellKnownStatusResolver struct {
+	defaults WellKnownStatusRuleResolver
+
+	lock   sync.RWMutex
+	custom map[schema.GroupVersionKind][]workapiv1.JsonPath
+}
+
+func NewConfigurableWellKnownStatusResolver() *ConfigurableWellKnownStatusResolver {
+	return &ConfigurableWellKnownStatusResolver{
+		defaults: DefaultWellKnownStatusRule(),
+		custom:   map[schema.GroupVersionKind][]workapiv1.JsonPath{},
+	}
+}
+
+// SetCustomRules replaces the custom rules.
+func (w *ConfigurableWellKnownStatusResolver) SetCustomRules(rules map[schema.GroupVersionKind][]workapiv1.JsonPath) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.custom = rules
+}
+
+func (w *ConfigurableWellKnownStatusResolver) GetPathsByKind(gvk schema.GroupVersionKind) []workapiv1.JsonPath {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if paths, ok := w.custom[gvk]; ok {
+		return paths
+	}
+	return w.defaults.GetPathsByKind(gvk)
+}
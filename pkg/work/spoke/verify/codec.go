@@ -0,0 +1,55 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cloudeventstypes "github.com/cloudevents/sdk-go/v2/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/agent/codec"
+	sourcecodec "open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/source/codec"
+)
+
+// ManifestBundleCodec is the agent codec of the ManifestWorks delivered by the cloudevents drivers which keeps
+// the signature of the work. The source sends the meta of the work in the metadata extension of the cloudevent,
+// while the agent codec of the sdk only decodes the spec, so the signature annotation is restored from the
+// extension.
+type ManifestBundleCodec struct {
+	*codec.ManifestBundleCodec
+}
+
+func NewManifestBundleCodec() *ManifestBundleCodec {
+	return &ManifestBundleCodec{ManifestBundleCodec: codec.NewManifestBundleCodec()}
+}
+
+// Decode a cloudevent whose data is ManifestBundle to a ManifestWork with the signature annotation.
+func (c *ManifestBundleCodec) Decode(evt *cloudevents.Event) (*workapiv1.ManifestWork, error) {
+	work, err := c.ManifestBundleCodec.Decode(evt)
+	if err != nil {
+		return nil, err
+	}
+
+	extension, ok := evt.Extensions()[sourcecodec.ExtensionWorkMeta]
+	if !ok {
+		return work, nil
+	}
+	metaJSON, err := cloudeventstypes.ToString(extension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s extension: %v", sourcecodec.ExtensionWorkMeta, err)
+	}
+	workMeta := metav1.ObjectMeta{}
+	if err := json.Unmarshal([]byte(metaJSON), &workMeta); err != nil {
+		return nil, fmt.Errorf("failed to decode %s extension: %v", sourcecodec.ExtensionWorkMeta, err)
+	}
+
+	if signature, ok := workMeta.Annotations[SignatureAnnotationKey]; ok {
+		if work.Annotations == nil {
+			work.Annotations = map[string]string{}
+		}
+		work.Annotations[SignatureAnnotationKey] = signature
+	}
+	return work, nil
+}
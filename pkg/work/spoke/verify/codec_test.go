@@ -0,0 +1,64 @@
+package verify
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/payload"
+	sourcecodec "open-cluster-management.io/sdk-go/pkg/cloudevents/clients/work/source/codec"
+	"open-cluster-management.io/sdk-go/pkg/cloudevents/generic/types"
+)
+
+func TestManifestBundleCodec(t *testing.T) {
+	signers := newSigners(t)
+	verifier, err := NewWorkVerifier(writeKeys(t, signers["ed25519"].public), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name           string
+		signature      []byte
+		expectedReason string
+	}{
+		{
+			name:      "signature is kept",
+			signature: sign(t, signers["ed25519"], configMap),
+		},
+		{
+			name:           "no signature",
+			expectedReason: SignatureMissingReason,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			work := newWork(c.signature, configMap)
+			work.ObjectMeta = metav1.ObjectMeta{
+				Name:            "test",
+				Namespace:       "cluster1",
+				UID:             "uid",
+				ResourceVersion: "1",
+				Annotations:     work.Annotations,
+			}
+
+			eventType := types.CloudEventsType{
+				CloudEventsDataType: payload.ManifestBundleEventDataType,
+				SubResource:         types.SubResourceSpec,
+				Action:              types.CreateRequestAction,
+			}
+			evt, err := sourcecodec.NewManifestBundleCodec().Encode("source1", eventType, work)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := NewManifestBundleCodec().Decode(evt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertVerification(t, verifier.Verify(context.TODO(), decoded), c.expectedReason)
+		})
+	}
+}
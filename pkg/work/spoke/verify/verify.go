@@ -0,0 +1,221 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+)
+
+const (
+	// SignatureAnnotationKey is the annotation key on the ManifestWork of the base64 encoded detached signature
+	// over the canonicalized spec.workload, see CanonicalWorkload and helper.SignatureAnnotationKey.
+	SignatureAnnotationKey = helper.SignatureAnnotationKey
+
+	SignatureMissingReason   = "SignatureMissing"
+	SignatureInvalidReason   = "SignatureInvalid"
+	ImageNotPinnedReason     = "ImageNotPinnedByDigest"
+	InvalidManifestReason    = "InvalidManifest"
+	VerificationPassedReason = "VerificationPassed"
+)
+
+// digestPattern matches an image reference pinned by a sha256 or sha512 digest.
+var digestPattern = regexp.MustCompile(`@sha(256:[a-f0-9]{64}|512:[a-f0-9]{128})$`)
+
+// Verifier verifies the ManifestWork before any of its manifests is applied.
+type Verifier interface {
+	Verify(ctx context.Context, manifestWork *workapiv1.ManifestWork) error
+}
+
+// VerificationError is returned when the ManifestWork fails the verification.
+type VerificationError struct {
+	Reason  string
+	Message string
+}
+
+func (e *VerificationError) Error() string {
+	return e.Message
+}
+
+// WorkVerifier verifies the signature of the ManifestWork with the trusted public keys, and requires the images
+// of the pod specs to be pinned by digest if the image digest is required.
+type WorkVerifier struct {
+	keys               []crypto.PublicKey
+	requireImageDigest bool
+}
+
+// NewWorkVerifier returns a WorkVerifier, the signature is not verified if the keys file is empty, otherwise the
+// file should contain the PEM encoded PKIX public keys.
+func NewWorkVerifier(keysFile string, requireImageDigest bool) (*WorkVerifier, error) {
+	verifier := &WorkVerifier{requireImageDigest: requireImageDigest}
+	if len(keysFile) == 0 {
+		return verifier, nil
+	}
+
+	data, err := os.ReadFile(keysFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the signing keys file %s: %w", keysFile, err)
+	}
+	verifier.keys, err = parsePublicKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing keys file %s: %w", keysFile, err)
+	}
+	return verifier, nil
+}
+
+func parsePublicKeys(data []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public key is found")
+	}
+	return keys, nil
+}
+
+// Verify returns a VerificationError if the ManifestWork is not signed by a trusted key, or an image is not
+// pinned by digest.
+func (v *WorkVerifier) Verify(_ context.Context, manifestWork *workapiv1.ManifestWork) error {
+	if len(v.keys) > 0 {
+		if err := v.verifySignature(manifestWork); err != nil {
+			return err
+		}
+	}
+	if v.requireImageDigest {
+		return verifyImageDigests(manifestWork.Spec.Workload.Manifests)
+	}
+	return nil
+}
+
+func (v *WorkVerifier) verifySignature(manifestWork *workapiv1.ManifestWork) error {
+	value, ok := manifestWork.Annotations[SignatureAnnotationKey]
+	if !ok {
+		return &VerificationError{
+			Reason:  SignatureMissingReason,
+			Message: fmt.Sprintf("The signature annotation %s is required", SignatureAnnotationKey),
+		}
+	}
+	signature, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return &VerificationError{
+			Reason:  SignatureInvalidReason,
+			Message: fmt.Sprintf("Failed to decode the signature: %v", err),
+		}
+	}
+
+	data, err := CanonicalWorkload(manifestWork.Spec.Workload)
+	if err != nil {
+		return &VerificationError{
+			Reason:  InvalidManifestReason,
+			Message: fmt.Sprintf("Failed to canonicalize the workload: %v", err),
+		}
+	}
+	for _, key := range v.keys {
+		if verifySignature(key, data, signature) {
+			return nil
+		}
+	}
+	return &VerificationError{
+		Reason:  SignatureInvalidReason,
+		Message: "The signature is not made by a trusted key or the workload is modified",
+	}
+}
+
+func verifySignature(key crypto.PublicKey, data, signature []byte) bool {
+	digest := sha256.Sum256(data)
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, data, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// CanonicalWorkload returns the canonical form of the workload which is signed. It is the json encoding of the
+// workload with each manifest decoded and encoded again, so the keys of the objects are sorted and no
+// insignificant whitespace is kept, e.g. {"manifests":[{"apiVersion":"v1","kind":"ConfigMap",...}]}
+func CanonicalWorkload(workload workapiv1.ManifestsTemplate) ([]byte, error) {
+	manifests := make([]interface{}, 0, len(workload.Manifests))
+	for index, manifest := range workload.Manifests {
+		// keep the numbers as they are instead of converting them to float64
+		decoder := json.NewDecoder(bytes.NewReader(manifest.Raw))
+		decoder.UseNumber()
+		var obj interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("failed to decode the manifest %d: %w", index, err)
+		}
+		manifests = append(manifests, obj)
+	}
+	return json.Marshal(map[string]interface{}{"manifests": manifests})
+}
+
+// verifyImageDigests returns a VerificationError if any image of the pod specs in the manifests is not pinned
+// by digest.
+func verifyImageDigests(manifests []workapiv1.Manifest) error {
+	var unpinned []string
+	for index, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Raw); err != nil {
+			return &VerificationError{
+				Reason:  InvalidManifestReason,
+				Message: fmt.Sprintf("Failed to decode the manifest %d: %v", index, err),
+			}
+		}
+		podSpec := helper.FindPodSpec(obj)
+		if podSpec == nil {
+			continue
+		}
+		for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, _, _ := unstructured.NestedSlice(podSpec, field)
+			for _, container := range containers {
+				containerMap, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				image, _, _ := unstructured.NestedString(containerMap, "image")
+				if !digestPattern.MatchString(image) {
+					unpinned = append(unpinned, image)
+				}
+			}
+		}
+	}
+
+	if len(unpinned) > 0 {
+		return &VerificationError{
+			Reason:  ImageNotPinnedReason,
+			Message: fmt.Sprintf("The images are not pinned by digest: %s", strings.Join(unpinned, ", ")),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package verify
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workapiv1 "open-cluster-management.io/api/work/v1"
+)
+
+const (
+	configMap         = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","namespace":"ns1"},"data":{"replicas":"9007199254740993"}}`
+	reformatConfigMap = `{"kind": "ConfigMap", "apiVersion": "v1", "metadata": {"namespace": "ns1", "name": "test"},
+		"data": {"replicas": "9007199254740993"}}`
+	tamperedConfigMap = `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"test","namespace":"ns1"},"data":{"replicas":"1"}}`
+	unpinnedPod       = `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"test","namespace":"ns1"},
+		"spec":{"containers":[{"name":"app","image":"quay.io/app:v1"}]}}`
+	pinnedDeployment = `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test","namespace":"ns1"},
+		"spec":{"template":{"spec":{"containers":[{"name":"app",
+		"image":"quay.io/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"}]}}}}`
+)
+
+type signer struct {
+	public crypto.PublicKey
+	sign   func(data []byte) []byte
+}
+
+func newSigners(t *testing.T) map[string]signer {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]signer{
+		"ed25519": {public: edPublic, sign: func(data []byte) []byte { return ed25519.Sign(edPrivate, data) }},
+		"ecdsa": {public: &ecPrivate.PublicKey, sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			signature, err := ecdsa.SignASN1(rand.Reader, ecPrivate, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signature
+		}},
+		"rsa": {public: &rsaPrivate.PublicKey, sign: func(data []byte) []byte {
+			digest := sha256.Sum256(data)
+			signature, err := rsa.SignPKCS1v15(rand.Reader, rsaPrivate, crypto.SHA256, digest[:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signature
+		}},
+	}
+}
+
+func writeKeys(t *testing.T, keys ...crypto.PublicKey) string {
+	var data []byte
+	for _, key := range keys {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...)
+	}
+	file := filepath.Join(t.TempDir(), "keys.pem")
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func newWork(signature []byte, manifests ...string) *workapiv1.ManifestWork {
+	work := &workapiv1.ManifestWork{}
+	for _, manifest := range manifests {
+		work.Spec.Workload.Manifests = append(work.Spec.Workload.Manifests,
+			workapiv1.Manifest{RawExtension: runtime.RawExtension{Raw: []byte(manifest)}})
+	}
+	if signature != nil {
+		work.Annotations = map[string]string{SignatureAnnotationKey: base64.StdEncoding.EncodeToString(signature)}
+	}
+	return work
+}
+
+func sign(t *testing.T, s signer, manifests ...string) []byte {
+	data, err := CanonicalWorkload(newWork(nil, manifests...).Spec.Workload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.sign(data)
+}
+
+func TestVerifySignature(t *testing.T) {
+	signers := newSigners(t)
+	untrusted := newSigners(t)
+	verifier, err := NewWorkVerifier(writeKeys(t, signers["ed25519"].public, signers["ecdsa"].public, signers["rsa"].public), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name           string
+		work           *workapiv1.ManifestWork
+		expectedReason string
+	}{
+		{
+			name: "signed by ed25519 key",
+			work: newWork(sign(t, signers["ed25519"], configMap), configMap),
+		},
+		{
+			name: "signed by ecdsa key",
+			work: newWork(sign(t, signers["ecdsa"], configMap), configMap),
+		},
+		{
+			name: "signed by rsa key",
+			work: newWork(sign(t, signers["rsa"], configMap), configMap),
+		},
+		{
+			name: "reformatted manifest",
+			work: newWork(sign(t, signers["ed25519"], configMap), reformatConfigMap),
+		},
+		{
+			name:           "unsigned",
+			work:           newWork(nil, configMap),
+			expectedReason: SignatureMissingReason,
+		},
+		{
+			name:           "tampered manifest",
+			work:           newWork(sign(t, signers["ed25519"], configMap), tamperedConfigMap),
+			expectedReason: SignatureInvalidReason,
+		},
+		{
+			name:           "added manifest",
+			work:           newWork(sign(t, signers["rsa"], configMap), configMap, configMap),
+			expectedReason: SignatureInvalidReason,
+		},
+		{
+			name:           "signed by untrusted key",
+			work:           newWork(sign(t, untrusted["ed25519"], configMap), configMap),
+			expectedReason: SignatureInvalidReason,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assertVerification(t, verifier.Verify(context.TODO(), c.work), c.expectedReason)
+		})
+	}
+}
+
+func TestVerifyImageDigest(t *testing.T) {
+	cases := []struct {
+		name           string
+		work           *workapiv1.ManifestWork
+		expectedReason string
+	}{
+		{
+			name: "no pod spec",
+			work: newWork(nil, configMap),
+		},
+		{
+			name: "pinned image",
+			work: newWork(nil, configMap, pinnedDeployment),
+		},
+		{
+			name:           "unpinned image",
+			work:           newWork(nil, pinnedDeployment, unpinnedPod),
+			expectedReason: ImageNotPinnedReason,
+		},
+	}
+
+	verifier, err := NewWorkVerifier("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assertVerification(t, verifier.Verify(context.TODO(), c.work), c.expectedReason)
+		})
+	}
+}
+
+func assertVerification(t *testing.T, err error, expectedReason string) {
+	if len(expectedReason) == 0 {
+		if err != nil {
+			t.Errorf("expected no error, but got %v", err)
+		}
+		return
+	}
+	var verificationError *VerificationError
+	if !errors.As(err, &verificationError) {
+		t.Fatalf("expected verification error, but got %v", err)
+	}
+	if verificationError.Reason != expectedReason {
+		t.Errorf("expected reason %s, but got %s: %s", expectedReason, verificationError.Reason, verificationError.Message)
+	}
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+	workv1alpha1 "open-cluster-management.io/api/work/v1alpha1"
+
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+)
+
+// The features below are alpha, they are configured by the annotations instead of the API fields, so they could
+// be changed before they are moved to the api repo. The annotation keys of the ManifestWork are defined in the
+// work helper package shared with the work agent, and the annotations are validated by the webhook when the object is created or updated, so an invalid
+// value is rejected up front instead of being reported by the controllers at runtime.
+//
+// ManifestWorkReplicaSet:
+//   - the templating of the ManifestWorkTemplate, see
+//     manifestworkreplicasetcontroller.ManifestWorkTemplateEnabledAnnotationKey and
+//     manifestworkreplicasetcontroller.ManifestWorkTemplateOverridesAnnotationKey.
+//   - the revision history and rollback of the ManifestWorkTemplate, see
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey,
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetRollbackToRevisionAnnotationKey and
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey.
+//   - the aggregation of the status feedback values across the clusters, see
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetFeedbackAggregationAnnotationKey.
+//   - the criteria of the rollout, see
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey,
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey and
+//     manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey.
+//
+// ManifestWork:
+//   - the apply waves and dependencies of the manifests, see helper.ApplyWaveAnnotationKey,
+//     helper.DependsOnAnnotationKey and helper.ApplyWaveReadyConditionAnnotationKey.
+//   - the drift detection of the applied resources, see helper.DriftDetectionAnnotationKey.
+//   - the deletion of the applied resources in the reverse order of the apply waves, see
+//     helper.DeletionPropagationPolicyAnnotationKey.
+//   - the feedback values returned by the CEL expressions, see helper.CELFeedbackRulesAnnotationKey,
+//     the rules are converted to the feedback rules of statusfeedback.CELType on the managed cluster.
+//   - the health evaluation of the applied resources, see helper.HealthCheckAnnotationKey.
+//   - the server side dry run of the manifests, see helper.DryRunAnnotationKey.
+//   - the signature of the workload verified by the work agent, see helper.SignatureAnnotationKey.
+//   - the sync control of the work agent, see helper.PausedAnnotationKey and helper.SyncNowAnnotationKey.
+var manifestWorkAnnotationValidators = []func(*workv1.ManifestWork) error{
+	helper.ValidateApplyWaveAnnotations,
+	helper.ValidateDriftDetectionAnnotation,
+	helper.ValidateDeletionAnnotations,
+	helper.ValidateCELFeedbackRulesAnnotation,
+	helper.ValidateHealthCheckAnnotation,
+	helper.ValidateDryRunAnnotation,
+	helper.ValidateSignatureAnnotation,
+	helper.ValidateSyncControlAnnotations,
+}
+
+var manifestWorkReplicaSetAnnotationValidators = []func(*workv1alpha1.ManifestWorkReplicaSet) error{
+	manifestworkreplicasetcontroller.ValidateTemplateAnnotations,
+	manifestworkreplicasetcontroller.ValidateRevisionAnnotations,
+	manifestworkreplicasetcontroller.ValidateFeedbackAggregationAnnotation,
+	manifestworkreplicasetcontroller.ValidateRolloutCriteriaAnnotations,
+}
+
+// ValidateManifestWorkAnnotations validates the alpha annotations of the ManifestWork and its manifests.
+func ValidateManifestWorkAnnotations(work *workv1.ManifestWork) error {
+	var errs []error
+	for _, validate := range manifestWorkAnnotationValidators {
+		if err := validate(work); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// ValidateManifestWorkReplicaSetAnnotations validates the alpha annotations of the ManifestWorkReplicaSet.
+func ValidateManifestWorkReplicaSetAnnotations(mwrSet *workv1alpha1.ManifestWorkReplicaSet) error {
+	var errs []error
+	for _, validate := range manifestWorkReplicaSetAnnotationValidators {
+		if err := validate(mwrSet); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
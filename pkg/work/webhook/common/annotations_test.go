@@ -0,0 +1,253 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	testingcommon "open-cluster-management.io/ocm/pkg/common/testing"
+	"open-cluster-management.io/ocm/pkg/work/helper"
+	"open-cluster-management.io/ocm/pkg/work/hub/controllers/manifestworkreplicasetcontroller"
+	helpertest "open-cluster-management.io/ocm/pkg/work/hub/test"
+	"open-cluster-management.io/ocm/pkg/work/spoke/spoketesting"
+)
+
+func newAnnotatedWork(annotations map[string]string, manifestAnnotations ...map[string]string) *workv1.ManifestWork {
+	work, _ := spoketesting.NewManifestWork(0)
+	work.Annotations = annotations
+	for i, a := range manifestAnnotations {
+		obj := testingcommon.NewUnstructured("v1", "ConfigMap", "ns1", fmt.Sprintf("cm%d", i))
+		obj.SetAnnotations(a)
+		raw, _ := obj.MarshalJSON()
+		work.Spec.Workload.Manifests = append(work.Spec.Workload.Manifests, workv1.Manifest{
+			RawExtension: runtime.RawExtension{Raw: raw},
+		})
+	}
+	return work
+}
+
+func TestValidateManifestWorkAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		work        *workv1.ManifestWork
+		expectedErr bool
+	}{
+		{
+			name: "no annotations",
+			work: newAnnotatedWork(nil, nil),
+		},
+		{
+			name: "apply waves",
+			work: newAnnotatedWork(nil,
+				map[string]string{helper.ApplyWaveAnnotationKey: "1"},
+				map[string]string{helper.DependsOnAnnotationKey: "/ConfigMap/ns1/cm0"}),
+		},
+		{
+			name:        "invalid apply wave",
+			work:        newAnnotatedWork(nil, map[string]string{helper.ApplyWaveAnnotationKey: "first"}),
+			expectedErr: true,
+		},
+		{
+			name:        "invalid dependency",
+			work:        newAnnotatedWork(nil, map[string]string{helper.DependsOnAnnotationKey: "cm0"}),
+			expectedErr: true,
+		},
+		{
+			name: "drift detection",
+			work: newAnnotatedWork(map[string]string{helper.DriftDetectionAnnotationKey: "ReportOnly"}, nil),
+		},
+		{
+			name:        "invalid drift detection",
+			work:        newAnnotatedWork(map[string]string{helper.DriftDetectionAnnotationKey: "true"}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "deletion propagation policy",
+			work: newAnnotatedWork(nil, map[string]string{helper.DeletionPropagationPolicyAnnotationKey: "Foreground"}),
+		},
+		{
+			name:        "invalid deletion propagation policy",
+			work:        newAnnotatedWork(nil, map[string]string{helper.DeletionPropagationPolicyAnnotationKey: "Orphan"}),
+			expectedErr: true,
+		},
+		{
+			name: "cel feedback rules",
+			work: newAnnotatedWork(map[string]string{helper.CELFeedbackRulesAnnotationKey: `[{
+				"resourceIdentifier": {"resource": "configmaps", "namespace": "ns1", "name": "cm0"},
+				"expressions": [{"name": "keys", "expression": "size(object.data)"}]}]`}, nil),
+		},
+		{
+			name: "invalid cel feedback rules",
+			work: newAnnotatedWork(map[string]string{
+				helper.CELFeedbackRulesAnnotationKey: `{"expressions": []}`}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "cel feedback expression could not be compiled",
+			work: newAnnotatedWork(map[string]string{helper.CELFeedbackRulesAnnotationKey: `[{
+				"resourceIdentifier": {"resource": "configmaps", "namespace": "ns1", "name": "cm0"},
+				"expressions": [{"name": "keys", "expression": "size(object.data"}]}]`}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "health check",
+			work: newAnnotatedWork(map[string]string{helper.HealthCheckAnnotationKey: "Enabled"}, nil),
+		},
+		{
+			name:        "invalid health check",
+			work:        newAnnotatedWork(map[string]string{helper.HealthCheckAnnotationKey: "true"}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "dry run",
+			work: newAnnotatedWork(map[string]string{helper.DryRunAnnotationKey: `[
+				{"resource": "configmaps", "namespace": "ns1", "name": "*"}]`}, nil),
+		},
+		{
+			name:        "invalid dry run",
+			work:        newAnnotatedWork(map[string]string{helper.DryRunAnnotationKey: "true"}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "signature",
+			work: newAnnotatedWork(map[string]string{helper.SignatureAnnotationKey: "c2lnbmF0dXJl"}, nil),
+		},
+		{
+			name:        "invalid signature",
+			work:        newAnnotatedWork(map[string]string{helper.SignatureAnnotationKey: "signature!"}, nil),
+			expectedErr: true,
+		},
+		{
+			name: "sync control",
+			work: newAnnotatedWork(map[string]string{
+				helper.PausedAnnotationKey:  "True",
+				helper.SyncNowAnnotationKey: "2024-01-01T00:00:00Z",
+			}, nil),
+		},
+		{
+			name:        "invalid paused",
+			work:        newAnnotatedWork(map[string]string{helper.PausedAnnotationKey: "yes"}, nil),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateManifestWorkAnnotations(c.work)
+			assert.Equal(t, c.expectedErr, err != nil, "unexpected error %v", err)
+		})
+	}
+}
+
+func TestValidateManifestWorkReplicaSetAnnotations(t *testing.T) {
+	cases := []struct {
+		name        string
+		annotations map[string]string
+		expectedErr bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "templating enabled",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkTemplateEnabledAnnotationKey:   "true",
+				manifestworkreplicasetcontroller.ManifestWorkTemplateOverridesAnnotationKey: "overrides",
+			},
+		},
+		{
+			name: "invalid templating enabled",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkTemplateEnabledAnnotationKey: "yes",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid template overrides",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkTemplateOverridesAnnotationKey: "Overrides/1",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "revisions",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey:  "5",
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRollbackToRevisionAnnotationKey:    "2",
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey: "20%",
+			},
+		},
+		{
+			name: "invalid revision history limit",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRevisionHistoryLimitAnnotationKey: "0",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid rollback revision",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRollbackToRevisionAnnotationKey: "latest",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid auto rollback threshold",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetAutoRollbackThresholdAnnotationKey: "half",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "feedback aggregation",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[{
+					"name": "readyReplicas", "feedbackName": "ReadyReplicas", "type": "Sum",
+					"resourceIdentifier": {"group": "apps", "resource": "deployments", "namespace": "ns1", "name": "app"}}]`,
+			},
+		},
+		{
+			name: "invalid feedback aggregation",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetFeedbackAggregationAnnotationKey: `[{
+					"name": "readyReplicas", "feedbackName": "ReadyReplicas", "type": "Avg"}]`,
+			},
+			expectedErr: true,
+		},
+		{
+			name: "rollout criteria",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey:  "conditions.Available == 'True'",
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey:         "10m",
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSuccessThresholdAnnotationKey: "80%",
+			},
+		},
+		{
+			name: "rollout criteria could not be compiled",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSuccessCriteriaAnnotationKey: "conditions.Available ==",
+			},
+			expectedErr: true,
+		},
+		{
+			name: "invalid rollout soak time",
+			annotations: map[string]string{
+				manifestworkreplicasetcontroller.ManifestWorkReplicaSetRolloutSoakTimeAnnotationKey: "10",
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mwrSet := helpertest.CreateTestManifestWorkReplicaSet("mwrset-test", "default", "place-test")
+			mwrSet.Annotations = c.annotations
+			err := ValidateManifestWorkReplicaSetAnnotations(mwrSet)
+			assert.Equal(t, c.expectedErr, err != nil, "unexpected error %v", err)
+		})
+	}
+}
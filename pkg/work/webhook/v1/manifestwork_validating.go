@@ -62,6 +62,14 @@ func (r *ManifestWorkWebhook) validateRequest(newWork, oldWork *workv1.ManifestW
 		return apierrors.NewBadRequest(err.Error())
 	}
 
+	// do not need to check the annotations when they are not changed, so the finalizers could still be removed.
+	if oldWork == nil || !reflect.DeepEqual(oldWork.Annotations, newWork.Annotations) ||
+		!reflect.DeepEqual(oldWork.Spec.Workload, newWork.Spec.Workload) {
+		if err := common.ValidateManifestWorkAnnotations(newWork); err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+	}
+
 	req, err := admission.RequestFromContext(ctx)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"context"
 	"errors"
+	"reflect"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -55,7 +56,7 @@ func (r *ManifestWorkReplicaSetWebhook) ValidateDelete(_ context.Context, _ runt
 }
 
 func (r *ManifestWorkReplicaSetWebhook) validateRequest(
-	newmwrSet *workv1alpha1.ManifestWorkReplicaSet, _ *workv1alpha1.ManifestWorkReplicaSet,
+	newmwrSet *workv1alpha1.ManifestWorkReplicaSet, oldmwrSet *workv1alpha1.ManifestWorkReplicaSet,
 	ctx context.Context) error {
 	if err := checkFeatureEnabled(); err != nil {
 		return err
@@ -65,6 +66,13 @@ func (r *ManifestWorkReplicaSetWebhook) validateRequest(
 		return apierrors.NewBadRequest(err.Error())
 	}
 
+	// do not need to check the annotations when they are not changed, so the finalizers could still be removed.
+	if oldmwrSet == nil || !reflect.DeepEqual(oldmwrSet.Annotations, newmwrSet.Annotations) {
+		if err := common.ValidateManifestWorkReplicaSetAnnotations(newmwrSet); err != nil {
+			return apierrors.NewBadRequest(err.Error())
+		}
+	}
+
 	_, err := admission.RequestFromContext(ctx)
 	if err != nil {
 		return apierrors.NewBadRequest(err.Error())